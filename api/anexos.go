@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// errAnexoGrande indica que o arquivo enviado passa de dominio.TamanhoMaximoAnexo
+var errAnexoGrande = errors.New("anexo maior que o tamanho máximo")
+
+// folgaMultipart é o espaço do corpo multipart além do arquivo, para os
+// cabeçalhos das partes e os delimitadores
+const folgaMultipart = 64 << 10
+
+// campoArquivo é o nome do campo multipart com o arquivo enviado
+const campoArquivo = "arquivo"
+
+// limiteAnexo interrompe a leitura com errAnexoGrande assim que o conteúdo
+// passa do tamanho máximo, sem ler o resto do arquivo
+type limiteAnexo struct {
+	r     io.Reader
+	lidos int64
+}
+
+func (l *limiteAnexo) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.lidos += int64(n)
+	if l.lidos > dominio.TamanhoMaximoAnexo {
+		return n, errAnexoGrande
+	}
+	return n, err
+}
+
+// manipuladorAnexos atende os anexos da tarefa: a coleção em
+// /api/tarefas/{id}/anexos, um anexo em /api/tarefas/{id}/anexos/{anexoID} e
+// o seu conteúdo em /api/tarefas/{id}/anexos/{anexoID}/conteudo
+func (s *servidor) manipuladorAnexos(w http.ResponseWriter, r *http.Request, dono, tarefaID, subID string) {
+	anexoID, resto, _ := strings.Cut(subID, "/")
+	if resto != "" && resto != "conteudo" {
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if _, err := s.tarefas.Buscar(dono, tarefaID); err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+	if anexoID != "" {
+		s.manipuladorAnexo(w, r, dono, tarefaID, anexoID, resto == "conteudo")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		anexos, err := s.anexos.Listar(dono, tarefaID)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(anexos)
+	case "POST":
+		s.enviarAnexo(w, r, dono, tarefaID)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST, OPTIONS")
+	}
+}
+
+// enviarAnexo lê o arquivo do campo "arquivo" do corpo multipart/form-data e
+// o anexa à tarefa. O tipo guardado é sempre o detectado pelo conteúdo; um
+// tipo declarado na parte precisa concordar com ele.
+func (s *servidor) enviarAnexo(w http.ResponseWriter, r *http.Request, dono, tarefaID string) {
+	r.Body = http.MaxBytesReader(w, r.Body, dominio.TamanhoMaximoAnexo+folgaMultipart)
+	leitor, err := r.MultipartReader()
+	if err != nil {
+		responderProblema(w, r, problemaCorpoInvalido)
+		return
+	}
+	var maximo *http.MaxBytesError
+	for {
+		parte, err := leitor.NextPart()
+		if errors.As(err, &maximo) {
+			responderProblema(w, r, problemaAnexoGrande)
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if parte.FormName() != campoArquivo {
+			continue
+		}
+
+		conteudo := bufio.NewReader(parte)
+		inicio, _ := conteudo.Peek(512)
+		tipo, ok := tipoDoAnexo(parte.Header.Get("Content-Type"), inicio)
+		if !ok {
+			responderProblema(w, r, problemaTipoAnexoNaoPermitido)
+			return
+		}
+
+		// A leitura do arquivo, a parte demorada, acontece sem muBlobs
+		recebido, err := s.blobs.Receber(&limiteAnexo{r: conteudo})
+		if errors.Is(err, errAnexoGrande) || errors.As(err, &maximo) {
+			responderProblema(w, r, problemaAnexoGrande)
+			return
+		}
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		a, err := s.guardarAnexo(dono, recebido, Anexo{
+			TarefaID: tarefaID,
+			Nome:     dominio.NomeAnexo(parte.FileName()),
+			Tipo:     tipo,
+			Tamanho:  recebido.Tamanho,
+			Hash:     recebido.Hash,
+		})
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/tarefas/"+tarefaID+"/anexos/"+a.ID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(a)
+		return
+	}
+
+	responderProblema(w, r, problemaValidacao, ErroValidacao{Campo: campoArquivo, Codigo: dominio.CodigoObrigatorio, Mensagens: dominio.Mensagens{
+		PtBR: "envie o arquivo no campo arquivo",
+		En:   "send the file in the arquivo field",
+	}})
+}
+
+// tiposTexto são os tipos aceitos que a detecção pelo conteúdo só reconhece
+// como texto simples
+var tiposTexto = map[string]bool{
+	"text/plain":       true,
+	"text/csv":         true,
+	"text/markdown":    true,
+	"application/json": true,
+}
+
+// tipoDoAnexo detecta o tipo pelo início do conteúdo e informa se ele é
+// aceito. O tipo declarado, quando presente e não genérico, precisa ser o
+// detectado; como CSV, Markdown e JSON são detectados como texto simples,
+// esses tipos concordam com text/plain.
+func tipoDoAnexo(declarado string, inicio []byte) (string, bool) {
+	detectado, ok := dominio.TipoAnexo(http.DetectContentType(inicio))
+	if !ok {
+		return "", false
+	}
+	if declarado == "" || strings.HasPrefix(declarado, "application/octet-stream") {
+		return detectado, true
+	}
+	declarado, ok = dominio.TipoAnexo(declarado)
+	if !ok {
+		return "", false
+	}
+	if declarado != detectado && !(detectado == "text/plain" && tiposTexto[declarado]) {
+		return "", false
+	}
+	return detectado, true
+}
+
+// guardarAnexo guarda o conteúdo recebido e cria o anexo que o referencia.
+// As duas coisas acontecem com muBlobs, para que a remoção de outro anexo
+// com o mesmo conteúdo não o apague entre uma e outra.
+func (s *servidor) guardarAnexo(dono string, recebido BlobRecebido, a Anexo) (Anexo, error) {
+	s.muBlobs.Lock()
+	defer s.muBlobs.Unlock()
+	if err := s.blobs.Guardar(recebido); err != nil {
+		s.blobs.Descartar(recebido)
+		return Anexo{}, err
+	}
+	criado, err := s.anexos.Criar(dono, a)
+	if err != nil {
+		// Sem o anexo, o conteúdo recém-guardado pode ter ficado sem uso
+		if errBlobs := s.removerBlobsSemUso([]Anexo{a}); errBlobs != nil {
+			return Anexo{}, errBlobs
+		}
+		return Anexo{}, err
+	}
+	return criado, nil
+}
+
+// manipuladorAnexo atende um anexo da tarefa: seus metadados, o download do
+// conteúdo e a remoção
+func (s *servidor) manipuladorAnexo(w http.ResponseWriter, r *http.Request, dono, tarefaID, id string, conteudo bool) {
+	a, err := s.anexos.Buscar(dono, id)
+	if err == nil && a.TarefaID != tarefaID {
+		err = ErrAnexoNaoEncontrado
+	}
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+
+	switch {
+	case conteudo && (r.Method == "GET" || r.Method == "HEAD"):
+		s.baixarAnexo(w, r, a)
+	case conteudo:
+		responderMetodoNaoPermitido(w, r, "GET, HEAD, OPTIONS")
+	case r.Method == "GET":
+		json.NewEncoder(w).Encode(a)
+	case r.Method == "DELETE":
+		if err := s.removerAnexo(dono, a); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, DELETE, OPTIONS")
+	}
+}
+
+// baixarAnexo responde o conteúdo do anexo para download com o nome original.
+// O hash serve de ETag, o que permite requisições condicionais e por faixas.
+func (s *servidor) baixarAnexo(w http.ResponseWriter, r *http.Request, a Anexo) {
+	f, err := s.blobs.Abrir(a.Hash)
+	if err != nil {
+		responderErroInterno(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	disposicao := mime.FormatMediaType("attachment", map[string]string{"filename": a.Nome})
+	if disposicao == "" {
+		disposicao = "attachment"
+	}
+	w.Header().Set("Content-Type", a.Tipo)
+	w.Header().Set("Content-Disposition", disposicao)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+a.Hash+`"`)
+	http.ServeContent(w, r, "", a.CriadoEm, f)
+}
+
+// removerAnexo exclui o anexo e, se nenhum outro usar o mesmo conteúdo, o
+// conteúdo guardado
+func (s *servidor) removerAnexo(dono string, a Anexo) error {
+	s.muBlobs.Lock()
+	defer s.muBlobs.Unlock()
+	if err := s.anexos.Remover(dono, a.ID); err != nil {
+		return err
+	}
+	return s.removerBlobsSemUso([]Anexo{a})
+}
+
+// removerAnexosDasTarefas exclui os anexos das tarefas removidas e os
+// conteúdos que deixaram de ser usados
+func (s *servidor) removerAnexosDasTarefas(dono string, tarefaIDs []string) error {
+	s.muBlobs.Lock()
+	defer s.muBlobs.Unlock()
+	removidos, err := s.anexos.RemoverDasTarefas(dono, tarefaIDs)
+	if err != nil {
+		return err
+	}
+	return s.removerBlobsSemUso(removidos)
+}
+
+// removerBlobsSemUso exclui os conteúdos dos anexos removidos que nenhum
+// anexo restante usa. O chamador deve possuir muBlobs.
+func (s *servidor) removerBlobsSemUso(removidos []Anexo) error {
+	for _, a := range removidos {
+		emUso, err := s.anexos.EmUso(a.Hash)
+		if err != nil {
+			return err
+		}
+		if emUso {
+			continue
+		}
+		if err := s.blobs.Remover(a.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// corpoArquivo monta um corpo multipart/form-data com o arquivo no campo
+// "arquivo" e retorna o corpo e o seu Content-Type. Sem tipo, a parte vai
+// sem Content-Type.
+func corpoArquivo(t *testing.T, nome, tipo, conteudo string) (string, string) {
+	t.Helper()
+	var b bytes.Buffer
+	escritor := multipart.NewWriter(&b)
+	cabecalho := textproto.MIMEHeader{}
+	cabecalho.Set("Content-Disposition", `form-data; name="arquivo"; filename="`+nome+`"`)
+	if tipo != "" {
+		cabecalho.Set("Content-Type", tipo)
+	}
+	parte, err := escritor.CreatePart(cabecalho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parte.Write([]byte(conteudo))
+	if err := escritor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String(), escritor.FormDataContentType()
+}
+
+// enviarArquivo envia o arquivo para os anexos da tarefa
+func enviarArquivo(t *testing.T, srv *servidor, tarefaID, nome, tipo, conteudo string) *httptest.ResponseRecorder {
+	t.Helper()
+	corpo, tipoCorpo := corpoArquivo(t, nome, tipo, conteudo)
+	req := httptest.NewRequest("POST", "/api/tarefas/"+tarefaID+"/anexos", strings.NewReader(corpo))
+	req.Header.Set("Content-Type", tipoCorpo)
+	req.Header.Set("Authorization", "Bearer "+tokenTeste(srv))
+	rr := httptest.NewRecorder()
+	srv.rotas().ServeHTTP(rr, req)
+	validarContrato(t, req, corpo, rr)
+	return rr
+}
+
+func TestAnexosDaTarefa(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Relatório")
+	colecao := "/api/tarefas/" + id + "/anexos"
+
+	rr := enviarArquivo(t, srv, id, "../notas da reunião.txt", "text/plain; charset=utf-8", "Decidimos usar Go")
+	var a Anexo
+	if err := json.Unmarshal(rr.Body.Bytes(), &a); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST anexos retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if a.Nome != "notas da reunião.txt" || a.Tipo != "text/plain" || a.Tamanho != 17 || len(a.Hash) != 64 || rr.Header().Get("Location") != colecao+"/"+a.ID {
+		t.Errorf("anexo criado: %+v", a)
+	}
+
+	// O download usa o tipo e o nome originais
+	rr = executar(t, srv, "GET", colecao+"/"+a.ID+"/conteudo", "")
+	if rr.Code != http.StatusOK || rr.Body.String() != "Decidimos usar Go" {
+		t.Fatalf("download retornou %d: %q", rr.Code, rr.Body.String())
+	}
+	if tipo := rr.Header().Get("Content-Type"); tipo != "text/plain" {
+		t.Errorf("Content-Type do download: %q", tipo)
+	}
+	if disposicao := rr.Header().Get("Content-Disposition"); disposicao != "attachment; filename*=utf-8''notas%20da%20reuni%C3%A3o.txt" {
+		t.Errorf("Content-Disposition do download: %q", disposicao)
+	}
+	if rr.Header().Get("ETag") != `"`+a.Hash+`"` || rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("cabeçalhos do download: %v", rr.Header())
+	}
+
+	// O mesmo conteúdo, em outra tarefa, é guardado uma única vez
+	outra := criarTarefaTeste(t, srv, "Outra")
+	rr = enviarArquivo(t, srv, outra, "copia.txt", "text/plain", "Decidimos usar Go")
+	var copia Anexo
+	json.Unmarshal(rr.Body.Bytes(), &copia)
+	if copia.Hash != a.Hash || copia.ID == a.ID {
+		t.Errorf("cópia do anexo: %+v", copia)
+	}
+	blob, _ := srv.blobs.(*BlobsLocais).caminho(a.Hash)
+	executar(t, srv, "DELETE", colecao+"/"+a.ID, "")
+	if _, err := os.Stat(blob); err != nil {
+		t.Errorf("conteúdo usado pela cópia foi removido: %v", err)
+	}
+	if rr := executar(t, srv, "GET", colecao+"/"+a.ID, ""); rr.Code != http.StatusNotFound || lerProblema(t, rr).Codigo != dominio.CodigoAnexoNaoEncontrado {
+		t.Errorf("anexo removido retornou %d", rr.Code)
+	}
+
+	// Purgar a tarefa da lixeira remove os anexos e o conteúdo que ficou sem uso
+	executar(t, srv, "DELETE", "/api/tarefas/"+outra, "")
+	if _, err := os.Stat(blob); err != nil {
+		t.Errorf("conteúdo da tarefa na lixeira foi removido: %v", err)
+	}
+	executar(t, srv, "DELETE", "/api/lixeira/"+outra, "")
+	if anexos, _ := srv.anexos.Listar(usuarioTeste, outra); len(anexos) != 0 {
+		t.Errorf("anexos da tarefa removida: %+v", anexos)
+	}
+	if _, err := os.Stat(blob); !os.IsNotExist(err) {
+		t.Errorf("conteúdo sem uso não foi removido: %v", err)
+	}
+}
+
+func TestLimitesDosAnexos(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Limites")
+
+	// Sem tipo declarado, o tipo vem do conteúdo
+	rr := enviarArquivo(t, srv, id, "imagem", "", "\x89PNG\r\n\x1a\n"+strings.Repeat("\x00", 16))
+	var a Anexo
+	json.Unmarshal(rr.Body.Bytes(), &a)
+	if rr.Code != http.StatusCreated || a.Tipo != "image/png" {
+		t.Errorf("tipo detectado: %d %q", rr.Code, a.Tipo)
+	}
+
+	rr = enviarArquivo(t, srv, id, "pagina.html", "text/html", "<script>alert(1)</script>")
+	if rr.Code != http.StatusUnsupportedMediaType || lerProblema(t, rr).Codigo != dominio.CodigoTipoAnexoNaoPermitido {
+		t.Errorf("tipo não permitido: obtido %d", rr.Code)
+	}
+
+	// O tipo declarado precisa concordar com o conteúdo, e o guardado é o
+	// detectado
+	rr = enviarArquivo(t, srv, id, "foto.png", "image/png", "<html><script>alert(1)</script></html>")
+	if rr.Code != http.StatusUnsupportedMediaType || lerProblema(t, rr).Codigo != dominio.CodigoTipoAnexoNaoPermitido {
+		t.Errorf("tipo declarado diferente do conteúdo: obtido %d", rr.Code)
+	}
+	rr = enviarArquivo(t, srv, id, "notas.txt", "text/plain", "\x89PNG\r\n\x1a\n"+strings.Repeat("\x00", 16))
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("imagem declarada como texto: obtido %d", rr.Code)
+	}
+	rr = enviarArquivo(t, srv, id, "dados.csv", "text/csv", "nome,valor\na,1\n")
+	a = Anexo{}
+	json.Unmarshal(rr.Body.Bytes(), &a)
+	if rr.Code != http.StatusCreated || a.Tipo != "text/plain" {
+		t.Errorf("CSV: %d %q", rr.Code, a.Tipo)
+	}
+
+	rr = enviarArquivo(t, srv, id, "grande.txt", "text/plain", strings.Repeat("a", dominio.TamanhoMaximoAnexo+1))
+	if rr.Code != http.StatusRequestEntityTooLarge || lerProblema(t, rr).Codigo != dominio.CodigoAnexoGrande {
+		t.Errorf("arquivo grande: obtido %d", rr.Code)
+	}
+	// O envio recusado não deixa arquivos para trás
+	if entradas, _ := filepath.Glob(filepath.Join(srv.blobs.(*BlobsLocais).diretorio, "envio-*")); len(entradas) != 0 {
+		t.Errorf("temporários deixados pelo envio recusado: %v", entradas)
+	}
+
+	rr = executar(t, srv, "POST", "/api/tarefas/"+id+"/anexos", `{"arquivo":"x"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("corpo sem multipart: obtido %d", rr.Code)
+	}
+	if anexos, _ := srv.anexos.Listar(usuarioTeste, id); len(anexos) != 2 {
+		t.Errorf("anexos gravados: %+v", anexos)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrNaoEncontrado indica que o documento solicitado não existe na coleção
+var ErrNaoEncontrado = errors.New("documento não encontrado")
+
+// ErrDuplicado indica que já existe um documento com o mesmo ID na coleção
+var ErrDuplicado = errors.New("documento duplicado")
+
+// Armazenamento persiste documentos JSON agrupados em coleções.
+// Os repositórios são construídos sobre ele, o que permite trocar o meio de
+// persistência (memória, arquivo JSON, SQLite) apenas por configuração.
+type Armazenamento interface {
+	// Listar retorna os documentos da coleção na ordem de inserção
+	Listar(colecao string) ([][]byte, error)
+	// ListarDoDono retorna, na ordem de inserção, os documentos da coleção
+	// cujo membro "dono" é o informado; documentos sem dono ficam sob "".
+	// Lê apenas os documentos desse dono, sem percorrer a coleção inteira.
+	ListarDoDono(colecao, dono string) ([][]byte, error)
+	// Buscar retorna o documento com o ID informado ou ErrNaoEncontrado
+	Buscar(colecao, id string) ([]byte, error)
+	// Inserir adiciona um novo documento ou retorna ErrDuplicado
+	Inserir(colecao, id string, doc []byte) error
+	// Atualizar lê o documento, aplica mudar e grava o resultado de forma
+	// atômica: nenhuma outra alteração do mesmo documento ocorre entre a leitura
+	// e a gravação. Se mudar retornar erro, nada é gravado.
+	Atualizar(colecao, id string, mudar func(doc []byte) ([]byte, error)) ([]byte, error)
+	// Remover exclui o documento com o ID informado
+	Remover(colecao, id string) error
+	// RemoverSe lê o documento e o exclui se condicao não retornar erro, de
+	// forma atômica como em Atualizar. O erro de condicao é retornado.
+	RemoverSe(colecao, id string, condicao func(doc []byte) error) error
+	// Fechar libera os recursos do armazenamento
+	Fechar() error
+}
+
+// colecaoMemoria guarda os documentos de uma coleção preservando a ordem de
+// inserção, com um índice dos IDs de cada dono
+type colecaoMemoria struct {
+	ids     []string
+	docs    map[string][]byte
+	donos   map[string]string
+	porDono map[string][]string
+}
+
+// donoDoDocumento retorna o membro "dono" do documento, ou "" se não houver
+func donoDoDocumento(doc []byte) string {
+	var chave struct {
+		Dono string `json:"dono"`
+	}
+	json.Unmarshal(doc, &chave)
+	return chave.Dono
+}
+
+// mudarDono passa o documento id para o índice do novo dono, preservando a
+// ordem de inserção entre os documentos dele
+func (c *colecaoMemoria) mudarDono(id, dono string) {
+	c.desindexar(id)
+	c.donos[id] = dono
+	c.porDono[dono] = slices.DeleteFunc(slices.Clone(c.ids), func(outro string) bool { return c.donos[outro] != dono })
+}
+
+// desindexar retira o documento id do índice de donos
+func (c *colecaoMemoria) desindexar(id string) {
+	dono := c.donos[id]
+	c.porDono[dono] = slices.DeleteFunc(c.porDono[dono], func(outro string) bool { return outro == id })
+	if len(c.porDono[dono]) == 0 {
+		delete(c.porDono, dono)
+	}
+	delete(c.donos, id)
+}
+
+// ArmazenamentoMemoria mantém os documentos apenas em memória
+type ArmazenamentoMemoria struct {
+	mu       sync.RWMutex
+	colecoes map[string]*colecaoMemoria
+}
+
+// NovoArmazenamentoMemoria cria um armazenamento em memória vazio
+func NovoArmazenamentoMemoria() *ArmazenamentoMemoria {
+	return &ArmazenamentoMemoria{colecoes: make(map[string]*colecaoMemoria)}
+}
+
+// colecao retorna a coleção com o nome informado, criando-a se necessário.
+// O chamador deve possuir o bloqueio de escrita.
+func (a *ArmazenamentoMemoria) colecao(nome string) *colecaoMemoria {
+	c, ok := a.colecoes[nome]
+	if !ok {
+		c = &colecaoMemoria{
+			docs:    make(map[string][]byte),
+			donos:   make(map[string]string),
+			porDono: make(map[string][]string),
+		}
+		a.colecoes[nome] = c
+	}
+	return c
+}
+
+func (a *ArmazenamentoMemoria) Listar(colecao string) ([][]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	c, ok := a.colecoes[colecao]
+	if !ok {
+		return nil, nil
+	}
+	docs := make([][]byte, 0, len(c.ids))
+	for _, id := range c.ids {
+		docs = append(docs, c.docs[id])
+	}
+	return docs, nil
+}
+
+func (a *ArmazenamentoMemoria) ListarDoDono(colecao, dono string) ([][]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	c, ok := a.colecoes[colecao]
+	if !ok {
+		return nil, nil
+	}
+	docs := make([][]byte, 0, len(c.porDono[dono]))
+	for _, id := range c.porDono[dono] {
+		docs = append(docs, c.docs[id])
+	}
+	return docs, nil
+}
+
+func (a *ArmazenamentoMemoria) Buscar(colecao, id string) ([]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	c, ok := a.colecoes[colecao]
+	if !ok {
+		return nil, ErrNaoEncontrado
+	}
+	doc, ok := c.docs[id]
+	if !ok {
+		return nil, ErrNaoEncontrado
+	}
+	return doc, nil
+}
+
+func (a *ArmazenamentoMemoria) Inserir(colecao, id string, doc []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inserir(colecao, id, doc)
+}
+
+func (a *ArmazenamentoMemoria) inserir(colecao, id string, doc []byte) error {
+	c := a.colecao(colecao)
+	if _, ok := c.docs[id]; ok {
+		return ErrDuplicado
+	}
+	c.ids = append(c.ids, id)
+	c.docs[id] = doc
+	dono := donoDoDocumento(doc)
+	c.donos[id] = dono
+	c.porDono[dono] = append(c.porDono[dono], id)
+	return nil
+}
+
+func (a *ArmazenamentoMemoria) Atualizar(colecao, id string, mudar func(doc []byte) ([]byte, error)) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.atualizar(colecao, id, mudar)
+}
+
+func (a *ArmazenamentoMemoria) atualizar(colecao, id string, mudar func(doc []byte) ([]byte, error)) ([]byte, error) {
+	c := a.colecao(colecao)
+	atual, ok := c.docs[id]
+	if !ok {
+		return nil, ErrNaoEncontrado
+	}
+	novo, err := mudar(atual)
+	if err != nil {
+		return nil, err
+	}
+	c.docs[id] = novo
+	if dono := donoDoDocumento(novo); dono != c.donos[id] {
+		c.mudarDono(id, dono)
+	}
+	return novo, nil
+}
+
+func (a *ArmazenamentoMemoria) Remover(colecao, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.remover(colecao, id)
+}
+
+func (a *ArmazenamentoMemoria) remover(colecao, id string) error {
+	c := a.colecao(colecao)
+	if _, ok := c.docs[id]; !ok {
+		return ErrNaoEncontrado
+	}
+	c.desindexar(id)
+	delete(c.docs, id)
+	for i, atual := range c.ids {
+		if atual == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (a *ArmazenamentoMemoria) RemoverSe(colecao, id string, condicao func(doc []byte) error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.removerSe(colecao, id, condicao)
+}
+
+func (a *ArmazenamentoMemoria) removerSe(colecao, id string, condicao func(doc []byte) error) error {
+	atual, ok := a.colecao(colecao).docs[id]
+	if !ok {
+		return ErrNaoEncontrado
+	}
+	if err := condicao(atual); err != nil {
+		return err
+	}
+	return a.remover(colecao, id)
+}
+
+func (a *ArmazenamentoMemoria) Fechar() error {
+	return nil
+}
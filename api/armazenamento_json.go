@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ArmazenamentoJSON mantém os documentos em memória e grava todas as coleções
+// em um único arquivo JSON a cada alteração.
+type ArmazenamentoJSON struct {
+	*ArmazenamentoMemoria
+	caminho string
+}
+
+// NovoArmazenamentoJSON abre (ou cria) o arquivo informado e carrega seu conteúdo
+func NovoArmazenamentoJSON(caminho string) (*ArmazenamentoJSON, error) {
+	a := &ArmazenamentoJSON{
+		ArmazenamentoMemoria: NovoArmazenamentoMemoria(),
+		caminho:              caminho,
+	}
+	if err := a.carregar(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// carregar lê o arquivo do disco; um arquivo inexistente equivale a um armazenamento vazio
+func (a *ArmazenamentoJSON) carregar() error {
+	conteudo, err := os.ReadFile(a.caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ler %s: %w", a.caminho, err)
+	}
+
+	var colecoes map[string][]json.RawMessage
+	if err := json.Unmarshal(conteudo, &colecoes); err != nil {
+		return fmt.Errorf("decodificar %s: %w", a.caminho, err)
+	}
+
+	a.colecoes = make(map[string]*colecaoMemoria)
+	for nome, docs := range colecoes {
+		for _, doc := range docs {
+			var chave struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(doc, &chave); err != nil || chave.ID == "" {
+				return fmt.Errorf("documento sem ID na coleção %q de %s", nome, a.caminho)
+			}
+			if err := a.inserir(nome, chave.ID, doc); err != nil {
+				return fmt.Errorf("coleção %q de %s: %w", nome, a.caminho, err)
+			}
+		}
+	}
+	return nil
+}
+
+// persistir grava todas as coleções em um arquivo temporário e o renomeia
+// sobre o original, para que uma falha no meio da escrita não corrompa os dados.
+// O chamador deve possuir o bloqueio de escrita.
+func (a *ArmazenamentoJSON) persistir() error {
+	colecoes := make(map[string][]json.RawMessage, len(a.colecoes))
+	for nome, c := range a.colecoes {
+		docs := make([]json.RawMessage, 0, len(c.ids))
+		for _, id := range c.ids {
+			docs = append(docs, c.docs[id])
+		}
+		colecoes[nome] = docs
+	}
+
+	conteudo, err := json.MarshalIndent(colecoes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(a.caminho), filepath.Base(a.caminho)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(conteudo); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), a.caminho)
+}
+
+// alterar aplica uma mudança em memória e a grava no disco.
+// Se a gravação falhar, o estado em memória é recarregado do arquivo.
+func (a *ArmazenamentoJSON) alterar(mudanca func() error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := mudanca(); err != nil {
+		return err
+	}
+	if err := a.persistir(); err != nil {
+		if errCarga := a.carregar(); errCarga != nil {
+			return errors.Join(err, errCarga)
+		}
+		return fmt.Errorf("gravar %s: %w", a.caminho, err)
+	}
+	return nil
+}
+
+func (a *ArmazenamentoJSON) Inserir(colecao, id string, doc []byte) error {
+	return a.alterar(func() error { return a.inserir(colecao, id, doc) })
+}
+
+func (a *ArmazenamentoJSON) Atualizar(colecao, id string, mudar func(doc []byte) ([]byte, error)) ([]byte, error) {
+	var novo []byte
+	err := a.alterar(func() (err error) {
+		novo, err = a.atualizar(colecao, id, mudar)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return novo, nil
+}
+
+func (a *ArmazenamentoJSON) Remover(colecao, id string) error {
+	return a.alterar(func() error { return a.remover(colecao, id) })
+}
+
+func (a *ArmazenamentoJSON) RemoverSe(colecao, id string, condicao func(doc []byte) error) error {
+	return a.alterar(func() error { return a.removerSe(colecao, id, condicao) })
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// esquemaSQLite cria a tabela única de documentos; a ordem de inserção é dada pelo rowid
+const esquemaSQLite = `
+CREATE TABLE IF NOT EXISTS documentos (
+	colecao TEXT NOT NULL,
+	id      TEXT NOT NULL,
+	dados   TEXT NOT NULL,
+	PRIMARY KEY (colecao, id)
+)`
+
+// colunaDono extrai o dono do documento, para que o índice documentos_dono
+// encontre os documentos de um usuário sem decodificar os demais
+const colunaDono = `dono TEXT GENERATED ALWAYS AS (coalesce(json_extract(dados, '$.dono'), '')) VIRTUAL`
+
+// indiceDono ordena os documentos de cada dono pelo rowid, na ordem de inserção
+const indiceDono = `CREATE INDEX IF NOT EXISTS documentos_dono ON documentos (colecao, dono)`
+
+// ArmazenamentoSQLite persiste os documentos em um banco SQLite embutido
+type ArmazenamentoSQLite struct {
+	db *sql.DB
+}
+
+// NovoArmazenamentoSQLite abre (ou cria) o banco no caminho informado
+func NovoArmazenamentoSQLite(caminho string) (*ArmazenamentoSQLite, error) {
+	db, err := sql.Open("sqlite", caminho)
+	if err != nil {
+		return nil, fmt.Errorf("abrir %s: %w", caminho, err)
+	}
+
+	// O SQLite admite um único escritor; uma conexão evita erros de banco ocupado
+	db.SetMaxOpenConns(1)
+
+	if err := migrarSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("criar esquema em %s: %w", caminho, err)
+	}
+	return &ArmazenamentoSQLite{db: db}, nil
+}
+
+// migrarSQLite cria a tabela de documentos e acrescenta a coluna e o índice
+// de donos aos bancos criados antes deles
+func migrarSQLite(db *sql.DB) error {
+	if _, err := db.Exec(esquemaSQLite); err != nil {
+		return err
+	}
+	var temDono bool
+	err := db.QueryRow(`SELECT count(*) > 0 FROM pragma_table_xinfo('documentos') WHERE name = 'dono'`).Scan(&temDono)
+	if err != nil {
+		return err
+	}
+	if !temDono {
+		if _, err := db.Exec(`ALTER TABLE documentos ADD COLUMN ` + colunaDono); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(indiceDono)
+	return err
+}
+
+func (a *ArmazenamentoSQLite) Listar(colecao string) ([][]byte, error) {
+	return a.consultar(`SELECT dados FROM documentos WHERE colecao = ? ORDER BY rowid`, colecao)
+}
+
+func (a *ArmazenamentoSQLite) ListarDoDono(colecao, dono string) ([][]byte, error) {
+	return a.consultar(`SELECT dados FROM documentos WHERE colecao = ? AND dono = ? ORDER BY rowid`, colecao, dono)
+}
+
+// consultar retorna os documentos selecionados pela consulta
+func (a *ArmazenamentoSQLite) consultar(consulta string, args ...any) ([][]byte, error) {
+	linhas, err := a.db.Query(consulta, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer linhas.Close()
+
+	var docs [][]byte
+	for linhas.Next() {
+		var doc []byte
+		if err := linhas.Scan(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, linhas.Err()
+}
+
+func (a *ArmazenamentoSQLite) Buscar(colecao, id string) ([]byte, error) {
+	var doc []byte
+	err := a.db.QueryRow(`SELECT dados FROM documentos WHERE colecao = ? AND id = ?`, colecao, id).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNaoEncontrado
+	}
+	return doc, err
+}
+
+func (a *ArmazenamentoSQLite) Inserir(colecao, id string, doc []byte) error {
+	res, err := a.db.Exec(`INSERT OR IGNORE INTO documentos (colecao, id, dados) VALUES (?, ?, ?)`,
+		colecao, id, string(doc))
+	if err != nil {
+		return err
+	}
+	return exigirAlteracao(res, ErrDuplicado)
+}
+
+func (a *ArmazenamentoSQLite) Atualizar(colecao, id string, mudar func(doc []byte) ([]byte, error)) ([]byte, error) {
+	// A leitura e a escrita ocorrem na mesma transação; como o pool tem uma
+	// única conexão, nenhuma outra operação intercala entre elas
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var atual []byte
+	err = tx.QueryRow(`SELECT dados FROM documentos WHERE colecao = ? AND id = ?`, colecao, id).Scan(&atual)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNaoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	novo, err := mudar(atual)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE documentos SET dados = ? WHERE colecao = ? AND id = ?`,
+		string(novo), colecao, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return novo, nil
+}
+
+func (a *ArmazenamentoSQLite) Remover(colecao, id string) error {
+	res, err := a.db.Exec(`DELETE FROM documentos WHERE colecao = ? AND id = ?`, colecao, id)
+	if err != nil {
+		return err
+	}
+	return exigirAlteracao(res, ErrNaoEncontrado)
+}
+
+func (a *ArmazenamentoSQLite) RemoverSe(colecao, id string, condicao func(doc []byte) error) error {
+	// Como em Atualizar, a leitura e a exclusão ocorrem na mesma transação
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var atual []byte
+	err = tx.QueryRow(`SELECT dados FROM documentos WHERE colecao = ? AND id = ?`, colecao, id).Scan(&atual)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNaoEncontrado
+	}
+	if err != nil {
+		return err
+	}
+	if err := condicao(atual); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM documentos WHERE colecao = ? AND id = ?`, colecao, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (a *ArmazenamentoSQLite) Fechar() error {
+	return a.db.Close()
+}
+
+// exigirAlteracao retorna errSemLinhas quando o comando não afetou nenhuma linha
+func exigirAlteracao(res sql.Result, errSemLinhas error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errSemLinhas
+	}
+	return nil
+}
@@ -0,0 +1,527 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// implementacoes retorna um construtor para cada tipo de armazenamento.
+// O construtor recebe o diretório temporário do teste para que reaberturas
+// usem o mesmo arquivo.
+func implementacoes() map[string]func(t *testing.T, dir string) Armazenamento {
+	return map[string]func(t *testing.T, dir string) Armazenamento{
+		"memoria": func(t *testing.T, dir string) Armazenamento {
+			return NovoArmazenamentoMemoria()
+		},
+		"json": func(t *testing.T, dir string) Armazenamento {
+			a, err := NovoArmazenamentoJSON(filepath.Join(dir, "tarefas.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return a
+		},
+		"sqlite": func(t *testing.T, dir string) Armazenamento {
+			a, err := NovoArmazenamentoSQLite(filepath.Join(dir, "tarefas.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return a
+		},
+	}
+}
+
+func TestRepositorioTarefas(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+
+			primeira, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Primeira"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			segunda, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Segunda"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if primeira.ID == "" || primeira.ID == segunda.ID {
+				t.Fatalf("IDs inválidos: %q e %q", primeira.ID, segunda.ID)
+			}
+
+			// Atualizar e buscar
+			primeira, err = repo.Atualizar(usuarioTeste, primeira.ID, 0, func(t *Tarefa) error {
+				t.Concluida = true
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			obtida, err := repo.Buscar(usuarioTeste, primeira.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !obtida.Concluida || obtida.Versao != 2 {
+				t.Errorf("atualização não foi gravada: %+v", obtida)
+			}
+
+			// Uma versão desatualizada é rejeitada sem alterar a tarefa
+			_, err = repo.Atualizar(usuarioTeste, primeira.ID, 1, func(t *Tarefa) error {
+				t.Titulo = "Sobrescrita"
+				return nil
+			})
+			if !errors.Is(err, ErrConflitoVersao) {
+				t.Errorf("Atualizar com versão antiga: obtido %v esperado %v", err, ErrConflitoVersao)
+			}
+
+			// A listagem preserva a ordem de criação
+			tarefas, err := repo.Listar(usuarioTeste)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tarefas) != 2 || tarefas[0].ID != primeira.ID || tarefas[1].ID != segunda.ID {
+				t.Errorf("listagem inesperada: %+v", tarefas)
+			}
+
+			// Remover e verificar os erros de tarefa inexistente
+			if err := repo.Remover(usuarioTeste, primeira.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Buscar(usuarioTeste, primeira.ID); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Buscar após Remover: obtido %v esperado %v", err, ErrTarefaNaoEncontrada)
+			}
+			if err := repo.Remover(usuarioTeste, primeira.ID); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Remover duplicado: obtido %v esperado %v", err, ErrTarefaNaoEncontrada)
+			}
+			semMudanca := func(*Tarefa) error { return nil }
+			if _, err := repo.Atualizar(usuarioTeste, primeira.ID, 0, semMudanca); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Atualizar inexistente: obtido %v esperado %v", err, ErrTarefaNaoEncontrada)
+			}
+		})
+	}
+}
+
+func TestRepositorioIsolaDonos(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+
+			daAna, err := repo.Criar("ana", Tarefa{Titulo: "Da Ana", Dono: "bruno"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if daAna.Dono != "ana" {
+				t.Errorf("dono enviado pelo cliente não foi ignorado: %q", daAna.Dono)
+			}
+
+			// Para outro usuário, a tarefa não existe
+			if tarefas, _ := repo.Listar("bruno"); len(tarefas) != 0 {
+				t.Errorf("bruno vê tarefas da ana: %+v", tarefas)
+			}
+			if _, err := repo.Buscar("bruno", daAna.ID); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Buscar de outro dono: obtido %v", err)
+			}
+			mudarDono := func(t *Tarefa) error { t.Dono = "bruno"; return nil }
+			if _, err := repo.Atualizar("bruno", daAna.ID, 0, mudarDono); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Atualizar de outro dono: obtido %v", err)
+			}
+			if err := repo.Remover("bruno", daAna.ID); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Remover de outro dono: obtido %v", err)
+			}
+
+			// O próprio dono altera a tarefa, mas não a transfere
+			alterada, err := repo.Atualizar("ana", daAna.ID, 0, mudarDono)
+			if err != nil || alterada.Dono != "ana" {
+				t.Errorf("Atualizar pelo dono: %v %+v", err, alterada)
+			}
+		})
+	}
+}
+
+func TestRepositorioPurgaSoDaLixeira(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+			qualquer := func(Tarefa) bool { return true }
+
+			tarefa, err := repo.Criar("ana", Tarefa{Titulo: "Descartada"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Purgar("ana", tarefa.ID, qualquer); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Purgar fora da lixeira: obtido %v", err)
+			}
+
+			// Em outra versão, a tarefa não vai para a lixeira
+			if _, err := repo.MoverParaLixeira("ana", tarefa.ID, tarefa.Versao+1, "", nil); !errors.Is(err, ErrConflitoVersao) {
+				t.Errorf("MoverParaLixeira com versão antiga: obtido %v", err)
+			}
+
+			// Restaurada antes da purga, a tarefa fica
+			if _, err := repo.MoverParaLixeira("ana", tarefa.ID, tarefa.Versao, "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Restaurar("ana", tarefa.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Purgar("ana", tarefa.ID, qualquer); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Purgar restaurada: obtido %v", err)
+			}
+
+			if _, err := repo.MoverParaLixeira("ana", tarefa.ID, 0, "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Purgar("bruno", tarefa.ID, qualquer); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Purgar de outro dono: obtido %v", err)
+			}
+			if err := repo.Purgar("ana", tarefa.ID, func(Tarefa) bool { return false }); !errors.Is(err, ErrTarefaNaoEncontrada) {
+				t.Errorf("Purgar recusada pela condição: obtido %v", err)
+			}
+			if excluidas, _ := repo.ListarLixeira("ana"); len(excluidas) != 1 {
+				t.Fatalf("lixeira antes da purga: %+v", excluidas)
+			}
+			if err := repo.Purgar("ana", tarefa.ID, qualquer); err != nil {
+				t.Errorf("Purgar: %v", err)
+			}
+			if excluidas, _ := repo.ListarLixeira("ana"); len(excluidas) != 0 {
+				t.Errorf("lixeira depois da purga: %+v", excluidas)
+			}
+		})
+	}
+}
+
+func TestRepositorioProjetos(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioProjetos(a)
+
+			// Contagens e dono enviados pelo chamador não são gravados
+			criado, err := repo.Criar("ana", Projeto{Nome: "Casa", Dono: "bruno", TotalTarefas: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if criado.ID == "" || criado.Dono != "ana" || criado.TotalTarefas != 0 {
+				t.Errorf("projeto criado inesperado: %+v", criado)
+			}
+
+			renomear := func(p *Projeto) error { p.Nome = "Lar"; p.Dono = "bruno"; return nil }
+			if _, err := repo.Atualizar("bruno", criado.ID, renomear); !errors.Is(err, ErrProjetoNaoEncontrado) {
+				t.Errorf("Atualizar de outro dono: obtido %v", err)
+			}
+			renomeado, err := repo.Atualizar("ana", criado.ID, renomear)
+			if err != nil || renomeado.Nome != "Lar" || renomeado.Dono != "ana" || !renomeado.CriadoEm.Equal(criado.CriadoEm) {
+				t.Errorf("Atualizar pelo dono: %+v %v", renomeado, err)
+			}
+			if projetos, _ := repo.Listar("bruno"); len(projetos) != 0 {
+				t.Errorf("bruno vê projetos da ana: %+v", projetos)
+			}
+
+			if err := repo.Remover("bruno", criado.ID); !errors.Is(err, ErrProjetoNaoEncontrado) {
+				t.Errorf("Remover de outro dono: obtido %v", err)
+			}
+			if err := repo.Remover("ana", criado.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Buscar("ana", criado.ID); !errors.Is(err, ErrProjetoNaoEncontrado) {
+				t.Errorf("Buscar após Remover: obtido %v", err)
+			}
+		})
+	}
+}
+
+func TestRepositorioEtiquetas(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioEtiquetas(a)
+
+			// A etiqueta é normalizada antes de ser gravada
+			criada, err := repo.Criar("ana", Etiqueta{Nome: " Casa ", Cor: "#ABCDEF", Dono: "bruno"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if criada.ID == "" || criada.Dono != "ana" || criada.Nome != "Casa" || criada.Cor != "#abcdef" {
+				t.Errorf("etiqueta criada inesperada: %+v", criada)
+			}
+
+			recolorir := func(e *Etiqueta) error { e.Cor = ""; e.Dono = "bruno"; return nil }
+			if _, err := repo.Atualizar("bruno", criada.ID, recolorir); !errors.Is(err, ErrEtiquetaNaoEncontrada) {
+				t.Errorf("Atualizar de outro dono: obtido %v", err)
+			}
+			alterada, err := repo.Atualizar("ana", criada.ID, recolorir)
+			if err != nil || alterada.Cor != dominio.CorPadraoEtiqueta || alterada.Dono != "ana" || !alterada.CriadaEm.Equal(criada.CriadaEm) {
+				t.Errorf("Atualizar pelo dono: %+v %v", alterada, err)
+			}
+			if etiquetas, _ := repo.Listar("bruno"); len(etiquetas) != 0 {
+				t.Errorf("bruno vê etiquetas da ana: %+v", etiquetas)
+			}
+
+			if err := repo.Remover("ana", criada.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Buscar("ana", criada.ID); !errors.Is(err, ErrEtiquetaNaoEncontrada) {
+				t.Errorf("Buscar após Remover: obtido %v", err)
+			}
+		})
+	}
+}
+
+func TestAdotarTarefasSemDono(t *testing.T) {
+	a := NovoArmazenamentoMemoria()
+	repo := NovoRepositorioTarefas(a)
+
+	// Tarefas gravadas antes de existirem donos
+	if err := a.Inserir(colecaoTarefas, "antiga", []byte(`{"id":"antiga","titulo":"Antiga","versao":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Criar("bruno", Tarefa{Titulo: "Do Bruno"}); err != nil {
+		t.Fatal(err)
+	}
+
+	adotadas, err := repo.AdotarSemDono("ana")
+	if err != nil || adotadas != 1 {
+		t.Fatalf("AdotarSemDono: obtido %d, %v esperado 1", adotadas, err)
+	}
+	if obtida, err := repo.Buscar("ana", "antiga"); err != nil || obtida.Dono != "ana" {
+		t.Errorf("tarefa antiga não foi adotada: %+v %v", obtida, err)
+	}
+	if tarefas, _ := repo.Listar("bruno"); len(tarefas) != 1 {
+		t.Errorf("tarefas de outro dono foram afetadas: %+v", tarefas)
+	}
+	if adotadas, _ := repo.AdotarSemDono("ana"); adotadas != 0 {
+		t.Errorf("segunda adoção atribuiu %d tarefas", adotadas)
+	}
+}
+
+func TestIndexarChavesSemDono(t *testing.T) {
+	a, err := NovoArmazenamentoSQLite(filepath.Join(t.TempDir(), "tarefas.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Fechar()
+	repo := NovoRepositorioChaves(a)
+
+	// Chave gravada antes do índice de donos, com o usuário em "usuario"
+	segredo := prefixoChaveAPI + "antiga_segredo"
+	antiga := `{"id":"antiga","nome":"Antiga","usuario":"ana","hash":"` + hashSegredo(segredo) + `"}`
+	if err := a.Inserir(colecaoChaves, "antiga", []byte(antiga)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Criar("bruno", "Do Bruno", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Antes da indexação a chave antiga continua válida e revogável
+	if _, usuario, err := repo.Validar(segredo); err != nil || usuario != "ana" {
+		t.Fatalf("Validar chave antiga: obtido %q, %v", usuario, err)
+	}
+
+	indexadas, err := repo.IndexarChavesSemDono()
+	if err != nil || indexadas != 1 {
+		t.Fatalf("IndexarChavesSemDono: obtido %d, %v esperado 1", indexadas, err)
+	}
+	if chaves, _ := repo.Listar("ana"); len(chaves) != 1 || chaves[0].ID != "antiga" {
+		t.Errorf("chaves de ana: %+v", chaves)
+	}
+	if chaves, _ := repo.Listar("bruno"); len(chaves) != 1 {
+		t.Errorf("chaves de outro dono foram afetadas: %+v", chaves)
+	}
+	if indexadas, _ := repo.IndexarChavesSemDono(); indexadas != 0 {
+		t.Errorf("segunda indexação passou %d chaves", indexadas)
+	}
+	if err := repo.Remover("ana", "antiga"); err != nil {
+		t.Errorf("Remover chave indexada: %v", err)
+	}
+}
+
+func TestArmazenamentoListarDoDono(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+
+			for _, doc := range []string{
+				`{"id":"1","dono":"ana"}`,
+				`{"id":"2","dono":"bruno"}`,
+				`{"id":"3"}`,
+				`{"id":"4","dono":"ana"}`,
+			} {
+				var chave struct{ ID string }
+				json.Unmarshal([]byte(doc), &chave)
+				if err := a.Inserir("docs", chave.ID, []byte(doc)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ids := func(dono string) string {
+				docs, err := a.ListarDoDono("docs", dono)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []string
+				for _, doc := range docs {
+					var chave struct{ ID string }
+					json.Unmarshal(doc, &chave)
+					ids = append(ids, chave.ID)
+				}
+				return strings.Join(ids, ",")
+			}
+			if obtidos := ids("ana"); obtidos != "1,4" {
+				t.Errorf("documentos de ana: %q", obtidos)
+			}
+			if obtidos := ids(""); obtidos != "3" {
+				t.Errorf("documentos sem dono: %q", obtidos)
+			}
+
+			// Um documento que muda de dono vai para a posição de inserção dele
+			if _, err := a.Atualizar("docs", "3", func([]byte) ([]byte, error) { return []byte(`{"id":"3","dono":"ana"}`), nil }); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Remover("docs", "1"); err != nil {
+				t.Fatal(err)
+			}
+			if obtidos := ids("ana"); obtidos != "3,4" {
+				t.Errorf("documentos de ana depois das mudanças: %q", obtidos)
+			}
+			if obtidos := ids(""); obtidos != "" {
+				t.Errorf("documentos sem dono depois das mudanças: %q", obtidos)
+			}
+			if obtidos := ids("bruno"); obtidos != "2" {
+				t.Errorf("documentos de bruno: %q", obtidos)
+			}
+		})
+	}
+}
+
+func TestSQLiteMigraColunaDono(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "tarefas.db")
+
+	// Banco criado antes da coluna de donos
+	db, err := sql.Open("sqlite", caminho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(esquemaSQLite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO documentos (colecao, id, dados) VALUES ('tarefas', 'antiga', '{"id":"antiga","dono":"ana"}')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	a, err := NovoArmazenamentoSQLite(caminho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Fechar()
+	if docs, err := a.ListarDoDono("tarefas", "ana"); err != nil || len(docs) != 1 {
+		t.Errorf("documentos de ana depois da migração: %d, %v", len(docs), err)
+	}
+}
+
+func TestArmazenamentoPersistente(t *testing.T) {
+	for _, nome := range []string{"json", "sqlite"} {
+		abrir := implementacoes()[nome]
+		t.Run(nome, func(t *testing.T) {
+			dir := t.TempDir()
+
+			a := abrir(t, dir)
+			criada, err := NovoRepositorioTarefas(a).Criar(usuarioTeste, Tarefa{Titulo: "Sobreviver ao reinício"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Fechar(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Reabrir o mesmo arquivo deve recuperar a tarefa
+			a = abrir(t, dir)
+			defer a.Fechar()
+			obtida, err := NovoRepositorioTarefas(a).Buscar(usuarioTeste, criada.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(obtida, criada) {
+				t.Errorf("tarefa recuperada difere: obtida %+v esperada %+v", obtida, criada)
+			}
+		})
+	}
+}
+
+func TestAbrirArmazenamentoDesconhecido(t *testing.T) {
+	if _, err := abrirArmazenamento("redis", ""); err == nil {
+		t.Error("esperado erro para tipo de armazenamento desconhecido")
+	}
+}
+
+func TestRepositorioControlaDatas(t *testing.T) {
+	// Relógio controlado pelo teste
+	instante := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	agoraOriginal := agora
+	agora = func() time.Time { return instante }
+	defer func() { agora = agoraOriginal }()
+
+	repo := NovoRepositorioTarefas(NovoArmazenamentoMemoria())
+	criada, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Com datas", CriadaEm: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !criada.CriadaEm.Equal(instante) || !criada.AtualizadaEm.Equal(instante) || criada.ConcluidaEm != nil {
+		t.Errorf("datas de criação inesperadas: %+v", criada)
+	}
+	if criada.Prioridade != dominio.PrioridadeMedia {
+		t.Errorf("prioridade padrão: obtida %q esperada %q", criada.Prioridade, dominio.PrioridadeMedia)
+	}
+
+	// Concluir registra concluida_em e atualizada_em
+	instante = instante.Add(time.Hour)
+	concluida, err := repo.Atualizar(usuarioTeste, criada.ID, 0, func(t *Tarefa) error {
+		t.Concluida = true
+		t.CriadaEm = time.Unix(0, 0)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !concluida.CriadaEm.Equal(criada.CriadaEm) || !concluida.AtualizadaEm.Equal(instante) ||
+		concluida.ConcluidaEm == nil || !concluida.ConcluidaEm.Equal(instante) {
+		t.Errorf("datas após concluir inesperadas: %+v", concluida)
+	}
+
+	// Alterar outro campo mantém a data de conclusão; reabrir a tarefa a remove
+	instante = instante.Add(time.Hour)
+	alterada, err := repo.Atualizar(usuarioTeste, criada.ID, 0, func(t *Tarefa) error {
+		t.Descricao = "Detalhes"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !alterada.ConcluidaEm.Equal(*concluida.ConcluidaEm) {
+		t.Errorf("concluida_em mudou sem reconclusão: %v", alterada.ConcluidaEm)
+	}
+	reaberta, err := repo.Atualizar(usuarioTeste, criada.ID, 0, func(t *Tarefa) error {
+		t.Concluida = false
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reaberta.ConcluidaEm != nil {
+		t.Errorf("concluida_em deveria ser removida ao reabrir: %v", reaberta.ConcluidaEm)
+	}
+}
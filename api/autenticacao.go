@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errTokenInvalido indica um JWT malformado, com assinatura incorreta ou expirado
+var errTokenInvalido = errors.New("token inválido ou expirado")
+
+// validadePadraoToken é a validade dos tokens emitidos no login quando
+// JWT_VALIDADE não é informada
+const validadePadraoToken = time.Hour
+
+// cabecalhoJWT é o cabeçalho fixo dos tokens emitidos, já codificado
+var cabecalhoJWT = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// hashSenhaInexistente é comparado quando o usuário não existe, para que o
+// tempo de resposta não revele quais logins existem
+var hashSenhaInexistente, _ = bcrypt.GenerateFromPassword([]byte("senha-inexistente"), bcrypt.DefaultCost)
+
+// emissorTokens emite e verifica JWTs assinados com HMAC-SHA256
+type emissorTokens struct {
+	segredo  []byte
+	validade time.Duration
+}
+
+// reivindicacoesJWT são as claims dos tokens emitidos; escopos seguem o
+// formato da claim scope da RFC 8693, separados por espaço
+type reivindicacoesJWT struct {
+	Sujeito   string `json:"sub"`
+	Escopo    string `json:"scope"`
+	EmitidoEm int64  `json:"iat"`
+	ExpiraEm  int64  `json:"exp"`
+}
+
+// novoEmissorTokens cria o emissor. Sem segredo, gera um aleatório: os tokens
+// deixam de valer quando a API reinicia.
+func novoEmissorTokens(segredo string, validade time.Duration) *emissorTokens {
+	if validade <= 0 {
+		validade = validadePadraoToken
+	}
+	e := &emissorTokens{segredo: []byte(segredo), validade: validade}
+	if segredo == "" {
+		log.Println("JWT_SEGREDO não definido: usando um segredo aleatório; os tokens expiram ao reiniciar a API")
+		e.segredo = make([]byte, 32)
+		if _, err := rand.Read(e.segredo); err != nil {
+			log.Panicf("falha ao gerar segredo JWT: %v", err)
+		}
+	}
+	return e
+}
+
+// emitir gera um token para o usuário com os escopos informados
+func (e *emissorTokens) emitir(usuario string, escopos []string) dominio.Sessao {
+	instante := agora()
+	expira := instante.Add(e.validade)
+	claims, _ := json.Marshal(reivindicacoesJWT{
+		Sujeito:   usuario,
+		Escopo:    strings.Join(escopos, " "),
+		EmitidoEm: instante.Unix(),
+		ExpiraEm:  expira.Unix(),
+	})
+	conteudo := cabecalhoJWT + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return dominio.Sessao{
+		Token:    conteudo + "." + e.assinar(conteudo),
+		Tipo:     "Bearer",
+		ExpiraEm: time.Unix(expira.Unix(), 0).UTC(),
+		Usuario:  usuario,
+		Escopos:  escopos,
+	}
+}
+
+// verificar valida assinatura e expiração e retorna a identidade do token.
+// Apenas o cabeçalho emitido por este servidor é aceito, o que impede trocar
+// o algoritmo (por exemplo, para "none").
+func (e *emissorTokens) verificar(token string) (identidade, error) {
+	partes := strings.Split(token, ".")
+	if len(partes) != 3 || partes[0] != cabecalhoJWT {
+		return identidade{}, errTokenInvalido
+	}
+	if !hmac.Equal([]byte(partes[2]), []byte(e.assinar(partes[0]+"."+partes[1]))) {
+		return identidade{}, errTokenInvalido
+	}
+	b, err := base64.RawURLEncoding.DecodeString(partes[1])
+	if err != nil {
+		return identidade{}, errTokenInvalido
+	}
+	var c reivindicacoesJWT
+	if err := json.Unmarshal(b, &c); err != nil || c.Sujeito == "" || agora().Unix() >= c.ExpiraEm {
+		return identidade{}, errTokenInvalido
+	}
+	return identidade{usuario: c.Sujeito, escopos: strings.Fields(c.Escopo)}, nil
+}
+
+// assinar calcula a assinatura HS256 do conteúdo
+func (e *emissorTokens) assinar(conteudo string) string {
+	mac := hmac.New(sha256.New, e.segredo)
+	mac.Write([]byte(conteudo))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// identidade é o chamador autenticado de uma requisição
+type identidade struct {
+	usuario string
+	escopos []string
+	// chave é o ID da chave de API usada; vazio para tokens de login
+	chave string
+}
+
+// permite informa se a identidade tem o escopo
+func (i identidade) permite(escopo string) bool {
+	for _, e := range i.escopos {
+		if e == escopo {
+			return true
+		}
+	}
+	return false
+}
+
+// chaveIdentidade é a chave da identidade autenticada no contexto
+type chaveIdentidade struct{}
+
+// identidadeDe retorna a identidade autenticada guardada no contexto
+func identidadeDe(ctx context.Context) (identidade, bool) {
+	id, ok := ctx.Value(chaveIdentidade{}).(identidade)
+	return id, ok
+}
+
+// usuarioDe retorna o login do usuário autenticado guardado no contexto
+func usuarioDe(ctx context.Context) string {
+	id, _ := identidadeDe(ctx)
+	return id.usuario
+}
+
+// autenticado exige uma credencial válida antes de chamar h. Requisições GET
+// precisam do escopo de leitura e as demais, do de escrita. OPTIONS passa
+// sem credencial, pois navegadores não as enviam no preflight de CORS.
+func (s *servidor) autenticado(leitura, escrita string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			h(w, r)
+			return
+		}
+
+		id, err := s.autenticar(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			responderProblema(w, r, problemaNaoAutenticado)
+			return
+		}
+
+		escopo := escrita
+		if r.Method == "GET" || r.Method == "HEAD" {
+			escopo = leitura
+		}
+		if !id.permite(escopo) {
+			responderProblema(w, r, problemaAcessoNegado)
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), chaveIdentidade{}, id)))
+	}
+}
+
+// autenticar identifica o chamador pelo cabeçalho Authorization: Bearer,
+// com um JWT ou uma chave de API, ou pelo cabeçalho X-API-Key
+func (s *servidor) autenticar(r *http.Request) (identidade, error) {
+	credencial := r.Header.Get("X-API-Key")
+	if autorizacao := r.Header.Get("Authorization"); autorizacao != "" {
+		tipo, valor, _ := strings.Cut(autorizacao, " ")
+		if !strings.EqualFold(tipo, "Bearer") {
+			return identidade{}, errTokenInvalido
+		}
+		credencial = strings.TrimSpace(valor)
+	}
+	if credencial == "" {
+		return identidade{}, errTokenInvalido
+	}
+
+	if !strings.HasPrefix(credencial, prefixoChaveAPI) {
+		return s.tokens.verificar(credencial)
+	}
+	chave, usuario, err := s.chaves.Validar(credencial)
+	if err != nil {
+		return identidade{}, err
+	}
+	return identidade{usuario: usuario, escopos: chave.Escopos, chave: chave.ID}, nil
+}
+
+// manipuladorLogin atende POST /api/auth/login, trocando usuário e senha por um JWT
+func (s *servidor) manipuladorLogin(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	if r.Method != "POST" {
+		responderMetodoNaoPermitido(w, r, "POST")
+		return
+	}
+
+	var login dominio.Login
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		responderProblema(w, r, problemaCorpoInvalido)
+		return
+	}
+
+	u, err := s.usuarios.Buscar(login.Usuario)
+	hash := []byte(u.SenhaHash)
+	switch {
+	case errors.Is(err, ErrUsuarioNaoEncontrado):
+		hash = hashSenhaInexistente
+	case err != nil:
+		responderErroInterno(w, r, err)
+		return
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(login.Senha)) != nil || err != nil {
+		responderProblema(w, r, problemaCredenciaisInvalidas)
+		return
+	}
+
+	// O login concede todos os escopos; chaves de API podem ser mais restritas
+	json.NewEncoder(w).Encode(s.tokens.emitir(u.ID, dominio.EscoposValidos))
+}
+
+// manipuladorChaves atende /api/chaves: lista e cria chaves de API do usuário
+func (s *servidor) manipuladorChaves(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	id, ok := s.exigirLogin(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		chaves, err := s.chaves.Listar(id.usuario)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(chaves)
+	case "POST":
+		var pedido struct {
+			Nome    string   `json:"nome"`
+			Escopos []string `json:"escopos"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if err := validarPedidoChave(pedido.Nome, pedido.Escopos); err != nil {
+			responderProblema(w, r, problemaValidacao, *err)
+			return
+		}
+		chave, err := s.chaves.Criar(id.usuario, strings.TrimSpace(pedido.Nome), pedido.Escopos)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/chaves/"+chave.ID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(chave)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST")
+	}
+}
+
+// manipuladorChave atende /api/chaves/{id}, revogando a chave
+func (s *servidor) manipuladorChave(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	id, ok := s.exigirLogin(w, r)
+	if !ok {
+		return
+	}
+
+	chave := strings.TrimPrefix(r.URL.Path, "/api/chaves/")
+	if chave == "" || strings.Contains(chave, "/") {
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+	if r.Method != "DELETE" {
+		responderMetodoNaoPermitido(w, r, "DELETE")
+		return
+	}
+
+	err := s.chaves.Remover(id.usuario, chave)
+	switch {
+	case errors.Is(err, ErrChaveNaoEncontrada):
+		responderProblema(w, r, problemaChaveNaoEncontrada)
+	case err != nil:
+		responderErroInterno(w, r, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// exigirLogin autentica a requisição e recusa chaves de API, para que uma
+// chave vazada não possa criar outras nem revogar as do dono
+func (s *servidor) exigirLogin(w http.ResponseWriter, r *http.Request) (identidade, bool) {
+	id, err := s.autenticar(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		responderProblema(w, r, problemaNaoAutenticado)
+		return id, false
+	}
+	if id.chave != "" {
+		responderProblema(w, r, problemaAcessoNegado)
+		return id, false
+	}
+	return id, true
+}
+
+// validarPedidoChave verifica o nome e os escopos de uma nova chave
+func validarPedidoChave(nome string, escopos []string) *dominio.ErroValidacao {
+	if strings.TrimSpace(nome) == "" {
+		return &dominio.ErroValidacao{Campo: "nome", Codigo: dominio.CodigoObrigatorio, Mensagens: dominio.Mensagens{
+			PtBR: "o nome da chave é obrigatório",
+			En:   "key name is required",
+		}}
+	}
+	if len(escopos) == 0 {
+		return &dominio.ErroValidacao{Campo: "escopos", Codigo: dominio.CodigoObrigatorio, Mensagens: dominio.Mensagens{
+			PtBR: "informe ao menos um escopo",
+			En:   "at least one scope is required",
+		}}
+	}
+	for _, e := range escopos {
+		if !dominio.EscopoValido(e) {
+			return &dominio.ErroValidacao{Campo: "escopos", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "escopo desconhecido: " + e,
+				En:   "unknown scope: " + e,
+			}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+func TestLogin(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	rr := executarComo(t, srv, "", "POST", "/api/auth/login", `{"usuario":"teste","senha":"senha-teste"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	var sessao dominio.Sessao
+	if err := json.Unmarshal(rr.Body.Bytes(), &sessao); err != nil {
+		t.Fatal(err)
+	}
+	if sessao.Usuario != usuarioTeste || sessao.Tipo != "Bearer" || len(sessao.Escopos) != 2 {
+		t.Errorf("sessão inesperada: %+v", sessao)
+	}
+
+	// O token emitido dá acesso às tarefas
+	if rr := executarComo(t, srv, sessao.Token, "GET", "/api/tarefas", ""); rr.Code != http.StatusOK {
+		t.Errorf("GET com token do login retornou %d", rr.Code)
+	}
+
+	// Senha errada e usuário inexistente recebem a mesma resposta
+	for _, corpo := range []string{`{"usuario":"teste","senha":"errada"}`, `{"usuario":"ninguem","senha":"senha-teste"}`} {
+		rr := executarComo(t, srv, "", "POST", "/api/auth/login", corpo)
+		if p := lerProblema(t, rr); rr.Code != http.StatusUnauthorized || p.Codigo != dominio.CodigoCredenciaisInvalidas {
+			t.Errorf("login %s: obtido %d %q", corpo, rr.Code, p.Codigo)
+		}
+	}
+}
+
+func TestTarefasExigemCredencialValida(t *testing.T) {
+	srv := novoServidorTeste(t)
+	valido := tokenTeste(srv)
+	partes := strings.Split(valido, ".")
+
+	// Token assinado com outro segredo
+	outro := novoServidor(NovoArmazenamentoMemoria(), configuracao{segredoJWT: "outro-segredo"})
+
+	casos := map[string]string{
+		"sem credencial":     "",
+		"token malformado":   "abc.def",
+		"claims adulteradas": partes[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","scope":"tarefas:read","exp":9999999999}`)) + "." + partes[2],
+		"alg none":           "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0." + partes[1] + ".",
+		"outro segredo":      tokenTeste(outro),
+		"chave inexistente":  prefixoChaveAPI + "abc_def",
+	}
+	for nome, credencial := range casos {
+		t.Run(nome, func(t *testing.T) {
+			rr := executarComo(t, srv, credencial, "GET", "/api/tarefas", "")
+			p := lerProblema(t, rr)
+			if rr.Code != http.StatusUnauthorized || p.Codigo != dominio.CodigoNaoAutenticado {
+				t.Errorf("obtido %d %q esperado 401 %q", rr.Code, p.Codigo, dominio.CodigoNaoAutenticado)
+			}
+			if rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("resposta 401 sem WWW-Authenticate")
+			}
+		})
+	}
+
+	// Rotas públicas continuam acessíveis sem credencial
+	for _, url := range []string{"/api/health", "/api/openapi.json", "/api/docs"} {
+		if rr := executarComo(t, srv, "", "GET", url, ""); rr.Code != http.StatusOK {
+			t.Errorf("GET %s sem credencial retornou %d", url, rr.Code)
+		}
+	}
+}
+
+func TestTokenExpirado(t *testing.T) {
+	srv := novoServidorTeste(t)
+	token := tokenTeste(srv)
+
+	agoraOriginal := agora
+	agora = func() time.Time { return agoraOriginal().Add(validadePadraoToken + time.Second) }
+	defer func() { agora = agoraOriginal }()
+
+	if rr := executarComo(t, srv, token, "GET", "/api/tarefas", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("token expirado retornou %d esperado %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestChavesDeAPIRespeitamEscopos(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	rr := executar(t, srv, "POST", "/api/chaves", `{"nome":"Relatórios","escopos":["tarefas:read"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/chaves retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	var leitura dominio.ChaveAPI
+	if err := json.Unmarshal(rr.Body.Bytes(), &leitura); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(leitura.Chave, prefixoChaveAPI) || rr.Header().Get("Location") != "/api/chaves/"+leitura.ID {
+		t.Fatalf("chave criada inesperada: %+v", leitura)
+	}
+
+	// A chave de leitura lista tarefas, pelos dois cabeçalhos aceitos
+	if rr := executarComo(t, srv, leitura.Chave, "GET", "/api/tarefas", ""); rr.Code != http.StatusOK {
+		t.Errorf("GET com chave de leitura retornou %d", rr.Code)
+	}
+	req := httptest.NewRequest("GET", "/api/tarefas", nil)
+	req.Header.Set("X-API-Key", leitura.Chave)
+	rr = httptest.NewRecorder()
+	srv.rotas().ServeHTTP(rr, req)
+	validarContrato(t, req, "", rr)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET com X-API-Key retornou %d", rr.Code)
+	}
+
+	// ...mas não altera tarefas nem gerencia chaves
+	rr = executarComo(t, srv, leitura.Chave, "POST", "/api/tarefas", `{"titulo":"Negada"}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusForbidden || p.Codigo != dominio.CodigoAcessoNegado {
+		t.Errorf("POST com chave de leitura: obtido %d %q esperado 403", rr.Code, p.Codigo)
+	}
+	if rr := executarComo(t, srv, leitura.Chave, "GET", "/api/chaves", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("GET /api/chaves com chave de API retornou %d esperado 403", rr.Code)
+	}
+
+	// A listagem não expõe segredos
+	rr = executar(t, srv, "GET", "/api/chaves", "")
+	if strings.Contains(rr.Body.String(), leitura.Chave) || !strings.Contains(rr.Body.String(), leitura.ID) {
+		t.Errorf("listagem de chaves inesperada: %s", rr.Body.String())
+	}
+
+	// Depois de revogada, a chave deixa de funcionar
+	if rr := executar(t, srv, "DELETE", "/api/chaves/"+leitura.ID, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	if rr := executarComo(t, srv, leitura.Chave, "GET", "/api/tarefas", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("chave revogada retornou %d esperado 401", rr.Code)
+	}
+	if rr := executar(t, srv, "DELETE", "/api/chaves/"+leitura.ID, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("segundo DELETE retornou %d esperado 404", rr.Code)
+	}
+}
+
+func TestCriarChaveValidaEscopos(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	casos := map[string]string{
+		"nome":    `{"nome":" ","escopos":["tarefas:read"]}`,
+		"escopos": `{"nome":"CI","escopos":[]}`,
+	}
+	for campo, corpo := range casos {
+		rr := executar(t, srv, "POST", "/api/chaves", corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != campo {
+			t.Errorf("%s: obtido %d %+v", corpo, rr.Code, p.Campos)
+		}
+	}
+}
+
+func TestCORSApenasOrigensPermitidas(t *testing.T) {
+	srv := novoServidor(NovoArmazenamentoMemoria(), configuracao{
+		segredoJWT:  "segredo-teste",
+		origensCORS: []string{"http://localhost:3000"},
+	})
+
+	preflight := func(origem string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "/api/tarefas", nil)
+		req.Header.Set("Origin", origem)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rr := httptest.NewRecorder()
+		srv.rotas().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := preflight("http://localhost:3000")
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Errorf("preflight da origem permitida: %d %v", rr.Code, rr.Header())
+	}
+	if !strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("preflight não libera Authorization: %q", rr.Header().Get("Access-Control-Allow-Headers"))
+	}
+
+	rr = preflight("http://malicioso.example")
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("origem não permitida recebeu Access-Control-Allow-Origin")
+	}
+	if rr.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary: obtido %q esperado Origin", rr.Header().Get("Vary"))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrBlobNaoEncontrado indica que não há conteúdo guardado com o hash informado
+var ErrBlobNaoEncontrado = errors.New("conteúdo não encontrado")
+
+// ArmazenamentoBlobs guarda o conteúdo dos anexos endereçado pelo hash
+// SHA-256, de modo que conteúdos iguais são guardados uma única vez. Os
+// metadados dos anexos ficam no Armazenamento; esta interface permite trocar
+// o meio onde ficam os arquivos (diretório local, serviço de objetos).
+type ArmazenamentoBlobs interface {
+	// Receber lê o conteúdo até o fim para um local temporário, calculando
+	// o hash e o tamanho, sem guardá-lo. Se a leitura falhar, nada fica.
+	Receber(conteudo io.Reader) (BlobRecebido, error)
+	// Guardar torna o conteúdo recebido disponível pelo seu hash; se já
+	// houver um conteúdo com o mesmo hash, o recebido é descartado
+	Guardar(recebido BlobRecebido) error
+	// Descartar abandona o conteúdo recebido quando Guardar não foi chamado
+	// ou falhou
+	Descartar(recebido BlobRecebido)
+	// Abrir retorna o conteúdo com o hash informado ou ErrBlobNaoEncontrado
+	Abrir(hash string) (io.ReadSeekCloser, error)
+	// Remover exclui o conteúdo com o hash informado; remover um conteúdo
+	// inexistente não é erro
+	Remover(hash string) error
+}
+
+// BlobRecebido é um conteúdo lido por completo mas ainda não guardado. Ler o
+// conteúdo e guardá-lo são passos separados para que só o segundo, rápido,
+// precise ser coordenado com a remoção dos conteúdos sem uso.
+type BlobRecebido struct {
+	Hash    string
+	Tamanho int64
+	// temp identifica o conteúdo temporário no meio de armazenamento
+	temp string
+}
+
+// BlobsLocais guarda os conteúdos em um diretório local, um arquivo por hash
+// em subdiretórios com os dois primeiros caracteres do hash
+type BlobsLocais struct {
+	diretorio string
+}
+
+// NovoBlobsLocais cria um armazenamento de conteúdos no diretório informado,
+// criado na primeira gravação
+func NovoBlobsLocais(diretorio string) *BlobsLocais {
+	return &BlobsLocais{diretorio: diretorio}
+}
+
+// caminho retorna o arquivo do hash; hashes malformados não são aceitos, o
+// que impede caminhos fora do diretório
+func (b *BlobsLocais) caminho(hash string) (string, bool) {
+	if len(hash) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return filepath.Join(b.diretorio, hash[:2], hash), true
+}
+
+func (b *BlobsLocais) Receber(conteudo io.Reader) (BlobRecebido, error) {
+	if err := os.MkdirAll(b.diretorio, 0o755); err != nil {
+		return BlobRecebido{}, err
+	}
+	// O conteúdo vai para um arquivo temporário no mesmo diretório, que
+	// Guardar renomeia quando o hash é conhecido
+	temp, err := os.CreateTemp(b.diretorio, "envio-*")
+	if err != nil {
+		return BlobRecebido{}, err
+	}
+
+	h := sha256.New()
+	tamanho, err := io.Copy(io.MultiWriter(temp, h), conteudo)
+	if errFechar := temp.Close(); err == nil {
+		err = errFechar
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return BlobRecebido{}, err
+	}
+	return BlobRecebido{Hash: hex.EncodeToString(h.Sum(nil)), Tamanho: tamanho, temp: temp.Name()}, nil
+}
+
+func (b *BlobsLocais) Guardar(recebido BlobRecebido) error {
+	destino, ok := b.caminho(recebido.Hash)
+	if !ok {
+		return ErrBlobNaoEncontrado
+	}
+	if _, err := os.Stat(destino); err == nil {
+		// Conteúdo já guardado: o temporário é descartado
+		b.Descartar(recebido)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(destino), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(recebido.temp, destino)
+}
+
+func (b *BlobsLocais) Descartar(recebido BlobRecebido) {
+	if recebido.temp != "" {
+		os.Remove(recebido.temp)
+	}
+}
+
+func (b *BlobsLocais) Abrir(hash string) (io.ReadSeekCloser, error) {
+	caminho, ok := b.caminho(hash)
+	if !ok {
+		return nil, ErrBlobNaoEncontrado
+	}
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNaoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (b *BlobsLocais) Remover(hash string) error {
+	caminho, ok := b.caminho(hash)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(caminho); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// manipuladorComentarios atende os comentários da tarefa: a coleção em
+// /api/tarefas/{id}/comentarios e, com comentarioID, um comentário em
+// /api/tarefas/{id}/comentarios/{comentarioID}
+func (s *servidor) manipuladorComentarios(w http.ResponseWriter, r *http.Request, dono, tarefaID, comentarioID string) {
+	if strings.Contains(comentarioID, "/") {
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+	if r.Method != "OPTIONS" {
+		if _, err := s.tarefas.Buscar(dono, tarefaID); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+	if comentarioID != "" {
+		s.manipuladorComentario(w, r, dono, tarefaID, comentarioID)
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		comentarios, err := s.comentarios.Listar(dono, tarefaID)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(comentarios)
+	case "POST":
+		var c Comentario
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if err := c.Validar(); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		c.TarefaID = tarefaID
+		c, err := s.comentarios.Criar(dono, c)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/tarefas/"+tarefaID+"/comentarios/"+c.ID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST, OPTIONS")
+	}
+}
+
+// manipuladorComentario atende um comentário da tarefa: PATCH troca o texto
+// e o marca como editado
+func (s *servidor) manipuladorComentario(w http.ResponseWriter, r *http.Request, dono, tarefaID, id string) {
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	c, err := s.comentarios.Buscar(dono, id)
+	if err == nil && c.TarefaID != tarefaID {
+		err = ErrComentarioNaoEncontrado
+	}
+
+	switch r.Method {
+	case "GET":
+	case "PATCH":
+		if err != nil {
+			break
+		}
+		var pedido struct {
+			Texto string `json:"texto"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if err := (Comentario{Texto: pedido.Texto}).Validar(); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		c, err = s.comentarios.Editar(dono, id, pedido.Texto)
+	case "DELETE":
+		if err == nil {
+			err = s.comentarios.Remover(dono, id)
+		}
+		if err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, PATCH, DELETE, OPTIONS")
+		return
+	}
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(c)
+}
+
+// registrarTitulo grava no histórico a troca do título da tarefa, se ele de
+// fato mudou
+func (s *servidor) registrarTitulo(dono, tarefaID, de, para string) error {
+	if de == para {
+		return nil
+	}
+	_, err := s.historico.RegistrarRenomeacao(dono, Renomeacao{TarefaID: tarefaID, De: de, Para: para})
+	return err
+}
+
+// manipuladorAtividade atende GET /api/tarefas/{id}/atividade, a linha do
+// tempo da tarefa com os comentários intercalados aos eventos do sistema:
+// criação, mudanças de estado e renomeações
+func (s *servidor) manipuladorAtividade(w http.ResponseWriter, r *http.Request, dono, id string) {
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		if _, err := s.tarefas.Buscar(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		estados, err := s.historico.Listar(dono, id)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		renomeacoes, err := s.historico.ListarRenomeacoes(dono, id)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		comentarios, err := s.comentarios.Listar(dono, id)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(dominio.LinhaDoTempo(estados, renomeacoes, comentarios))
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, OPTIONS")
+	}
+}
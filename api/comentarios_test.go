@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+func TestComentariosDaTarefa(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Revisar PR")
+	colecao := "/api/tarefas/" + id + "/comentarios"
+
+	rr := executar(t, srv, "POST", colecao, `{"texto":"  Falta **testar** no Safari  "}`)
+	var c Comentario
+	if err := json.Unmarshal(rr.Body.Bytes(), &c); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST comentarios retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if c.Texto != "Falta **testar** no Safari" || c.TarefaID != id || c.Editado || rr.Header().Get("Location") != colecao+"/"+c.ID {
+		t.Errorf("comentário criado: %+v", c)
+	}
+	rr = executar(t, srv, "POST", colecao, `{"texto":"   "}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "texto" {
+		t.Errorf("comentário vazio: obtido %d %+v", rr.Code, p.Campos)
+	}
+
+	// Editar marca o comentário como editado
+	rr = executar(t, srv, "PATCH", colecao+"/"+c.ID, `{"texto":"Testado no Safari"}`)
+	var editado Comentario
+	if err := json.Unmarshal(rr.Body.Bytes(), &editado); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("PATCH comentario retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if editado.Texto != "Testado no Safari" || !editado.Editado || editado.EditadoEm == nil || !editado.CriadoEm.Equal(c.CriadoEm) {
+		t.Errorf("comentário editado: %+v", editado)
+	}
+
+	// O comentário só existe sob a sua tarefa e para o seu dono
+	outra := criarTarefaTeste(t, srv, "Outra")
+	if rr := executar(t, srv, "GET", "/api/tarefas/"+outra+"/comentarios/"+c.ID, ""); rr.Code != http.StatusNotFound || lerProblema(t, rr).Codigo != dominio.CodigoComentarioNaoEncontrado {
+		t.Errorf("comentário sob outra tarefa retornou %d", rr.Code)
+	}
+	if err := srv.usuarios.DefinirSenha("outro", "senha-outro"); err != nil {
+		t.Fatal(err)
+	}
+	outro := srv.tokens.emitir("outro", dominio.EscoposValidos).Token
+	if rr := executarComo(t, srv, outro, "GET", colecao, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("comentários de outro usuário retornaram %d", rr.Code)
+	}
+
+	rr = executar(t, srv, "DELETE", colecao+"/"+c.ID, "")
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("DELETE comentario retornou %d", rr.Code)
+	}
+	if rr := executar(t, srv, "GET", colecao+"/"+c.ID, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("comentário removido retornou %d", rr.Code)
+	}
+
+	// Purgar a tarefa da lixeira remove seus comentários
+	executar(t, srv, "POST", colecao, `{"texto":"Último"}`)
+	executar(t, srv, "DELETE", "/api/tarefas/"+id, "")
+	executar(t, srv, "DELETE", "/api/lixeira/"+id, "")
+	if comentarios, _ := srv.comentarios.Listar(usuarioTeste, id); len(comentarios) != 0 {
+		t.Errorf("comentários da tarefa removida: %+v", comentarios)
+	}
+}
+
+func TestAtividadeIntercalaComentariosEEventos(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Login")
+	executar(t, srv, "POST", "/api/tarefas/"+id+"/comentarios", `{"texto":"Vou usar OAuth"}`)
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"titulo":"Login social"}`)
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":true}`)
+	// Reenviar o mesmo título não é uma renomeação
+	executar(t, srv, "PUT", "/api/tarefas/"+id, `{"titulo":"Login social","concluida":true}`)
+
+	rr := executar(t, srv, "GET", "/api/tarefas/"+id+"/atividade", "")
+	var atividades []Atividade
+	if err := json.Unmarshal(rr.Body.Bytes(), &atividades); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("GET atividade retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	var tipos []string
+	for _, a := range atividades {
+		tipos = append(tipos, a.Tipo)
+	}
+	esperado := []string{dominio.AtividadeCriada, dominio.AtividadeComentario, dominio.AtividadeRenomeada, dominio.AtividadeEstado}
+	if !slices.Equal(tipos, esperado) {
+		t.Fatalf("atividades obtidas %v esperadas %v", tipos, esperado)
+	}
+	if a := atividades[2]; a.De != "Login" || a.Para != "Login social" {
+		t.Errorf("renomeação: %+v", a)
+	}
+	if a := atividades[1]; a.Comentario == nil || !strings.Contains(a.Comentario.Texto, "OAuth") {
+		t.Errorf("comentário na atividade: %+v", a)
+	}
+
+	if rr := executar(t, srv, "GET", "/api/tarefas/inexistente/atividade", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("atividade de tarefa inexistente retornou %d", rr.Code)
+	}
+}
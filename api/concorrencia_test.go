@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Estes testes devem ser executados com -race: qualquer acesso sem
+// sincronização aos dados do armazenamento é reportado pelo detector.
+
+const operacoesConcorrentes = 50
+
+func TestAtualizacoesConcorrentesNaoSePerdem(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+
+			tarefa, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Contador"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Cada goroutine alterna o campo concluida; a versão final prova
+			// que nenhuma atualização sobrescreveu outra
+			var wg sync.WaitGroup
+			for i := 0; i < operacoesConcorrentes; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := repo.Atualizar(usuarioTeste, tarefa.ID, 0, func(t *Tarefa) error {
+						t.Concluida = !t.Concluida
+						return nil
+					})
+					if err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			final, err := repo.Buscar(usuarioTeste, tarefa.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if final.Versao != 1+operacoesConcorrentes {
+				t.Errorf("versão final: obtida %d esperada %d", final.Versao, 1+operacoesConcorrentes)
+			}
+			if final.Concluida != (operacoesConcorrentes%2 == 1) {
+				t.Errorf("estado final inconsistente: %+v", final)
+			}
+		})
+	}
+}
+
+func TestCompareAndSwapApenasUmVence(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+
+			tarefa, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Disputada"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Todas as goroutines tentam atualizar a partir da mesma versão
+			var vencedores, conflitos atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < operacoesConcorrentes; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := repo.Atualizar(usuarioTeste, tarefa.ID, tarefa.Versao, func(t *Tarefa) error {
+						t.Concluida = true
+						return nil
+					})
+					switch {
+					case err == nil:
+						vencedores.Add(1)
+					case errors.Is(err, ErrConflitoVersao):
+						conflitos.Add(1)
+					default:
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if vencedores.Load() != 1 || conflitos.Load() != operacoesConcorrentes-1 {
+				t.Errorf("esperado 1 vencedor e %d conflitos, obtido %d e %d",
+					operacoesConcorrentes-1, vencedores.Load(), conflitos.Load())
+			}
+		})
+	}
+}
+
+func TestCriacoesERemocoesConcorrentes(t *testing.T) {
+	for nome, abrir := range implementacoes() {
+		t.Run(nome, func(t *testing.T) {
+			a := abrir(t, t.TempDir())
+			defer a.Fechar()
+			repo := NovoRepositorioTarefas(a)
+
+			// Metade das tarefas criadas é removida logo em seguida, enquanto
+			// outras goroutines listam e atualizam em paralelo
+			var wg sync.WaitGroup
+			for i := 0; i < operacoesConcorrentes; i++ {
+				wg.Add(2)
+				go func(i int) {
+					defer wg.Done()
+					criada, err := repo.Criar(usuarioTeste, Tarefa{Titulo: "Paralela"})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					_, err = repo.Atualizar(usuarioTeste, criada.ID, criada.Versao, func(t *Tarefa) error {
+						t.Concluida = true
+						return nil
+					})
+					if err != nil {
+						t.Error(err)
+					}
+					if i%2 == 0 {
+						if err := repo.Remover(usuarioTeste, criada.ID); err != nil {
+							t.Error(err)
+						}
+					}
+				}(i)
+				go func() {
+					defer wg.Done()
+					if _, err := repo.Listar(usuarioTeste); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			tarefas, err := repo.Listar(usuarioTeste)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tarefas) != operacoesConcorrentes/2 {
+				t.Errorf("quantidade final: obtida %d esperada %d", len(tarefas), operacoesConcorrentes/2)
+			}
+			for _, tarefa := range tarefas {
+				if !tarefa.Concluida || tarefa.Versao != 2 {
+					t.Errorf("tarefa em estado inesperado: %+v", tarefa)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+const (
+	// limitePadrao é o tamanho de página usado quando ?limit não é informado
+	limitePadrao = 50
+	// limiteMaximo é o maior tamanho de página aceito
+	limiteMaximo = 200
+)
+
+// campoOrdenacao descreve um campo aceito em ?sort
+type campoOrdenacao struct {
+	// comparar retorna um valor negativo, zero ou positivo como strings.Compare
+	comparar func(a, b Tarefa) int
+	// chave copia para uma tarefa vazia apenas o campo usado na comparação,
+	// que é o que o cursor precisa guardar
+	chave func(t Tarefa) Tarefa
+}
+
+// camposOrdenacao lista os campos aceitos em ?sort
+var camposOrdenacao = map[string]campoOrdenacao{
+	"criada_em": {
+		comparar: func(a, b Tarefa) int { return a.CriadaEm.Compare(b.CriadaEm) },
+		chave:    func(t Tarefa) Tarefa { return Tarefa{CriadaEm: t.CriadaEm} },
+	},
+	"atualizada_em": {
+		comparar: func(a, b Tarefa) int { return a.AtualizadaEm.Compare(b.AtualizadaEm) },
+		chave:    func(t Tarefa) Tarefa { return Tarefa{AtualizadaEm: t.AtualizadaEm} },
+	},
+	"prazo": {
+		comparar: compararPrazo,
+		chave:    func(t Tarefa) Tarefa { return Tarefa{Prazo: t.Prazo} },
+	},
+	"prioridade": {
+		comparar: func(a, b Tarefa) int { return a.Prioridade.Peso() - b.Prioridade.Peso() },
+		chave:    func(t Tarefa) Tarefa { return Tarefa{Prioridade: t.Prioridade} },
+	},
+	"titulo": {
+		comparar: func(a, b Tarefa) int { return strings.Compare(strings.ToLower(a.Titulo), strings.ToLower(b.Titulo)) },
+		chave:    func(t Tarefa) Tarefa { return Tarefa{Titulo: t.Titulo} },
+	},
+}
+
+// compararPrazo ordena por prazo, deixando as tarefas sem prazo por último
+func compararPrazo(a, b Tarefa) int {
+	switch {
+	case a.Prazo == nil && b.Prazo == nil:
+		return 0
+	case a.Prazo == nil:
+		return 1
+	case b.Prazo == nil:
+		return -1
+	}
+	return a.Prazo.Compare(*b.Prazo)
+}
+
+// consultaTarefas representa os parâmetros de filtro, ordenação e paginação da listagem
+type consultaTarefas struct {
+	concluida  *bool
+	prioridade dominio.Prioridade
+	projeto    string
+	etiquetas  []string
+	alguma     bool
+	pais       []string
+	raiz       bool
+	acionaveis bool
+	texto      string
+	ordem      string
+	campo      campoOrdenacao
+	desc       bool
+	limite     int
+	cursor     *cursorTarefas
+}
+
+// cursorTarefas identifica a última tarefa entregue em uma página. A próxima
+// página começa logo após ela na ordenação, mesmo que tarefas tenham sido
+// criadas ou removidas entre as requisições.
+type cursorTarefas struct {
+	Ordem string `json:"o"`
+	ID    string `json:"id"`
+	Chave Tarefa `json:"k"`
+}
+
+// errConsulta indica um parâmetro de consulta inválido
+type errConsulta struct {
+	parametro string
+	motivo    dominio.Mensagens
+}
+
+func (e *errConsulta) Error() string {
+	return "parâmetro " + e.parametro + " inválido: " + e.motivo.PtBR
+}
+
+// campo descreve o parâmetro inválido como um erro de campo da resposta
+func (e *errConsulta) campo() dominio.ErroValidacao {
+	return dominio.ErroValidacao{
+		Campo:  e.parametro,
+		Codigo: dominio.CodigoInvalido,
+		Mensagens: dominio.Mensagens{
+			PtBR: "parâmetro " + e.parametro + " inválido: " + e.motivo.PtBR,
+			En:   "invalid " + e.parametro + " parameter: " + e.motivo.En,
+		},
+	}
+}
+
+// lerConsultaTarefas interpreta os parâmetros ?concluida, ?prioridade, ?projeto,
+// ?etiquetas, ?modo_etiquetas, ?pai, ?raiz, ?acionaveis, ?q, ?sort, ?limit e ?cursor
+func lerConsultaTarefas(q url.Values) (consultaTarefas, *errConsulta) {
+	c := consultaTarefas{
+		projeto: q.Get("projeto"),
+		texto:   strings.ToLower(strings.TrimSpace(q.Get("q"))),
+		ordem:   "criada_em",
+		limite:  limitePadrao,
+	}
+
+	if v := q.Get("concluida"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, &errConsulta{"concluida", dominio.Mensagens{PtBR: "use true ou false", En: "use true or false"}}
+		}
+		c.concluida = &b
+	}
+
+	if v := dominio.Prioridade(q.Get("prioridade")); v != "" {
+		if !v.Valida() {
+			return c, &errConsulta{"prioridade", dominio.Mensagens{PtBR: "use baixa, media, alta ou urgente", En: "use baixa, media, alta or urgente"}}
+		}
+		c.prioridade = v
+	}
+
+	// ?etiquetas recebe IDs separados por vírgula; por padrão a tarefa precisa
+	// ter todas elas, e ?modo_etiquetas=alguma aceita qualquer uma
+	for _, id := range strings.Split(q.Get("etiquetas"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			c.etiquetas = append(c.etiquetas, id)
+		}
+	}
+	switch q.Get("modo_etiquetas") {
+	case "", "todas":
+	case "alguma":
+		c.alguma = true
+	default:
+		return c, &errConsulta{"modo_etiquetas", dominio.Mensagens{PtBR: "use todas ou alguma", En: "use todas or alguma"}}
+	}
+
+	// ?pai recebe IDs separados por vírgula e lista as subtarefas diretas de
+	// qualquer um deles; ?raiz=true lista apenas as tarefas sem pai
+	for _, id := range strings.Split(q.Get("pai"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			c.pais = append(c.pais, id)
+		}
+	}
+	if v := q.Get("raiz"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, &errConsulta{"raiz", dominio.Mensagens{PtBR: "use true ou false", En: "use true or false"}}
+		}
+		c.raiz = b
+	}
+
+	// ?acionaveis=true lista apenas as tarefas que podem ser feitas agora:
+	// pendentes e sem dependências pendentes
+	if v := q.Get("acionaveis"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, &errConsulta{"acionaveis", dominio.Mensagens{PtBR: "use true ou false", En: "use true or false"}}
+		}
+		c.acionaveis = b
+	}
+
+	if v := q.Get("sort"); v != "" {
+		c.ordem = v
+	}
+	campo, ok := camposOrdenacao[strings.TrimPrefix(c.ordem, "-")]
+	if !ok {
+		return c, &errConsulta{"sort", dominio.Mensagens{
+			PtBR: "campo desconhecido " + strconv.Quote(c.ordem),
+			En:   "unknown field " + strconv.Quote(c.ordem),
+		}}
+	}
+	c.campo = campo
+	c.desc = strings.HasPrefix(c.ordem, "-")
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > limiteMaximo {
+			return c, &errConsulta{"limit", dominio.Mensagens{
+				PtBR: "use um número entre 1 e " + strconv.Itoa(limiteMaximo),
+				En:   "use a number between 1 and " + strconv.Itoa(limiteMaximo),
+			}}
+		}
+		c.limite = n
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodificarCursor(v)
+		if err != nil || cursor.Ordem != c.ordem {
+			return c, &errConsulta{"cursor", dominio.Mensagens{PtBR: "cursor inválido para esta ordenação", En: "cursor does not match this sort order"}}
+		}
+		c.cursor = &cursor
+	}
+	return c, nil
+}
+
+// aceita informa se a tarefa passa pelos filtros da consulta. O bloqueio
+// precisa já ter sido preenchido.
+func (c consultaTarefas) aceita(t Tarefa) bool {
+	if c.concluida != nil && t.Concluida != *c.concluida {
+		return false
+	}
+	if c.prioridade != "" && t.Prioridade != c.prioridade {
+		return false
+	}
+	if c.projeto != "" && t.ProjetoID != c.projeto {
+		return false
+	}
+	if len(c.etiquetas) > 0 && !c.aceitaEtiquetas(t) {
+		return false
+	}
+	if c.raiz && t.PaiID != "" {
+		return false
+	}
+	if len(c.pais) > 0 && !slices.Contains(c.pais, t.PaiID) {
+		return false
+	}
+	if c.acionaveis && (t.Concluida || t.Bloqueada) {
+		return false
+	}
+	if c.texto != "" &&
+		!strings.Contains(strings.ToLower(t.Titulo), c.texto) &&
+		!strings.Contains(strings.ToLower(t.Descricao), c.texto) {
+		return false
+	}
+	return true
+}
+
+// aceitaEtiquetas aplica o filtro de etiquetas no modo escolhido
+func (c consultaTarefas) aceitaEtiquetas(t Tarefa) bool {
+	for _, id := range c.etiquetas {
+		if t.TemEtiqueta(id) == c.alguma {
+			return c.alguma
+		}
+	}
+	return !c.alguma
+}
+
+// comparar ordena pelo campo escolhido e desempata pelo ID, para que a ordem
+// seja total e o cursor aponte para uma posição única
+func (c consultaTarefas) comparar(a, b Tarefa) int {
+	r := c.campo.comparar(a, b)
+	if r == 0 {
+		r = strings.Compare(a.ID, b.ID)
+	}
+	if c.desc {
+		return -r
+	}
+	return r
+}
+
+// aplicar filtra, ordena e pagina as tarefas, retornando a página e o cursor
+// da próxima página (vazio quando não há mais tarefas)
+func (c consultaTarefas) aplicar(tarefas []Tarefa) dominio.PaginaTarefas {
+	filtradas := make([]Tarefa, 0, len(tarefas))
+	for _, t := range tarefas {
+		if c.aceita(t) {
+			filtradas = append(filtradas, t)
+		}
+	}
+	sort.SliceStable(filtradas, func(i, j int) bool {
+		return c.comparar(filtradas[i], filtradas[j]) < 0
+	})
+
+	inicio := 0
+	if c.cursor != nil {
+		ref := c.cursor.Chave
+		ref.ID = c.cursor.ID
+		inicio = sort.Search(len(filtradas), func(i int) bool {
+			return c.comparar(filtradas[i], ref) > 0
+		})
+	}
+
+	fim := inicio + c.limite
+	pagina := dominio.PaginaTarefas{Limit: c.limite}
+	if fim < len(filtradas) {
+		pagina.NextCursor = codificarCursor(c.ordem, c.campo, filtradas[fim-1])
+	} else {
+		fim = len(filtradas)
+	}
+	pagina.Tarefas = filtradas[inicio:fim]
+	return pagina
+}
+
+// codificarCursor gera o cursor opaco que aponta para a tarefa informada
+func codificarCursor(ordem string, campo campoOrdenacao, t Tarefa) string {
+	b, _ := json.Marshal(cursorTarefas{Ordem: ordem, ID: t.ID, Chave: campo.chave(t)})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodificarCursor interpreta um cursor gerado por codificarCursor
+func decodificarCursor(s string) (cursorTarefas, error) {
+	var c cursorTarefas
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		return c, errors.New("cursor sem ID")
+	}
+	return c, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// tarefasConsulta monta tarefas com datas de criação crescentes
+func tarefasConsulta() []Tarefa {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	titulos := []string{"Deploy", "aprender Go", "Configurar CI", "Revisar deploy", "Backup"}
+	tarefas := make([]Tarefa, len(titulos))
+	for i, titulo := range titulos {
+		tarefas[i] = Tarefa{
+			ID:         string(rune('a' + i)),
+			Titulo:     titulo,
+			Concluida:  i%2 == 1,
+			Prioridade: []dominio.Prioridade{dominio.PrioridadeAlta, dominio.PrioridadeBaixa, dominio.PrioridadeMedia, dominio.PrioridadeUrgente, dominio.PrioridadeMedia}[i],
+			ProjetoID:  []string{"p1", "", "p1", "p2", ""}[i],
+			Etiquetas:  [][]string{{"e1", "e2"}, {"e1"}, {"e2"}, nil, {"e3"}}[i],
+			PaiID:      []string{"", "a", "a", "c", ""}[i],
+			Bloqueada:  i == 2,
+			CriadaEm:   base.Add(time.Duration(i) * time.Hour),
+		}
+	}
+	return tarefas
+}
+
+// idsDe extrai os IDs na ordem da página
+func idsDe(tarefas []Tarefa) string {
+	var ids string
+	for _, t := range tarefas {
+		ids += t.ID
+	}
+	return ids
+}
+
+func TestConsultaTarefasFiltrosEOrdenacao(t *testing.T) {
+	casos := []struct {
+		query    string
+		esperado string
+	}{
+		{"", "abcde"},
+		{"concluida=false", "ace"},
+		{"concluida=true", "bd"},
+		{"q=DEPLOY", "ad"},
+		{"q=deploy&concluida=true", "d"},
+		{"sort=-criada_em", "edcba"},
+		{"sort=titulo", "becad"},
+		{"sort=-titulo&concluida=false", "ace"},
+		{"prioridade=media", "ce"},
+		{"projeto=p1", "ac"},
+		{"projeto=p1&concluida=false&sort=-criada_em", "ca"},
+		{"sort=-prioridade", "daecb"},
+		{"etiquetas=e1", "ab"},
+		{"etiquetas=e1,e2", "a"},
+		{"etiquetas=e1,e2&modo_etiquetas=todas", "a"},
+		{"etiquetas=e1,e2&modo_etiquetas=alguma", "abc"},
+		{"etiquetas=e2,e3&modo_etiquetas=alguma&concluida=false", "ace"},
+		{"raiz=true", "ae"},
+		{"raiz=false", "abcde"},
+		{"pai=a", "bc"},
+		{"pai=a,c&concluida=false", "c"},
+		{"acionaveis=true", "ae"},
+		{"acionaveis=false", "abcde"},
+		{"acionaveis=true&sort=-criada_em", "ea"},
+	}
+	for _, caso := range casos {
+		q, _ := url.ParseQuery(caso.query)
+		consulta, err := lerConsultaTarefas(q)
+		if err != nil {
+			t.Fatalf("%q: %v", caso.query, err)
+		}
+		if obtido := idsDe(consulta.aplicar(tarefasConsulta()).Tarefas); obtido != caso.esperado {
+			t.Errorf("%q: obtido %q esperado %q", caso.query, obtido, caso.esperado)
+		}
+	}
+}
+
+func TestConsultaTarefasPaginacao(t *testing.T) {
+	tarefas := tarefasConsulta()
+	q := url.Values{"sort": {"-criada_em"}, "limit": {"2"}}
+
+	var paginas []string
+	for {
+		consulta, err := lerConsultaTarefas(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pagina := consulta.aplicar(tarefas)
+		paginas = append(paginas, idsDe(pagina.Tarefas))
+		if pagina.NextCursor == "" {
+			break
+		}
+		q.Set("cursor", pagina.NextCursor)
+
+		// Remover a tarefa que encerrou a página não desloca a próxima
+		tarefas = tarefas[:len(tarefas)-1]
+		if len(paginas) > 5 {
+			t.Fatal("paginação não terminou")
+		}
+	}
+
+	if len(paginas) != 3 || paginas[0] != "ed" || paginas[1] != "cb" || paginas[2] != "a" {
+		t.Errorf("páginas inesperadas: %v", paginas)
+	}
+}
+
+func TestConsultaTarefasParametrosInvalidos(t *testing.T) {
+	invalidas := []string{
+		"concluida=talvez",
+		"sort=cor",
+		"prioridade=maxima",
+		"limit=0",
+		"limit=1000",
+		"etiquetas=e1&modo_etiquetas=nenhuma",
+		"raiz=sim",
+		"acionaveis=talvez",
+		"cursor=xyz",
+		// Um cursor só vale para a ordenação que o gerou
+		"sort=titulo&cursor=" + codificarCursor("criada_em", camposOrdenacao["criada_em"], Tarefa{ID: "a"}),
+	}
+	for _, query := range invalidas {
+		q, _ := url.ParseQuery(query)
+		if _, err := lerConsultaTarefas(q); err == nil {
+			t.Errorf("%q: esperado erro", query)
+		}
+	}
+}
+
+func TestListarTarefasPaginadas(t *testing.T) {
+	srv := novoServidorTeste(t)
+	criarTarefaTeste(t, srv, "Terceira")
+
+	rr := executar(t, srv, "GET", "/api/tarefas?limit=2", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+	}
+	var pagina dominio.PaginaTarefas
+	if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina.Tarefas) != 2 || pagina.NextCursor == "" || pagina.Limit != 2 {
+		t.Fatalf("primeira página inesperada: %+v", pagina)
+	}
+
+	vistas := map[string]bool{pagina.Tarefas[0].ID: true, pagina.Tarefas[1].ID: true}
+
+	rr = executar(t, srv, "GET", "/api/tarefas?limit=2&cursor="+pagina.NextCursor, "")
+	pagina = dominio.PaginaTarefas{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina.Tarefas) != 1 || vistas[pagina.Tarefas[0].ID] || pagina.NextCursor != "" {
+		t.Errorf("segunda página inesperada: %+v", pagina)
+	}
+
+	rr = executar(t, srv, "GET", "/api/tarefas?limit=abc", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("limit inválido retornou %v esperado %v", rr.Code, http.StatusBadRequest)
+	}
+}
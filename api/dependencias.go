@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// ErrTarefaBloqueada indica uma tentativa de concluir uma tarefa que ainda
+// depende de tarefas pendentes
+var ErrTarefaBloqueada = errors.New("a tarefa depende de tarefas pendentes")
+
+// pendentesDe retorna os IDs das tarefas ainda não concluídas
+func pendentesDe(tarefas []Tarefa) map[string]bool {
+	pendentes := make(map[string]bool, len(tarefas))
+	for _, t := range tarefas {
+		if !t.Concluida {
+			pendentes[t.ID] = true
+		}
+	}
+	return pendentes
+}
+
+// pendentes retorna os IDs das tarefas do dono ainda não concluídas
+func (s *servidor) pendentes(dono string) (map[string]bool, error) {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return nil, err
+	}
+	return pendentesDe(tarefas), nil
+}
+
+// dependeDePendentes informa se alguma das tarefas de que t depende está pendente
+func dependeDePendentes(t Tarefa, pendentes map[string]bool) bool {
+	for _, id := range t.BloqueadaPor {
+		if pendentes[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// bloqueada informa se a tarefa está pendente e depende de alguma tarefa pendente
+func bloqueada(t Tarefa, pendentes map[string]bool) bool {
+	return !t.Concluida && dependeDePendentes(t, pendentes)
+}
+
+// preencherBloqueio calcula, a partir de todas as tarefas do dono, se cada
+// tarefa de alvo está bloqueada
+func preencherBloqueio(todas []Tarefa, alvo []Tarefa) {
+	pendentes := pendentesDe(todas)
+	for i := range alvo {
+		alvo[i].Bloqueada = bloqueada(alvo[i], pendentes)
+	}
+}
+
+// validarBloqueiosDaTarefa confere que as tarefas que bloqueiam a tarefa id
+// são do dono e que as novas dependências não criam um ciclo. id é vazio em
+// tarefas novas, que ainda não podem bloquear nenhuma outra. Uma dependência
+// de tarefa na lixeira só é aceita se a tarefa já a tinha.
+func (s *servidor) validarBloqueiosDaTarefa(dono, id string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	excluidas, err := s.tarefas.ListarLixeira(dono)
+	if err != nil {
+		return err
+	}
+	porID := make(map[string]Tarefa, len(tarefas))
+	for _, t := range tarefas {
+		porID[t.ID] = t
+	}
+	// As dependências das tarefas na lixeira voltam com elas, então contam
+	// na procura por ciclos
+	naLixeira := make(map[string]Tarefa, len(excluidas))
+	for _, t := range excluidas {
+		naLixeira[t.ID] = t
+	}
+	existente := func(b string) bool {
+		if _, ok := porID[b]; ok {
+			return true
+		}
+		_, ok := naLixeira[b]
+		return ok && slices.Contains(porID[id].BloqueadaPor, b)
+	}
+	for _, b := range ids {
+		if b == "" {
+			continue
+		}
+		if b == id {
+			return &ErroValidacao{Campo: "bloqueada_por", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "a tarefa não pode bloquear a si mesma",
+				En:   "a task cannot block itself",
+			}}
+		}
+		if !existente(b) {
+			return &ErroValidacao{Campo: "bloqueada_por", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "a tarefa " + b + " não existe",
+				En:   "task " + b + " does not exist",
+			}}
+		}
+	}
+	if id == "" {
+		return nil
+	}
+
+	// Seguir as dependências das novas bloqueadoras: chegar à própria tarefa
+	// significa que ela passaria a depender de si mesma
+	vistas := map[string]bool{}
+	fila := slices.Clone(ids)
+	for len(fila) > 0 {
+		atual := fila[0]
+		fila = fila[1:]
+		if atual == id {
+			return &ErroValidacao{Campo: "bloqueada_por", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "as dependências formariam um ciclo",
+				En:   "the dependencies would form a cycle",
+			}}
+		}
+		if vistas[atual] {
+			continue
+		}
+		vistas[atual] = true
+		if t, ok := porID[atual]; ok {
+			fila = append(fila, t.BloqueadaPor...)
+		} else {
+			fila = append(fila, naLixeira[atual].BloqueadaPor...)
+		}
+	}
+	return nil
+}
+
+// removerBloqueios tira as tarefas purgadas das dependências das demais,
+// para que não fiquem apontando para IDs que não existem mais
+func (s *servidor) removerBloqueios(dono string, removidas []string) error {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	for _, t := range tarefas {
+		if !slices.ContainsFunc(t.BloqueadaPor, func(id string) bool { return slices.Contains(removidas, id) }) {
+			continue
+		}
+		_, err := s.tarefas.Atualizar(dono, t.ID, 0, func(t *Tarefa) error {
+			t.BloqueadaPor = slices.DeleteFunc(t.BloqueadaPor, func(id string) bool {
+				return slices.Contains(removidas, id)
+			})
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrTarefaNaoEncontrada) {
+			return err
+		}
+	}
+	return nil
+}
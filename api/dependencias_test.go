@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// bloquearTeste faz a tarefa id depender das tarefas informadas
+func bloquearTeste(t *testing.T, srv *servidor, id string, bloqueadoras ...string) {
+	t.Helper()
+	corpo, _ := json.Marshal(map[string][]string{"bloqueada_por": bloqueadoras})
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+id, string(corpo)); rr.Code != http.StatusOK {
+		t.Fatalf("PATCH bloqueada_por retornou %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDependenciasBloqueiamAConclusao(t *testing.T) {
+	srv := novoServidorTeste(t)
+	servidores := criarTarefaTeste(t, srv, "Provisionar servidores")
+	banco := criarTarefaTeste(t, srv, "Migrar banco")
+	deploy := criarTarefaTeste(t, srv, "Deploy")
+	bloquearTeste(t, srv, deploy, servidores, banco)
+
+	if tarefa := buscarTarefaTeste(t, srv, deploy); !tarefa.Bloqueada || len(tarefa.BloqueadaPor) != 2 {
+		t.Errorf("tarefa com dependências pendentes: %+v", tarefa)
+	}
+
+	// Concluir uma tarefa bloqueada é recusado sem alterá-la
+	rr := executar(t, srv, "PATCH", "/api/tarefas/"+deploy, `{"concluida":true}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusConflict || p.Codigo != dominio.CodigoTarefaBloqueada {
+		t.Errorf("concluir tarefa bloqueada: obtido %d %q", rr.Code, p.Codigo)
+	}
+	if buscarTarefaTeste(t, srv, deploy).Concluida {
+		t.Error("tarefa bloqueada foi concluída")
+	}
+
+	// ?acionaveis=true lista apenas as pendentes sem dependências pendentes
+	acionaveis := func() map[string]bool {
+		t.Helper()
+		rr := executar(t, srv, "GET", "/api/tarefas?acionaveis=true", "")
+		var pagina dominio.PaginaTarefas
+		if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+			t.Fatalf("GET ?acionaveis retornou %d: %s", rr.Code, rr.Body.String())
+		}
+		ids := map[string]bool{}
+		for _, tarefa := range pagina.Tarefas {
+			ids[tarefa.ID] = true
+		}
+		return ids
+	}
+	if ids := acionaveis(); !ids[servidores] || !ids[banco] || ids[deploy] {
+		t.Errorf("acionáveis com o deploy bloqueado: %v", ids)
+	}
+
+	// Concluir a última dependência libera a tarefa
+	executar(t, srv, "PATCH", "/api/tarefas/"+servidores, `{"concluida":true}`)
+	if !buscarTarefaTeste(t, srv, deploy).Bloqueada {
+		t.Error("tarefa liberada com uma dependência pendente")
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+banco, `{"concluida":true}`)
+	if buscarTarefaTeste(t, srv, deploy).Bloqueada {
+		t.Error("tarefa continua bloqueada com as dependências concluídas")
+	}
+	if ids := acionaveis(); ids[servidores] || ids[banco] || !ids[deploy] {
+		t.Errorf("acionáveis com as dependências concluídas: %v", ids)
+	}
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+deploy, `{"concluida":true}`); rr.Code != http.StatusOK {
+		t.Errorf("concluir tarefa liberada retornou %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Reabrir uma dependência volta a bloquear apenas as tarefas pendentes
+	executar(t, srv, "PATCH", "/api/tarefas/"+banco, `{"concluida":false}`)
+	if tarefa := buscarTarefaTeste(t, srv, deploy); tarefa.Bloqueada {
+		t.Errorf("tarefa concluída marcada como bloqueada: %+v", tarefa)
+	}
+}
+
+func TestDependenciasRejeitamCiclos(t *testing.T) {
+	srv := novoServidorTeste(t)
+	a := criarTarefaTeste(t, srv, "A")
+	b := criarTarefaTeste(t, srv, "B")
+	c := criarTarefaTeste(t, srv, "C")
+	bloquearTeste(t, srv, b, a)
+	bloquearTeste(t, srv, c, b)
+	alheia, err := srv.tarefas.Criar("outro", Tarefa{Titulo: "Alheia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct{ metodo, url, corpo string }{
+		{"POST", "/api/tarefas", `{"titulo":"Nova","bloqueada_por":["inexistente"]}`},
+		{"POST", "/api/tarefas", `{"titulo":"Intrusa","bloqueada_por":["` + alheia.ID + `"]}`},
+		{"PATCH", "/api/tarefas/" + a, `{"bloqueada_por":["` + a + `"]}`},
+		{"PATCH", "/api/tarefas/" + a, `{"bloqueada_por":["` + b + `"]}`},
+		{"PUT", "/api/tarefas/" + a, `{"titulo":"A","bloqueada_por":["` + c + `"]}`},
+	}
+	for _, caso := range casos {
+		rr := executar(t, srv, caso.metodo, caso.url, caso.corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "bloqueada_por" {
+			t.Errorf("%s %s: obtido %d %+v", caso.metodo, caso.corpo, rr.Code, p.Campos)
+		}
+	}
+
+	// Dependências em diamante não formam ciclo
+	bloquearTeste(t, srv, c, a, b)
+	if tarefa := buscarTarefaTeste(t, srv, a); len(tarefa.BloqueadaPor) != 0 {
+		t.Errorf("tarefa alterada por um ciclo recusado: %+v", tarefa)
+	}
+}
+
+func TestRemoverTarefaLiberaDependentes(t *testing.T) {
+	srv := novoServidorTeste(t)
+	bloqueadora := criarTarefaTeste(t, srv, "Bloqueadora")
+	outra := criarTarefaTeste(t, srv, "Outra")
+	dependente := criarTarefaTeste(t, srv, "Dependente")
+	bloquearTeste(t, srv, dependente, bloqueadora, outra)
+
+	if rr := executar(t, srv, "DELETE", "/api/tarefas/"+bloqueadora, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	// A dependência fica até a purga, mas a tarefa na lixeira conta como
+	// resolvida
+	tarefa := buscarTarefaTeste(t, srv, dependente)
+	if len(tarefa.BloqueadaPor) != 2 || !tarefa.Bloqueada {
+		t.Errorf("dependências após remover a bloqueadora: %+v", tarefa)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+outra, `{"concluida":true}`)
+	if tarefa := buscarTarefaTeste(t, srv, dependente); tarefa.Bloqueada {
+		t.Errorf("bloqueada só pela tarefa na lixeira: %+v", tarefa)
+	}
+	// Mantê-la é aceito, mas uma nova dependência dela não
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+dependente, `{"bloqueada_por":["`+bloqueadora+`","`+outra+`"]}`); rr.Code != http.StatusOK {
+		t.Errorf("manter dependência da tarefa na lixeira: obtido %d", rr.Code)
+	}
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+outra, `{"bloqueada_por":["`+bloqueadora+`"]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("nova dependência da tarefa na lixeira: obtido %d", rr.Code)
+	}
+
+	// A purga tira a dependência
+	executar(t, srv, "DELETE", "/api/lixeira/"+bloqueadora, "")
+	if tarefa := buscarTarefaTeste(t, srv, dependente); len(tarefa.BloqueadaPor) != 1 || tarefa.BloqueadaPor[0] != outra {
+		t.Errorf("dependências após purgar a bloqueadora: %+v", tarefa)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+outra, `{"concluida":false}`)
+
+	// Criar já concluída uma tarefa com dependências pendentes é recusado
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Pronta","concluida":true,"bloqueada_por":["`+outra+`"]}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusConflict || p.Codigo != dominio.CodigoTarefaBloqueada {
+		t.Errorf("criar concluída e bloqueada: obtido %d %q", rr.Code, p.Codigo)
+	}
+}
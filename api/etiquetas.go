@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// manipuladorEtiquetas atende a coleção /api/etiquetas, com as etiquetas do
+// usuário autenticado
+func (s *servidor) manipuladorEtiquetas(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		etiquetas, err := s.etiquetas.Listar(dono)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(etiquetas)
+	case "POST":
+		var e Etiqueta
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if err := e.Validar(); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		e, err := s.etiquetas.Criar(dono, e)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/etiquetas/"+e.ID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(e)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST, OPTIONS")
+	}
+}
+
+// manipuladorEtiqueta atende uma etiqueta individual em /api/etiquetas/{id}.
+// PATCH renomeia ou troca a cor, mantendo os campos não enviados.
+func (s *servidor) manipuladorEtiqueta(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/etiquetas/")
+	if id == "" || strings.Contains(id, "/") {
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		e, err := s.etiquetas.Buscar(dono, id)
+		if err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(e)
+	case "PATCH":
+		corpo, err := io.ReadAll(r.Body)
+		if err != nil || !json.Valid(corpo) {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		e, err := s.etiquetas.Atualizar(dono, id, func(e *Etiqueta) error {
+			if err := json.Unmarshal(corpo, e); err != nil {
+				return errCorpoInvalido
+			}
+			e.Nome = strings.TrimSpace(e.Nome)
+			return e.Validar()
+		})
+		if err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(e)
+	case "DELETE":
+		if err := s.removerEtiqueta(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, PATCH, DELETE, OPTIONS")
+	}
+}
+
+// removerEtiqueta exclui a etiqueta e a retira das tarefas que a usam
+func (s *servidor) removerEtiqueta(dono, id string) error {
+	if err := s.etiquetas.Remover(dono, id); err != nil {
+		return err
+	}
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	for _, t := range tarefas {
+		if !t.TemEtiqueta(id) {
+			continue
+		}
+		_, err := s.tarefas.Atualizar(dono, t.ID, 0, func(t *Tarefa) error {
+			mantidas := t.Etiquetas[:0]
+			for _, e := range t.Etiquetas {
+				if e != id {
+					mantidas = append(mantidas, e)
+				}
+			}
+			t.Etiquetas = mantidas
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrTarefaNaoEncontrada) {
+			return err
+		}
+	}
+	return nil
+}
+
+// validarEtiquetasDaTarefa confere que todas as etiquetas de uma tarefa
+// existem e são do mesmo dono
+func (s *servidor) validarEtiquetasDaTarefa(dono string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	etiquetas, err := s.etiquetas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	existentes := make(map[string]bool, len(etiquetas))
+	for _, e := range etiquetas {
+		existentes[e.ID] = true
+	}
+	for _, id := range ids {
+		if !existentes[id] {
+			return &ErroValidacao{Campo: "etiquetas", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "a etiqueta " + id + " não existe",
+				En:   "tag " + id + " does not exist",
+			}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+func TestEtiquetasDasTarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	rr := executar(t, srv, "POST", "/api/etiquetas", `{"nome":"  Urgente  ","cor":"#E74C3C"}`)
+	var urgente Etiqueta
+	if err := json.Unmarshal(rr.Body.Bytes(), &urgente); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/etiquetas retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if urgente.Nome != "Urgente" || urgente.Cor != "#e74c3c" || rr.Header().Get("Location") != "/api/etiquetas/"+urgente.ID {
+		t.Errorf("etiqueta criada inesperada: %+v", urgente)
+	}
+	casa, err := srv.etiquetas.Criar(usuarioTeste, Etiqueta{Nome: "Casa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if casa.Cor != dominio.CorPadraoEtiqueta {
+		t.Errorf("cor padrão não aplicada: %q", casa.Cor)
+	}
+
+	// Uma tarefa com as duas etiquetas e outra só com a primeira
+	rr = executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Consertar pia","etiquetas":["`+urgente.ID+`","`+casa.ID+`"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST tarefa com etiquetas retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	var ambas Tarefa
+	json.Unmarshal(rr.Body.Bytes(), &ambas)
+	so := criarTarefaTeste(t, srv, "Pagar boleto")
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+so, `{"etiquetas":["`+urgente.ID+`"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("etiquetar tarefa retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	criarTarefaTeste(t, srv, "Sem etiqueta")
+
+	// Todas as etiquetas por padrão, qualquer uma com modo_etiquetas=alguma
+	casos := []struct {
+		query    string
+		esperado int
+	}{
+		{"etiquetas=" + urgente.ID, 2},
+		{"etiquetas=" + urgente.ID + "," + casa.ID, 1},
+		{"etiquetas=" + urgente.ID + "," + casa.ID + "&modo_etiquetas=alguma", 2},
+	}
+	for _, c := range casos {
+		rr := executar(t, srv, "GET", "/api/tarefas?"+c.query, "")
+		var pagina dominio.PaginaTarefas
+		if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+			t.Fatal(err)
+		}
+		if len(pagina.Tarefas) != c.esperado {
+			t.Errorf("%q: %d tarefas esperado %d", c.query, len(pagina.Tarefas), c.esperado)
+		}
+	}
+
+	// Renomear e trocar a cor não mexem nas tarefas; campos ausentes são mantidos
+	rr = executar(t, srv, "PATCH", "/api/etiquetas/"+casa.ID, `{"nome":"Lar"}`)
+	var alterada Etiqueta
+	if err := json.Unmarshal(rr.Body.Bytes(), &alterada); err != nil || alterada.Nome != "Lar" || alterada.Cor != dominio.CorPadraoEtiqueta {
+		t.Errorf("PATCH nome: obtido %d %+v", rr.Code, alterada)
+	}
+	rr = executar(t, srv, "PATCH", "/api/etiquetas/"+casa.ID, `{"cor":"#27AE60"}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &alterada); err != nil || alterada.Nome != "Lar" || alterada.Cor != "#27ae60" {
+		t.Errorf("PATCH cor: obtido %d %+v", rr.Code, alterada)
+	}
+	rr = executar(t, srv, "PATCH", "/api/etiquetas/"+casa.ID, `{"cor":"verde"}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "cor" {
+		t.Errorf("PATCH cor inválida: obtido %d %+v", rr.Code, p.Campos)
+	}
+
+	// Remover a etiqueta a retira das tarefas
+	if rr := executar(t, srv, "DELETE", "/api/etiquetas/"+urgente.ID, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	obtida, err := srv.tarefas.Buscar(usuarioTeste, ambas.ID)
+	if err != nil || len(obtida.Etiquetas) != 1 || obtida.Etiquetas[0] != casa.ID {
+		t.Errorf("tarefa após remover etiqueta: %+v %v", obtida, err)
+	}
+	rr = executar(t, srv, "GET", "/api/etiquetas/"+urgente.ID, "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusNotFound || p.Codigo != dominio.CodigoEtiquetaNaoEncontrada {
+		t.Errorf("GET após DELETE: obtido %d %q", rr.Code, p.Codigo)
+	}
+}
+
+func TestTarefaExigeEtiquetasDoDono(t *testing.T) {
+	srv := novoServidorTeste(t)
+	alheia, err := srv.etiquetas.Criar("outro", Etiqueta{Nome: "Alheia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := criarTarefaTeste(t, srv, "Sem etiqueta")
+
+	casos := []struct{ metodo, url, corpo string }{
+		{"POST", "/api/tarefas", `{"titulo":"Perdida","etiquetas":["inexistente"]}`},
+		{"PATCH", "/api/tarefas/" + id, `{"etiquetas":["` + alheia.ID + `"]}`},
+	}
+	for _, c := range casos {
+		rr := executar(t, srv, c.metodo, c.url, c.corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "etiquetas" {
+			t.Errorf("%s %s: obtido %d %+v", c.metodo, c.corpo, rr.Code, p.Campos)
+		}
+	}
+
+	for _, metodo := range []string{"GET", "PATCH", "DELETE"} {
+		rr := executar(t, srv, metodo, "/api/etiquetas/"+alheia.ID, `{"nome":"Invadida"}`)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s de etiqueta alheia retornou %d", metodo, rr.Code)
+		}
+	}
+}
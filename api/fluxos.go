@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// fluxosPorProjeto guarda o fluxo de trabalho de cada projeto do dono
+type fluxosPorProjeto map[string]dominio.Fluxo
+
+// de retorna o fluxo do projeto, ou o padrão para tarefas sem projeto
+func (f fluxosPorProjeto) de(projetoID string) dominio.Fluxo {
+	if fluxo, ok := f[projetoID]; ok {
+		return fluxo
+	}
+	return dominio.FluxoPadrao()
+}
+
+// fluxos retorna os fluxos de trabalho dos projetos do dono. Como a
+// listagem das tarefas, é lido antes de Atualizar, que não pode consultar
+// outros repositórios enquanto bloqueia a tarefa.
+func (s *servidor) fluxos(dono string) (fluxosPorProjeto, error) {
+	projetos, err := s.projetos.Listar(dono)
+	if err != nil {
+		return nil, err
+	}
+	fluxos := make(fluxosPorProjeto, len(projetos))
+	for _, p := range projetos {
+		fluxos[p.ID] = p.FluxoDeTrabalho()
+	}
+	return fluxos, nil
+}
+
+// criarTarefa grava uma nova tarefa do dono no estado informado ou no
+// estado inicial do fluxo do seu projeto e abre o histórico de estados.
+// Exige s.muEstados tomado; a tarefa é recusada se a coluna do seu estado
+// estiver cheia.
+func (s *servidor) criarTarefa(dono string, t Tarefa) (Tarefa, error) {
+	fluxos, err := s.fluxos(dono)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	if err := fluxos.de(t.ProjetoID).AplicarEstado(nil, &t); err != nil {
+		return Tarefa{}, err
+	}
+	todas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	if err := fluxos.de(t.ProjetoID).ConferirLimite(t, todas); err != nil {
+		return Tarefa{}, err
+	}
+	t, err = s.tarefas.Criar(dono, t)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	return t, s.registrarEstado(dono, t.ID, "", t.Estado)
+}
+
+// atualizarNoFluxo aplica mudar, que recebe os fluxos dos projetos do dono,
+// à tarefa gravada e registra no histórico a mudança de estado resultante.
+// É o caminho de toda alteração que pode levar a tarefa a outra coluna do
+// quadro e exige s.muEstados tomado. Entrar em uma coluna cheia é recusado
+// com *dominio.ErroLimiteEstado, assim como concluir uma tarefa recorrente
+// cuja próxima ocorrência não cabe no estado inicial; a próxima ocorrência
+// é criada logo depois da alteração. Com versao maior que zero, a tarefa
+// precisa estar nessa versão.
+func (s *servidor) atualizarNoFluxo(dono, id string, versao int, mudar func(t *Tarefa, fluxos fluxosPorProjeto) error) (antes, depois Tarefa, err error) {
+	fluxos, err := s.fluxos(dono)
+	if err != nil {
+		return Tarefa{}, Tarefa{}, err
+	}
+	todas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return Tarefa{}, Tarefa{}, err
+	}
+
+	var proxima *Tarefa
+	depois, err = s.tarefas.Atualizar(dono, id, versao, func(t *Tarefa) error {
+		antes = copiarTarefa(*t)
+		if err := mudar(t, fluxos); err != nil {
+			return err
+		}
+		// Só entrar na coluna é barrado: uma tarefa que já está nela pode ser
+		// alterada mesmo que o limite tenha sido reduzido depois
+		if t.Estado != antes.Estado || t.ProjetoID != antes.ProjetoID {
+			if err := fluxos.de(t.ProjetoID).ConferirLimite(*t, todas); err != nil {
+				return err
+			}
+		}
+		proxima = proximaOcorrencia(t, antes.Concluida)
+		if proxima == nil {
+			return nil
+		}
+		fluxo := fluxos.de(proxima.ProjetoID)
+		if err := fluxo.AplicarEstado(nil, proxima); err != nil {
+			return err
+		}
+		ocupadas := slices.Clone(todas)
+		for i := range ocupadas {
+			if ocupadas[i].ID == t.ID {
+				ocupadas[i] = *t
+			}
+		}
+		return fluxo.ConferirLimite(*proxima, ocupadas)
+	})
+	if err != nil {
+		return Tarefa{}, Tarefa{}, err
+	}
+	if err := s.registrarEstado(dono, id, antes.Estado, depois.Estado); err != nil {
+		return Tarefa{}, Tarefa{}, err
+	}
+	return antes, depois, s.criarProximaOcorrencia(dono, proxima)
+}
+
+// registrarEstado grava no histórico a passagem da tarefa do estado de para
+// o estado para, se o estado de fato mudou
+func (s *servidor) registrarEstado(dono, tarefaID, de, para string) error {
+	if de == para {
+		return nil
+	}
+	_, err := s.historico.Registrar(dono, MudancaEstado{TarefaID: tarefaID, De: de, Para: para})
+	return err
+}
+
+// reaplicarFluxo passa as tarefas do projeto para o fluxo informado depois
+// que o fluxo do projeto mudou. Tarefas em estados que deixaram de existir
+// vão para o estado equivalente a concluida. Exige s.muEstados tomado.
+func (s *servidor) reaplicarFluxo(dono, projetoID string, fluxo dominio.Fluxo) error {
+	return s.moverTarefasDoProjeto(dono, projetoID, func(t *Tarefa) error {
+		antes := *t
+		return fluxo.AplicarEstado(&antes, t)
+	})
+}
+
+// conferirLimitesDoFluxo verifica, antes de o projeto passar a usar o
+// fluxo, se as tarefas do projeto entre todas que mudariam de estado cabem
+// nas colunas de destino, contando as demais já nos estados novos
+func conferirLimitesDoFluxo(fluxo dominio.Fluxo, projetoID string, todas []Tarefa) error {
+	depois := make([]Tarefa, len(todas))
+	for i, t := range todas {
+		depois[i] = t
+		if t.ProjetoID != projetoID {
+			continue
+		}
+		if err := fluxo.AplicarEstado(&t, &depois[i]); err != nil {
+			return err
+		}
+	}
+	for i, t := range depois {
+		if t.ProjetoID != projetoID || t.Estado == todas[i].Estado {
+			continue
+		}
+		if err := fluxo.ConferirLimite(t, depois); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errSemMudanca interrompe a gravação de uma tarefa que mudar deixou como
+// estava, para que ela não ganhe uma versão nova à toa
+var errSemMudanca = errors.New("tarefa sem mudança")
+
+// moverTarefasDoProjeto aplica mudar a cada tarefa do projeto e registra no
+// histórico as mudanças de estado resultantes. Só as tarefas que mudam são
+// gravadas: salvar o fluxo ou os limites sem mexer nos estados não invalida
+// os formulários abertos. Exige s.muEstados tomado.
+func (s *servidor) moverTarefasDoProjeto(dono, projetoID string, mudar func(t *Tarefa) error) error {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	for _, t := range tarefas {
+		if t.ProjetoID != projetoID {
+			continue
+		}
+		_, _, err := s.atualizarNoFluxo(dono, t.ID, 0, func(t *Tarefa, _ fluxosPorProjeto) error {
+			antes := copiarTarefa(*t)
+			if t.ProjetoID != projetoID {
+				return errSemMudanca
+			}
+			if err := mudar(t); err != nil {
+				return err
+			}
+			if len(dominio.DiferencasTarefa(&antes, *t)) == 0 {
+				return errSemMudanca
+			}
+			return nil
+		})
+		if errors.Is(err, ErrTarefaNaoEncontrada) || errors.Is(err, errSemMudanca) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manipuladorHistorico atende GET /api/tarefas/{id}/historico/estados, com as
+// mudanças de estado da tarefa da mais antiga para a mais recente
+func (s *servidor) manipuladorHistorico(w http.ResponseWriter, r *http.Request, dono, id string) {
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		if _, err := s.tarefas.Buscar(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		mudancas, err := s.historico.Listar(dono, id)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(mudancas)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, OPTIONS")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// fluxoRevisaoTeste exige revisão antes da conclusão
+const fluxoRevisaoTeste = `{"estados":[
+	{"id":"backlog","nome":"Backlog"},
+	{"id":"em_andamento","nome":"Em andamento"},
+	{"id":"revisao","nome":"Revisão"},
+	{"id":"concluida","nome":"Concluída","final":true}],
+"transicoes":[
+	{"de":"backlog","para":"em_andamento"},
+	{"de":"em_andamento","para":"revisao"},
+	{"de":"revisao","para":"em_andamento"},
+	{"de":"revisao","para":"concluida"},
+	{"de":"concluida","para":"em_andamento"}]}`
+
+// criarProjetoComFluxoTeste cria um projeto com o fluxo informado e retorna seu ID
+func criarProjetoComFluxoTeste(t *testing.T, srv *servidor, fluxo string) string {
+	t.Helper()
+	rr := executar(t, srv, "POST", "/api/projetos", `{"nome":"Produto","fluxo":`+fluxo+`}`)
+	var p Projeto
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/projetos retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	return p.ID
+}
+
+// historicoTeste retorna a sequência de estados registrada para a tarefa
+func historicoTeste(t *testing.T, srv *servidor, id string) []string {
+	t.Helper()
+	rr := executar(t, srv, "GET", "/api/tarefas/"+id+"/historico/estados", "")
+	var mudancas []MudancaEstado
+	if err := json.Unmarshal(rr.Body.Bytes(), &mudancas); err != nil {
+		t.Fatalf("GET historico retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	var estados []string
+	for _, m := range mudancas {
+		estados = append(estados, m.De+">"+m.Para)
+	}
+	return estados
+}
+
+func TestFluxoDoProjetoControlaOsEstados(t *testing.T) {
+	srv := novoServidorTeste(t)
+	projeto := criarProjetoComFluxoTeste(t, srv, fluxoRevisaoTeste)
+
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Login social","projeto_id":"`+projeto+`"}`)
+	var tarefa Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil || tarefa.Estado != "backlog" || tarefa.Concluida {
+		t.Fatalf("tarefa nova no projeto: %d %s", rr.Code, rr.Body.String())
+	}
+	id := tarefa.ID
+
+	// Pular a revisão é recusado sem alterar a tarefa
+	rr = executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"concluida"}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusConflict || p.Codigo != dominio.CodigoTransicaoInvalida || len(p.Campos) != 1 || p.Campos[0].Campo != "estado" {
+		t.Errorf("transição não permitida: obtido %d %+v", rr.Code, p)
+	}
+	rr = executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"pronta"}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "estado" {
+		t.Errorf("estado inexistente: obtido %d %+v", rr.Code, p.Campos)
+	}
+
+	// concluida, dos clientes antigos, só conclui quando o fluxo permite
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"em_andamento"}`)
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":true}`); rr.Code != http.StatusConflict {
+		t.Errorf("concluir fora da revisão retornou %d", rr.Code)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"revisao"}`)
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":true}`); rr.Code != http.StatusOK {
+		t.Errorf("concluir na revisão retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if tarefa := buscarTarefaTeste(t, srv, id); tarefa.Estado != "concluida" || !tarefa.Concluida || tarefa.ConcluidaEm == nil {
+		t.Errorf("tarefa concluída pelo campo antigo: %+v", tarefa)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":false}`)
+
+	esperado := []string{">backlog", "backlog>em_andamento", "em_andamento>revisao", "revisao>concluida", "concluida>em_andamento"}
+	if historico := historicoTeste(t, srv, id); !slices.Equal(historico, esperado) {
+		t.Errorf("histórico obtido %v esperado %v", historico, esperado)
+	}
+
+	// O histórico de outro usuário não existe e some com a tarefa
+	if err := srv.usuarios.DefinirSenha("outro", "senha-outro"); err != nil {
+		t.Fatal(err)
+	}
+	outro := srv.tokens.emitir("outro", dominio.EscoposValidos).Token
+	if rr := executarComo(t, srv, outro, "GET", "/api/tarefas/"+id+"/historico/estados", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("histórico de outro usuário retornou %d", rr.Code)
+	}
+	executar(t, srv, "DELETE", "/api/tarefas/"+id, "")
+	executar(t, srv, "DELETE", "/api/lixeira/"+id, "")
+	if mudancas, _ := srv.historico.Listar(usuarioTeste, id); len(mudancas) != 0 {
+		t.Errorf("histórico da tarefa removida: %+v", mudancas)
+	}
+}
+
+func TestMudarFluxoReposicionaAsTarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+	projeto := criarProjetoComFluxoTeste(t, srv, fluxoRevisaoTeste)
+	emRevisao := criarTarefaTeste(t, srv, "Em revisão")
+	iniciada := criarTarefaTeste(t, srv, "Iniciada")
+	for _, id := range []string{emRevisao, iniciada} {
+		executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"projeto_id":"`+projeto+`"}`)
+		executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"em_andamento"}`)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+emRevisao, `{"estado":"revisao"}`)
+
+	// Um fluxo inválido é recusado
+	rr := executar(t, srv, "PUT", "/api/projetos/"+projeto, `{"nome":"Produto","fluxo":{"estados":[{"id":"unico","nome":"Único"}]}}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "fluxo" {
+		t.Errorf("fluxo inválido: obtido %d %+v", rr.Code, p.Campos)
+	}
+
+	// Sem a revisão, a tarefa que estava nela volta ao estado inicial; a que
+	// não muda de estado não é regravada e mantém a versão
+	versaoIniciada := buscarTarefaTeste(t, srv, iniciada).Versao
+	rr = executar(t, srv, "PUT", "/api/projetos/"+projeto, `{"nome":"Produto","fluxo":{"estados":[
+		{"id":"backlog","nome":"Backlog"},{"id":"em_andamento","nome":"Em andamento"},{"id":"concluida","nome":"Concluída","final":true}]}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT com fluxo retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if estado := buscarTarefaTeste(t, srv, emRevisao).Estado; estado != "backlog" {
+		t.Errorf("tarefa em estado removido foi para %q", estado)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, iniciada); tarefa.Estado != "em_andamento" || tarefa.Versao != versaoIniciada {
+		t.Errorf("tarefa em estado mantido: %q, versão %d", tarefa.Estado, tarefa.Versao)
+	}
+
+	// Renomear sem enviar o fluxo o mantém; fluxo null volta ao padrão
+	executar(t, srv, "PUT", "/api/projetos/"+projeto, `{"nome":"Produto 2"}`)
+	if p, _ := srv.projetos.Buscar(usuarioTeste, projeto); p.Fluxo == nil || len(p.Fluxo.Estados) != 3 {
+		t.Errorf("renomear alterou o fluxo: %+v", p.Fluxo)
+	}
+	executar(t, srv, "PUT", "/api/projetos/"+projeto, `{"nome":"Produto 2","fluxo":null}`)
+	if estado := buscarTarefaTeste(t, srv, iniciada).Estado; estado != dominio.EstadoPendente {
+		t.Errorf("tarefa no fluxo padrão foi para %q", estado)
+	}
+	historico := historicoTeste(t, srv, emRevisao)
+	if ultimo := historico[len(historico)-1]; ultimo != "backlog>"+dominio.EstadoPendente {
+		t.Errorf("última mudança registrada: %v", historico)
+	}
+}
+
+func TestRemoverProjetoVoltaAoFluxoPadrao(t *testing.T) {
+	srv := novoServidorTeste(t)
+	projeto := criarProjetoComFluxoTeste(t, srv, `{"estados":[{"id":"aberta","nome":"Aberta"},{"id":"entregue","nome":"Entregue","final":true}]}`)
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Entregar","projeto_id":"`+projeto+`","estado":"entregue"}`)
+	var tarefa Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil || tarefa.Estado != "entregue" || !tarefa.Concluida {
+		t.Fatalf("tarefa criada em um estado final: %d %s", rr.Code, rr.Body.String())
+	}
+
+	executar(t, srv, "DELETE", "/api/projetos/"+projeto, "")
+	if tarefa := buscarTarefaTeste(t, srv, tarefa.ID); tarefa.ProjetoID != "" || tarefa.Estado != dominio.EstadoConcluida || !tarefa.Concluida {
+		t.Errorf("tarefa do projeto removido: %+v", tarefa)
+	}
+}
+
+func TestLimiteDoEstadoNaAPI(t *testing.T) {
+	srv := novoServidorTeste(t)
+	projeto := criarProjetoComFluxoTeste(t, srv, `{"estados":[
+		{"id":"backlog","nome":"Backlog"},
+		{"id":"fazendo","nome":"Fazendo","limite":2},
+		{"id":"feito","nome":"Feito","final":true}]}`)
+	criar := func(corpo string) string {
+		rr := executar(t, srv, "POST", "/api/tarefas", corpo)
+		var tarefa Tarefa
+		if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil || rr.Code != http.StatusCreated {
+			t.Fatalf("POST /api/tarefas retornou %d: %s", rr.Code, rr.Body.String())
+		}
+		return tarefa.ID
+	}
+	pai := criar(`{"titulo":"Release","projeto_id":"` + projeto + `"}`)
+	sub := criar(`{"titulo":"Changelog","projeto_id":"` + projeto + `","pai_id":"` + pai + `"}`)
+
+	// A subtarefa ocupa a coluna como qualquer tarefa
+	for _, id := range []string{sub, pai} {
+		if rr := executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"fazendo"}`); rr.Code != http.StatusOK {
+			t.Fatalf("mover para a coluna com vaga retornou %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+	outra := criar(`{"titulo":"Docs","projeto_id":"` + projeto + `"}`)
+	rr := executar(t, srv, "PATCH", "/api/tarefas/"+outra, `{"estado":"fazendo"}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusConflict || p.Codigo != dominio.CodigoLimiteEstado || len(p.Campos) != 1 || p.Campos[0].Campo != "estado" {
+		t.Errorf("coluna cheia: obtido %d %+v", rr.Code, p)
+	}
+
+	// Uma tarefa que já está na coluna continua podendo ser alterada
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+pai, `{"titulo":"Release 2"}`); rr.Code != http.StatusOK {
+		t.Errorf("alterar tarefa na coluna cheia retornou %d", rr.Code)
+	}
+
+	// Mudanças concorrentes não passam juntas da última vaga
+	executar(t, srv, "PATCH", "/api/tarefas/"+sub, `{"estado":"feito"}`)
+	candidatas := []string{outra, criar(`{"titulo":"Testes","projeto_id":"` + projeto + `"}`), criar(`{"titulo":"Deploy","projeto_id":"` + projeto + `"}`)}
+	var wg sync.WaitGroup
+	codigos := make([]int, len(candidatas))
+	for i, id := range candidatas {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			codigos[i] = executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"estado":"fazendo"}`).Code
+		}(i, id)
+	}
+	wg.Wait()
+	aceitas := 0
+	for _, codigo := range codigos {
+		if codigo == http.StatusOK {
+			aceitas++
+		}
+	}
+	if aceitas != 1 {
+		t.Errorf("mudanças concorrentes para a última vaga: %v", codigos)
+	}
+}
+
+func TestLimiteDoEstadoAoCriarRestaurarEConcluirPai(t *testing.T) {
+	srv := novoServidorTeste(t)
+	projeto := criarProjetoComFluxoTeste(t, srv, `{"estados":[
+		{"id":"backlog","nome":"Backlog"},
+		{"id":"fazendo","nome":"Fazendo","limite":1},
+		{"id":"feito","nome":"Feito","final":true,"limite":1}]}`)
+	criar := func(corpo string) *httptest.ResponseRecorder {
+		return executar(t, srv, "POST", "/api/tarefas", `{"projeto_id":"`+projeto+`",`+corpo+`}`)
+	}
+	idDe := func(rr *httptest.ResponseRecorder) string {
+		t.Helper()
+		var tarefa Tarefa
+		if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil || rr.Code != http.StatusCreated {
+			t.Fatalf("POST /api/tarefas retornou %d: %s", rr.Code, rr.Body.String())
+		}
+		return tarefa.ID
+	}
+	limiteAtingido := func(contexto string, rr *httptest.ResponseRecorder) {
+		t.Helper()
+		if p := lerProblema(t, rr); rr.Code != http.StatusConflict || p.Codigo != dominio.CodigoLimiteEstado {
+			t.Errorf("%s: obtido %d %+v", contexto, rr.Code, p)
+		}
+	}
+
+	// Criar direto no estado também ocupa a coluna
+	primeira := idDe(criar(`"titulo":"Login","estado":"fazendo"`))
+	limiteAtingido("criar na coluna cheia", criar(`"titulo":"Cadastro","estado":"fazendo"`))
+
+	// Voltar da lixeira é entrar de novo na coluna
+	executar(t, srv, "DELETE", "/api/tarefas/"+primeira, "")
+	idDe(criar(`"titulo":"Cadastro","estado":"fazendo"`))
+	limiteAtingido("restaurar para a coluna cheia", executar(t, srv, "POST", "/api/lixeira/"+primeira+"/restaurar", ""))
+	if itens := lixeiraTeste(t, srv); len(itens) != 1 || itens[0].Tarefa.ID != primeira {
+		t.Errorf("tarefa recusada saiu da lixeira: %+v", itens)
+	}
+
+	// O pai completo não entra na coluna cheia e continua pendente, sem
+	// desfazer a conclusão da subtarefa
+	idDe(criar(`"titulo":"Deploy","estado":"feito"`))
+	pai := idDe(criar(`"titulo":"Release","concluir_com_subtarefas":true`))
+	sub := criarSubtarefaTeste(t, srv, pai, "Changelog")
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+sub, `{"concluida":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("concluir a subtarefa retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if tarefa := buscarTarefaTeste(t, srv, pai); tarefa.Concluida || tarefa.Estado != "backlog" {
+		t.Errorf("pai concluído na coluna cheia: %+v", tarefa)
+	}
+
+	// Trocar o fluxo não leva tarefas para uma coluna cheia: sem fazendo,
+	// Cadastro voltaria ao backlog, onde o pai já ocupa a única vaga
+	rr := executar(t, srv, "PUT", "/api/projetos/"+projeto, `{"nome":"Produto","fluxo":{"estados":[
+		{"id":"backlog","nome":"Backlog","limite":1},
+		{"id":"feito","nome":"Feito","final":true}]}}`)
+	limiteAtingido("trocar o fluxo", rr)
+	if p, _ := srv.projetos.Buscar(usuarioTeste, projeto); len(p.FluxoDeTrabalho().Estados) != 3 {
+		t.Errorf("fluxo recusado foi gravado: %+v", p.Fluxo)
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// intervaloPurgaLixeira é de quanto em quanto tempo a purga automática
+// procura tarefas que passaram do prazo de retenção da lixeira
+const intervaloPurgaLixeira = time.Hour
+
+// manipuladorLixeira atende GET /api/lixeira, com as tarefas removidas do
+// usuário, das removidas mais recentemente para as mais antigas
+func (s *servidor) manipuladorLixeira(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		itens, err := s.itensLixeira(dono)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(itens)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, OPTIONS")
+	}
+}
+
+// manipuladorItemLixeira atende /api/lixeira/{id}, que purga a tarefa de
+// vez, e POST /api/lixeira/{id}/restaurar. Só as tarefas removidas
+// diretamente são itens da lixeira; as subtarefas removidas junto com elas
+// voltam ou são purgadas com a tarefa.
+func (s *servidor) manipuladorItemLixeira(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	id, subrecurso, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/lixeira/"), "/")
+	switch {
+	case id == "", subrecurso != "" && subrecurso != "restaurar":
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+	case r.Method == "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case subrecurso == "restaurar" && r.Method == "POST":
+		if err := s.restaurarTarefa(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		t, err := s.buscarCalculada(dono, id)
+		if err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		definirETag(w, t)
+		json.NewEncoder(w).Encode(t)
+	case subrecurso == "restaurar":
+		responderMetodoNaoPermitido(w, r, "POST, OPTIONS")
+	case r.Method == "DELETE":
+		if err := s.purgarTarefa(dono, id, agora()); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		responderMetodoNaoPermitido(w, r, "DELETE, OPTIONS")
+	}
+}
+
+// itensLixeira agrupa as tarefas na lixeira do dono pela tarefa removida
+// diretamente
+func (s *servidor) itensLixeira(dono string) ([]dominio.ItemLixeira, error) {
+	excluidas, err := s.tarefas.ListarLixeira(dono)
+	if err != nil {
+		return nil, err
+	}
+	itens := []dominio.ItemLixeira{}
+	for _, t := range excluidas {
+		if t.ExcluidaCom != "" {
+			continue
+		}
+		item := dominio.ItemLixeira{
+			Tarefa:     t,
+			Subtarefas: []Tarefa{},
+			ExcluidaEm: *t.ExcluidaEm,
+			PurgaEm:    t.ExcluidaEm.Add(s.retencaoLixeira),
+		}
+		for _, sub := range excluidas {
+			if sub.ExcluidaCom == t.ID {
+				item.Subtarefas = append(item.Subtarefas, sub)
+			}
+		}
+		itens = append(itens, item)
+	}
+	slices.SortStableFunc(itens, func(a, b dominio.ItemLixeira) int { return b.ExcluidaEm.Compare(a.ExcluidaEm) })
+	return itens, nil
+}
+
+// grupoLixeira retorna a tarefa removida diretamente com o ID informado
+// seguida das subtarefas removidas junto com ela, ou ErrTarefaNaoEncontrada
+func (s *servidor) grupoLixeira(dono, id string) ([]Tarefa, error) {
+	excluidas, err := s.tarefas.ListarLixeira(dono)
+	if err != nil {
+		return nil, err
+	}
+	var grupo []Tarefa
+	for _, t := range excluidas {
+		if t.ID == id && t.ExcluidaCom == "" {
+			grupo = append([]Tarefa{t}, grupo...)
+		} else if t.ExcluidaCom == id {
+			grupo = append(grupo, t)
+		}
+	}
+	if len(grupo) == 0 || grupo[0].ID != id {
+		return nil, ErrTarefaNaoEncontrada
+	}
+	return grupo, nil
+}
+
+// restaurarTarefa tira da lixeira a tarefa e as subtarefas removidas junto
+// com ela, e devolve a ela as subtarefas promovidas ao pai na remoção que
+// continuam lá. O que mudou enquanto estavam na lixeira é corrigido em uma
+// nova revisão: pai, projeto, etiquetas e bloqueios que não existem mais são
+// retirados, e o estado passa ao equivalente no fluxo do projeto. Os
+// bloqueios por tarefas que ainda estão na lixeira ficam. Se alguma das
+// tarefas não couber na coluna do seu estado, nada é restaurado.
+func (s *servidor) restaurarTarefa(dono, id string) error {
+	s.muEstados.Lock()
+	defer s.muEstados.Unlock()
+	grupo, err := s.grupoLixeira(dono, id)
+	if err != nil {
+		return err
+	}
+
+	// As tarefas do grupo contam como existentes, pois voltam juntas
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	excluidas, err := s.tarefas.ListarLixeira(dono)
+	if err != nil {
+		return err
+	}
+	existentes := make(map[string]bool, len(tarefas)+len(grupo))
+	for _, t := range tarefas {
+		existentes[t.ID] = true
+	}
+	for _, t := range grupo {
+		existentes[t.ID] = true
+	}
+	naLixeira := make(map[string]bool, len(excluidas))
+	for _, t := range excluidas {
+		naLixeira[t.ID] = true
+	}
+	etiquetas, err := s.etiquetas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	etiquetasExistentes := make(map[string]bool, len(etiquetas))
+	for _, e := range etiquetas {
+		etiquetasExistentes[e.ID] = true
+	}
+	fluxos, err := s.fluxos(dono)
+	if err != nil {
+		return err
+	}
+	reparar := func(t *Tarefa) error {
+		antes := copiarTarefa(*t)
+		if !existentes[t.PaiID] {
+			t.PaiID = ""
+		}
+		if _, ok := fluxos[t.ProjetoID]; !ok {
+			t.ProjetoID = ""
+		}
+		t.Etiquetas = slices.DeleteFunc(t.Etiquetas, func(e string) bool { return !etiquetasExistentes[e] })
+		t.BloqueadaPor = slices.DeleteFunc(t.BloqueadaPor, func(b string) bool { return !existentes[b] && !naLixeira[b] })
+		return fluxos.de(t.ProjetoID).AplicarEstado(&antes, t)
+	}
+
+	// Voltar da lixeira é entrar de novo na coluna: o limite é conferido
+	// com o grupo todo já reparado, antes de restaurar qualquer tarefa
+	reparadas := make([]Tarefa, len(grupo))
+	for i, t := range grupo {
+		reparadas[i] = copiarTarefa(t)
+		if err := reparar(&reparadas[i]); err != nil {
+			return err
+		}
+	}
+	ocupadas := append(slices.Clone(tarefas), reparadas...)
+	for _, t := range reparadas {
+		if err := fluxos.de(t.ProjetoID).ConferirLimite(t, ocupadas); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range grupo {
+		if _, err := s.tarefas.Restaurar(dono, t.ID); err != nil {
+			return err
+		}
+	}
+	for i, t := range grupo {
+		if len(dominio.DiferencasTarefa(&t, reparadas[i])) == 0 {
+			continue
+		}
+		_, _, err := s.atualizarNoFluxo(dono, t.ID, 0, func(t *Tarefa, _ fluxosPorProjeto) error {
+			return reparar(t)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.devolverPromovidas(dono, grupo[0]); err != nil {
+		return err
+	}
+	// Uma subtarefa concluída que volta pode completar o pai
+	return s.concluirPais(dono, grupo[0].PaiID)
+}
+
+// errSubtarefaMovida interrompe a promoção ou a devolução de uma subtarefa
+// que foi movida para outro pai nesse meio tempo
+var errSubtarefaMovida = errors.New("subtarefa movida depois da remoção")
+
+// devolverPromovidas põe de volta sob a tarefa restaurada as subtarefas que
+// passaram para o pai dela na remoção, se continuam sob esse pai
+func (s *servidor) devolverPromovidas(dono string, restaurada Tarefa) error {
+	for _, id := range restaurada.SubtarefasPromovidas {
+		_, err := s.tarefas.Atualizar(dono, id, 0, func(t *Tarefa) error {
+			if t.PaiID != restaurada.PaiID {
+				return errSubtarefaMovida
+			}
+			t.PaiID = restaurada.ID
+			return nil
+		})
+		if err != nil && !errors.Is(err, errSubtarefaMovida) && !errors.Is(err, ErrTarefaNaoEncontrada) {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgarTarefa exclui de vez a tarefa da lixeira, se ela foi para lá até
+// limite, as subtarefas removidas junto com ela e tudo o que pertence a elas
+func (s *servidor) purgarTarefa(dono, id string, limite time.Time) error {
+	grupo, err := s.grupoLixeira(dono, id)
+	if err != nil {
+		return err
+	}
+	// A tarefa sai antes das subtarefas e só se continuar na lixeira: se
+	// foi restaurada depois da leitura do grupo, nada é excluído
+	err = s.tarefas.Purgar(dono, id, func(t Tarefa) bool {
+		return t.ExcluidaCom == "" && !t.ExcluidaEm.After(limite)
+	})
+	if err != nil {
+		return err
+	}
+	ids := []string{id}
+	for _, sub := range grupo[1:] {
+		err := s.tarefas.Purgar(dono, sub.ID, func(t Tarefa) bool { return t.ExcluidaCom == id })
+		if err != nil && !errors.Is(err, ErrTarefaNaoEncontrada) {
+			return err
+		}
+		ids = append(ids, sub.ID)
+	}
+	return s.removerDadosDasTarefas(dono, ids)
+}
+
+// removerDadosDasTarefas exclui o que pertence às tarefas purgadas e tira
+// essas tarefas das dependências das demais
+func (s *servidor) removerDadosDasTarefas(dono string, ids []string) error {
+	if err := s.historico.RemoverDasTarefas(dono, ids); err != nil {
+		return err
+	}
+	if err := s.revisoes.RemoverDasTarefas(dono, ids); err != nil {
+		return err
+	}
+	if err := s.comentarios.RemoverDasTarefas(dono, ids); err != nil {
+		return err
+	}
+	if err := s.removerBloqueios(dono, ids); err != nil {
+		return err
+	}
+	return s.removerAnexosDasTarefas(dono, ids)
+}
+
+// purgarLixeira exclui de vez as tarefas de todos os usuários que estão na
+// lixeira há mais tempo que a retenção e retorna quantos itens purgou
+func (s *servidor) purgarLixeira() (int, error) {
+	limite := agora().Add(-s.retencaoLixeira)
+	expiradas, err := s.tarefas.ExcluidasAntesDe(limite)
+	if err != nil {
+		return 0, err
+	}
+	purgados := 0
+	grupos := map[string]bool{}
+	for _, t := range expiradas {
+		if t.ExcluidaCom != "" {
+			continue
+		}
+		grupos[t.ID] = true
+		err := s.purgarTarefa(t.Dono, t.ID, limite)
+		switch {
+		case err == nil:
+			purgados++
+		case errors.Is(err, ErrTarefaNaoEncontrada):
+			// Restaurada ou purgada nesse meio tempo
+		default:
+			return purgados, err
+		}
+	}
+
+	// Uma purga interrompida no meio deixa subtarefas cuja tarefa não existe
+	// mais; elas são purgadas sozinhas
+	for _, t := range expiradas {
+		if t.ExcluidaCom == "" || grupos[t.ExcluidaCom] {
+			continue
+		}
+		orfa, err := s.orfaNaLixeira(t)
+		if err != nil {
+			return purgados, err
+		}
+		if !orfa {
+			continue
+		}
+		err = s.tarefas.Purgar(t.Dono, t.ID, func(atual Tarefa) bool { return atual.ExcluidaCom == t.ExcluidaCom })
+		if errors.Is(err, ErrTarefaNaoEncontrada) {
+			continue
+		}
+		if err != nil {
+			return purgados, err
+		}
+		if err := s.removerDadosDasTarefas(t.Dono, []string{t.ID}); err != nil {
+			return purgados, err
+		}
+	}
+	return purgados, nil
+}
+
+// orfaNaLixeira informa se a tarefa que foi para a lixeira junto com outra
+// ficou sem ela: nem na lixeira, nem restaurada
+func (s *servidor) orfaNaLixeira(t Tarefa) (bool, error) {
+	_, err := s.grupoLixeira(t.Dono, t.ExcluidaCom)
+	if !errors.Is(err, ErrTarefaNaoEncontrada) {
+		return false, err
+	}
+	_, err = s.tarefas.Buscar(t.Dono, t.ExcluidaCom)
+	if errors.Is(err, ErrTarefaNaoEncontrada) {
+		return true, nil
+	}
+	return false, err
+}
+
+// purgarLixeiraPeriodicamente executa purgarLixeira ao iniciar e depois a
+// cada intervalo, até ctx ser cancelado
+func (s *servidor) purgarLixeiraPeriodicamente(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		purgados, err := s.purgarLixeira()
+		if err != nil {
+			log.Printf("erro ao purgar a lixeira: %v", err)
+		}
+		if purgados > 0 {
+			log.Printf("%d tarefas purgadas da lixeira", purgados)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// lixeiraTeste retorna os itens da lixeira do usuário de teste
+func lixeiraTeste(t *testing.T, srv *servidor) []dominio.ItemLixeira {
+	t.Helper()
+	rr := executar(t, srv, "GET", "/api/lixeira", "")
+	var itens []dominio.ItemLixeira
+	if err := json.Unmarshal(rr.Body.Bytes(), &itens); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("GET lixeira retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	return itens
+}
+
+func TestLixeiraRestauraEPurga(t *testing.T) {
+	srv := novoServidorTeste(t)
+	rr := executar(t, srv, "POST", "/api/etiquetas", `{"nome":"Casa"}`)
+	var etiqueta Etiqueta
+	json.Unmarshal(rr.Body.Bytes(), &etiqueta)
+	pai := criarTarefaTeste(t, srv, "Mudança")
+	executar(t, srv, "PATCH", "/api/tarefas/"+pai, `{"etiquetas":["`+etiqueta.ID+`"]}`)
+	filha := criarSubtarefaTeste(t, srv, pai, "Caixas")
+	executar(t, srv, "POST", "/api/tarefas/"+filha+"/comentarios", `{"texto":"Comprar fita"}`)
+	dependente := criarTarefaTeste(t, srv, "Transporte")
+	executar(t, srv, "PATCH", "/api/tarefas/"+dependente, `{"bloqueada_por":["`+pai+`"]}`)
+
+	// A tarefa e as subtarefas saem das listagens, mas não somem
+	if rr := executar(t, srv, "DELETE", "/api/tarefas/"+pai+"?subtarefas=remover", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	for _, id := range []string{pai, filha} {
+		if rr := executar(t, srv, "GET", "/api/tarefas/"+id, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("tarefa %s na lixeira retornou %d", id, rr.Code)
+		}
+	}
+	if tarefa := buscarTarefaTeste(t, srv, dependente); tarefa.Bloqueada || len(tarefa.BloqueadaPor) != 1 {
+		t.Errorf("tarefa na lixeira ainda bloqueia: %+v", tarefa)
+	}
+	itens := lixeiraTeste(t, srv)
+	if len(itens) != 1 || itens[0].Tarefa.ID != pai || len(itens[0].Subtarefas) != 1 || itens[0].Subtarefas[0].ID != filha {
+		t.Fatalf("itens da lixeira: %+v", itens)
+	}
+	if item := itens[0]; !item.PurgaEm.Equal(item.ExcluidaEm.Add(dominio.RetencaoLixeiraPadrao)) || item.Tarefa.ExcluidaEm == nil {
+		t.Errorf("datas do item: %+v", item)
+	}
+	// A subtarefa volta ou é purgada só junto com a tarefa
+	if rr := executar(t, srv, "POST", "/api/lixeira/"+filha+"/restaurar", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("restaurar subtarefa sozinha retornou %d", rr.Code)
+	}
+
+	// Ao restaurar, referências removidas nesse meio tempo são retiradas
+	executar(t, srv, "DELETE", "/api/etiquetas/"+etiqueta.ID, "")
+	rr = executar(t, srv, "POST", "/api/lixeira/"+pai+"/restaurar", "")
+	var restaurada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &restaurada); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("POST restaurar retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if restaurada.ExcluidaEm != nil || len(restaurada.Etiquetas) != 0 || restaurada.Subtarefas == nil || restaurada.Subtarefas.Total != 1 {
+		t.Errorf("tarefa restaurada: %+v", restaurada)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, filha); tarefa.PaiID != pai {
+		t.Errorf("subtarefa restaurada: %+v", tarefa)
+	}
+	if revisoes := revisoesTeste(t, srv, pai); !slices.Equal(camposAlterados(revisoes[len(revisoes)-1]), []string{`etiquetas:["` + etiqueta.ID + `"]>null`}) {
+		t.Errorf("revisão da restauração: %+v", revisoes[len(revisoes)-1])
+	}
+	if itens := lixeiraTeste(t, srv); len(itens) != 0 {
+		t.Errorf("lixeira depois de restaurar: %+v", itens)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, dependente); !tarefa.Bloqueada {
+		t.Errorf("dependência da tarefa restaurada: %+v", tarefa)
+	}
+
+	// Purgar exclui as tarefas e o que pertence a elas
+	executar(t, srv, "DELETE", "/api/tarefas/"+pai+"?subtarefas=remover", "")
+	if err := srv.usuarios.DefinirSenha("outro", "senha-outro"); err != nil {
+		t.Fatal(err)
+	}
+	outro := srv.tokens.emitir("outro", dominio.EscoposValidos).Token
+	if rr := executarComo(t, srv, outro, "DELETE", "/api/lixeira/"+pai, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("purgar tarefa de outro usuário retornou %d", rr.Code)
+	}
+	if rr := executar(t, srv, "DELETE", "/api/lixeira/"+pai, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE lixeira retornou %d", rr.Code)
+	}
+	if excluidas, _ := srv.tarefas.ListarLixeira(usuarioTeste); len(excluidas) != 0 {
+		t.Errorf("tarefas purgadas continuam na lixeira: %+v", excluidas)
+	}
+	if comentarios, _ := srv.comentarios.Listar(usuarioTeste, filha); len(comentarios) != 0 {
+		t.Errorf("comentários da tarefa purgada: %+v", comentarios)
+	}
+	if rr := executar(t, srv, "POST", "/api/lixeira/"+pai+"/restaurar", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("restaurar tarefa purgada retornou %d", rr.Code)
+	}
+	if rr := executar(t, srv, "GET", "/api/lixeira/"+pai+"/restaurar", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET restaurar retornou %d", rr.Code)
+	}
+}
+
+func TestRestaurarDevolveSubtarefasPromovidas(t *testing.T) {
+	srv := novoServidorTeste(t)
+	avo := criarTarefaTeste(t, srv, "Evento")
+	pai := criarSubtarefaTeste(t, srv, avo, "Buffet")
+	salgados := criarSubtarefaTeste(t, srv, pai, "Salgados")
+	doces := criarSubtarefaTeste(t, srv, pai, "Doces")
+	outra := criarTarefaTeste(t, srv, "Decoração")
+
+	// Sem cascata, as subtarefas passam para o avô
+	executar(t, srv, "DELETE", "/api/tarefas/"+pai, "")
+	for _, id := range []string{salgados, doces} {
+		if tarefa := buscarTarefaTeste(t, srv, id); tarefa.PaiID != avo {
+			t.Errorf("subtarefa promovida: %+v", tarefa)
+		}
+	}
+	// A que foi movida enquanto o pai estava na lixeira fica onde está
+	executar(t, srv, "PATCH", "/api/tarefas/"+doces, `{"pai_id":"`+outra+`"}`)
+
+	if rr := executar(t, srv, "POST", "/api/lixeira/"+pai+"/restaurar", ""); rr.Code != http.StatusOK {
+		t.Fatalf("POST restaurar retornou %d", rr.Code)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, salgados); tarefa.PaiID != pai {
+		t.Errorf("subtarefa devolvida: %+v", tarefa)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, doces); tarefa.PaiID != outra {
+		t.Errorf("subtarefa movida: %+v", tarefa)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, pai); tarefa.PaiID != avo || len(tarefa.SubtarefasPromovidas) != 0 {
+		t.Errorf("tarefa restaurada: %+v", tarefa)
+	}
+}
+
+func TestPurgaAutomaticaDaLixeira(t *testing.T) {
+	srv := novoServidorTeste(t)
+	antiga := criarTarefaTeste(t, srv, "Antiga")
+	recente := criarTarefaTeste(t, srv, "Recente")
+
+	agoraOriginal := agora
+	defer func() { agora = agoraOriginal }()
+	agora = func() time.Time { return agoraOriginal().Add(-dominio.RetencaoLixeiraPadrao - time.Minute) }
+	executar(t, srv, "DELETE", "/api/tarefas/"+antiga, "")
+	agora = agoraOriginal
+	executar(t, srv, "DELETE", "/api/tarefas/"+recente, "")
+
+	purgados, err := srv.purgarLixeira()
+	if err != nil || purgados != 1 {
+		t.Fatalf("purgarLixeira: %d, %v", purgados, err)
+	}
+	if itens := lixeiraTeste(t, srv); len(itens) != 1 || itens[0].Tarefa.ID != recente {
+		t.Errorf("lixeira depois da purga: %+v", itens)
+	}
+
+	// A purga no início e a cada intervalo para quando o contexto é cancelado
+	agora = func() time.Time { return agoraOriginal().Add(-dominio.RetencaoLixeiraPadrao - time.Minute) }
+	executar(t, srv, "DELETE", "/api/tarefas/"+recente, "")
+	executar(t, srv, "POST", "/api/lixeira/"+recente+"/restaurar", "")
+	executar(t, srv, "DELETE", "/api/tarefas/"+recente, "")
+	agora = agoraOriginal
+	ctx, cancelar := context.WithCancel(context.Background())
+	encerrada := make(chan struct{})
+	go func() {
+		defer close(encerrada)
+		srv.purgarLixeiraPeriodicamente(ctx, time.Hour)
+	}()
+	cancelar()
+	select {
+	case <-encerrada:
+	case <-time.After(5 * time.Second):
+		t.Fatal("purga periódica não parou")
+	}
+	if itens := lixeiraTeste(t, srv); len(itens) != 0 {
+		t.Errorf("lixeira depois da purga inicial: %+v", itens)
+	}
+}
+
+func TestRetencaoLixeiraDoAmbiente(t *testing.T) {
+	t.Setenv("LIXEIRA_RETENCAO", "")
+	if c, err := configuracaoDoAmbiente(); err != nil || c.retencaoLixeira != dominio.RetencaoLixeiraPadrao {
+		t.Errorf("retenção padrão: %v, %v", c.retencaoLixeira, err)
+	}
+	t.Setenv("LIXEIRA_RETENCAO", "72h")
+	if c, err := configuracaoDoAmbiente(); err != nil || c.retencaoLixeira != 72*time.Hour {
+		t.Errorf("retenção configurada: %v, %v", c.retencaoLixeira, err)
+	}
+	t.Setenv("LIXEIRA_RETENCAO", "-1h")
+	if _, err := configuracaoDoAmbiente(); err == nil {
+		t.Error("retenção negativa aceita")
+	}
+}
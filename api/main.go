@@ -1,55 +1,280 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
 )
 
-// Tarefa representa uma tarefa no sistema
-type Tarefa struct {
-	ID        string `json:"id"`
-	Titulo    string `json:"titulo"`
-	Concluida bool   `json:"concluida"`
+// tarefasIniciais são criadas para cada usuário quando a API usa o
+// armazenamento em memória
+var tarefasIniciais = []Tarefa{
+	{Titulo: "Aprender Go", Concluida: false},
+	{Titulo: "Implementar CI/CD", Concluida: false},
 }
 
-// Armazenamento em memória para tarefas
-var tarefas = []Tarefa{
-	{ID: "1", Titulo: "Aprender Go", Concluida: false},
-	{ID: "2", Titulo: "Implementar CI/CD", Concluida: false},
+// usuariosIniciais são criados quando a API usa o armazenamento em memória e
+// USUARIOS não foi definida
+const usuariosIniciais = "demo:demo"
+
+// configuracao reúne as opções da API lidas do ambiente
+type configuracao struct {
+	// segredoJWT assina os tokens de login (JWT_SEGREDO)
+	segredoJWT string
+	// validadeJWT é a validade dos tokens de login (JWT_VALIDADE)
+	validadeJWT time.Duration
+	// origensCORS são as origens de navegador liberadas (CORS_ORIGENS)
+	origensCORS []string
+	// usuarios são pares login:senha criados ou atualizados ao iniciar
+	// (USUARIOS). O primeiro recebe as tarefas gravadas antes de as tarefas
+	// terem dono.
+	usuarios string
+	// diretorioAnexos guarda o conteúdo dos anexos das tarefas
+	// (ANEXOS_CAMINHO)
+	diretorioAnexos string
+	// retencaoLixeira é por quanto tempo as tarefas removidas ficam na
+	// lixeira antes da purga automática (LIXEIRA_RETENCAO)
+	retencaoLixeira time.Duration
 }
 
-func main() {
-	// Configurar rotas
-	http.HandleFunc("/api/tarefas", manipuladorTarefas)
-	http.HandleFunc("/api/health", manipuladorHealth)
+// configuracaoDoAmbiente lê a configuração das variáveis de ambiente
+func configuracaoDoAmbiente() (configuracao, error) {
+	c := configuracao{
+		segredoJWT:      os.Getenv("JWT_SEGREDO"),
+		usuarios:        os.Getenv("USUARIOS"),
+		diretorioAnexos: os.Getenv("ANEXOS_CAMINHO"),
+		retencaoLixeira: dominio.RetencaoLixeiraPadrao,
+	}
+	if c.diretorioAnexos == "" {
+		c.diretorioAnexos = "anexos"
+	}
+	if v := os.Getenv("JWT_VALIDADE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return c, fmt.Errorf("JWT_VALIDADE inválida: %q", v)
+		}
+		c.validadeJWT = d
+	}
+	if v := os.Getenv("LIXEIRA_RETENCAO"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return c, fmt.Errorf("LIXEIRA_RETENCAO inválida: %q", v)
+		}
+		c.retencaoLixeira = d
+	}
+	for _, o := range strings.Split(os.Getenv("CORS_ORIGENS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			c.origensCORS = append(c.origensCORS, o)
+		}
+	}
+	return c, nil
+}
 
-	// Iniciar servidor
-	log.Println("Servidor API iniciando na porta 8080...")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+// servidor reúne as dependências dos manipuladores HTTP
+type servidor struct {
+	tarefas     TarefaRepository
+	projetos    ProjetoRepository
+	etiquetas   EtiquetaRepository
+	historico   HistoricoRepository
+	revisoes    RevisaoRepository
+	comentarios ComentarioRepository
+	anexos      AnexoRepository
+	blobs       ArmazenamentoBlobs
+	usuarios    UsuarioRepository
+	chaves      ChaveRepository
+	tokens      *emissorTokens
+	origens     []string
+	// retencaoLixeira é por quanto tempo as tarefas removidas ficam na lixeira
+	retencaoLixeira time.Duration
+	// muBlobs impede que um conteúdo seja removido por não estar em uso
+	// enquanto um novo anexo com o mesmo hash é gravado
+	muBlobs sync.Mutex
+	// muEstados serializa as alterações de tarefas, para que duas mudanças
+	// de estado não ocupem ao mesmo tempo a última vaga de uma coluna
+	muEstados sync.Mutex
 }
 
-func manipuladorTarefas(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// novoServidor cria um servidor com os repositórios sobre o armazenamento informado
+func novoServidor(a Armazenamento, c configuracao) *servidor {
+	revisoes := NovoRepositorioRevisoes(a)
+	return &servidor{
+		tarefas:         comRevisoes(NovoRepositorioTarefas(a), revisoes),
+		projetos:        NovoRepositorioProjetos(a),
+		etiquetas:       NovoRepositorioEtiquetas(a),
+		historico:       NovoRepositorioHistorico(a),
+		revisoes:        revisoes,
+		comentarios:     NovoRepositorioComentarios(a),
+		anexos:          NovoRepositorioAnexos(a),
+		blobs:           NovoBlobsLocais(c.diretorioAnexos),
+		usuarios:        NovoRepositorioUsuarios(a),
+		chaves:          NovoRepositorioChaves(a),
+		tokens:          novoEmissorTokens(c.segredoJWT, c.validadeJWT),
+		origens:         c.origensCORS,
+		retencaoLixeira: c.retencaoLixeira,
+	}
+}
+
+// rotas registra os manipuladores da API. /api/health, o login e a
+// documentação são públicos; as tarefas, os projetos e as
+// etiquetas exigem autenticação.
+func (s *servidor) rotas() http.Handler {
+	tarefas := func(h http.HandlerFunc) http.HandlerFunc {
+		return s.autenticado(dominio.EscopoTarefasLeitura, dominio.EscopoTarefasEscrita, h)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tarefas", tarefas(s.manipuladorTarefas))
+	mux.HandleFunc("/api/tarefas/", tarefas(s.manipuladorTarefa))
+	mux.HandleFunc("/api/projetos", tarefas(s.manipuladorProjetos))
+	mux.HandleFunc("/api/projetos/", tarefas(s.manipuladorProjeto))
+	mux.HandleFunc("/api/etiquetas", tarefas(s.manipuladorEtiquetas))
+	mux.HandleFunc("/api/etiquetas/", tarefas(s.manipuladorEtiqueta))
+	mux.HandleFunc("/api/lixeira", tarefas(s.manipuladorLixeira))
+	mux.HandleFunc("/api/lixeira/", tarefas(s.manipuladorItemLixeira))
+	mux.HandleFunc("/api/auth/login", s.manipuladorLogin)
+	mux.HandleFunc("/api/chaves", s.manipuladorChaves)
+	mux.HandleFunc("/api/chaves/", s.manipuladorChave)
+	mux.HandleFunc("/api/health", manipuladorHealth)
+	mux.HandleFunc("/api/openapi.json", manipuladorOpenAPI)
+	mux.HandleFunc("/api/docs", manipuladorDocs)
+	mux.HandleFunc("/", manipuladorNaoEncontrado)
+	return comRequestID(comCORS(s.origens, mux))
+}
+
+// criarUsuarios cria ou atualiza os usuários da lista login:senha separada
+// por vírgulas e retorna seus logins, na ordem da lista
+func (s *servidor) criarUsuarios(lista string) ([]string, error) {
+	var logins []string
+	for _, par := range strings.Split(lista, ",") {
+		if par = strings.TrimSpace(par); par == "" {
+			continue
+		}
+		login, senha, ok := strings.Cut(par, ":")
+		if !ok || login == "" || senha == "" {
+			return nil, fmt.Errorf("USUARIOS: use login:senha, obtido %q", par)
+		}
+		if err := s.usuarios.DefinirSenha(login, senha); err != nil {
+			return nil, err
+		}
+		logins = append(logins, login)
+	}
+	return logins, nil
+}
+
+func main() {
+	config, err := configuracaoDoAmbiente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Abrir o armazenamento configurado
+	armazenamento, err := armazenamentoDoAmbiente()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer armazenamento.Fechar()
 
-	// Permitir CORS para desenvolvimento
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	srv := novoServidor(armazenamento, config)
+	_, emMemoria := armazenamento.(*ArmazenamentoMemoria)
+	if emMemoria && config.usuarios == "" {
+		config.usuarios = usuariosIniciais
+	}
+	logins, err := srv.criarUsuarios(config.usuarios)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Chaves de API gravadas antes do índice de donos passam ao índice do
+	// seu usuário
+	if indexadas, err := srv.chaves.IndexarChavesSemDono(); err != nil {
+		log.Fatal(err)
+	} else if indexadas > 0 {
+		log.Printf("%d chaves de API indexadas pelo dono", indexadas)
+	}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
+	switch {
+	case emMemoria:
+		for _, login := range logins {
+			for _, t := range tarefasIniciais {
+				if _, err := srv.criarTarefa(login, t); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	case len(logins) > 0:
+		// Tarefas gravadas antes de existirem usuários passam ao primeiro
+		adotadas, err := srv.tarefas.AdotarSemDono(logins[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if adotadas > 0 {
+			log.Printf("%d tarefas sem dono atribuídas a %s", adotadas, logins[0])
+		}
 	}
 
-	// Apenas implementando GET para simplificar
-	if r.Method == "GET" {
-		json.NewEncoder(w).Encode(tarefas)
-		return
+	// SIGINT e SIGTERM encerram o servidor e a purga da lixeira antes de
+	// fechar o armazenamento
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+
+	// Tarefas na lixeira há mais tempo que a retenção são purgadas de vez
+	purgaEncerrada := make(chan struct{})
+	go func() {
+		defer close(purgaEncerrada)
+		srv.purgarLixeiraPeriodicamente(ctx, intervaloPurgaLixeira)
+	}()
+
+	// Iniciar servidor
+	servidorHTTP := &http.Server{Addr: ":8080", Handler: srv.rotas()}
+	encerrado := make(chan struct{})
+	go func() {
+		defer close(encerrado)
+		<-ctx.Done()
+		desligar, cancelar := context.WithTimeout(context.Background(), tempoEncerramento)
+		defer cancelar()
+		if err := servidorHTTP.Shutdown(desligar); err != nil {
+			log.Printf("erro ao encerrar o servidor: %v", err)
+		}
+	}()
+	log.Println("Servidor API iniciando na porta 8080...")
+	if err := servidorHTTP.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
 	}
+	<-encerrado
+	<-purgaEncerrada
+	log.Println("Servidor API encerrado")
+}
 
-	// Método não suportado
-	w.WriteHeader(http.StatusMethodNotAllowed)
+// tempoEncerramento é quanto o servidor espera as requisições em andamento
+// terminarem ao ser encerrado
+const tempoEncerramento = 10 * time.Second
+
+// definirCabecalhos aplica os cabeçalhos comuns às rotas JSON. Os cabeçalhos
+// CORS são aplicados por comCORS.
+func definirCabecalhos(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+}
+
+// novoID gera um identificador aleatório para um novo documento
+func novoID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		log.Panicf("falha ao gerar ID: %v", err)
+	}
+	return hex.EncodeToString(b)
 }
 
 func manipuladorHealth(w http.ResponseWriter, r *http.Request) {
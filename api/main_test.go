@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestManipuladorHealth(t *testing.T) {
@@ -50,9 +55,10 @@ func TestManipuladorTarefas(t *testing.T) {
 
 	// Criar um ResponseRecorder para gravar a resposta
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(manipuladorTarefas)
+	handler := http.HandlerFunc(novoServidorTeste(t).manipuladorTarefas)
 
 	// Chamar o manipulador com a requisição e o response recorder
+	req = comUsuarioTeste(req)
 	handler.ServeHTTP(rr, req)
 
 	// Verificar o código de status
@@ -62,14 +68,285 @@ func TestManipuladorTarefas(t *testing.T) {
 	}
 
 	// Verificar o corpo da resposta
-	var tarefasResposta []Tarefa
-	err = json.Unmarshal(rr.Body.Bytes(), &tarefasResposta)
+	var pagina dominio.PaginaTarefas
+	err = json.Unmarshal(rr.Body.Bytes(), &pagina)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Verificar se retornou pelo menos uma tarefa
-	if len(tarefasResposta) == 0 {
+	if len(pagina.Tarefas) == 0 {
 		t.Errorf("handler retornou lista de tarefas vazia")
 	}
 }
+
+func TestCriarTarefa(t *testing.T) {
+	// Criar uma requisição HTTP POST com uma nova tarefa
+	corpo := strings.NewReader(`{"titulo":"Escrever testes"}`)
+	req, err := http.NewRequest("POST", "/api/tarefas", corpo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	req = comUsuarioTeste(req)
+	http.HandlerFunc(novoServidorTeste(t).manipuladorTarefas).ServeHTTP(rr, req)
+
+	// Verificar o código de status
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler retornou código de status errado: obtido %v esperado %v",
+			status, http.StatusCreated)
+	}
+
+	// Verificar se o ID foi gerado pelo servidor
+	var criada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &criada); err != nil {
+		t.Fatal(err)
+	}
+	if criada.ID == "" {
+		t.Errorf("handler não gerou o ID da tarefa")
+	}
+	if local := rr.Header().Get("Location"); local != "/api/tarefas/"+criada.ID {
+		t.Errorf("cabeçalho Location inesperado: %v", local)
+	}
+}
+
+func TestCriarTarefaSemTitulo(t *testing.T) {
+	req, err := http.NewRequest("POST", "/api/tarefas", strings.NewReader(`{"titulo":"  "}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	req = comUsuarioTeste(req)
+	http.HandlerFunc(novoServidorTeste(t).manipuladorTarefas).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler retornou código de status errado: obtido %v esperado %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestAtualizarTarefa(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Revisar PR")
+
+	// Substituir a tarefa com PUT
+	rr := executar(t, srv, "PUT", "/api/tarefas/"+id, `{"titulo":"Revisar PR #2","concluida":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+	}
+
+	// Alterar apenas o campo concluida com PATCH
+	rr = executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PATCH retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+	}
+
+	var tarefa Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil {
+		t.Fatal(err)
+	}
+	if tarefa.ID != id || tarefa.Titulo != "Revisar PR #2" || tarefa.Concluida {
+		t.Errorf("tarefa inesperada após PATCH: %+v", tarefa)
+	}
+}
+
+func TestRemoverTarefa(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Tarefa temporária")
+
+	rr := executar(t, srv, "DELETE", "/api/tarefas/"+id, "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusNoContent)
+	}
+
+	// A tarefa removida não deve mais ser encontrada
+	rr = executar(t, srv, "GET", "/api/tarefas/"+id, "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET após DELETE retornou %v esperado %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+// usuarioTeste e senhaTeste são as credenciais criadas por novoServidorTeste
+const (
+	usuarioTeste = "teste"
+	senhaTeste   = "senha-teste"
+)
+
+func init() {
+	// O custo mínimo do bcrypt mantém os testes rápidos
+	custoSenha = bcrypt.MinCost
+}
+
+// novoServidorTeste cria um servidor com as tarefas iniciais e o usuário de
+// teste em memória
+func novoServidorTeste(t *testing.T) *servidor {
+	t.Helper()
+	srv := novoServidor(NovoArmazenamentoMemoria(), configuracao{segredoJWT: "segredo-teste", diretorioAnexos: t.TempDir(), retencaoLixeira: dominio.RetencaoLixeiraPadrao})
+	for _, tarefa := range tarefasIniciais {
+		if _, err := srv.tarefas.Criar(usuarioTeste, tarefa); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.usuarios.DefinirSenha(usuarioTeste, senhaTeste); err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+// comUsuarioTeste autentica a requisição como o usuário de teste, para
+// chamar os manipuladores diretamente, sem passar por rotas()
+func comUsuarioTeste(req *http.Request) *http.Request {
+	id := identidade{usuario: usuarioTeste, escopos: dominio.EscoposValidos}
+	return req.WithContext(context.WithValue(req.Context(), chaveIdentidade{}, id))
+}
+
+// tokenTeste emite um token de login do usuário de teste
+func tokenTeste(srv *servidor) string {
+	return srv.tokens.emitir(usuarioTeste, dominio.EscoposValidos).Token
+}
+
+// executar envia uma requisição autenticada com o usuário de teste às rotas
+// do servidor e confere a requisição e a resposta com a especificação OpenAPI
+func executar(t *testing.T, srv *servidor, metodo, url, corpo string) *httptest.ResponseRecorder {
+	t.Helper()
+	return executarComo(t, srv, tokenTeste(srv), metodo, url, corpo)
+}
+
+// executarComo é executar com a credencial informada; vazia, a requisição
+// vai sem Authorization. As escritas em uma tarefa, inclusive a reversão,
+// levam If-Match: *, para que os testes que não tratam de concorrência não
+// precisem ler o ETag.
+func executarComo(t *testing.T, srv *servidor, credencial, metodo, url, corpo string) *httptest.ResponseRecorder {
+	t.Helper()
+	ifMatch := ""
+	caminho, _, _ := strings.Cut(strings.TrimPrefix(url, "/api/tarefas/"), "?")
+	id, subrecurso, _ := strings.Cut(caminho, "/")
+	switch {
+	case caminho == url:
+	case (metodo == "PUT" || metodo == "PATCH" || metodo == "DELETE") && subrecurso == "" && !strings.HasSuffix(caminho, "/"):
+		ifMatch = "*"
+	case metodo == "POST" && id != "" && subrecurso == "reverter":
+		ifMatch = "*"
+	}
+	return enviarRequisicao(t, srv, credencial, ifMatch, metodo, url, corpo)
+}
+
+// executarCondicional é executar com o If-Match informado; vazio, a
+// requisição vai sem o cabeçalho
+func executarCondicional(t *testing.T, srv *servidor, ifMatch, metodo, url, corpo string) *httptest.ResponseRecorder {
+	t.Helper()
+	return enviarRequisicao(t, srv, tokenTeste(srv), ifMatch, metodo, url, corpo)
+}
+
+// enviarRequisicao executa a requisição com a credencial e o If-Match
+// informados e confere a resposta contra o contrato OpenAPI
+func enviarRequisicao(t *testing.T, srv *servidor, credencial, ifMatch, metodo, url, corpo string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(metodo, url, strings.NewReader(corpo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corpo != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if credencial != "" {
+		req.Header.Set("Authorization", "Bearer "+credencial)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rr := httptest.NewRecorder()
+	srv.rotas().ServeHTTP(rr, req)
+	validarContrato(t, req, corpo, rr)
+	return rr
+}
+
+// criarTarefaTeste cria uma tarefa via POST e retorna seu ID
+func criarTarefaTeste(t *testing.T, srv *servidor, titulo string) string {
+	t.Helper()
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"`+titulo+`"}`)
+
+	var criada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &criada); err != nil {
+		t.Fatal(err)
+	}
+	return criada.ID
+}
+
+func TestTarefasIsoladasPorUsuario(t *testing.T) {
+	srv := novoServidorTeste(t)
+	if err := srv.usuarios.DefinirSenha("outro", "senha-outro"); err != nil {
+		t.Fatal(err)
+	}
+	outro := srv.tokens.emitir("outro", dominio.EscoposValidos).Token
+	id := criarTarefaTeste(t, srv, "Particular")
+
+	// O outro usuário começa sem tarefas e não enxerga as do usuário de teste
+	rr := executarComo(t, srv, outro, "GET", "/api/tarefas", "")
+	var pagina dominio.PaginaTarefas
+	if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina.Tarefas) != 0 {
+		t.Errorf("outro usuário vê %d tarefas alheias", len(pagina.Tarefas))
+	}
+
+	// Acessar a tarefa alheia responde 404, como se ela não existisse
+	for _, r := range []struct{ metodo, corpo string }{
+		{"GET", ""}, {"PUT", `{"titulo":"Invadida"}`}, {"PATCH", `{"concluida":true}`}, {"DELETE", ""},
+	} {
+		rr := executarComo(t, srv, outro, r.metodo, "/api/tarefas/"+id, r.corpo)
+		if p := lerProblema(t, rr); rr.Code != http.StatusNotFound || p.Codigo != dominio.CodigoTarefaNaoEncontrada {
+			t.Errorf("%s de tarefa alheia: obtido %d %q esperado 404", r.metodo, rr.Code, p.Codigo)
+		}
+	}
+
+	// A tarefa continua intacta para o dono
+	rr = executar(t, srv, "GET", "/api/tarefas/"+id, "")
+	var tarefa Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil {
+		t.Fatal(err)
+	}
+	if tarefa.Titulo != "Particular" || tarefa.Versao != 1 || tarefa.Dono != usuarioTeste {
+		t.Errorf("tarefa alterada por outro usuário: %+v", tarefa)
+	}
+}
+
+func TestCompatibilidadeClientesAntigos(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	// Um cliente novo cria a tarefa com os campos adicionais
+	rr := executar(t, srv, "POST", "/api/tarefas",
+		`{"titulo":"Migrar banco","descricao":"Usar SQLite","prioridade":"alta","prazo":"2024-06-01T18:00:00Z"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusCreated)
+	}
+	var criada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &criada); err != nil {
+		t.Fatal(err)
+	}
+
+	// Um cliente antigo envia apenas os três campos originais
+	rr = executar(t, srv, "PUT", "/api/tarefas/"+criada.ID, `{"id":"`+criada.ID+`","titulo":"Migrar banco","concluida":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT retornou código de status errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+	}
+	var alterada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &alterada); err != nil {
+		t.Fatal(err)
+	}
+	if alterada.Descricao != "Usar SQLite" || alterada.Prioridade != dominio.PrioridadeAlta || alterada.Prazo == nil {
+		t.Errorf("PUT de cliente antigo apagou campos novos: %+v", alterada)
+	}
+	if !alterada.Concluida || alterada.ConcluidaEm == nil {
+		t.Errorf("conclusão não registrada: %+v", alterada)
+	}
+
+	// Prioridade desconhecida é rejeitada
+	rr = executar(t, srv, "PATCH", "/api/tarefas/"+criada.ID, `{"prioridade":"maxima"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("PATCH com prioridade inválida retornou %v esperado %v", rr.Code, http.StatusBadRequest)
+	}
+}
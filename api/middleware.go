@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// cabecalhoRequestID é o cabeçalho que carrega o identificador da requisição
+const cabecalhoRequestID = "X-Request-ID"
+
+// tamanhoMaximoRequestID limita o identificador aceito do cliente
+const tamanhoMaximoRequestID = 128
+
+// chaveRequestID é a chave do identificador da requisição no contexto
+type chaveRequestID struct{}
+
+// comRequestID atribui um identificador a cada requisição, reaproveitando o
+// enviado pelo cliente em X-Request-ID quando válido, e o devolve na resposta
+func comRequestID(proximo http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(cabecalhoRequestID)
+		if !requestIDValido(id) {
+			id = novoID()
+		}
+		w.Header().Set(cabecalhoRequestID, id)
+		proximo.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), chaveRequestID{}, id)))
+	})
+}
+
+// requestID retorna o identificador da requisição guardado no contexto
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(chaveRequestID{}).(string)
+	return id
+}
+
+// requestIDValido aceita apenas identificadores curtos com letras, dígitos,
+// hífens, pontos e sublinhados, para que possam ir aos logs com segurança
+func requestIDValido(id string) bool {
+	if id == "" || len(id) > tamanhoMaximoRequestID {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// comCORS libera o acesso de navegadores apenas para as origens configuradas.
+// Requisições de outras origens não recebem cabeçalhos CORS e são bloqueadas
+// pelo navegador; clientes que não são navegadores não são afetados.
+func comCORS(origens []string, proximo http.Handler) http.Handler {
+	permitidas := make(map[string]bool, len(origens))
+	for _, o := range origens {
+		permitidas[strings.TrimRight(o, "/")] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origem := r.Header.Get("Origin")
+		if origem == "" {
+			proximo.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if permitidas[origem] {
+			w.Header().Set("Access-Control-Allow-Origin", origem)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key, Content-Type, Accept-Language, X-Request-ID, If-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "Location, ETag, X-Request-ID")
+		}
+
+		// Preflight é respondido aqui, sem exigir credenciais
+		if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		proximo.ServeHTTP(w, r)
+	})
+}
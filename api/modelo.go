@@ -0,0 +1,17 @@
+package main
+
+import "github.com/seu-usuario/ci-cd-demo/dominio"
+
+// Tipos de domínio compartilhados com o frontend pelo módulo dominio
+type (
+	Tarefa        = dominio.Tarefa
+	Projeto       = dominio.Projeto
+	Etiqueta      = dominio.Etiqueta
+	ErroValidacao = dominio.ErroValidacao
+	MudancaEstado = dominio.MudancaEstado
+	Renomeacao    = dominio.Renomeacao
+	Comentario    = dominio.Comentario
+	Atividade     = dominio.Atividade
+	Anexo         = dominio.Anexo
+	Revisao       = dominio.Revisao
+)
@@ -0,0 +1,272 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// especificacaoOpenAPI é o contrato da API, validado contra os manipuladores
+// nos testes
+//
+//go:embed openapi.json
+var especificacaoOpenAPI []byte
+
+// metodosOpenAPI são os métodos exibidos na documentação, nesta ordem
+var metodosOpenAPI = []string{"get", "post", "put", "patch", "delete"}
+
+// documentoOpenAPI contém apenas a parte da especificação usada pela página
+// de documentação
+type documentoOpenAPI struct {
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]esquemaOpenAPI `json:"schemas"`
+	} `json:"components"`
+}
+
+type parametroOpenAPI struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Required    bool           `json:"required"`
+	Description string         `json:"description"`
+	Schema      esquemaOpenAPI `json:"schema"`
+}
+
+type operacaoOpenAPI struct {
+	Summary     string             `json:"summary"`
+	Description string             `json:"description"`
+	Parameters  []parametroOpenAPI `json:"parameters"`
+	// Security nulo herda a segurança global; vazio torna a operação pública
+	Security    *[]map[string][]string `json:"security"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema esquemaOpenAPI `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Ref         string `json:"$ref"`
+		Description string `json:"description"`
+	} `json:"responses"`
+}
+
+type esquemaOpenAPI struct {
+	Ref         string                    `json:"$ref"`
+	Type        string                    `json:"type"`
+	Format      string                    `json:"format"`
+	Description string                    `json:"description"`
+	Enum        []string                  `json:"enum"`
+	Required    []string                  `json:"required"`
+	Properties  map[string]esquemaOpenAPI `json:"properties"`
+	Items       *esquemaOpenAPI           `json:"items"`
+}
+
+// tipo descreve o esquema em poucas palavras, como "string (date-time)" ou
+// "array de Tarefa"
+func (e esquemaOpenAPI) tipo() string {
+	switch {
+	case e.Ref != "":
+		return e.Ref[strings.LastIndex(e.Ref, "/")+1:]
+	case e.Items != nil:
+		return "array de " + e.Items.tipo()
+	case e.Format != "":
+		return e.Type + " (" + e.Format + ")"
+	case len(e.Enum) > 0:
+		return e.Type + ": " + strings.Join(e.Enum, ", ")
+	}
+	return e.Type
+}
+
+// Estruturas exibidas pelo template da documentação
+type (
+	docOperacao struct {
+		Metodo, Caminho, Resumo, Descricao string
+		Publica                            bool
+		Parametros                         []docCampo
+		Corpo                              string
+		Respostas                          []docCampo
+	}
+	docEsquema struct {
+		Nome, Descricao string
+		Campos          []docCampo
+	}
+	docCampo struct {
+		Nome, Tipo, Descricao string
+		Obrigatorio           bool
+	}
+)
+
+// manipuladorOpenAPI serve a especificação OpenAPI
+func manipuladorOpenAPI(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	if r.Method != "GET" {
+		responderMetodoNaoPermitido(w, r, "GET")
+		return
+	}
+	w.Write(especificacaoOpenAPI)
+}
+
+// manipuladorDocs serve a documentação em HTML gerada a partir da especificação
+func manipuladorDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		responderMetodoNaoPermitido(w, r, "GET")
+		return
+	}
+	dados, err := dadosDocumentacao()
+	if err != nil {
+		responderErroInterno(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templateDocs.Execute(w, dados); err != nil {
+		log.Printf("erro ao renderizar documentação: %v", err)
+	}
+}
+
+// dadosDocumentacao organiza a especificação para o template, com caminhos,
+// parâmetros e esquemas em ordem alfabética
+func dadosDocumentacao() (map[string]any, error) {
+	var doc documentoOpenAPI
+	if err := json.Unmarshal(especificacaoOpenAPI, &doc); err != nil {
+		return nil, err
+	}
+
+	var operacoes []docOperacao
+	for _, caminho := range chavesOrdenadas(doc.Paths) {
+		item := doc.Paths[caminho]
+		var comuns []parametroOpenAPI
+		if bruto, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(bruto, &comuns); err != nil {
+				return nil, err
+			}
+		}
+		for _, metodo := range metodosOpenAPI {
+			bruto, ok := item[metodo]
+			if !ok {
+				continue
+			}
+			var op operacaoOpenAPI
+			if err := json.Unmarshal(bruto, &op); err != nil {
+				return nil, err
+			}
+			d := docOperacao{
+				Metodo:    strings.ToUpper(metodo),
+				Caminho:   caminho,
+				Resumo:    op.Summary,
+				Descricao: op.Description,
+				Publica:   op.Security != nil && len(*op.Security) == 0,
+			}
+			for _, p := range append(comuns, op.Parameters...) {
+				d.Parametros = append(d.Parametros, docCampo{p.Name + " (" + p.In + ")", p.Schema.tipo(), p.Description, p.Required})
+			}
+			if op.RequestBody != nil {
+				for tipo, conteudo := range op.RequestBody.Content {
+					d.Corpo = conteudo.Schema.tipo() + " (" + tipo + ")"
+				}
+			}
+			for _, status := range chavesOrdenadas(op.Responses) {
+				resposta := op.Responses[status]
+				descricao := resposta.Description
+				if resposta.Ref != "" {
+					descricao = resposta.Ref[strings.LastIndex(resposta.Ref, "/")+1:]
+				}
+				d.Respostas = append(d.Respostas, docCampo{Nome: status, Descricao: descricao})
+			}
+			operacoes = append(operacoes, d)
+		}
+	}
+
+	var esquemas []docEsquema
+	for _, nome := range chavesOrdenadas(doc.Components.Schemas) {
+		e := doc.Components.Schemas[nome]
+		d := docEsquema{Nome: nome, Descricao: e.Description}
+		if len(e.Properties) == 0 {
+			d.Campos = []docCampo{{Tipo: e.tipo()}}
+		}
+		for _, campo := range chavesOrdenadas(e.Properties) {
+			p := e.Properties[campo]
+			obrigatorio := false
+			for _, r := range e.Required {
+				obrigatorio = obrigatorio || r == campo
+			}
+			d.Campos = append(d.Campos, docCampo{campo, p.tipo(), p.Description, obrigatorio})
+		}
+		esquemas = append(esquemas, d)
+	}
+
+	return map[string]any{
+		"Titulo":    doc.Info.Title,
+		"Versao":    doc.Info.Version,
+		"Descricao": doc.Info.Description,
+		"Operacoes": operacoes,
+		"Esquemas":  esquemas,
+	}, nil
+}
+
+// chavesOrdenadas retorna as chaves do mapa em ordem alfabética
+func chavesOrdenadas[V any](m map[string]V) []string {
+	chaves := make([]string, 0, len(m))
+	for k := range m {
+		chaves = append(chaves, k)
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// templateDocs é a página de documentação, sem dependências externas
+var templateDocs = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="UTF-8">
+<title>{{.Titulo}} {{.Versao}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 0 auto; padding: 20px; color: #333; }
+h2 { border-bottom: 2px solid #ecf0f1; padding-bottom: 6px; }
+.operacao { border: 1px solid #dde; border-radius: 4px; margin-bottom: 16px; padding: 10px 14px; }
+.metodo { display: inline-block; min-width: 60px; font-weight: bold; color: white; background: #3498db; border-radius: 3px; padding: 2px 6px; text-align: center; }
+.metodo.POST { background: #27ae60; } .metodo.PUT, .metodo.PATCH { background: #f39c12; } .metodo.DELETE { background: #e74c3c; }
+table { border-collapse: collapse; width: 100%; margin: 6px 0; }
+td, th { border-bottom: 1px solid #eee; padding: 4px 6px; text-align: left; vertical-align: top; }
+code { background: #f4f4f4; padding: 1px 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Titulo}} <small>{{.Versao}}</small></h1>
+<p>{{.Descricao}}</p>
+<p>Especificação: <a href="/api/openapi.json"><code>/api/openapi.json</code></a></p>
+
+<h2>Operações</h2>
+{{range .Operacoes}}
+<div class="operacao">
+<p><span class="metodo {{.Metodo}}">{{.Metodo}}</span> <code>{{.Caminho}}</code> — {{.Resumo}}{{if .Publica}} <em>(pública)</em>{{end}}</p>
+{{if .Descricao}}<p>{{.Descricao}}</p>{{end}}
+{{if .Parametros}}<table><tr><th>Parâmetro</th><th>Tipo</th><th>Descrição</th></tr>
+{{range .Parametros}}<tr><td><code>{{.Nome}}</code>{{if .Obrigatorio}} *{{end}}</td><td>{{.Tipo}}</td><td>{{.Descricao}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Corpo}}<p>Corpo: {{.Corpo}}</p>{{end}}
+<table><tr><th>Status</th><th>Resposta</th></tr>
+{{range .Respostas}}<tr><td>{{.Nome}}</td><td>{{.Descricao}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}
+
+<h2>Esquemas</h2>
+{{range .Esquemas}}
+<h3 id="{{.Nome}}">{{.Nome}}</h3>
+{{if .Descricao}}<p>{{.Descricao}}</p>{{end}}
+<table><tr><th>Campo</th><th>Tipo</th><th>Descrição</th></tr>
+{{range .Campos}}<tr><td><code>{{.Nome}}</code>{{if .Obrigatorio}} *{{end}}</td><td>{{.Tipo}}</td><td>{{.Descricao}}</td></tr>
+{{end}}</table>
+{{end}}
+<p>* obrigatório</p>
+</body>
+</html>
+`))
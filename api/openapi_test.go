@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+var (
+	carregarContrato sync.Once
+	contrato         *openapi3.T
+	roteadorContrato routers.Router
+	errContrato      error
+)
+
+// especificacaoTeste carrega e valida a especificação embutida uma única vez
+func especificacaoTeste(t *testing.T) (*openapi3.T, routers.Router) {
+	t.Helper()
+	carregarContrato.Do(func() {
+		contrato, errContrato = openapi3.NewLoader().LoadFromData(especificacaoOpenAPI)
+		if errContrato != nil {
+			return
+		}
+		if errContrato = contrato.Validate(context.Background()); errContrato != nil {
+			return
+		}
+		roteadorContrato, errContrato = legacy.NewRouter(contrato)
+
+		// A página de documentação é validada apenas como texto
+		openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+	})
+	if errContrato != nil {
+		t.Fatalf("especificação OpenAPI inválida: %v", errContrato)
+	}
+	return contrato, roteadorContrato
+}
+
+// validarContrato confere uma requisição e sua resposta com a especificação.
+// Respostas a requisições válidas precisam seguir o esquema documentado;
+// requisições que violam a especificação precisam ser rejeitadas com 4xx.
+// Rotas fora da especificação só podem responder 404, 405 ou OPTIONS.
+// Retorna a operação documentada, ou nil se a rota não está na especificação.
+func validarContrato(t *testing.T, req *http.Request, corpo string, rr *httptest.ResponseRecorder) *openapi3.Operation {
+	t.Helper()
+	_, roteador := especificacaoTeste(t)
+
+	rota, parametros, err := roteador.FindRoute(req)
+	if err != nil {
+		if req.Method != "OPTIONS" && rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s respondeu %d mas não está na especificação OpenAPI", req.Method, req.URL, rr.Code)
+		}
+		return nil
+	}
+
+	entrada := &openapi3filter.RequestValidationInput{
+		Request:    req.Clone(context.Background()),
+		PathParams: parametros,
+		Route:      rota,
+		Options:    &openapi3filter.Options{MultiError: true, AuthenticationFunc: credencialPresente},
+	}
+	entrada.Request.Body = io.NopCloser(strings.NewReader(corpo))
+	if err := openapi3filter.ValidateRequest(context.Background(), entrada); err != nil {
+		if rr.Code < 400 || rr.Code >= 500 {
+			t.Errorf("%s %s viola a especificação (%v) mas foi aceita com status %d", req.Method, req.URL, err, rr.Code)
+		}
+		return rota.Operation
+	}
+
+	saida := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: entrada,
+		Status:                 rr.Code,
+		Header:                 rr.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(rr.Body.Bytes())),
+		Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+	}
+	if err := openapi3filter.ValidateResponse(context.Background(), saida); err != nil {
+		t.Errorf("resposta de %s %s diverge da especificação: %v\ncorpo: %s", req.Method, req.URL, err, rr.Body.String())
+	}
+	return rota.Operation
+}
+
+// credencialPresente confere apenas se a requisição traz a credencial do
+// esquema de segurança; a validade dela é assunto dos testes de autenticação
+func credencialPresente(_ context.Context, in *openapi3filter.AuthenticationInput) error {
+	esquema := in.SecurityScheme
+	switch {
+	case esquema.Type == "http" && esquema.Scheme == "bearer":
+		tipo, _, _ := strings.Cut(in.RequestValidationInput.Request.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(tipo, "Bearer") {
+			return in.NewError(errors.New("cabeçalho Authorization: Bearer ausente"))
+		}
+	case esquema.Type == "apiKey" && esquema.In == "header":
+		if in.RequestValidationInput.Request.Header.Get(esquema.Name) == "" {
+			return in.NewError(errors.New("cabeçalho " + esquema.Name + " ausente"))
+		}
+	default:
+		return in.NewError(errors.New("esquema de segurança não suportado nos testes"))
+	}
+	return nil
+}
+
+func TestEspecificacaoServida(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	rr := executar(t, srv, "GET", "/api/openapi.json", "")
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil || doc["openapi"] != "3.0.3" {
+		t.Fatalf("documento OpenAPI inválido: %v", err)
+	}
+
+	rr = executar(t, srv, "GET", "/api/docs", "")
+	corpo := rr.Body.String()
+	for _, texto := range []string{"/api/tarefas/{id}", "PATCH", "PaginaTarefas", "next_cursor"} {
+		if !strings.Contains(corpo, texto) {
+			t.Errorf("documentação não menciona %q", texto)
+		}
+	}
+}
+
+func TestContratoCobreTodasAsOperacoes(t *testing.T) {
+	doc, _ := especificacaoTeste(t)
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Contrato")
+	chave, err := srv.chaves.Criar(usuarioTeste, "Revogar", []string{dominio.EscopoTarefasLeitura})
+	if err != nil {
+		t.Fatal(err)
+	}
+	projeto, err := srv.projetos.Criar(usuarioTeste, Projeto{Nome: "Contrato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etiqueta, err := srv.etiquetas.Criar(usuarioTeste, Etiqueta{Nome: "Contrato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comentario, err := srv.comentarios.Criar(usuarioTeste, Comentario{TarefaID: id, Texto: "Contrato"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comentarios := "/api/tarefas/" + id + "/comentarios"
+	recebido, err := srv.blobs.Receber(strings.NewReader("contrato"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	anexo, err := srv.guardarAnexo(usuarioTeste, recebido, Anexo{TarefaID: id, Nome: "contrato.txt", Tipo: "text/plain", Tamanho: recebido.Tamanho, Hash: recebido.Hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anexos := "/api/tarefas/" + id + "/anexos"
+	descartada := criarTarefaTeste(t, srv, "Descartada")
+	if err := srv.removerTarefa(usuarioTeste, descartada, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	arquivo, tipoArquivo := corpoArquivo(t, "notas.txt", "text/plain", "Notas da reunião")
+
+	// Uma requisição bem-sucedida para cada operação documentada. Documentar
+	// uma operação nova sem incluí-la aqui faz o teste falhar.
+	requisicoes := map[string]struct{ metodo, url, corpo string }{
+		"verificarSaude":         {"GET", "/api/health", ""},
+		"listarTarefas":          {"GET", "/api/tarefas?concluida=false&prioridade=media&projeto=" + projeto.ID + "&etiquetas=" + etiqueta.ID + "&modo_etiquetas=alguma&raiz=true&acionaveis=true&q=contrato&sort=-titulo&limit=1", ""},
+		"criarTarefa":            {"POST", "/api/tarefas", `{"titulo":"Nova","prioridade":"alta","prazo":"2024-06-01T18:00:00Z","recorrencia":"FREQ=MONTHLY;BYMONTHDAY=-1","fuso_horario":"America/Sao_Paulo","projeto_id":"` + projeto.ID + `","etiquetas":["` + etiqueta.ID + `"],"pai_id":"` + id + `","bloqueada_por":["` + id + `"],"estado":"pendente"}`},
+		"buscarTarefa":           {"GET", "/api/tarefas/" + id, ""},
+		"atualizarTarefa":        {"PUT", "/api/tarefas/" + id, `{"titulo":"Contrato","concluida":true}`},
+		"alterarTarefa":          {"PATCH", "/api/tarefas/" + id, `{"descricao":"Validada","projeto_id":"` + projeto.ID + `","concluir_com_subtarefas":false}`},
+		"listarRevisoes":         {"GET", "/api/tarefas/" + id + "/historico", ""},
+		"listarHistoricoEstados": {"GET", "/api/tarefas/" + id + "/historico/estados", ""},
+		"reverterTarefa":         {"POST", "/api/tarefas/" + id + "/reverter?revisao=1", ""},
+		"listarAtividade":        {"GET", "/api/tarefas/" + id + "/atividade", ""},
+		"listarComentarios":      {"GET", comentarios, ""},
+		"criarComentario":        {"POST", comentarios, `{"texto":"Falta **revisar**"}`},
+		"buscarComentario":       {"GET", comentarios + "/" + comentario.ID, ""},
+		"editarComentario":       {"PATCH", comentarios + "/" + comentario.ID, `{"texto":"Revisado"}`},
+		"removerComentario":      {"DELETE", comentarios + "/" + comentario.ID, ""},
+		"listarAnexos":           {"GET", anexos, ""},
+		"enviarAnexo":            {"POST", anexos, arquivo},
+		"buscarAnexo":            {"GET", anexos + "/" + anexo.ID, ""},
+		"baixarAnexo":            {"GET", anexos + "/" + anexo.ID + "/conteudo", ""},
+		"removerAnexo":           {"DELETE", anexos + "/" + anexo.ID, ""},
+		"removerTarefa":          {"DELETE", "/api/tarefas/" + id + "?subtarefas=remover", ""},
+		"listarLixeira":          {"GET", "/api/lixeira", ""},
+		"restaurarTarefa":        {"POST", "/api/lixeira/" + id + "/restaurar", ""},
+		"purgarTarefa":           {"DELETE", "/api/lixeira/" + descartada, ""},
+		"obterEspecificacao":     {"GET", "/api/openapi.json", ""},
+		"obterDocumentacao":      {"GET", "/api/docs", ""},
+		"entrar":                 {"POST", "/api/auth/login", `{"usuario":"` + usuarioTeste + `","senha":"` + senhaTeste + `"}`},
+		"listarChaves":           {"GET", "/api/chaves", ""},
+		"criarChave":             {"POST", "/api/chaves", `{"nome":"CI","escopos":["tarefas:read"]}`},
+		"removerChave":           {"DELETE", "/api/chaves/" + chave.ID, ""},
+		"listarProjetos":         {"GET", "/api/projetos", ""},
+		"criarProjeto":           {"POST", "/api/projetos", `{"nome":"Casa"}`},
+		"buscarProjeto":          {"GET", "/api/projetos/" + projeto.ID, ""},
+		"renomearProjeto":        {"PUT", "/api/projetos/" + projeto.ID, `{"nome":"Trabalho","fluxo":{"estados":[{"id":"a_fazer","nome":"A fazer","limite":3},{"id":"feita","nome":"Feita","final":true}],"transicoes":[{"de":"a_fazer","para":"feita"}]}}`},
+		"removerProjeto":         {"DELETE", "/api/projetos/" + projeto.ID, ""},
+		"listarEtiquetas":        {"GET", "/api/etiquetas", ""},
+		"criarEtiqueta":          {"POST", "/api/etiquetas", `{"nome":"Casa","cor":"#27ae60"}`},
+		"buscarEtiqueta":         {"GET", "/api/etiquetas/" + etiqueta.ID, ""},
+		"alterarEtiqueta":        {"PATCH", "/api/etiquetas/" + etiqueta.ID, `{"cor":"#e74c3c"}`},
+		"removerEtiqueta":        {"DELETE", "/api/etiquetas/" + etiqueta.ID, ""},
+	}
+
+	exercitadas := map[string]bool{}
+	for _, nome := range []string{"verificarSaude", "listarTarefas", "criarTarefa", "buscarTarefa",
+		"atualizarTarefa", "alterarTarefa", "listarRevisoes", "listarHistoricoEstados", "reverterTarefa",
+		"listarComentarios", "criarComentario", "buscarComentario", "editarComentario", "listarAtividade", "removerComentario", "listarAnexos", "enviarAnexo",
+		"buscarAnexo", "baixarAnexo", "removerAnexo", "removerTarefa", "listarLixeira", "restaurarTarefa", "purgarTarefa",
+		"obterEspecificacao", "obterDocumentacao",
+		"entrar", "listarChaves", "criarChave", "removerChave", "listarProjetos", "criarProjeto",
+		"buscarProjeto", "renomearProjeto", "removerProjeto", "listarEtiquetas", "criarEtiqueta",
+		"buscarEtiqueta", "alterarEtiqueta", "removerEtiqueta"} {
+		r := requisicoes[nome]
+		req := httptest.NewRequest(r.metodo, r.url, strings.NewReader(r.corpo))
+		if r.corpo != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if nome == "enviarAnexo" {
+			req.Header.Set("Content-Type", tipoArquivo)
+		}
+		req.Header.Set("Authorization", "Bearer "+tokenTeste(srv))
+		if nome == "atualizarTarefa" || nome == "alterarTarefa" || nome == "removerTarefa" || nome == "reverterTarefa" {
+			atual, err := srv.tarefas.Buscar(usuarioTeste, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("If-Match", atual.ETag())
+		}
+		rr := httptest.NewRecorder()
+		srv.rotas().ServeHTTP(rr, req)
+		if rr.Code >= 400 {
+			t.Errorf("%s: status %d: %s", nome, rr.Code, rr.Body.String())
+		}
+		if op := validarContrato(t, req, r.corpo, rr); op != nil {
+			exercitadas[op.OperationID] = true
+		}
+	}
+
+	for caminho, item := range doc.Paths.Map() {
+		for metodo, op := range item.Operations() {
+			if !exercitadas[op.OperationID] {
+				t.Errorf("operação %s %s (%s) não foi exercitada pelo teste de contrato", metodo, caminho, op.OperationID)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// errPrecondicaoAusente indica uma escrita em tarefa sem o cabeçalho If-Match
+var errPrecondicaoAusente = errors.New("cabeçalho If-Match ausente")
+
+// definirETag envia no cabeçalho ETag a versão da tarefa respondida
+func definirETag(w http.ResponseWriter, t Tarefa) {
+	w.Header().Set("ETag", t.ETag())
+}
+
+// versaoCondicional confere o If-Match da requisição com a tarefa gravada e
+// retorna a versão que a escrita deve exigir do repositório, para que uma
+// alteração concorrente entre a conferência e a gravação também seja
+// recusada. If-Match: * aceita qualquer versão e resulta em zero.
+//
+// Sem o cabeçalho retorna errPrecondicaoAusente; se nenhum ETag enviado
+// corresponde à versão atual, ErrConflitoVersao. ETags fracos nunca
+// correspondem, como manda a comparação forte de If-Match (RFC 9110).
+func (s *servidor) versaoCondicional(r *http.Request, dono, id string) (int, error) {
+	valor := strings.TrimSpace(strings.Join(r.Header.Values("If-Match"), ","))
+	if valor == "" {
+		return 0, errPrecondicaoAusente
+	}
+	atual, err := s.tarefas.Buscar(dono, id)
+	if err != nil {
+		return 0, err
+	}
+	if valor == "*" {
+		return 0, nil
+	}
+	for _, etag := range strings.Split(valor, ",") {
+		if strings.TrimSpace(etag) == atual.ETag() {
+			return atual.Versao, nil
+		}
+	}
+	return 0, ErrConflitoVersao
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+func TestEscritasExigemIfMatch(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Relatório")
+	url := "/api/tarefas/" + id
+
+	rr := executar(t, srv, "GET", url, "")
+	if etag := rr.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag da tarefa criada: %q", etag)
+	}
+
+	// Sem If-Match nenhuma escrita é aceita
+	for _, metodo := range []string{"PUT", "PATCH", "DELETE"} {
+		rr := executarCondicional(t, srv, "", metodo, url, `{"titulo":"Sem versão"}`)
+		if p := lerProblema(t, rr); rr.Code != http.StatusPreconditionRequired || p.Codigo != dominio.CodigoPrecondicaoAusente {
+			t.Errorf("%s sem If-Match retornou %d (%s)", metodo, rr.Code, p.Codigo)
+		}
+	}
+
+	// A versão lida permite a escrita e o ETag acompanha a nova versão
+	rr = executarCondicional(t, srv, `"1"`, "PATCH", url, `{"titulo":"Relatório mensal"}`)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PATCH com a versão atual retornou %d, ETag %q", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	// Quem ainda tem a versão 1 não sobrescreve a alteração
+	for _, metodo := range []string{"PUT", "PATCH", "DELETE"} {
+		rr := executarCondicional(t, srv, `"1"`, metodo, url, `{"titulo":"Versão antiga"}`)
+		if p := lerProblema(t, rr); rr.Code != http.StatusPreconditionFailed || p.Codigo != dominio.CodigoConflitoVersao {
+			t.Errorf("%s com versão desatualizada retornou %d (%s)", metodo, rr.Code, p.Codigo)
+		}
+	}
+	if tarefa := buscarTarefaTeste(t, srv, id); tarefa.Titulo != "Relatório mensal" || tarefa.Versao != 2 {
+		t.Errorf("tarefa depois dos conflitos: %+v", tarefa)
+	}
+
+	// ETags fracos nunca correspondem; uma lista basta ter o atual
+	if rr := executarCondicional(t, srv, `W/"2"`, "PATCH", url, `{"descricao":"Fraco"}`); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH com ETag fraco retornou %d", rr.Code)
+	}
+	if rr := executarCondicional(t, srv, `"1", "2"`, "PUT", url, `{"titulo":"Relatório"}`); rr.Code != http.StatusOK {
+		t.Errorf("PUT com lista de ETags retornou %d", rr.Code)
+	}
+	if rr := executarCondicional(t, srv, "*", "PATCH", url, `{"descricao":"Qualquer versão"}`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"4"` {
+		t.Errorf("PATCH com * retornou %d, ETag %q", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	if rr := executarCondicional(t, srv, `"4"`, "DELETE", url, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE com a versão atual retornou %d", rr.Code)
+	}
+	if rr := executarCondicional(t, srv, "*", "DELETE", url, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("DELETE de tarefa removida retornou %d", rr.Code)
+	}
+}
+
+func TestReverterExigeIfMatch(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Relatório")
+	executarCondicional(t, srv, `"1"`, "PATCH", "/api/tarefas/"+id, `{"titulo":"Relatório anual"}`)
+	url := "/api/tarefas/" + id + "/reverter?revisao=1"
+
+	rr := executarCondicional(t, srv, "", "POST", url, "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusPreconditionRequired || p.Codigo != dominio.CodigoPrecondicaoAusente {
+		t.Errorf("reverter sem If-Match retornou %d (%s)", rr.Code, p.Codigo)
+	}
+
+	// Quem leu a versão 1 não desfaz a renomeação que não viu
+	rr = executarCondicional(t, srv, `"1"`, "POST", url, "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusPreconditionFailed || p.Codigo != dominio.CodigoConflitoVersao {
+		t.Errorf("reverter com versão desatualizada retornou %d (%s)", rr.Code, p.Codigo)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, id); tarefa.Titulo != "Relatório anual" || tarefa.Versao != 2 {
+		t.Errorf("tarefa depois do conflito: %+v", tarefa)
+	}
+
+	rr = executarCondicional(t, srv, `"2"`, "POST", url, "")
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` {
+		t.Errorf("reverter com a versão atual retornou %d, ETag %q", rr.Code, rr.Header().Get("ETag"))
+	}
+}
+
+func TestConflitoNaoRemoveSubtarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+	pai := criarTarefaTeste(t, srv, "Viagem")
+	filha := criarSubtarefaTeste(t, srv, pai, "Passagens")
+	executar(t, srv, "PATCH", "/api/tarefas/"+pai, `{"descricao":"Julho"}`)
+
+	rr := executarCondicional(t, srv, `"1"`, "DELETE", "/api/tarefas/"+pai+"?subtarefas=remover", "")
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("DELETE com versão desatualizada retornou %d", rr.Code)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, filha); tarefa.PaiID != pai {
+		t.Errorf("subtarefa depois do conflito: %+v", tarefa)
+	}
+	// A versão é conferida junto com a ida para a lixeira, mesmo que a
+	// tarefa mude depois do If-Match ser aceito
+	for _, destino := range []string{dominio.SubtarefasRemover, dominio.SubtarefasPromover} {
+		if err := srv.removerTarefa(usuarioTeste, pai, destino, 1); !errors.Is(err, ErrConflitoVersao) {
+			t.Errorf("removerTarefa %s com versão desatualizada: %v", destino, err)
+		}
+		if tarefa := buscarTarefaTeste(t, srv, filha); tarefa.PaiID != pai {
+			t.Errorf("subtarefa depois do conflito em %s: %+v", destino, tarefa)
+		}
+	}
+}
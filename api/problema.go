@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// prefixoTipoProblema forma o URI do membro type a partir do código do erro
+const prefixoTipoProblema = "urn:ci-cd-demo:problema:"
+
+// tipoProblema descreve um tipo de erro da API
+type tipoProblema struct {
+	status   int
+	codigo   string
+	titulo   string
+	mensagem dominio.Mensagens
+}
+
+// Tipos de erro respondidos pela API
+var (
+	problemaCorpoInvalido = tipoProblema{http.StatusBadRequest, dominio.CodigoCorpoInvalido, "Invalid request body",
+		dominio.Mensagens{PtBR: "corpo da requisição inválido", En: "invalid request body"}}
+	problemaValidacao = tipoProblema{http.StatusBadRequest, dominio.CodigoValidacao, "Validation failed",
+		dominio.Mensagens{PtBR: "a requisição tem campos inválidos", En: "the request has invalid fields"}}
+	problemaParametroInvalido = tipoProblema{http.StatusBadRequest, dominio.CodigoParametroInvalido, "Invalid query parameter",
+		dominio.Mensagens{PtBR: "parâmetro de consulta inválido", En: "invalid query parameter"}}
+	problemaTarefaNaoEncontrada = tipoProblema{http.StatusNotFound, dominio.CodigoTarefaNaoEncontrada, "Task not found",
+		dominio.Mensagens{PtBR: "tarefa não encontrada", En: "task not found"}}
+	problemaProjetoNaoEncontrado = tipoProblema{http.StatusNotFound, dominio.CodigoProjetoNaoEncontrado, "Project not found",
+		dominio.Mensagens{PtBR: "projeto não encontrado", En: "project not found"}}
+	problemaEtiquetaNaoEncontrada = tipoProblema{http.StatusNotFound, dominio.CodigoEtiquetaNaoEncontrada, "Tag not found",
+		dominio.Mensagens{PtBR: "etiqueta não encontrada", En: "tag not found"}}
+	problemaComentarioNaoEncontrado = tipoProblema{http.StatusNotFound, dominio.CodigoComentarioNaoEncontrado, "Comment not found",
+		dominio.Mensagens{PtBR: "comentário não encontrado", En: "comment not found"}}
+	problemaAnexoNaoEncontrado = tipoProblema{http.StatusNotFound, dominio.CodigoAnexoNaoEncontrado, "Attachment not found",
+		dominio.Mensagens{PtBR: "anexo não encontrado", En: "attachment not found"}}
+	problemaRevisaoNaoEncontrada = tipoProblema{http.StatusNotFound, dominio.CodigoRevisaoNaoEncontrada, "Revision not found",
+		dominio.Mensagens{PtBR: "revisão não encontrada", En: "revision not found"}}
+	problemaAnexoGrande = tipoProblema{http.StatusRequestEntityTooLarge, dominio.CodigoAnexoGrande, "Attachment too large",
+		dominio.Mensagens{PtBR: "o arquivo passa do tamanho máximo de 10 MiB", En: "the file exceeds the maximum size of 10 MiB"}}
+	problemaTipoAnexoNaoPermitido = tipoProblema{http.StatusUnsupportedMediaType, dominio.CodigoTipoAnexoNaoPermitido, "Attachment type not allowed",
+		dominio.Mensagens{PtBR: "o tipo do arquivo não é aceito nos anexos", En: "the file type is not allowed in attachments"}}
+	problemaRotaNaoEncontrada = tipoProblema{http.StatusNotFound, dominio.CodigoRotaNaoEncontrada, "Route not found",
+		dominio.Mensagens{PtBR: "rota não encontrada", En: "route not found"}}
+	problemaMetodoNaoPermitido = tipoProblema{http.StatusMethodNotAllowed, dominio.CodigoMetodoNaoPermitido, "Method not allowed",
+		dominio.Mensagens{PtBR: "método não permitido nesta rota", En: "method not allowed on this route"}}
+	problemaConflitoVersao = tipoProblema{http.StatusPreconditionFailed, dominio.CodigoConflitoVersao, "Version conflict",
+		dominio.Mensagens{PtBR: "a tarefa foi alterada por outra requisição", En: "the task was changed by another request"}}
+	problemaPrecondicaoAusente = tipoProblema{http.StatusPreconditionRequired, dominio.CodigoPrecondicaoAusente, "Precondition required",
+		dominio.Mensagens{PtBR: "envie o cabeçalho If-Match com o ETag da tarefa", En: "send the If-Match header with the task ETag"}}
+	problemaTarefaBloqueada = tipoProblema{http.StatusConflict, dominio.CodigoTarefaBloqueada, "Task is blocked",
+		dominio.Mensagens{PtBR: "a tarefa depende de tarefas pendentes; conclua-as primeiro", En: "the task depends on pending tasks; complete them first"}}
+	problemaTransicaoInvalida = tipoProblema{http.StatusConflict, dominio.CodigoTransicaoInvalida, "Invalid state transition",
+		dominio.Mensagens{PtBR: "o fluxo do projeto não permite esta mudança de estado", En: "the project workflow does not allow this state change"}}
+	problemaLimiteEstado = tipoProblema{http.StatusConflict, dominio.CodigoLimiteEstado, "State limit reached",
+		dominio.Mensagens{PtBR: "a coluna do estado já atingiu o seu limite", En: "the state column has reached its limit"}}
+	problemaNaoAutenticado = tipoProblema{http.StatusUnauthorized, dominio.CodigoNaoAutenticado, "Authentication required",
+		dominio.Mensagens{PtBR: "envie um token ou chave de API válido no cabeçalho Authorization", En: "send a valid token or API key in the Authorization header"}}
+	problemaCredenciaisInvalidas = tipoProblema{http.StatusUnauthorized, dominio.CodigoCredenciaisInvalidas, "Invalid credentials",
+		dominio.Mensagens{PtBR: "usuário ou senha inválidos", En: "invalid username or password"}}
+	problemaAcessoNegado = tipoProblema{http.StatusForbidden, dominio.CodigoAcessoNegado, "Access denied",
+		dominio.Mensagens{PtBR: "a credencial não tem permissão para esta operação", En: "the credential is not allowed to perform this operation"}}
+	problemaChaveNaoEncontrada = tipoProblema{http.StatusNotFound, dominio.CodigoChaveNaoEncontrada, "API key not found",
+		dominio.Mensagens{PtBR: "chave de API não encontrada", En: "API key not found"}}
+	problemaErroInterno = tipoProblema{http.StatusInternalServerError, dominio.CodigoErroInterno, "Internal server error",
+		dominio.Mensagens{PtBR: "erro interno", En: "internal error"}}
+)
+
+// responderProblema escreve uma resposta application/problem+json do tipo
+// informado. Os erros de campo, se houver, detalham a mensagem.
+func responderProblema(w http.ResponseWriter, r *http.Request, tipo tipoProblema, campos ...dominio.ErroValidacao) {
+	mensagens := tipo.mensagem
+	if len(campos) == 1 {
+		mensagens = campos[0].Mensagens
+	}
+
+	idioma := idiomaPreferido(r)
+	p := dominio.Problema{
+		Tipo:      prefixoTipoProblema + tipo.codigo,
+		Titulo:    tipo.titulo,
+		Status:    tipo.status,
+		Detalhe:   mensagens.Em(idioma),
+		Instancia: r.URL.Path,
+		Codigo:    tipo.codigo,
+		Mensagens: mensagens,
+		Campos:    campos,
+		RequestID: requestID(r.Context()),
+	}
+
+	w.Header().Set("Content-Type", dominio.TipoConteudoProblema)
+	w.Header().Set("Content-Language", idioma)
+	w.WriteHeader(tipo.status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// responderErroInterno registra um erro inesperado e responde 500
+func responderErroInterno(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("erro interno [%s]: %v", requestID(r.Context()), err)
+	responderProblema(w, r, problemaErroInterno)
+}
+
+// responderMetodoNaoPermitido responde 405 informando os métodos aceitos
+func responderMetodoNaoPermitido(w http.ResponseWriter, r *http.Request, permitidos string) {
+	w.Header().Set("Allow", permitidos)
+	responderProblema(w, r, problemaMetodoNaoPermitido)
+}
+
+// manipuladorNaoEncontrado responde 404 para rotas desconhecidas
+func manipuladorNaoEncontrado(w http.ResponseWriter, r *http.Request) {
+	responderProblema(w, r, problemaRotaNaoEncontrada)
+}
+
+// idiomaPreferido escolhe entre pt-BR (padrão) e en a partir do cabeçalho
+// Accept-Language, respeitando a ordem em que os idiomas aparecem
+func idiomaPreferido(r *http.Request) string {
+	for _, parte := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(parte), ";")
+		tag = strings.ToLower(tag)
+		switch {
+		case tag == "en" || strings.HasPrefix(tag, "en-"):
+			return "en"
+		case tag == "pt" || strings.HasPrefix(tag, "pt-"):
+			return "pt-BR"
+		}
+	}
+	return "pt-BR"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// lerProblema verifica o Content-Type e decodifica uma resposta de erro
+func lerProblema(t *testing.T, rr *httptest.ResponseRecorder) dominio.Problema {
+	t.Helper()
+	if tipo := rr.Header().Get("Content-Type"); tipo != dominio.TipoConteudoProblema {
+		t.Fatalf("Content-Type: obtido %q esperado %q", tipo, dominio.TipoConteudoProblema)
+	}
+	var p dominio.Problema
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRespostasDeErroProblemJSON(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Existente")
+
+	casos := []struct {
+		nome, metodo, url, corpo string
+		status                   int
+		codigo                   string
+		campo                    string
+	}{
+		{"tarefa inexistente", "GET", "/api/tarefas/nao-existe", "", http.StatusNotFound, dominio.CodigoTarefaNaoEncontrada, ""},
+		{"rota desconhecida", "GET", "/api/desconhecida", "", http.StatusNotFound, dominio.CodigoRotaNaoEncontrada, ""},
+		{"método não permitido", "PATCH", "/api/tarefas", "", http.StatusMethodNotAllowed, dominio.CodigoMetodoNaoPermitido, ""},
+		{"corpo inválido", "POST", "/api/tarefas", "{", http.StatusBadRequest, dominio.CodigoCorpoInvalido, ""},
+		{"título ausente", "POST", "/api/tarefas", `{"titulo":""}`, http.StatusBadRequest, dominio.CodigoValidacao, "titulo"},
+		{"PUT sem título", "PUT", "/api/tarefas/" + id, `{}`, http.StatusBadRequest, dominio.CodigoValidacao, "titulo"},
+		{"prioridade inválida", "PATCH", "/api/tarefas/" + id, `{"prioridade":"maxima"}`, http.StatusBadRequest, dominio.CodigoValidacao, "prioridade"},
+		{"parâmetro inválido", "GET", "/api/tarefas?limit=0", "", http.StatusBadRequest, dominio.CodigoParametroInvalido, "limit"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			rr := executar(t, srv, caso.metodo, caso.url, caso.corpo)
+			if rr.Code != caso.status {
+				t.Fatalf("status: obtido %d esperado %d", rr.Code, caso.status)
+			}
+			p := lerProblema(t, rr)
+			if p.Status != caso.status || p.Codigo != caso.codigo || p.Tipo != prefixoTipoProblema+caso.codigo {
+				t.Errorf("problema inesperado: %+v", p)
+			}
+			if p.Titulo == "" || p.Detalhe == "" || p.Mensagens.PtBR == "" || p.Mensagens.En == "" {
+				t.Errorf("problema sem título ou mensagens: %+v", p)
+			}
+			if p.RequestID == "" || p.RequestID != rr.Header().Get(cabecalhoRequestID) {
+				t.Errorf("request_id %q difere do cabeçalho %q", p.RequestID, rr.Header().Get(cabecalhoRequestID))
+			}
+			if caso.campo != "" && (len(p.Campos) != 1 || p.Campos[0].Campo != caso.campo || p.Campos[0].Codigo == "") {
+				t.Errorf("erros de campo: obtido %+v esperado campo %q", p.Campos, caso.campo)
+			}
+		})
+	}
+}
+
+func TestProblemaRespeitaIdiomaERequestID(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	req := httptest.NewRequest("POST", "/api/tarefas", strings.NewReader(`{"titulo":" "}`))
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,pt-BR;q=0.8")
+	req.Header.Set(cabecalhoRequestID, "req-123")
+	req.Header.Set("Authorization", "Bearer "+tokenTeste(srv))
+	rr := httptest.NewRecorder()
+	srv.rotas().ServeHTTP(rr, req)
+
+	p := lerProblema(t, rr)
+	if p.Detalhe != "title is required" || rr.Header().Get("Content-Language") != "en" {
+		t.Errorf("detalhe em inglês esperado, obtido %q (%s)", p.Detalhe, rr.Header().Get("Content-Language"))
+	}
+	if p.Mensagens.PtBR != "o título é obrigatório" {
+		t.Errorf("mensagem em português: obtida %q", p.Mensagens.PtBR)
+	}
+	if p.RequestID != "req-123" || rr.Header().Get(cabecalhoRequestID) != "req-123" {
+		t.Errorf("request ID do cliente não foi mantido: %q", p.RequestID)
+	}
+
+	// Sem Accept-Language o detalhe vem em português, e um ID inválido é substituído
+	req = httptest.NewRequest("DELETE", "/api/tarefas/nao-existe", nil)
+	req.Header.Set(cabecalhoRequestID, "inválido com espaços")
+	req.Header.Set("Authorization", "Bearer "+tokenTeste(srv))
+	req.Header.Set("If-Match", "*")
+	rr = httptest.NewRecorder()
+	srv.rotas().ServeHTTP(rr, req)
+
+	p = lerProblema(t, rr)
+	if p.Detalhe != "tarefa não encontrada" || p.Instancia != "/api/tarefas/nao-existe" {
+		t.Errorf("problema inesperado: %+v", p)
+	}
+	if p.RequestID == "" || p.RequestID == "inválido com espaços" {
+		t.Errorf("request ID inválido deveria ser substituído: %q", p.RequestID)
+	}
+}
+
+func TestMetodoNaoPermitidoInformaAllow(t *testing.T) {
+	rr := executar(t, novoServidorTeste(t), "POST", "/api/tarefas/abc", "")
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") == "" {
+		t.Errorf("obtido %d com Allow %q", rr.Code, rr.Header().Get("Allow"))
+	}
+}
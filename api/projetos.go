@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// manipuladorProjetos atende a coleção /api/projetos, com os projetos do
+// usuário autenticado e a contagem de suas tarefas
+func (s *servidor) manipuladorProjetos(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		projetos, err := s.projetos.Listar(dono)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		if err := s.contarTarefas(dono, projetos); err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(projetos)
+	case "POST":
+		var p Projeto
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		p.Nome = strings.TrimSpace(p.Nome)
+		if err := p.Validar(); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		p, err := s.projetos.Criar(dono, p)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/projetos/"+p.ID)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST, OPTIONS")
+	}
+}
+
+// manipuladorProjeto atende um projeto individual em /api/projetos/{id}
+func (s *servidor) manipuladorProjeto(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/projetos/")
+	if id == "" || strings.Contains(id, "/") {
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+
+	var (
+		p   Projeto
+		err error
+	)
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+		return
+	case "GET":
+		p, err = s.projetos.Buscar(dono, id)
+	case "PUT":
+		// Sem o campo fluxo, o projeto mantém o fluxo atual; fluxo null volta
+		// ao fluxo padrão
+		var pedido struct {
+			Nome  string          `json:"nome"`
+			Fluxo json.RawMessage `json:"fluxo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		p, err = s.atualizarProjeto(dono, id, pedido.Nome, pedido.Fluxo)
+	case "DELETE":
+		if err := s.removerProjeto(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, PUT, DELETE, OPTIONS")
+		return
+	}
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+
+	projetos := []Projeto{p}
+	if err := s.contarTarefas(dono, projetos); err != nil {
+		responderErroInterno(w, r, err)
+		return
+	}
+	json.NewEncoder(w).Encode(projetos[0])
+}
+
+// atualizarProjeto renomeia o projeto e, se fluxo não for nil, troca seu
+// fluxo de trabalho e passa as tarefas do projeto para ele. A troca é
+// recusada se alguma tarefa que muda de estado não couber na nova coluna.
+func (s *servidor) atualizarProjeto(dono, id, nome string, fluxo json.RawMessage) (Projeto, error) {
+	s.muEstados.Lock()
+	defer s.muEstados.Unlock()
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return Projeto{}, err
+	}
+	p, err := s.projetos.Atualizar(dono, id, func(p *Projeto) error {
+		p.Nome = strings.TrimSpace(nome)
+		if fluxo == nil {
+			return p.Validar()
+		}
+		p.Fluxo = nil
+		if err := json.Unmarshal(fluxo, &p.Fluxo); err != nil {
+			return errCorpoInvalido
+		}
+		if err := p.Validar(); err != nil {
+			return err
+		}
+		return conferirLimitesDoFluxo(p.FluxoDeTrabalho(), p.ID, tarefas)
+	})
+	if err != nil || fluxo == nil {
+		return p, err
+	}
+	return p, s.reaplicarFluxo(dono, id, p.FluxoDeTrabalho())
+}
+
+// removerProjeto exclui o projeto e tira dele as suas tarefas, que continuam
+// existindo sem projeto, no estado equivalente do fluxo padrão
+func (s *servidor) removerProjeto(dono, id string) error {
+	s.muEstados.Lock()
+	defer s.muEstados.Unlock()
+	if err := s.projetos.Remover(dono, id); err != nil {
+		return err
+	}
+	return s.moverTarefasDoProjeto(dono, id, func(t *Tarefa) error {
+		antes := *t
+		t.ProjetoID = ""
+		return dominio.FluxoPadrao().AplicarEstado(&antes, t)
+	})
+}
+
+// contarTarefas preenche em cada projeto o total de tarefas do dono e quantas
+// ainda estão pendentes
+func (s *servidor) contarTarefas(dono string, projetos []Projeto) error {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	indice := make(map[string]*Projeto, len(projetos))
+	for i := range projetos {
+		indice[projetos[i].ID] = &projetos[i]
+	}
+	for _, t := range tarefas {
+		if p := indice[t.ProjetoID]; p != nil {
+			p.TotalTarefas++
+			if !t.Concluida {
+				p.TarefasPendentes++
+			}
+		}
+	}
+	return nil
+}
+
+// validarProjetoDaTarefa confere que o projeto de uma tarefa existe e é do
+// mesmo dono. Uma tarefa sem projeto é sempre válida.
+func (s *servidor) validarProjetoDaTarefa(dono, projetoID string) error {
+	if projetoID == "" {
+		return nil
+	}
+	_, err := s.projetos.Buscar(dono, projetoID)
+	if errors.Is(err, ErrProjetoNaoEncontrado) {
+		return &ErroValidacao{Campo: "projeto_id", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+			PtBR: "o projeto informado não existe",
+			En:   "the given project does not exist",
+		}}
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+func TestProjetosAgrupamTarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	rr := executar(t, srv, "POST", "/api/projetos", `{"nome":"  Casa  ","total_tarefas":9}`)
+	var casa Projeto
+	if err := json.Unmarshal(rr.Body.Bytes(), &casa); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/projetos retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if casa.Nome != "Casa" || casa.TotalTarefas != 0 || rr.Header().Get("Location") != "/api/projetos/"+casa.ID {
+		t.Errorf("projeto criado inesperado: %+v", casa)
+	}
+
+	// Criar uma tarefa no projeto e mover outra para ele
+	rr = executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Lavar louça","projeto_id":"`+casa.ID+`"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST tarefa no projeto retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	movida := criarTarefaTeste(t, srv, "Regar plantas")
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+movida, `{"projeto_id":"`+casa.ID+`","concluida":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("mover tarefa retornou %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// O filtro por projeto e as contagens refletem as duas tarefas
+	rr = executar(t, srv, "GET", "/api/tarefas?projeto="+casa.ID, "")
+	var pagina dominio.PaginaTarefas
+	if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina.Tarefas) != 2 {
+		t.Errorf("filtro por projeto retornou %d tarefas esperado 2", len(pagina.Tarefas))
+	}
+	rr = executar(t, srv, "GET", "/api/projetos", "")
+	var projetos []Projeto
+	if err := json.Unmarshal(rr.Body.Bytes(), &projetos); err != nil {
+		t.Fatal(err)
+	}
+	if len(projetos) != 1 || projetos[0].TotalTarefas != 2 || projetos[0].TarefasPendentes != 1 {
+		t.Errorf("contagens inesperadas: %+v", projetos)
+	}
+
+	// Renomear mantém o ID e valida o nome
+	if rr := executar(t, srv, "PUT", "/api/projetos/"+casa.ID, `{"nome":"Lar"}`); rr.Code != http.StatusOK {
+		t.Errorf("PUT retornou %d", rr.Code)
+	}
+	rr = executar(t, srv, "PUT", "/api/projetos/"+casa.ID, `{"nome":" "}`)
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "nome" {
+		t.Errorf("PUT sem nome: obtido %d %+v", rr.Code, p.Campos)
+	}
+
+	// Remover o projeto mantém as tarefas, agora sem projeto
+	if rr := executar(t, srv, "DELETE", "/api/projetos/"+casa.ID, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	obtida, err := srv.tarefas.Buscar(usuarioTeste, movida)
+	if err != nil || obtida.ProjetoID != "" {
+		t.Errorf("tarefa do projeto removido: %+v %v", obtida, err)
+	}
+	rr = executar(t, srv, "GET", "/api/projetos/"+casa.ID, "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusNotFound || p.Codigo != dominio.CodigoProjetoNaoEncontrado {
+		t.Errorf("GET após DELETE: obtido %d %q", rr.Code, p.Codigo)
+	}
+}
+
+func TestTarefaExigeProjetoDoDono(t *testing.T) {
+	srv := novoServidorTeste(t)
+	alheio, err := srv.projetos.Criar("outro", Projeto{Nome: "Alheio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := criarTarefaTeste(t, srv, "Sem projeto")
+
+	casos := []struct{ metodo, url, corpo string }{
+		{"POST", "/api/tarefas", `{"titulo":"Perdida","projeto_id":"inexistente"}`},
+		{"POST", "/api/tarefas", `{"titulo":"Intrusa","projeto_id":"` + alheio.ID + `"}`},
+		{"PATCH", "/api/tarefas/" + id, `{"projeto_id":"` + alheio.ID + `"}`},
+	}
+	for _, c := range casos {
+		rr := executar(t, srv, c.metodo, c.url, c.corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "projeto_id" {
+			t.Errorf("%s %s: obtido %d %+v", c.metodo, c.corpo, rr.Code, p.Campos)
+		}
+	}
+
+	// Projetos de outros usuários respondem 404
+	for _, metodo := range []string{"GET", "PUT", "DELETE"} {
+		rr := executar(t, srv, metodo, "/api/projetos/"+alheio.ID, `{"nome":"Invadido"}`)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s de projeto alheio retornou %d", metodo, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+// proximaOcorrencia é chamada dentro de Atualizar, depois de mudar a tarefa.
+// Se a tarefa recorrente acabou de ser concluída, passa sua regra para a
+// próxima ocorrência e a retorna, para ser criada depois que a alteração for
+// gravada; caso contrário, retorna nil.
+func proximaOcorrencia(t *Tarefa, concluidaAntes bool) *Tarefa {
+	if concluidaAntes || !t.Concluida {
+		return nil
+	}
+	if proxima, ok := t.ProximaOcorrencia(); ok {
+		return &proxima
+	}
+	return nil
+}
+
+// criarProximaOcorrencia grava a próxima ocorrência de uma tarefa recorrente
+// concluída, se houver uma
+func (s *servidor) criarProximaOcorrencia(dono string, proxima *Tarefa) error {
+	if proxima == nil {
+		return nil
+	}
+	_, err := s.criarTarefa(dono, *proxima)
+	return err
+}
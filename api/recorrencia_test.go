@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// ocorrenciasDe lista as tarefas do usuário de teste com o título informado
+func ocorrenciasDe(t *testing.T, srv *servidor, titulo string) []Tarefa {
+	t.Helper()
+	tarefas, err := srv.tarefas.Listar(usuarioTeste)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ocorrencias []Tarefa
+	for _, tarefa := range tarefas {
+		if tarefa.Titulo == titulo {
+			ocorrencias = append(ocorrencias, tarefa)
+		}
+	}
+	return ocorrencias
+}
+
+func TestConcluirTarefaRecorrente(t *testing.T) {
+	srv := novoServidorTeste(t)
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Checklist de operações","prazo":"2024-01-29T12:00:00Z",`+
+		`"recorrencia":"rrule:freq=weekly;byday=mo;count=2","fuso_horario":"America/Sao_Paulo"}`)
+	var criada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &criada); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if criada.Recorrencia != "FREQ=WEEKLY;BYDAY=MO;COUNT=2" {
+		t.Errorf("regra não normalizada: %q", criada.Recorrencia)
+	}
+
+	// Concluir gera a próxima ocorrência, que herda a regra
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+criada.ID, `{"concluida":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("PATCH retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	ocorrencias := ocorrenciasDe(t, srv, "Checklist de operações")
+	if len(ocorrencias) != 2 {
+		t.Fatalf("esperadas 2 ocorrências, obtidas %+v", ocorrencias)
+	}
+	concluida, proxima := ocorrencias[0], ocorrencias[1]
+	if !concluida.Concluida || concluida.Recorrencia != "" {
+		t.Errorf("ocorrência concluída: %+v", concluida)
+	}
+	esperado := time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC)
+	if proxima.Concluida || proxima.Prazo == nil || !proxima.Prazo.Equal(esperado) ||
+		proxima.Recorrencia != "FREQ=WEEKLY;BYDAY=MO;COUNT=1" || proxima.FusoHorario != "America/Sao_Paulo" {
+		t.Errorf("próxima ocorrência: %+v", proxima)
+	}
+
+	// Reabrir e concluir de novo não duplica a ocorrência seguinte
+	executar(t, srv, "PATCH", "/api/tarefas/"+concluida.ID, `{"concluida":false}`)
+	executar(t, srv, "PATCH", "/api/tarefas/"+concluida.ID, `{"concluida":true}`)
+	if n := len(ocorrenciasDe(t, srv, "Checklist de operações")); n != 2 {
+		t.Errorf("reabrir e concluir gerou ocorrências: %d", n)
+	}
+
+	// A última ocorrência da série não gera outra
+	executar(t, srv, "PUT", "/api/tarefas/"+proxima.ID, `{"titulo":"Checklist de operações","concluida":true}`)
+	if n := len(ocorrenciasDe(t, srv, "Checklist de operações")); n != 2 {
+		t.Errorf("série com COUNT esgotado continuou: %d ocorrências", n)
+	}
+}
+
+func TestTarefaRecorrenteConcluidaPelasSubtarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"Fechamento","prazo":"2024-01-31T18:00:00Z",`+
+		`"recorrencia":"FREQ=MONTHLY;BYMONTHDAY=-1","concluir_com_subtarefas":true}`)
+	var pai Tarefa
+	json.Unmarshal(rr.Body.Bytes(), &pai)
+	filha := criarSubtarefaTeste(t, srv, pai.ID, "Conciliar contas")
+
+	executar(t, srv, "PATCH", "/api/tarefas/"+filha, `{"concluida":true}`)
+	ocorrencias := ocorrenciasDe(t, srv, "Fechamento")
+	if len(ocorrencias) != 2 || !ocorrencias[0].Concluida {
+		t.Fatalf("conclusão automática não gerou a próxima ocorrência: %+v", ocorrencias)
+	}
+	if esperado := time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC); !ocorrencias[1].Prazo.Equal(esperado) {
+		t.Errorf("prazo no fim do mês: obtido %v, esperado %v", ocorrencias[1].Prazo, esperado)
+	}
+}
+
+func TestRecorrenciaInvalida(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Sem prazo")
+	casos := []struct{ metodo, url, corpo, campo string }{
+		{"POST", "/api/tarefas", `{"titulo":"Nunca","prazo":"2024-01-01T09:00:00Z","recorrencia":"FREQ=SOMETIMES"}`, "recorrencia"},
+		{"POST", "/api/tarefas", `{"titulo":"Sem prazo","recorrencia":"FREQ=DAILY"}`, "prazo"},
+		{"PATCH", "/api/tarefas/" + id, `{"recorrencia":"FREQ=DAILY"}`, "prazo"},
+		{"PATCH", "/api/tarefas/" + id, `{"fuso_horario":"Marte/Olympus"}`, "fuso_horario"},
+	}
+	for _, c := range casos {
+		rr := executar(t, srv, c.metodo, c.url, c.corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != c.campo {
+			t.Errorf("%s %s: obtido %d %+v", c.metodo, c.corpo, rr.Code, p.Campos)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrTarefaNaoEncontrada indica que não existe tarefa com o ID informado
+var ErrTarefaNaoEncontrada = errors.New("tarefa não encontrada")
+
+// ErrConflitoVersao indica que a tarefa foi alterada por outra operação
+// desde a versão informada pelo chamador
+var ErrConflitoVersao = errors.New("versão da tarefa desatualizada")
+
+// colecaoTarefas é o nome da coleção de tarefas no armazenamento
+const colecaoTarefas = "tarefas"
+
+// TarefaRepository define as operações de persistência de tarefas.
+// Os manipuladores HTTP dependem apenas desta interface.
+//
+// Toda tarefa pertence a um dono, o login do usuário que a criou. As
+// operações recebem o dono e tratam tarefas de outros usuários como
+// inexistentes, para não revelar quais IDs existem.
+//
+// Tarefas na lixeira também são tratadas como inexistentes, exceto pelos
+// métodos da lixeira e por Remover.
+type TarefaRepository interface {
+	// Listar retorna as tarefas do dono na ordem de criação
+	Listar(dono string) ([]Tarefa, error)
+	// Buscar retorna a tarefa do dono com o ID informado ou ErrTarefaNaoEncontrada
+	Buscar(dono, id string) (Tarefa, error)
+	// Criar grava uma nova tarefa do dono, gerando seu ID e a data de criação, na versão 1
+	Criar(dono string, t Tarefa) (Tarefa, error)
+	// Atualizar aplica mudar à tarefa de forma atômica e incrementa sua versão.
+	// Com versao maior que zero, a alteração só ocorre se a tarefa ainda estiver
+	// nessa versão; caso contrário retorna ErrConflitoVersao (compare-and-swap).
+	// ID, dono, versão, datas e remoção são controlados pelo repositório e não
+	// podem ser alterados por mudar. O progresso das subtarefas e o bloqueio são
+	// calculados na leitura e nunca são gravados.
+	Atualizar(dono, id string, versao int, mudar func(t *Tarefa) error) (Tarefa, error)
+	// Remover exclui de vez a tarefa do dono com o ID informado, esteja ela
+	// na lixeira ou não
+	Remover(dono, id string) error
+	// MoverParaLixeira marca a tarefa do dono como excluída agora e retorna
+	// a tarefa marcada. raiz é o ID da tarefa cuja remoção a levou junto,
+	// vazio se foi removida diretamente; promovidas são as subtarefas que
+	// passam para o pai dela. Com versao maior que zero, a tarefa só vai para
+	// a lixeira se ainda estiver nessa versão, como em Atualizar.
+	MoverParaLixeira(dono, id string, versao int, raiz string, promovidas []string) (Tarefa, error)
+	// ListarLixeira retorna as tarefas do dono que estão na lixeira, na
+	// ordem de criação
+	ListarLixeira(dono string) ([]Tarefa, error)
+	// Restaurar tira da lixeira a tarefa do dono e incrementa sua versão, ou
+	// retorna ErrTarefaNaoEncontrada se ela não estiver lá
+	Restaurar(dono, id string) (Tarefa, error)
+	// Purgar exclui de vez a tarefa do dono se ela estiver na lixeira e
+	// satisfizer purgavel, ou retorna ErrTarefaNaoEncontrada. A conferência
+	// e a exclusão são atômicas, de modo que uma tarefa restaurada nesse
+	// meio tempo não é excluída.
+	Purgar(dono, id string, purgavel func(t Tarefa) bool) error
+	// ExcluidasAntesDe retorna as tarefas de todos os donos que foram para a
+	// lixeira antes de limite
+	ExcluidasAntesDe(limite time.Time) ([]Tarefa, error)
+	// AdotarSemDono atribui ao dono as tarefas gravadas antes de as tarefas
+	// terem dono, retornando quantas foram atribuídas
+	AdotarSemDono(dono string) (int, error)
+}
+
+// repositorioTarefas implementa TarefaRepository sobre um Armazenamento
+type repositorioTarefas struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioTarefas cria um repositório de tarefas sobre o armazenamento informado
+func NovoRepositorioTarefas(a Armazenamento) TarefaRepository {
+	return &repositorioTarefas{armazenamento: a}
+}
+
+// listarDo retorna as tarefas do dono que satisfazem filtro, na ordem de
+// criação, lendo apenas os documentos desse dono
+func (r *repositorioTarefas) listarDo(dono string, filtro func(t Tarefa) bool) ([]Tarefa, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoTarefas, dono)
+	if err != nil {
+		return nil, err
+	}
+	return filtrarTarefas(docs, filtro)
+}
+
+// filtrarTarefas decodifica os documentos e retorna as tarefas que satisfazem filtro
+func filtrarTarefas(docs [][]byte, filtro func(t Tarefa) bool) ([]Tarefa, error) {
+	tarefas := make([]Tarefa, 0, len(docs))
+	for _, doc := range docs {
+		t, err := decodificarTarefa(doc)
+		if err != nil {
+			return nil, err
+		}
+		if filtro(t) {
+			tarefas = append(tarefas, t)
+		}
+	}
+	return tarefas, nil
+}
+
+func (r *repositorioTarefas) Listar(dono string) ([]Tarefa, error) {
+	return r.listarDo(dono, func(t Tarefa) bool { return t.ExcluidaEm == nil })
+}
+
+func (r *repositorioTarefas) Buscar(dono, id string) (Tarefa, error) {
+	t, err := r.buscar(dono, id)
+	if err == nil && t.ExcluidaEm != nil {
+		return Tarefa{}, ErrTarefaNaoEncontrada
+	}
+	return t, err
+}
+
+// buscar retorna a tarefa do dono com o ID informado, mesmo na lixeira
+func (r *repositorioTarefas) buscar(dono, id string) (Tarefa, error) {
+	doc, err := r.armazenamento.Buscar(colecaoTarefas, id)
+	if err != nil {
+		return Tarefa{}, traduzirErro(err)
+	}
+	t, err := decodificarTarefa(doc)
+	if err == nil && t.Dono != dono {
+		return Tarefa{}, ErrTarefaNaoEncontrada
+	}
+	return t, err
+}
+
+func (r *repositorioTarefas) Criar(dono string, t Tarefa) (Tarefa, error) {
+	instante := agora()
+	t.ID = novoID()
+	t.Dono = dono
+	t.Versao = 1
+	t.CriadaEm = instante
+	t.AtualizadaEm = instante
+	t.ConcluidaEm = nil
+	if t.Concluida {
+		t.ConcluidaEm = &instante
+	}
+	t.Subtarefas = nil
+	t.Bloqueada = false
+	t.ExcluidaEm = nil
+	t.ExcluidaCom = ""
+	t.SubtarefasPromovidas = nil
+	t.Normalizar()
+	doc, err := json.Marshal(t)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoTarefas, t.ID, doc); err != nil {
+		return Tarefa{}, err
+	}
+	return t, nil
+}
+
+func (r *repositorioTarefas) Atualizar(dono, id string, versao int, mudar func(t *Tarefa) error) (Tarefa, error) {
+	var t Tarefa
+	_, err := r.armazenamento.Atualizar(colecaoTarefas, id, func(doc []byte) ([]byte, error) {
+		var err error
+		if t, err = decodificarTarefa(doc); err != nil {
+			return nil, err
+		}
+		if t.Dono != dono || t.ExcluidaEm != nil {
+			return nil, ErrTarefaNaoEncontrada
+		}
+		if versao > 0 && t.Versao != versao {
+			return nil, ErrConflitoVersao
+		}
+
+		antes := t
+		if err := mudar(&t); err != nil {
+			return nil, err
+		}
+
+		// Campos controlados pelo servidor
+		instante := agora()
+		t.ID = id
+		t.Dono = antes.Dono
+		t.Versao = antes.Versao + 1
+		t.CriadaEm = antes.CriadaEm
+		t.AtualizadaEm = instante
+		switch {
+		case !t.Concluida:
+			t.ConcluidaEm = nil
+		case !antes.Concluida:
+			t.ConcluidaEm = &instante
+		default:
+			t.ConcluidaEm = antes.ConcluidaEm
+		}
+		t.Subtarefas = nil
+		t.Bloqueada = false
+		t.ExcluidaEm = nil
+		t.ExcluidaCom = ""
+		t.SubtarefasPromovidas = nil
+		t.Normalizar()
+		return json.Marshal(t)
+	})
+	if err != nil {
+		return Tarefa{}, traduzirErro(err)
+	}
+	return t, nil
+}
+
+func (r *repositorioTarefas) Remover(dono, id string) error {
+	// O dono de uma tarefa nunca muda, então conferi-lo antes de remover
+	// não abre espaço para corrida
+	if _, err := r.buscar(dono, id); err != nil {
+		return err
+	}
+	return traduzirErro(r.armazenamento.Remover(colecaoTarefas, id))
+}
+
+func (r *repositorioTarefas) MoverParaLixeira(dono, id string, versao int, raiz string, promovidas []string) (Tarefa, error) {
+	return r.marcarExclusao(dono, id, versao, false, func(t *Tarefa) {
+		instante := agora()
+		t.ExcluidaEm = &instante
+		t.ExcluidaCom = raiz
+		t.SubtarefasPromovidas = promovidas
+	})
+}
+
+func (r *repositorioTarefas) ListarLixeira(dono string) ([]Tarefa, error) {
+	return r.listarDo(dono, func(t Tarefa) bool { return t.ExcluidaEm != nil })
+}
+
+func (r *repositorioTarefas) Restaurar(dono, id string) (Tarefa, error) {
+	return r.marcarExclusao(dono, id, 0, true, func(t *Tarefa) {
+		t.ExcluidaEm = nil
+		t.ExcluidaCom = ""
+		t.SubtarefasPromovidas = nil
+	})
+}
+
+func (r *repositorioTarefas) Purgar(dono, id string, purgavel func(t Tarefa) bool) error {
+	err := r.armazenamento.RemoverSe(colecaoTarefas, id, func(doc []byte) error {
+		t, err := decodificarTarefa(doc)
+		if err != nil {
+			return err
+		}
+		if t.Dono != dono || t.ExcluidaEm == nil || !purgavel(t) {
+			return ErrTarefaNaoEncontrada
+		}
+		return nil
+	})
+	return traduzirErro(err)
+}
+
+func (r *repositorioTarefas) ExcluidasAntesDe(limite time.Time) ([]Tarefa, error) {
+	// A purga vale para todos os donos e percorre a coleção inteira
+	docs, err := r.armazenamento.Listar(colecaoTarefas)
+	if err != nil {
+		return nil, err
+	}
+	return filtrarTarefas(docs, func(t Tarefa) bool { return t.ExcluidaEm != nil && t.ExcluidaEm.Before(limite) })
+}
+
+// marcarExclusao aplica marcar a uma tarefa do dono que esteja (naLixeira)
+// ou não na lixeira, incrementando sua versão. Com versao maior que zero, a
+// tarefa precisa estar nessa versão.
+func (r *repositorioTarefas) marcarExclusao(dono, id string, versao int, naLixeira bool, marcar func(t *Tarefa)) (Tarefa, error) {
+	var t Tarefa
+	_, err := r.armazenamento.Atualizar(colecaoTarefas, id, func(doc []byte) ([]byte, error) {
+		var err error
+		if t, err = decodificarTarefa(doc); err != nil {
+			return nil, err
+		}
+		if t.Dono != dono || (t.ExcluidaEm != nil) != naLixeira {
+			return nil, ErrTarefaNaoEncontrada
+		}
+		if versao > 0 && t.Versao != versao {
+			return nil, ErrConflitoVersao
+		}
+		marcar(&t)
+		t.Versao++
+		t.AtualizadaEm = agora()
+		return json.Marshal(t)
+	})
+	if err != nil {
+		return Tarefa{}, traduzirErro(err)
+	}
+	return t, nil
+}
+
+func (r *repositorioTarefas) AdotarSemDono(dono string) (int, error) {
+	semDono, err := r.Listar("")
+	if err != nil {
+		return 0, err
+	}
+	adotadas := 0
+	for _, t := range semDono {
+		_, err := r.armazenamento.Atualizar(colecaoTarefas, t.ID, func(doc []byte) ([]byte, error) {
+			atual, err := decodificarTarefa(doc)
+			if err != nil {
+				return nil, err
+			}
+			if atual.Dono != "" {
+				return nil, errJaAdotada
+			}
+			atual.Dono = dono
+			return json.Marshal(atual)
+		})
+		switch {
+		case err == nil:
+			adotadas++
+		case errors.Is(err, errJaAdotada), errors.Is(err, ErrNaoEncontrado):
+			// Outra instância adotou ou removeu a tarefa nesse meio tempo
+		default:
+			return adotadas, err
+		}
+	}
+	return adotadas, nil
+}
+
+// errJaAdotada interrompe a adoção de uma tarefa que já ganhou dono
+var errJaAdotada = errors.New("tarefa já tem dono")
+
+// decodificarTarefa lê uma tarefa gravada e aplica os valores padrão
+func decodificarTarefa(doc []byte) (Tarefa, error) {
+	var t Tarefa
+	if err := json.Unmarshal(doc, &t); err != nil {
+		return t, err
+	}
+	t.Normalizar()
+	return t, nil
+}
+
+// agora retorna o instante atual em UTC; é uma variável para que os testes
+// possam controlar o relógio
+var agora = func() time.Time {
+	return time.Now().UTC()
+}
+
+// traduzirErro converte erros do armazenamento em erros do domínio de tarefas
+func traduzirErro(err error) error {
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrTarefaNaoEncontrada
+	}
+	return err
+}
+
+// abrirArmazenamento escolhe a implementação de Armazenamento conforme o tipo
+// configurado: "memoria" (padrão), "json" ou "sqlite"
+func abrirArmazenamento(tipo, caminho string) (Armazenamento, error) {
+	switch tipo {
+	case "", "memoria":
+		return NovoArmazenamentoMemoria(), nil
+	case "json":
+		if caminho == "" {
+			caminho = "tarefas.json"
+		}
+		return NovoArmazenamentoJSON(caminho)
+	case "sqlite":
+		if caminho == "" {
+			caminho = "tarefas.db"
+		}
+		return NovoArmazenamentoSQLite(caminho)
+	default:
+		return nil, fmt.Errorf("tipo de armazenamento desconhecido: %q", tipo)
+	}
+}
+
+// armazenamentoDoAmbiente abre o armazenamento definido pelas variáveis
+// ARMAZENAMENTO e ARMAZENAMENTO_CAMINHO
+func armazenamentoDoAmbiente() (Armazenamento, error) {
+	return abrirArmazenamento(os.Getenv("ARMAZENAMENTO"), os.Getenv("ARMAZENAMENTO_CAMINHO"))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// ErrAnexoNaoEncontrado indica que não existe anexo com o ID informado na
+// tarefa do usuário
+var ErrAnexoNaoEncontrado = errors.New("anexo não encontrado")
+
+// colecaoAnexos é o nome da coleção de metadados dos anexos no armazenamento
+const colecaoAnexos = "anexos"
+
+// AnexoRepository define as operações de persistência dos metadados dos
+// anexos. O conteúdo fica em um ArmazenamentoBlobs, referenciado pelo hash.
+type AnexoRepository interface {
+	// Listar retorna os anexos da tarefa na ordem de envio
+	Listar(dono, tarefaID string) ([]Anexo, error)
+	// Buscar retorna o anexo do dono com o ID informado ou ErrAnexoNaoEncontrado
+	Buscar(dono, id string) (Anexo, error)
+	// Criar grava um novo anexo do dono, gerando seu ID e a data
+	Criar(dono string, a Anexo) (Anexo, error)
+	// Remover exclui o anexo do dono
+	Remover(dono, id string) error
+	// RemoverDasTarefas exclui os anexos das tarefas informadas e os retorna
+	RemoverDasTarefas(dono string, tarefaIDs []string) ([]Anexo, error)
+	// EmUso informa se algum anexo, de qualquer usuário, usa o conteúdo com
+	// o hash informado
+	EmUso(hash string) (bool, error)
+}
+
+// repositorioAnexos implementa AnexoRepository sobre um Armazenamento
+type repositorioAnexos struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioAnexos cria um repositório de anexos sobre o armazenamento informado
+func NovoRepositorioAnexos(a Armazenamento) AnexoRepository {
+	return &repositorioAnexos{armazenamento: a}
+}
+
+// listarDo retorna os anexos do dono que satisfazem filtro
+func (r *repositorioAnexos) listarDo(dono string, filtro func(a Anexo) bool) ([]Anexo, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoAnexos, dono)
+	if err != nil {
+		return nil, err
+	}
+	anexos := []Anexo{}
+	for _, doc := range docs {
+		var a Anexo
+		if err := json.Unmarshal(doc, &a); err != nil {
+			return nil, err
+		}
+		if filtro(a) {
+			anexos = append(anexos, a)
+		}
+	}
+	return anexos, nil
+}
+
+func (r *repositorioAnexos) Listar(dono, tarefaID string) ([]Anexo, error) {
+	return r.listarDo(dono, func(a Anexo) bool { return a.TarefaID == tarefaID })
+}
+
+func (r *repositorioAnexos) Buscar(dono, id string) (Anexo, error) {
+	var a Anexo
+	doc, err := r.armazenamento.Buscar(colecaoAnexos, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return a, ErrAnexoNaoEncontrado
+	}
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(doc, &a); err != nil {
+		return a, err
+	}
+	if a.Dono != dono {
+		return Anexo{}, ErrAnexoNaoEncontrado
+	}
+	return a, nil
+}
+
+func (r *repositorioAnexos) Criar(dono string, a Anexo) (Anexo, error) {
+	a.ID, a.Dono, a.CriadoEm = novoID(), dono, agora()
+	doc, err := json.Marshal(a)
+	if err != nil {
+		return Anexo{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoAnexos, a.ID, doc); err != nil {
+		return Anexo{}, err
+	}
+	return a, nil
+}
+
+func (r *repositorioAnexos) Remover(dono, id string) error {
+	if _, err := r.Buscar(dono, id); err != nil {
+		return err
+	}
+	err := r.armazenamento.Remover(colecaoAnexos, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrAnexoNaoEncontrado
+	}
+	return err
+}
+
+func (r *repositorioAnexos) RemoverDasTarefas(dono string, tarefaIDs []string) ([]Anexo, error) {
+	anexos, err := r.listarDo(dono, func(a Anexo) bool { return slices.Contains(tarefaIDs, a.TarefaID) })
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range anexos {
+		if err := r.armazenamento.Remover(colecaoAnexos, a.ID); err != nil && !errors.Is(err, ErrNaoEncontrado) {
+			return nil, err
+		}
+	}
+	return anexos, nil
+}
+
+func (r *repositorioAnexos) EmUso(hash string) (bool, error) {
+	// O conteúdo é compartilhado entre donos, então a busca percorre a coleção inteira
+	docs, err := r.armazenamento.Listar(colecaoAnexos)
+	if err != nil {
+		return false, err
+	}
+	for _, doc := range docs {
+		var a Anexo
+		if err := json.Unmarshal(doc, &a); err != nil {
+			return false, err
+		}
+		if a.Hash == hash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
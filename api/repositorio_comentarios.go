@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// ErrComentarioNaoEncontrado indica que não existe comentário com o ID
+// informado na tarefa do usuário
+var ErrComentarioNaoEncontrado = errors.New("comentário não encontrado")
+
+// colecaoComentarios é o nome da coleção de comentários no armazenamento
+const colecaoComentarios = "comentarios"
+
+// ComentarioRepository define as operações de persistência dos comentários
+// das tarefas. Cada comentário pertence ao dono da tarefa e os de outros
+// usuários são tratados como inexistentes.
+type ComentarioRepository interface {
+	// Listar retorna os comentários da tarefa na ordem de criação
+	Listar(dono, tarefaID string) ([]Comentario, error)
+	// Buscar retorna o comentário do dono com o ID informado ou ErrComentarioNaoEncontrado
+	Buscar(dono, id string) (Comentario, error)
+	// Criar grava um novo comentário do dono, gerando seu ID e a data
+	Criar(dono string, c Comentario) (Comentario, error)
+	// Editar troca o texto do comentário e o marca como editado
+	Editar(dono, id, texto string) (Comentario, error)
+	// Remover exclui o comentário do dono
+	Remover(dono, id string) error
+	// RemoverDasTarefas exclui os comentários das tarefas informadas
+	RemoverDasTarefas(dono string, tarefaIDs []string) error
+}
+
+// repositorioComentarios implementa ComentarioRepository sobre um Armazenamento
+type repositorioComentarios struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioComentarios cria um repositório de comentários sobre o armazenamento informado
+func NovoRepositorioComentarios(a Armazenamento) ComentarioRepository {
+	return &repositorioComentarios{armazenamento: a}
+}
+
+// listarDo retorna os comentários do dono que satisfazem filtro
+func (r *repositorioComentarios) listarDo(dono string, filtro func(c Comentario) bool) ([]Comentario, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoComentarios, dono)
+	if err != nil {
+		return nil, err
+	}
+	comentarios := []Comentario{}
+	for _, doc := range docs {
+		var c Comentario
+		if err := json.Unmarshal(doc, &c); err != nil {
+			return nil, err
+		}
+		if filtro(c) {
+			comentarios = append(comentarios, c)
+		}
+	}
+	return comentarios, nil
+}
+
+func (r *repositorioComentarios) Listar(dono, tarefaID string) ([]Comentario, error) {
+	return r.listarDo(dono, func(c Comentario) bool { return c.TarefaID == tarefaID })
+}
+
+func (r *repositorioComentarios) Buscar(dono, id string) (Comentario, error) {
+	var c Comentario
+	doc, err := r.armazenamento.Buscar(colecaoComentarios, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return c, ErrComentarioNaoEncontrado
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(doc, &c); err != nil {
+		return c, err
+	}
+	if c.Dono != dono {
+		return Comentario{}, ErrComentarioNaoEncontrado
+	}
+	return c, nil
+}
+
+func (r *repositorioComentarios) Criar(dono string, c Comentario) (Comentario, error) {
+	c = Comentario{ID: novoID(), TarefaID: c.TarefaID, Texto: c.Texto, Dono: dono, CriadoEm: agora()}
+	c.Normalizar()
+	doc, err := json.Marshal(c)
+	if err != nil {
+		return Comentario{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoComentarios, c.ID, doc); err != nil {
+		return Comentario{}, err
+	}
+	return c, nil
+}
+
+func (r *repositorioComentarios) Editar(dono, id, texto string) (Comentario, error) {
+	var c Comentario
+	_, err := r.armazenamento.Atualizar(colecaoComentarios, id, func(doc []byte) ([]byte, error) {
+		if err := json.Unmarshal(doc, &c); err != nil {
+			return nil, err
+		}
+		if c.Dono != dono {
+			return nil, ErrComentarioNaoEncontrado
+		}
+		instante := agora()
+		c.Texto, c.Editado, c.EditadoEm = texto, true, &instante
+		c.Normalizar()
+		return json.Marshal(c)
+	})
+	if errors.Is(err, ErrNaoEncontrado) {
+		return Comentario{}, ErrComentarioNaoEncontrado
+	}
+	if err != nil {
+		return Comentario{}, err
+	}
+	return c, nil
+}
+
+func (r *repositorioComentarios) Remover(dono, id string) error {
+	if _, err := r.Buscar(dono, id); err != nil {
+		return err
+	}
+	err := r.armazenamento.Remover(colecaoComentarios, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrComentarioNaoEncontrado
+	}
+	return err
+}
+
+func (r *repositorioComentarios) RemoverDasTarefas(dono string, tarefaIDs []string) error {
+	comentarios, err := r.listarDo(dono, func(c Comentario) bool { return slices.Contains(tarefaIDs, c.TarefaID) })
+	if err != nil {
+		return err
+	}
+	for _, c := range comentarios {
+		if err := r.armazenamento.Remover(colecaoComentarios, c.ID); err != nil && !errors.Is(err, ErrNaoEncontrado) {
+			return err
+		}
+	}
+	return nil
+}
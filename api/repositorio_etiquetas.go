@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrEtiquetaNaoEncontrada indica que não existe etiqueta com o ID informado
+// para o usuário
+var ErrEtiquetaNaoEncontrada = errors.New("etiqueta não encontrada")
+
+// colecaoEtiquetas é o nome da coleção de etiquetas no armazenamento
+const colecaoEtiquetas = "etiquetas"
+
+// EtiquetaRepository define as operações de persistência de etiquetas. Cada
+// etiqueta pertence a um dono e as de outros usuários são tratadas como
+// inexistentes.
+type EtiquetaRepository interface {
+	// Listar retorna as etiquetas do dono na ordem de criação
+	Listar(dono string) ([]Etiqueta, error)
+	// Buscar retorna a etiqueta do dono com o ID informado ou ErrEtiquetaNaoEncontrada
+	Buscar(dono, id string) (Etiqueta, error)
+	// Criar grava uma nova etiqueta do dono, gerando seu ID e as datas
+	Criar(dono string, e Etiqueta) (Etiqueta, error)
+	// Atualizar aplica mudar à etiqueta de forma atômica. ID, dono e datas são
+	// controlados pelo repositório.
+	Atualizar(dono, id string, mudar func(e *Etiqueta) error) (Etiqueta, error)
+	// Remover exclui a etiqueta do dono; as tarefas que a usam não são afetadas
+	Remover(dono, id string) error
+}
+
+// repositorioEtiquetas implementa EtiquetaRepository sobre um Armazenamento
+type repositorioEtiquetas struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioEtiquetas cria um repositório de etiquetas sobre o armazenamento informado
+func NovoRepositorioEtiquetas(a Armazenamento) EtiquetaRepository {
+	return &repositorioEtiquetas{armazenamento: a}
+}
+
+func (r *repositorioEtiquetas) Listar(dono string) ([]Etiqueta, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoEtiquetas, dono)
+	if err != nil {
+		return nil, err
+	}
+	etiquetas := []Etiqueta{}
+	for _, doc := range docs {
+		var e Etiqueta
+		if err := json.Unmarshal(doc, &e); err != nil {
+			return nil, err
+		}
+		etiquetas = append(etiquetas, e)
+	}
+	return etiquetas, nil
+}
+
+func (r *repositorioEtiquetas) Buscar(dono, id string) (Etiqueta, error) {
+	var e Etiqueta
+	doc, err := r.armazenamento.Buscar(colecaoEtiquetas, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return e, ErrEtiquetaNaoEncontrada
+	}
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(doc, &e); err != nil {
+		return e, err
+	}
+	if e.Dono != dono {
+		return Etiqueta{}, ErrEtiquetaNaoEncontrada
+	}
+	return e, nil
+}
+
+func (r *repositorioEtiquetas) Criar(dono string, e Etiqueta) (Etiqueta, error) {
+	instante := agora()
+	e = Etiqueta{ID: novoID(), Nome: e.Nome, Cor: e.Cor, Dono: dono, CriadaEm: instante, AtualizadaEm: instante}
+	e.Normalizar()
+	doc, err := json.Marshal(e)
+	if err != nil {
+		return Etiqueta{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoEtiquetas, e.ID, doc); err != nil {
+		return Etiqueta{}, err
+	}
+	return e, nil
+}
+
+func (r *repositorioEtiquetas) Atualizar(dono, id string, mudar func(e *Etiqueta) error) (Etiqueta, error) {
+	var e Etiqueta
+	_, err := r.armazenamento.Atualizar(colecaoEtiquetas, id, func(doc []byte) ([]byte, error) {
+		if err := json.Unmarshal(doc, &e); err != nil {
+			return nil, err
+		}
+		if e.Dono != dono {
+			return nil, ErrEtiquetaNaoEncontrada
+		}
+
+		antes := e
+		if err := mudar(&e); err != nil {
+			return nil, err
+		}
+		e = Etiqueta{ID: id, Nome: e.Nome, Cor: e.Cor, Dono: antes.Dono, CriadaEm: antes.CriadaEm, AtualizadaEm: agora()}
+		e.Normalizar()
+		return json.Marshal(e)
+	})
+	if errors.Is(err, ErrNaoEncontrado) {
+		return Etiqueta{}, ErrEtiquetaNaoEncontrada
+	}
+	if err != nil {
+		return Etiqueta{}, err
+	}
+	return e, nil
+}
+
+func (r *repositorioEtiquetas) Remover(dono, id string) error {
+	if _, err := r.Buscar(dono, id); err != nil {
+		return err
+	}
+	err := r.armazenamento.Remover(colecaoEtiquetas, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrEtiquetaNaoEncontrada
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// Nomes das coleções do histórico das tarefas no armazenamento
+const (
+	colecaoHistorico   = "historico_estados"
+	colecaoRenomeacoes = "renomeacoes"
+)
+
+// HistoricoRepository define as operações de persistência do histórico das
+// tarefas: as mudanças de estado e as renomeações. Cada registro pertence
+// ao dono da tarefa e os de outros usuários são tratados como inexistentes.
+type HistoricoRepository interface {
+	// Registrar grava uma mudança de estado do dono, gerando seu ID e a data
+	Registrar(dono string, m MudancaEstado) (MudancaEstado, error)
+	// Listar retorna as mudanças de estado da tarefa, da mais antiga para a
+	// mais recente
+	Listar(dono, tarefaID string) ([]MudancaEstado, error)
+	// RegistrarRenomeacao grava uma troca de título do dono, gerando seu ID
+	// e a data
+	RegistrarRenomeacao(dono string, r Renomeacao) (Renomeacao, error)
+	// ListarRenomeacoes retorna as trocas de título da tarefa, da mais
+	// antiga para a mais recente
+	ListarRenomeacoes(dono, tarefaID string) ([]Renomeacao, error)
+	// RemoverDasTarefas exclui o histórico das tarefas informadas
+	RemoverDasTarefas(dono string, tarefaIDs []string) error
+}
+
+// repositorioHistorico implementa HistoricoRepository sobre um Armazenamento
+type repositorioHistorico struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioHistorico cria um repositório de histórico sobre o armazenamento informado
+func NovoRepositorioHistorico(a Armazenamento) HistoricoRepository {
+	return &repositorioHistorico{armazenamento: a}
+}
+
+// listarDo retorna as mudanças de estado do dono que satisfazem filtro
+func (r *repositorioHistorico) listarDo(dono string, filtro func(m MudancaEstado) bool) ([]MudancaEstado, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoHistorico, dono)
+	if err != nil {
+		return nil, err
+	}
+	mudancas := []MudancaEstado{}
+	for _, doc := range docs {
+		var m MudancaEstado
+		if err := json.Unmarshal(doc, &m); err != nil {
+			return nil, err
+		}
+		if filtro(m) {
+			mudancas = append(mudancas, m)
+		}
+	}
+	return mudancas, nil
+}
+
+func (r *repositorioHistorico) Registrar(dono string, m MudancaEstado) (MudancaEstado, error) {
+	m.ID = novoID()
+	m.Dono = dono
+	m.Em = agora()
+	doc, err := json.Marshal(m)
+	if err != nil {
+		return MudancaEstado{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoHistorico, m.ID, doc); err != nil {
+		return MudancaEstado{}, err
+	}
+	return m, nil
+}
+
+func (r *repositorioHistorico) Listar(dono, tarefaID string) ([]MudancaEstado, error) {
+	return r.listarDo(dono, func(m MudancaEstado) bool { return m.TarefaID == tarefaID })
+}
+
+func (r *repositorioHistorico) RegistrarRenomeacao(dono string, ren Renomeacao) (Renomeacao, error) {
+	ren.ID = novoID()
+	ren.Dono = dono
+	ren.Em = agora()
+	doc, err := json.Marshal(ren)
+	if err != nil {
+		return Renomeacao{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoRenomeacoes, ren.ID, doc); err != nil {
+		return Renomeacao{}, err
+	}
+	return ren, nil
+}
+
+// renomeacoesDo retorna as renomeações do dono que satisfazem filtro
+func (r *repositorioHistorico) renomeacoesDo(dono string, filtro func(ren Renomeacao) bool) ([]Renomeacao, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoRenomeacoes, dono)
+	if err != nil {
+		return nil, err
+	}
+	renomeacoes := []Renomeacao{}
+	for _, doc := range docs {
+		var ren Renomeacao
+		if err := json.Unmarshal(doc, &ren); err != nil {
+			return nil, err
+		}
+		if filtro(ren) {
+			renomeacoes = append(renomeacoes, ren)
+		}
+	}
+	return renomeacoes, nil
+}
+
+func (r *repositorioHistorico) ListarRenomeacoes(dono, tarefaID string) ([]Renomeacao, error) {
+	return r.renomeacoesDo(dono, func(ren Renomeacao) bool { return ren.TarefaID == tarefaID })
+}
+
+func (r *repositorioHistorico) RemoverDasTarefas(dono string, tarefaIDs []string) error {
+	mudancas, err := r.listarDo(dono, func(m MudancaEstado) bool { return slices.Contains(tarefaIDs, m.TarefaID) })
+	if err != nil {
+		return err
+	}
+	renomeacoes, err := r.renomeacoesDo(dono, func(ren Renomeacao) bool { return slices.Contains(tarefaIDs, ren.TarefaID) })
+	if err != nil {
+		return err
+	}
+	remover := func(colecao, id string) error {
+		if err := r.armazenamento.Remover(colecao, id); err != nil && !errors.Is(err, ErrNaoEncontrado) {
+			return err
+		}
+		return nil
+	}
+	for _, m := range mudancas {
+		if err := remover(colecaoHistorico, m.ID); err != nil {
+			return err
+		}
+	}
+	for _, ren := range renomeacoes {
+		if err := remover(colecaoRenomeacoes, ren.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrProjetoNaoEncontrado indica que não existe projeto com o ID informado
+// para o usuário
+var ErrProjetoNaoEncontrado = errors.New("projeto não encontrado")
+
+// colecaoProjetos é o nome da coleção de projetos no armazenamento
+const colecaoProjetos = "projetos"
+
+// ProjetoRepository define as operações de persistência de projetos. Como as
+// tarefas, cada projeto pertence a um dono e os de outros usuários são
+// tratados como inexistentes. As contagens de tarefas não são gravadas.
+type ProjetoRepository interface {
+	// Listar retorna os projetos do dono na ordem de criação
+	Listar(dono string) ([]Projeto, error)
+	// Buscar retorna o projeto do dono com o ID informado ou ErrProjetoNaoEncontrado
+	Buscar(dono, id string) (Projeto, error)
+	// Criar grava um novo projeto do dono, gerando seu ID e as datas
+	Criar(dono string, p Projeto) (Projeto, error)
+	// Atualizar aplica mudar ao projeto de forma atômica. ID, dono e datas são
+	// controlados pelo repositório.
+	Atualizar(dono, id string, mudar func(p *Projeto) error) (Projeto, error)
+	// Remover exclui o projeto do dono; suas tarefas não são afetadas
+	Remover(dono, id string) error
+}
+
+// repositorioProjetos implementa ProjetoRepository sobre um Armazenamento
+type repositorioProjetos struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioProjetos cria um repositório de projetos sobre o armazenamento informado
+func NovoRepositorioProjetos(a Armazenamento) ProjetoRepository {
+	return &repositorioProjetos{armazenamento: a}
+}
+
+func (r *repositorioProjetos) Listar(dono string) ([]Projeto, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoProjetos, dono)
+	if err != nil {
+		return nil, err
+	}
+	projetos := []Projeto{}
+	for _, doc := range docs {
+		var p Projeto
+		if err := json.Unmarshal(doc, &p); err != nil {
+			return nil, err
+		}
+		projetos = append(projetos, p)
+	}
+	return projetos, nil
+}
+
+func (r *repositorioProjetos) Buscar(dono, id string) (Projeto, error) {
+	var p Projeto
+	doc, err := r.armazenamento.Buscar(colecaoProjetos, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return p, ErrProjetoNaoEncontrado
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(doc, &p); err != nil {
+		return p, err
+	}
+	if p.Dono != dono {
+		return Projeto{}, ErrProjetoNaoEncontrado
+	}
+	return p, nil
+}
+
+func (r *repositorioProjetos) Criar(dono string, p Projeto) (Projeto, error) {
+	instante := agora()
+	p = Projeto{ID: novoID(), Nome: p.Nome, Fluxo: p.Fluxo, Dono: dono, CriadoEm: instante, AtualizadoEm: instante}
+	doc, err := json.Marshal(p)
+	if err != nil {
+		return Projeto{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoProjetos, p.ID, doc); err != nil {
+		return Projeto{}, err
+	}
+	return p, nil
+}
+
+func (r *repositorioProjetos) Atualizar(dono, id string, mudar func(p *Projeto) error) (Projeto, error) {
+	var p Projeto
+	_, err := r.armazenamento.Atualizar(colecaoProjetos, id, func(doc []byte) ([]byte, error) {
+		if err := json.Unmarshal(doc, &p); err != nil {
+			return nil, err
+		}
+		if p.Dono != dono {
+			return nil, ErrProjetoNaoEncontrado
+		}
+
+		antes := p
+		if err := mudar(&p); err != nil {
+			return nil, err
+		}
+		p = Projeto{ID: id, Nome: p.Nome, Fluxo: p.Fluxo, Dono: antes.Dono, CriadoEm: antes.CriadoEm, AtualizadoEm: agora()}
+		return json.Marshal(p)
+	})
+	if errors.Is(err, ErrNaoEncontrado) {
+		return Projeto{}, ErrProjetoNaoEncontrado
+	}
+	if err != nil {
+		return Projeto{}, err
+	}
+	return p, nil
+}
+
+func (r *repositorioProjetos) Remover(dono, id string) error {
+	if _, err := r.Buscar(dono, id); err != nil {
+		return err
+	}
+	err := r.armazenamento.Remover(colecaoProjetos, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrProjetoNaoEncontrado
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// ErrRevisaoNaoEncontrada indica que a tarefa do usuário não tem revisão com
+// o número informado
+var ErrRevisaoNaoEncontrada = errors.New("revisão não encontrada")
+
+// colecaoRevisoes é o nome da coleção de revisões das tarefas no armazenamento
+const colecaoRevisoes = "revisoes"
+
+// RevisaoRepository define as operações de persistência das revisões das
+// tarefas. Revisões não são alteradas depois de gravadas; só saem junto com
+// a tarefa.
+type RevisaoRepository interface {
+	// Registrar grava uma revisão do dono, gerando seu ID e a data
+	Registrar(dono string, rev Revisao) (Revisao, error)
+	// Listar retorna as revisões da tarefa, da mais antiga para a mais recente
+	Listar(dono, tarefaID string) ([]Revisao, error)
+	// Buscar retorna a revisão da tarefa com o número informado ou
+	// ErrRevisaoNaoEncontrada
+	Buscar(dono, tarefaID string, numero int) (Revisao, error)
+	// RemoverDasTarefas exclui as revisões das tarefas informadas
+	RemoverDasTarefas(dono string, tarefaIDs []string) error
+}
+
+// repositorioRevisoes implementa RevisaoRepository sobre um Armazenamento
+type repositorioRevisoes struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioRevisoes cria um repositório de revisões sobre o armazenamento informado
+func NovoRepositorioRevisoes(a Armazenamento) RevisaoRepository {
+	return &repositorioRevisoes{armazenamento: a}
+}
+
+// listarDo retorna as revisões do dono que satisfazem filtro, ordenadas pelo número
+func (r *repositorioRevisoes) listarDo(dono string, filtro func(rev Revisao) bool) ([]Revisao, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoRevisoes, dono)
+	if err != nil {
+		return nil, err
+	}
+	revisoes := []Revisao{}
+	for _, doc := range docs {
+		var rev Revisao
+		if err := json.Unmarshal(doc, &rev); err != nil {
+			return nil, err
+		}
+		if filtro(rev) {
+			revisoes = append(revisoes, rev)
+		}
+	}
+	// Alterações concorrentes podem gravar as revisões fora de ordem
+	slices.SortStableFunc(revisoes, func(a, b Revisao) int { return a.Numero - b.Numero })
+	return revisoes, nil
+}
+
+func (r *repositorioRevisoes) Registrar(dono string, rev Revisao) (Revisao, error) {
+	rev.ID = novoID()
+	rev.Dono = dono
+	rev.Em = agora()
+	doc, err := json.Marshal(rev)
+	if err != nil {
+		return Revisao{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoRevisoes, rev.ID, doc); err != nil {
+		return Revisao{}, err
+	}
+	return rev, nil
+}
+
+func (r *repositorioRevisoes) Listar(dono, tarefaID string) ([]Revisao, error) {
+	return r.listarDo(dono, func(rev Revisao) bool { return rev.TarefaID == tarefaID })
+}
+
+func (r *repositorioRevisoes) Buscar(dono, tarefaID string, numero int) (Revisao, error) {
+	revisoes, err := r.listarDo(dono, func(rev Revisao) bool { return rev.TarefaID == tarefaID && rev.Numero == numero })
+	if err != nil {
+		return Revisao{}, err
+	}
+	if len(revisoes) == 0 {
+		return Revisao{}, ErrRevisaoNaoEncontrada
+	}
+	return revisoes[0], nil
+}
+
+func (r *repositorioRevisoes) RemoverDasTarefas(dono string, tarefaIDs []string) error {
+	revisoes, err := r.listarDo(dono, func(rev Revisao) bool { return slices.Contains(tarefaIDs, rev.TarefaID) })
+	if err != nil {
+		return err
+	}
+	for _, rev := range revisoes {
+		if err := r.armazenamento.Remover(colecaoRevisoes, rev.ID); err != nil && !errors.Is(err, ErrNaoEncontrado) {
+			return err
+		}
+	}
+	return nil
+}
+
+// tarefasComRevisoes envolve um TarefaRepository e grava uma revisão a cada
+// criação ou alteração de tarefa. Ficar no repositório garante a revisão
+// também nas alterações feitas em cascata, como a conclusão automática do
+// pai ou a remoção de uma etiqueta.
+type tarefasComRevisoes struct {
+	TarefaRepository
+	revisoes RevisaoRepository
+}
+
+// comRevisoes retorna o repositório de tarefas que grava as revisões em revisoes
+func comRevisoes(tarefas TarefaRepository, revisoes RevisaoRepository) TarefaRepository {
+	return &tarefasComRevisoes{TarefaRepository: tarefas, revisoes: revisoes}
+}
+
+func (r *tarefasComRevisoes) Criar(dono string, t Tarefa) (Tarefa, error) {
+	t, err := r.TarefaRepository.Criar(dono, t)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	return t, r.registrar(dono, nil, t)
+}
+
+func (r *tarefasComRevisoes) Atualizar(dono, id string, versao int, mudar func(t *Tarefa) error) (Tarefa, error) {
+	var antes Tarefa
+	t, err := r.TarefaRepository.Atualizar(dono, id, versao, func(t *Tarefa) error {
+		antes = copiarTarefa(*t)
+		return mudar(t)
+	})
+	if err != nil {
+		return Tarefa{}, err
+	}
+	return t, r.registrar(dono, &antes, t)
+}
+
+// registrar grava a revisão da tarefa, se algum campo revisado mudou
+func (r *tarefasComRevisoes) registrar(dono string, antes *Tarefa, depois Tarefa) error {
+	diferencas := dominio.DiferencasTarefa(antes, depois)
+	if len(diferencas) == 0 {
+		return nil
+	}
+	_, err := r.revisoes.Registrar(dono, Revisao{
+		TarefaID:   depois.ID,
+		Numero:     depois.Versao,
+		Autor:      dono,
+		Diferencas: diferencas,
+		Tarefa:     depois,
+	})
+	return err
+}
+
+// copiarTarefa copia a tarefa sem compartilhar as listas e o prazo, que
+// json.Unmarshal reaproveita ao aplicar uma alteração sobre a tarefa
+func copiarTarefa(t Tarefa) Tarefa {
+	t.Etiquetas = slices.Clone(t.Etiquetas)
+	t.BloqueadaPor = slices.Clone(t.BloqueadaPor)
+	if t.Prazo != nil {
+		prazo := *t.Prazo
+		t.Prazo = &prazo
+	}
+	return t
+}
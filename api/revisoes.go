@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// manipuladorRevisoes atende GET /api/tarefas/{id}/historico, com as
+// revisões da tarefa da mais antiga para a mais recente
+func (s *servidor) manipuladorRevisoes(w http.ResponseWriter, r *http.Request, dono, id string) {
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		if _, err := s.tarefas.Buscar(dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		revisoes, err := s.revisoes.Listar(dono, id)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(revisoes)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, OPTIONS")
+	}
+}
+
+// reverterTarefa atende POST /api/tarefas/{id}/reverter?revisao=N, que
+// devolve a tarefa ao que era na revisão N. A reversão é uma alteração
+// como as outras: exige If-Match, segue as mesmas validações e gera uma nova
+// revisão.
+func (s *servidor) reverterTarefa(w http.ResponseWriter, r *http.Request, dono, id string) {
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+		return
+	case "POST":
+	default:
+		responderMetodoNaoPermitido(w, r, "POST, OPTIONS")
+		return
+	}
+
+	numero, err := strconv.Atoi(r.URL.Query().Get("revisao"))
+	if err != nil || numero < 1 {
+		responderProblema(w, r, problemaParametroInvalido, (&errConsulta{"revisao", dominio.Mensagens{
+			PtBR: "informe o número da revisão, a partir de 1",
+			En:   "provide the revision number, starting at 1",
+		}}).campo())
+		return
+	}
+	// Como as demais escritas, a reversão exige em If-Match o ETag da versão
+	// que o cliente leu
+	versao, err := s.versaoCondicional(r, dono, id)
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+	rev, err := s.revisoes.Buscar(dono, id, numero)
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+
+	anterior := rev.Tarefa
+	campos := camposAlteracao{
+		Titulo:       &anterior.Titulo,
+		ProjetoID:    &anterior.ProjetoID,
+		Etiquetas:    &anterior.Etiquetas,
+		PaiID:        &anterior.PaiID,
+		BloqueadaPor: &anterior.BloqueadaPor,
+	}
+	s.aplicarAlteracao(w, r, dono, id, versao, campos, func(t *Tarefa) error {
+		// O repositório mantém o ID, o dono, a versão e as datas
+		*t = copiarTarefa(anterior)
+		return nil
+	})
+}
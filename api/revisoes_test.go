@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// revisoesTeste retorna as revisões da tarefa
+func revisoesTeste(t *testing.T, srv *servidor, id string) []Revisao {
+	t.Helper()
+	rr := executar(t, srv, "GET", "/api/tarefas/"+id+"/historico", "")
+	var revisoes []Revisao
+	if err := json.Unmarshal(rr.Body.Bytes(), &revisoes); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("GET historico retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	return revisoes
+}
+
+// camposAlterados resume as diferenças da revisão como campo:de>para
+func camposAlterados(rev Revisao) []string {
+	var campos []string
+	for _, d := range rev.Diferencas {
+		campos = append(campos, d.Campo+":"+string(d.De)+">"+string(d.Para))
+	}
+	return campos
+}
+
+func TestRevisoesDaTarefa(t *testing.T) {
+	srv := novoServidorTeste(t)
+	id := criarTarefaTeste(t, srv, "Relatório")
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"titulo":"Relatório anual"}`)
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"concluida":true}`)
+	// Reenviar os mesmos valores não gera revisão
+	executar(t, srv, "PUT", "/api/tarefas/"+id, `{"titulo":"Relatório anual","concluida":true}`)
+
+	// Alterações em cascata também ficam registradas
+	rr := executar(t, srv, "POST", "/api/etiquetas", `{"nome":"Financeiro"}`)
+	var etiqueta Etiqueta
+	json.Unmarshal(rr.Body.Bytes(), &etiqueta)
+	executar(t, srv, "PATCH", "/api/tarefas/"+id, `{"etiquetas":["`+etiqueta.ID+`"]}`)
+	executar(t, srv, "DELETE", "/api/etiquetas/"+etiqueta.ID, "")
+
+	revisoes := revisoesTeste(t, srv, id)
+	var numeros []int
+	for _, rev := range revisoes {
+		numeros = append(numeros, rev.Numero)
+		if rev.Autor != usuarioTeste || rev.TarefaID != id || rev.Tarefa.Versao != rev.Numero {
+			t.Errorf("revisão %d: %+v", rev.Numero, rev)
+		}
+	}
+	if !slices.Equal(numeros, []int{1, 2, 3, 5, 6}) {
+		t.Fatalf("números das revisões: %v", numeros)
+	}
+	if campos := camposAlterados(revisoes[1]); !slices.Equal(campos, []string{`titulo:"Relatório">"Relatório anual"`}) {
+		t.Errorf("diferenças da renomeação: %v", campos)
+	}
+	if campos := camposAlterados(revisoes[2]); !slices.Equal(campos, []string{`concluida:false>true`, `estado:"pendente">"concluida"`}) {
+		t.Errorf("diferenças da conclusão: %v", campos)
+	}
+	if campos := camposAlterados(revisoes[4]); !slices.Equal(campos, []string{`etiquetas:["` + etiqueta.ID + `"]>null`}) {
+		t.Errorf("diferenças da remoção da etiqueta: %v", campos)
+	}
+
+	// Reverter desfaz a renomeação e a conclusão em uma nova revisão
+	rr = executar(t, srv, "POST", "/api/tarefas/"+id+"/reverter?revisao=1", "")
+	var revertida Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &revertida); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("POST reverter retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if revertida.Titulo != "Relatório" || revertida.Concluida || revertida.Estado != dominio.EstadoPendente || revertida.Versao != 7 {
+		t.Errorf("tarefa revertida: %+v", revertida)
+	}
+	revisoes = revisoesTeste(t, srv, id)
+	ultima := revisoes[len(revisoes)-1]
+	if campos := camposAlterados(ultima); ultima.Numero != 7 || len(campos) != 3 {
+		t.Errorf("revisão da reversão %d: %v", ultima.Numero, campos)
+	}
+
+	// Uma revisão que referencia uma etiqueta removida não pode ser restaurada
+	rr = executar(t, srv, "POST", "/api/tarefas/"+id+"/reverter?revisao=5", "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "etiquetas" {
+		t.Errorf("reverter para etiqueta removida: obtido %d %+v", rr.Code, p.Campos)
+	}
+	rr = executar(t, srv, "POST", "/api/tarefas/"+id+"/reverter?revisao=4", "")
+	if rr.Code != http.StatusNotFound || lerProblema(t, rr).Codigo != dominio.CodigoRevisaoNaoEncontrada {
+		t.Errorf("revisão inexistente: obtido %d", rr.Code)
+	}
+	if rr := executar(t, srv, "POST", "/api/tarefas/"+id+"/reverter?revisao=zero", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("número de revisão inválido: obtido %d", rr.Code)
+	}
+	if rr := executar(t, srv, "GET", "/api/tarefas/"+id+"/reverter?revisao=1", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET reverter retornou %d", rr.Code)
+	}
+
+	if err := srv.usuarios.DefinirSenha("outro", "senha-outro"); err != nil {
+		t.Fatal(err)
+	}
+	outro := srv.tokens.emitir("outro", dominio.EscoposValidos).Token
+	if rr := executarComo(t, srv, outro, "GET", "/api/tarefas/"+id+"/historico", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("revisões de outro usuário retornaram %d", rr.Code)
+	}
+	if rr := executarComo(t, srv, outro, "POST", "/api/tarefas/"+id+"/reverter?revisao=1", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("reverter tarefa de outro usuário retornou %d", rr.Code)
+	}
+
+	// Purgar a tarefa da lixeira remove suas revisões
+	executar(t, srv, "DELETE", "/api/tarefas/"+id, "")
+	executar(t, srv, "DELETE", "/api/lixeira/"+id, "")
+	if revisoes, _ := srv.revisoes.Listar(usuarioTeste, id); len(revisoes) != 0 {
+		t.Errorf("revisões da tarefa removida: %+v", revisoes)
+	}
+}
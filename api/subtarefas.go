@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// progressoDe conta as subtarefas diretas da tarefa com o ID informado
+func progressoDe(tarefas []Tarefa, id string) dominio.ProgressoSubtarefas {
+	var p dominio.ProgressoSubtarefas
+	for _, t := range tarefas {
+		if t.PaiID == id {
+			p.Total++
+			if t.Concluida {
+				p.Concluidas++
+			}
+		}
+	}
+	return p
+}
+
+// preencherProgresso calcula, a partir de todas as tarefas do dono, o
+// progresso das subtarefas de cada tarefa de alvo
+func preencherProgresso(todas []Tarefa, alvo []Tarefa) {
+	progressos := map[string]*dominio.ProgressoSubtarefas{}
+	for _, t := range todas {
+		if t.PaiID == "" {
+			continue
+		}
+		p := progressos[t.PaiID]
+		if p == nil {
+			p = &dominio.ProgressoSubtarefas{}
+			progressos[t.PaiID] = p
+		}
+		p.Total++
+		if t.Concluida {
+			p.Concluidas++
+		}
+	}
+	for i := range alvo {
+		if p := progressos[alvo[i].ID]; p != nil {
+			copia := *p
+			alvo[i].Subtarefas = &copia
+		}
+	}
+}
+
+// validarPaiDaTarefa confere que o pai informado é uma tarefa do dono e que
+// torná-lo pai da tarefa id não cria um ciclo. id é vazio em tarefas novas.
+func (s *servidor) validarPaiDaTarefa(dono, id, paiID string) error {
+	if paiID == "" {
+		return nil
+	}
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	porID := make(map[string]Tarefa, len(tarefas))
+	for _, t := range tarefas {
+		porID[t.ID] = t
+	}
+	if _, ok := porID[paiID]; !ok {
+		return &ErroValidacao{Campo: "pai_id", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+			PtBR: "a tarefa pai informada não existe",
+			En:   "the given parent task does not exist",
+		}}
+	}
+
+	// Subir pelos ancestrais do novo pai: encontrar a própria tarefa
+	// significa que ela passaria a ser ancestral de si mesma
+	vistas := map[string]bool{}
+	for atual := paiID; atual != "" && !vistas[atual]; atual = porID[atual].PaiID {
+		if atual == id {
+			return &ErroValidacao{Campo: "pai_id", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "a tarefa não pode ser subtarefa de si mesma nem de suas subtarefas",
+				En:   "a task cannot be a subtask of itself or of its subtasks",
+			}}
+		}
+		vistas[atual] = true
+	}
+	return nil
+}
+
+// concluirPais conclui a tarefa id e seus ancestrais que pedem conclusão
+// automática, tiveram todas as subtarefas concluídas e não estão bloqueados.
+// Exige s.muEstados tomado.
+func (s *servidor) concluirPais(dono, id string) error {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	porID := make(map[string]*Tarefa, len(tarefas))
+	for i := range tarefas {
+		porID[tarefas[i].ID] = &tarefas[i]
+	}
+	pendentes := pendentesDe(tarefas)
+
+	// Uma tarefa bloqueada continua pendente mesmo com as subtarefas
+	// concluídas, até que suas dependências também sejam
+	vistas := map[string]bool{}
+	for id != "" && !vistas[id] {
+		vistas[id] = true
+		t, ok := porID[id]
+		if !ok {
+			return nil
+		}
+		if t.ConcluirComSubtarefas && !t.Concluida && progressoDe(tarefas, id).Completo() && !bloqueada(*t, pendentes) {
+			_, concluida, err := s.atualizarNoFluxo(dono, id, 0, func(t *Tarefa, fluxos fluxosPorProjeto) error {
+				antes := *t
+				t.Concluida = true
+				return fluxos.de(t.ProjetoID).AplicarEstado(&antes, t)
+			})
+			// Sem um estado final alcançável ou com a coluna dele cheia, o
+			// pai continua no estado atual: a alteração que o completou vale
+			// por si e não é desfeita
+			var transicao *dominio.ErroTransicao
+			var limite *dominio.ErroLimiteEstado
+			if errors.Is(err, ErrTarefaNaoEncontrada) || errors.As(err, &transicao) || errors.As(err, &limite) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			*t = concluida
+			delete(pendentes, id)
+		}
+		id = t.PaiID
+	}
+	return nil
+}
+
+// removerTarefa move a tarefa para a lixeira e dá destino às suas
+// subtarefas: com SubtarefasRemover, todas as descendentes vão para a
+// lixeira junto com ela; senão as subtarefas diretas passam para o pai da
+// tarefa removida, que guarda quais foram para devolvê-las na restauração.
+// As tarefas na lixeira deixam de bloquear as demais, mas as dependências,
+// os comentários, os anexos e os históricos ficam até a purga (veja
+// lixeira.go).
+// Com versao maior que zero, a tarefa precisa estar nessa versão. A
+// conferência acontece junto com a ida da tarefa para a lixeira, antes de
+// mexer nas subtarefas, para que um conflito não deixe a remoção pela metade.
+func (s *servidor) removerTarefa(dono, id, destino string, versao int) error {
+	s.muEstados.Lock()
+	defer s.muEstados.Unlock()
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return err
+	}
+	var filhas []string
+	if destino != dominio.SubtarefasRemover {
+		for _, t := range tarefas {
+			if t.PaiID == id {
+				filhas = append(filhas, t.ID)
+			}
+		}
+	}
+
+	removida, err := s.tarefas.MoverParaLixeira(dono, id, versao, "", filhas)
+	if err != nil {
+		return err
+	}
+	if destino == dominio.SubtarefasRemover {
+		for _, descendente := range descendentesDe(tarefas, id) {
+			if _, err := s.tarefas.MoverParaLixeira(dono, descendente, 0, id, nil); err != nil && !errors.Is(err, ErrTarefaNaoEncontrada) {
+				return err
+			}
+		}
+	} else {
+		for _, filha := range filhas {
+			_, err := s.tarefas.Atualizar(dono, filha, 0, func(t *Tarefa) error {
+				if t.PaiID != id {
+					return errSubtarefaMovida
+				}
+				t.PaiID = removida.PaiID
+				return nil
+			})
+			if err != nil && !errors.Is(err, errSubtarefaMovida) && !errors.Is(err, ErrTarefaNaoEncontrada) {
+				return err
+			}
+		}
+	}
+	// Sem uma subtarefa pendente, o pai pode ter ficado completo
+	return s.concluirPais(dono, removida.PaiID)
+}
+
+// descendentesDe lista as subtarefas de id, em qualquer nível, em ordem de
+// profundidade crescente
+func descendentesDe(tarefas []Tarefa, id string) []string {
+	var descendentes []string
+	vistas := map[string]bool{id: true}
+	for nivel := []string{id}; len(nivel) > 0; {
+		var proximo []string
+		for _, t := range tarefas {
+			if vistas[t.ID] {
+				continue
+			}
+			for _, pai := range nivel {
+				if t.PaiID == pai {
+					vistas[t.ID] = true
+					proximo = append(proximo, t.ID)
+					break
+				}
+			}
+		}
+		descendentes = append(descendentes, proximo...)
+		nivel = proximo
+	}
+	return descendentes
+}
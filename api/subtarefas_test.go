@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// criarSubtarefaTeste cria uma subtarefa de pai e retorna seu ID
+func criarSubtarefaTeste(t *testing.T, srv *servidor, pai, titulo string) string {
+	t.Helper()
+	rr := executar(t, srv, "POST", "/api/tarefas", `{"titulo":"`+titulo+`","pai_id":"`+pai+`"}`)
+	var criada Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &criada); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("POST subtarefa retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	return criada.ID
+}
+
+// buscarTarefaTeste lê a tarefa pela API, com o progresso das subtarefas
+func buscarTarefaTeste(t *testing.T, srv *servidor, id string) Tarefa {
+	t.Helper()
+	rr := executar(t, srv, "GET", "/api/tarefas/"+id, "")
+	var tarefa Tarefa
+	if err := json.Unmarshal(rr.Body.Bytes(), &tarefa); err != nil {
+		t.Fatalf("GET %s retornou %d: %s", id, rr.Code, rr.Body.String())
+	}
+	return tarefa
+}
+
+func TestSubtarefasConcluemOPai(t *testing.T) {
+	srv := novoServidorTeste(t)
+	pai := criarTarefaTeste(t, srv, "Mudança")
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+pai, `{"concluir_com_subtarefas":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("PATCH retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	caixas := criarSubtarefaTeste(t, srv, pai, "Embalar caixas")
+	frete := criarSubtarefaTeste(t, srv, pai, "Contratar frete")
+	criarSubtarefaTeste(t, srv, caixas, "Comprar fita")
+
+	if p := buscarTarefaTeste(t, srv, pai).Subtarefas; p == nil || p.Total != 2 || p.Concluidas != 0 {
+		t.Errorf("progresso inicial: %+v", p)
+	}
+
+	// ?pai lista as subtarefas diretas e ?raiz=true apenas as tarefas sem pai
+	rr := executar(t, srv, "GET", "/api/tarefas?pai="+pai, "")
+	var pagina dominio.PaginaTarefas
+	if err := json.Unmarshal(rr.Body.Bytes(), &pagina); err != nil || len(pagina.Tarefas) != 2 {
+		t.Errorf("?pai: %d tarefas: %s", len(pagina.Tarefas), rr.Body.String())
+	}
+	if len(pagina.Tarefas) == 2 && (pagina.Tarefas[0].Subtarefas == nil || pagina.Tarefas[0].Subtarefas.Total != 1) {
+		t.Errorf("subtarefa com subtarefa sem progresso na listagem: %+v", pagina.Tarefas[0])
+	}
+	rr = executar(t, srv, "GET", "/api/tarefas?raiz=true", "")
+	var raizes dominio.PaginaTarefas
+	json.Unmarshal(rr.Body.Bytes(), &raizes)
+	for _, tarefa := range raizes.Tarefas {
+		if tarefa.PaiID != "" {
+			t.Errorf("?raiz=true incluiu a subtarefa %+v", tarefa)
+		}
+	}
+
+	// A primeira conclusão só avança o progresso; a última conclui o pai
+	executar(t, srv, "PATCH", "/api/tarefas/"+caixas, `{"concluida":true}`)
+	if tarefa := buscarTarefaTeste(t, srv, pai); tarefa.Concluida || tarefa.Subtarefas.Concluidas != 1 {
+		t.Errorf("pai após a primeira subtarefa: %+v", tarefa)
+	}
+	executar(t, srv, "PATCH", "/api/tarefas/"+frete, `{"concluida":true}`)
+	if tarefa := buscarTarefaTeste(t, srv, pai); !tarefa.Concluida || tarefa.ConcluidaEm == nil || tarefa.Subtarefas.Concluidas != 2 {
+		t.Errorf("pai não foi concluído com as subtarefas: %+v", tarefa)
+	}
+
+	// Sem a opção, o pai continua pendente
+	outro := criarTarefaTeste(t, srv, "Sem conclusão automática")
+	filha := criarSubtarefaTeste(t, srv, outro, "Única")
+	executar(t, srv, "PATCH", "/api/tarefas/"+filha, `{"concluida":true}`)
+	if buscarTarefaTeste(t, srv, outro).Concluida {
+		t.Errorf("pai sem concluir_com_subtarefas foi concluído")
+	}
+}
+
+func TestSubtarefasRejeitamCiclos(t *testing.T) {
+	srv := novoServidorTeste(t)
+	avo := criarTarefaTeste(t, srv, "Avó")
+	mae := criarSubtarefaTeste(t, srv, avo, "Mãe")
+	neta := criarSubtarefaTeste(t, srv, mae, "Neta")
+	alheia, err := srv.tarefas.Criar("outro", Tarefa{Titulo: "Alheia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct{ metodo, url, corpo string }{
+		{"POST", "/api/tarefas", `{"titulo":"Órfã","pai_id":"inexistente"}`},
+		{"POST", "/api/tarefas", `{"titulo":"Intrusa","pai_id":"` + alheia.ID + `"}`},
+		{"PATCH", "/api/tarefas/" + avo, `{"pai_id":"` + avo + `"}`},
+		{"PATCH", "/api/tarefas/" + avo, `{"pai_id":"` + neta + `"}`},
+		{"PUT", "/api/tarefas/" + mae, `{"titulo":"Mãe","pai_id":"` + neta + `"}`},
+	}
+	for _, c := range casos {
+		rr := executar(t, srv, c.metodo, c.url, c.corpo)
+		p := lerProblema(t, rr)
+		if rr.Code != http.StatusBadRequest || len(p.Campos) != 1 || p.Campos[0].Campo != "pai_id" {
+			t.Errorf("%s %s: obtido %d %+v", c.metodo, c.corpo, rr.Code, p.Campos)
+		}
+	}
+
+	// Mover para outro ramo e tornar a tarefa independente são aceitos
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+neta, `{"pai_id":"`+avo+`"}`); rr.Code != http.StatusOK {
+		t.Errorf("mover subtarefa retornou %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := executar(t, srv, "PATCH", "/api/tarefas/"+mae, `{"pai_id":""}`); rr.Code != http.StatusOK {
+		t.Errorf("tornar independente retornou %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRemoverTarefaComSubtarefas(t *testing.T) {
+	srv := novoServidorTeste(t)
+
+	// Por padrão, as subtarefas passam para o pai da tarefa removida
+	avo := criarTarefaTeste(t, srv, "Avó")
+	mae := criarSubtarefaTeste(t, srv, avo, "Mãe")
+	filha := criarSubtarefaTeste(t, srv, mae, "Filha")
+	if rr := executar(t, srv, "DELETE", "/api/tarefas/"+mae, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE retornou %d", rr.Code)
+	}
+	if tarefa := buscarTarefaTeste(t, srv, filha); tarefa.PaiID != avo {
+		t.Errorf("subtarefa não foi promovida: %+v", tarefa)
+	}
+
+	// Com ?subtarefas=remover, todas as descendentes são removidas
+	neta := criarSubtarefaTeste(t, srv, filha, "Neta")
+	if rr := executar(t, srv, "DELETE", "/api/tarefas/"+avo+"?subtarefas=remover", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE em cascata retornou %d", rr.Code)
+	}
+	for _, id := range []string{avo, filha, neta} {
+		if _, err := srv.tarefas.Buscar(usuarioTeste, id); err == nil {
+			t.Errorf("tarefa %s não foi removida em cascata", id)
+		}
+	}
+
+	// Um destino desconhecido é recusado sem remover nada
+	id := criarTarefaTeste(t, srv, "Fica")
+	rr := executar(t, srv, "DELETE", "/api/tarefas/"+id+"?subtarefas=arquivar", "")
+	if p := lerProblema(t, rr); rr.Code != http.StatusBadRequest || p.Codigo != dominio.CodigoParametroInvalido {
+		t.Errorf("destino inválido: obtido %d %q", rr.Code, p.Codigo)
+	}
+	if _, err := srv.tarefas.Buscar(usuarioTeste, id); err != nil {
+		t.Errorf("tarefa removida com destino inválido: %v", err)
+	}
+}
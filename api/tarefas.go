@@ -0,0 +1,394 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// errCorpoInvalido indica que o corpo da requisição não é um JSON de tarefa válido
+var errCorpoInvalido = errors.New("corpo da requisição inválido")
+
+// manipuladorTarefas atende a coleção /api/tarefas, com as tarefas do
+// usuário autenticado
+func (s *servidor) manipuladorTarefas(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		consulta, errParametro := lerConsultaTarefas(r.URL.Query())
+		if errParametro != nil {
+			responderProblema(w, r, problemaParametroInvalido, errParametro.campo())
+			return
+		}
+		tarefas, err := s.tarefas.Listar(dono)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		// Os campos calculados vêm antes dos filtros, que dependem do bloqueio
+		preencherCalculados(tarefas, tarefas)
+		pagina := consulta.aplicar(tarefas)
+		json.NewEncoder(w).Encode(pagina)
+	case "POST":
+		var t Tarefa
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			responderProblema(w, r, problemaCorpoInvalido)
+			return
+		}
+		if err := t.Validar(); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		if err := s.validarProjetoDaTarefa(dono, t.ProjetoID); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		if err := s.validarEtiquetasDaTarefa(dono, t.Etiquetas); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		if err := s.validarPaiDaTarefa(dono, "", t.PaiID); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		if err := s.validarBloqueiosDaTarefa(dono, "", t.BloqueadaPor); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		// O estado define concluida, que precisa estar certo antes de olhar
+		// as dependências
+		fluxos, err := s.fluxos(dono)
+		if err != nil {
+			responderErroInterno(w, r, err)
+			return
+		}
+		if err := fluxos.de(t.ProjetoID).AplicarEstado(nil, &t); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		if t.Concluida {
+			pendentes, err := s.pendentes(dono)
+			if err != nil {
+				responderErroInterno(w, r, err)
+				return
+			}
+			if dependeDePendentes(t, pendentes) {
+				responderErroRepositorio(w, r, ErrTarefaBloqueada)
+				return
+			}
+		}
+
+		// O ID, o dono, a versão e as datas são sempre definidos pelo servidor
+		s.muEstados.Lock()
+		t, err = s.criarTarefa(dono, t)
+		if err == nil {
+			err = s.concluirPais(dono, t.PaiID)
+		}
+		s.muEstados.Unlock()
+		if err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/tarefas/"+t.ID)
+		definirETag(w, t)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, POST, OPTIONS")
+	}
+}
+
+// manipuladorTarefa atende uma tarefa individual em /api/tarefas/{id} e seus
+// subrecursos: as revisões em /api/tarefas/{id}/historico, o histórico de
+// estados em /api/tarefas/{id}/historico/estados, a atividade, os
+// comentários e os anexos. Tarefas de outros usuários respondem 404, como
+// se não existissem.
+func (s *servidor) manipuladorTarefa(w http.ResponseWriter, r *http.Request) {
+	definirCabecalhos(w)
+	dono := usuarioDe(r.Context())
+
+	id, subrecurso, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/tarefas/"), "/")
+	recurso, subID, _ := strings.Cut(subrecurso, "/")
+	switch {
+	case id == "":
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	case subrecurso == "historico":
+		s.manipuladorRevisoes(w, r, dono, id)
+		return
+	case subrecurso == "historico/estados":
+		s.manipuladorHistorico(w, r, dono, id)
+		return
+	case subrecurso == "reverter":
+		s.reverterTarefa(w, r, dono, id)
+		return
+	case subrecurso == "atividade":
+		s.manipuladorAtividade(w, r, dono, id)
+		return
+	case recurso == "comentarios":
+		s.manipuladorComentarios(w, r, dono, id, subID)
+		return
+	case recurso == "anexos":
+		s.manipuladorAnexos(w, r, dono, id, subID)
+		return
+	case subrecurso != "":
+		responderProblema(w, r, problemaRotaNaoEncontrada)
+		return
+	}
+
+	// As escritas exigem em If-Match o ETag da versão que o cliente leu, para
+	// que uma alteração feita por outra pessoa nesse meio tempo não se perca
+	var versao int
+	if r.Method == "PUT" || r.Method == "PATCH" || r.Method == "DELETE" {
+		var err error
+		if versao, err = s.versaoCondicional(r, dono, id); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "GET":
+		t, err := s.buscarCalculada(dono, id)
+		if err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		definirETag(w, t)
+		json.NewEncoder(w).Encode(t)
+	case "PUT":
+		// PUT exige a representação com título; os demais campos ausentes
+		// mantêm o valor atual, para que clientes que conhecem apenas os campos
+		// originais não apaguem os novos
+		s.alterarTarefa(w, r, dono, id, versao, true)
+	case "PATCH":
+		s.alterarTarefa(w, r, dono, id, versao, false)
+	case "DELETE":
+		// ?subtarefas escolhe entre promover as subtarefas (padrão) e
+		// removê-las junto com a tarefa
+		destino := r.URL.Query().Get("subtarefas")
+		switch destino {
+		case "", dominio.SubtarefasPromover, dominio.SubtarefasRemover:
+		default:
+			responderProblema(w, r, problemaParametroInvalido, (&errConsulta{"subtarefas", dominio.Mensagens{
+				PtBR: "use promover ou remover",
+				En:   "use promover or remover",
+			}}).campo())
+			return
+		}
+		if err := s.removerTarefa(dono, id, destino, versao); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		responderMetodoNaoPermitido(w, r, "GET, PUT, PATCH, DELETE, OPTIONS")
+	}
+}
+
+// camposAlteracao são os campos de uma alteração que referenciam outros
+// recursos e por isso são validados antes de alterar a tarefa
+type camposAlteracao struct {
+	Titulo       *string   `json:"titulo"`
+	ProjetoID    *string   `json:"projeto_id"`
+	Etiquetas    *[]string `json:"etiquetas"`
+	PaiID        *string   `json:"pai_id"`
+	BloqueadaPor *[]string `json:"bloqueada_por"`
+}
+
+// alterarTarefa aplica o corpo JSON da requisição sobre a tarefa gravada, se
+// ela ainda estiver na versão informada (zero aceita qualquer versão).
+// Campos enviados substituem os atuais; campos somente leitura são ignorados.
+func (s *servidor) alterarTarefa(w http.ResponseWriter, r *http.Request, dono, id string, versao int, exigirTitulo bool) {
+	corpo, err := io.ReadAll(r.Body)
+	if err != nil {
+		responderProblema(w, r, problemaCorpoInvalido)
+		return
+	}
+	var campos camposAlteracao
+	if err := json.Unmarshal(corpo, &campos); err != nil {
+		responderProblema(w, r, problemaCorpoInvalido)
+		return
+	}
+	if exigirTitulo && campos.Titulo == nil {
+		responderErroRepositorio(w, r, (Tarefa{}).Validar())
+		return
+	}
+	s.aplicarAlteracao(w, r, dono, id, versao, campos, func(t *Tarefa) error {
+		if err := json.Unmarshal(corpo, t); err != nil {
+			return errCorpoInvalido
+		}
+		return nil
+	})
+}
+
+// aplicarAlteracao valida as referências em campos, aplica mudar à tarefa
+// gravada com as regras de estado, bloqueio e recorrência e responde a
+// tarefa alterada. Com versao maior que zero, a tarefa precisa estar nessa
+// versão.
+func (s *servidor) aplicarAlteracao(w http.ResponseWriter, r *http.Request, dono, id string, versao int, campos camposAlteracao, mudar func(t *Tarefa) error) {
+	// Mover a tarefa exige que o projeto de destino exista; um projeto_id
+	// vazio tira a tarefa do projeto. As etiquetas enviadas substituem as
+	// atuais e também precisam existir.
+	if campos.ProjetoID != nil {
+		if err := s.validarProjetoDaTarefa(dono, *campos.ProjetoID); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+	if campos.Etiquetas != nil {
+		if err := s.validarEtiquetasDaTarefa(dono, *campos.Etiquetas); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+	if campos.PaiID != nil {
+		if err := s.validarPaiDaTarefa(dono, id, *campos.PaiID); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+	if campos.BloqueadaPor != nil {
+		if err := s.validarBloqueiosDaTarefa(dono, id, *campos.BloqueadaPor); err != nil {
+			responderErroRepositorio(w, r, err)
+			return
+		}
+	}
+	pendentes, err := s.pendentes(dono)
+	if err != nil {
+		responderErroInterno(w, r, err)
+		return
+	}
+
+	// As alterações de tarefas são serializadas, para que duas mudanças de
+	// estado não ocupem ao mesmo tempo a última vaga de uma coluna
+	s.muEstados.Lock()
+	err = s.alterarNoFluxo(dono, id, versao, pendentes, mudar)
+	s.muEstados.Unlock()
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+	t, err := s.buscarCalculada(dono, id)
+	if err != nil {
+		responderErroRepositorio(w, r, err)
+		return
+	}
+	definirETag(w, t)
+	json.NewEncoder(w).Encode(t)
+}
+
+// alterarNoFluxo aplica mudar à tarefa gravada com as regras de estado,
+// bloqueio e recorrência, registra o título e conclui os pais que ficaram
+// completos. Exige s.muEstados tomado.
+func (s *servidor) alterarNoFluxo(dono, id string, versao int, pendentes map[string]bool, mudar func(t *Tarefa) error) error {
+	// Ler, aplicar e gravar em uma única operação atômica evita perder
+	// atualizações concorrentes de outros campos
+	antes, t, err := s.atualizarNoFluxo(dono, id, versao, func(t *Tarefa, fluxos fluxosPorProjeto) error {
+		antes := *t
+		if err := mudar(t); err != nil {
+			return err
+		}
+		if err := t.Validar(); err != nil {
+			return err
+		}
+		// O estado segue o fluxo do projeto de destino, e concluida passa a
+		// refletir o estado antes das demais regras
+		if err := fluxos.de(t.ProjetoID).AplicarEstado(&antes, t); err != nil {
+			return err
+		}
+		// Só a conclusão é barrada: uma tarefa já concluída pode ganhar
+		// dependências, e reabri-la é sempre permitido
+		if !antes.Concluida && t.Concluida && dependeDePendentes(*t, pendentes) {
+			return ErrTarefaBloqueada
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.registrarTitulo(dono, id, antes.Titulo, t.Titulo); err != nil {
+		return err
+	}
+
+	// Concluir ou mover a tarefa pode completar as subtarefas do pai atual
+	// ou do anterior
+	if err := s.concluirPais(dono, t.ID); err != nil {
+		return err
+	}
+	if antes.PaiID != t.PaiID {
+		return s.concluirPais(dono, antes.PaiID)
+	}
+	return nil
+}
+
+// responderErroRepositorio traduz erros do repositório em respostas HTTP
+func responderErroRepositorio(w http.ResponseWriter, r *http.Request, err error) {
+	var validacao *ErroValidacao
+	var transicao *dominio.ErroTransicao
+	var limite *dominio.ErroLimiteEstado
+	switch {
+	case errors.Is(err, errCorpoInvalido):
+		responderProblema(w, r, problemaCorpoInvalido)
+	case errors.As(err, &transicao):
+		responderProblema(w, r, problemaTransicaoInvalida, transicao.Campo())
+	case errors.As(err, &limite):
+		responderProblema(w, r, problemaLimiteEstado, limite.Campo())
+	case errors.As(err, &validacao):
+		responderProblema(w, r, problemaValidacao, *validacao)
+	case errors.Is(err, ErrTarefaNaoEncontrada):
+		responderProblema(w, r, problemaTarefaNaoEncontrada)
+	case errors.Is(err, ErrProjetoNaoEncontrado):
+		responderProblema(w, r, problemaProjetoNaoEncontrado)
+	case errors.Is(err, ErrEtiquetaNaoEncontrada):
+		responderProblema(w, r, problemaEtiquetaNaoEncontrada)
+	case errors.Is(err, ErrComentarioNaoEncontrado):
+		responderProblema(w, r, problemaComentarioNaoEncontrado)
+	case errors.Is(err, ErrAnexoNaoEncontrado):
+		responderProblema(w, r, problemaAnexoNaoEncontrado)
+	case errors.Is(err, ErrRevisaoNaoEncontrada):
+		responderProblema(w, r, problemaRevisaoNaoEncontrada)
+	case errors.Is(err, ErrConflitoVersao):
+		responderProblema(w, r, problemaConflitoVersao)
+	case errors.Is(err, errPrecondicaoAusente):
+		responderProblema(w, r, problemaPrecondicaoAusente)
+	case errors.Is(err, ErrTarefaBloqueada):
+		responderProblema(w, r, problemaTarefaBloqueada)
+	default:
+		responderErroInterno(w, r, err)
+	}
+}
+
+// preencherCalculados preenche, a partir de todas as tarefas do dono, os
+// campos que nunca são gravados: o progresso das subtarefas e o bloqueio
+func preencherCalculados(todas []Tarefa, alvo []Tarefa) {
+	preencherProgresso(todas, alvo)
+	preencherBloqueio(todas, alvo)
+}
+
+// buscarCalculada retorna a tarefa do dono com os campos calculados preenchidos
+func (s *servidor) buscarCalculada(dono, id string) (Tarefa, error) {
+	tarefas, err := s.tarefas.Listar(dono)
+	if err != nil {
+		return Tarefa{}, err
+	}
+	for _, t := range tarefas {
+		if t.ID == id {
+			alvo := []Tarefa{t}
+			preencherCalculados(tarefas, alvo)
+			return alvo[0], nil
+		}
+	}
+	return Tarefa{}, ErrTarefaNaoEncontrada
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUsuarioNaoEncontrado indica que não existe usuário com o login informado
+var ErrUsuarioNaoEncontrado = errors.New("usuário não encontrado")
+
+// ErrChaveNaoEncontrada indica que não existe chave de API com o ID informado
+// para o usuário
+var ErrChaveNaoEncontrada = errors.New("chave de API não encontrada")
+
+// Nomes das coleções de usuários e chaves no armazenamento
+const (
+	colecaoUsuarios = "usuarios"
+	colecaoChaves   = "chaves"
+)
+
+// custoSenha é o custo do bcrypt ao gravar senhas
+var custoSenha = bcrypt.DefaultCost
+
+// prefixoChaveAPI identifica chaves de API, para diferenciá-las de JWTs
+const prefixoChaveAPI = "tk_"
+
+// Usuario é uma conta que pode fazer login na API. O ID é o próprio login.
+type Usuario struct {
+	ID        string    `json:"id"`
+	SenhaHash string    `json:"senha_hash"`
+	CriadoEm  time.Time `json:"criado_em"`
+}
+
+// chaveGravada é uma chave de API como guardada no armazenamento, com o hash
+// do segredo no lugar do segredo. O usuário fica em "dono", como nas demais
+// coleções, para que as chaves dele sejam lidas pelo índice de donos.
+type chaveGravada struct {
+	dominio.ChaveAPI
+	Dono string `json:"dono"`
+	Hash string `json:"hash"`
+	// Usuario guarda o dono das chaves gravadas antes do índice; veja
+	// IndexarChavesSemDono
+	Usuario string `json:"usuario,omitempty"`
+}
+
+// UsuarioRepository define as operações de persistência de usuários
+type UsuarioRepository interface {
+	// Buscar retorna o usuário com o login informado ou ErrUsuarioNaoEncontrado
+	Buscar(login string) (Usuario, error)
+	// DefinirSenha cria o usuário ou troca sua senha
+	DefinirSenha(login, senha string) error
+}
+
+// ChaveRepository define as operações de persistência de chaves de API
+type ChaveRepository interface {
+	// Criar gera uma chave para o usuário. Apenas a chave retornada contém o segredo.
+	Criar(usuario, nome string, escopos []string) (dominio.ChaveAPI, error)
+	// Validar retorna a chave correspondente ao segredo apresentado, e o
+	// usuário dono dela, ou ErrChaveNaoEncontrada
+	Validar(segredo string) (dominio.ChaveAPI, string, error)
+	// Listar retorna as chaves do usuário, sem os segredos
+	Listar(usuario string) ([]dominio.ChaveAPI, error)
+	// Remover revoga a chave do usuário
+	Remover(usuario, id string) error
+	// IndexarChavesSemDono passa as chaves gravadas antes do índice de donos
+	// para o índice do seu usuário e retorna quantas foram passadas
+	IndexarChavesSemDono() (int, error)
+}
+
+// repositorioUsuarios implementa UsuarioRepository sobre um Armazenamento
+type repositorioUsuarios struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioUsuarios cria um repositório de usuários sobre o armazenamento informado
+func NovoRepositorioUsuarios(a Armazenamento) UsuarioRepository {
+	return &repositorioUsuarios{armazenamento: a}
+}
+
+func (r *repositorioUsuarios) Buscar(login string) (Usuario, error) {
+	var u Usuario
+	doc, err := r.armazenamento.Buscar(colecaoUsuarios, login)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return u, ErrUsuarioNaoEncontrado
+	}
+	if err != nil {
+		return u, err
+	}
+	err = json.Unmarshal(doc, &u)
+	return u, err
+}
+
+func (r *repositorioUsuarios) DefinirSenha(login, senha string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(senha), custoSenha)
+	if err != nil {
+		return err
+	}
+
+	doc, err := json.Marshal(Usuario{ID: login, SenhaHash: string(hash), CriadoEm: agora()})
+	if err != nil {
+		return err
+	}
+	err = r.armazenamento.Inserir(colecaoUsuarios, login, doc)
+	if !errors.Is(err, ErrDuplicado) {
+		return err
+	}
+
+	// Usuário existente: trocar apenas a senha
+	_, err = r.armazenamento.Atualizar(colecaoUsuarios, login, func(doc []byte) ([]byte, error) {
+		var u Usuario
+		if err := json.Unmarshal(doc, &u); err != nil {
+			return nil, err
+		}
+		u.SenhaHash = string(hash)
+		return json.Marshal(u)
+	})
+	return err
+}
+
+// repositorioChaves implementa ChaveRepository sobre um Armazenamento
+type repositorioChaves struct {
+	armazenamento Armazenamento
+}
+
+// NovoRepositorioChaves cria um repositório de chaves de API sobre o armazenamento informado
+func NovoRepositorioChaves(a Armazenamento) ChaveRepository {
+	return &repositorioChaves{armazenamento: a}
+}
+
+func (r *repositorioChaves) Criar(usuario, nome string, escopos []string) (dominio.ChaveAPI, error) {
+	// O segredo completo é tk_<id>_<aleatório>; o ID permite achar a chave
+	// sem percorrer todas elas
+	id := novoID()
+	segredo := prefixoChaveAPI + id + "_" + novoID() + novoID()
+	g := chaveGravada{
+		ChaveAPI: dominio.ChaveAPI{ID: id, Nome: nome, Escopos: escopos, CriadaEm: agora()},
+		Dono:     usuario,
+		Hash:     hashSegredo(segredo),
+	}
+	doc, err := json.Marshal(g)
+	if err != nil {
+		return dominio.ChaveAPI{}, err
+	}
+	if err := r.armazenamento.Inserir(colecaoChaves, id, doc); err != nil {
+		return dominio.ChaveAPI{}, err
+	}
+	g.ChaveAPI.Chave = segredo
+	return g.ChaveAPI, nil
+}
+
+func (r *repositorioChaves) Validar(segredo string) (dominio.ChaveAPI, string, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(segredo, prefixoChaveAPI), "_")
+	if !ok || !strings.HasPrefix(segredo, prefixoChaveAPI) {
+		return dominio.ChaveAPI{}, "", ErrChaveNaoEncontrada
+	}
+	g, err := r.buscar(id)
+	if err != nil {
+		return dominio.ChaveAPI{}, "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(g.Hash), []byte(hashSegredo(segredo))) != 1 {
+		return dominio.ChaveAPI{}, "", ErrChaveNaoEncontrada
+	}
+	return g.ChaveAPI, g.Dono, nil
+}
+
+func (r *repositorioChaves) Listar(usuario string) ([]dominio.ChaveAPI, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoChaves, usuario)
+	if err != nil {
+		return nil, err
+	}
+	chaves := []dominio.ChaveAPI{}
+	for _, doc := range docs {
+		g, err := decodificarChave(doc)
+		if err != nil {
+			return nil, err
+		}
+		chaves = append(chaves, g.ChaveAPI)
+	}
+	return chaves, nil
+}
+
+func (r *repositorioChaves) Remover(usuario, id string) error {
+	// Chaves de outros usuários são tratadas como inexistentes
+	g, err := r.buscar(id)
+	if err != nil {
+		return err
+	}
+	if g.Dono != usuario {
+		return ErrChaveNaoEncontrada
+	}
+	err = r.armazenamento.Remover(colecaoChaves, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return ErrChaveNaoEncontrada
+	}
+	return err
+}
+
+// buscar lê a chave gravada com o ID informado
+func (r *repositorioChaves) buscar(id string) (chaveGravada, error) {
+	var g chaveGravada
+	doc, err := r.armazenamento.Buscar(colecaoChaves, id)
+	if errors.Is(err, ErrNaoEncontrado) {
+		return g, ErrChaveNaoEncontrada
+	}
+	if err != nil {
+		return g, err
+	}
+	return decodificarChave(doc)
+}
+
+func (r *repositorioChaves) IndexarChavesSemDono() (int, error) {
+	docs, err := r.armazenamento.ListarDoDono(colecaoChaves, "")
+	if err != nil {
+		return 0, err
+	}
+	indexadas := 0
+	for _, doc := range docs {
+		g, err := decodificarChave(doc)
+		if err != nil {
+			return indexadas, err
+		}
+		_, err = r.armazenamento.Atualizar(colecaoChaves, g.ID, func(doc []byte) ([]byte, error) {
+			atual, err := decodificarChave(doc)
+			if err != nil {
+				return nil, err
+			}
+			atual.Usuario = ""
+			return json.Marshal(atual)
+		})
+		switch {
+		case err == nil:
+			indexadas++
+		case errors.Is(err, ErrNaoEncontrado):
+			// A chave foi revogada nesse meio tempo
+		default:
+			return indexadas, err
+		}
+	}
+	return indexadas, nil
+}
+
+// decodificarChave lê uma chave gravada; nas anteriores ao índice de donos o
+// usuário está só em "usuario"
+func decodificarChave(doc []byte) (chaveGravada, error) {
+	var g chaveGravada
+	if err := json.Unmarshal(doc, &g); err != nil {
+		return g, err
+	}
+	if g.Dono == "" {
+		g.Dono = g.Usuario
+	}
+	return g, nil
+}
+
+// hashSegredo calcula o hash guardado no lugar do segredo de uma chave. Como
+// o segredo é aleatório e longo, SHA-256 basta; não é uma senha escolhida.
+func hashSegredo(segredo string) string {
+	h := sha256.Sum256([]byte(segredo))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,79 @@
+package dominio
+
+import (
+	"mime"
+	"strings"
+	"time"
+)
+
+// Códigos de erro dos anexos
+const (
+	CodigoAnexoNaoEncontrado    = "anexo_nao_encontrado"
+	CodigoAnexoGrande           = "anexo_grande"
+	CodigoTipoAnexoNaoPermitido = "tipo_anexo_nao_permitido"
+)
+
+// TamanhoMaximoAnexo é o tamanho máximo, em bytes, de um arquivo anexado
+const TamanhoMaximoAnexo = 10 << 20
+
+// TiposAnexoPermitidos são os tipos MIME aceitos nos anexos
+var TiposAnexoPermitidos = []string{
+	"application/json",
+	"application/pdf",
+	"application/zip",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"text/csv",
+	"text/markdown",
+	"text/plain",
+}
+
+// Anexo descreve um arquivo enviado para uma tarefa. O conteúdo fica no
+// armazenamento de arquivos da API, endereçado pelo seu hash SHA-256, de modo
+// que arquivos iguais são guardados uma única vez.
+type Anexo struct {
+	ID       string `json:"id"`
+	TarefaID string `json:"tarefa_id"`
+	// Nome é o nome original do arquivo, usado no download
+	Nome string `json:"nome"`
+	// Tipo é o tipo MIME do conteúdo, sem parâmetros
+	Tipo    string `json:"tipo"`
+	Tamanho int64  `json:"tamanho"`
+	// Hash é o SHA-256 do conteúdo em hexadecimal
+	Hash     string    `json:"sha256"`
+	Dono     string    `json:"dono,omitempty"`
+	CriadoEm time.Time `json:"criado_em"`
+}
+
+// TipoAnexo normaliza o tipo MIME informado, removendo parâmetros como
+// charset, e informa se ele é aceito nos anexos
+func TipoAnexo(tipo string) (string, bool) {
+	base, _, err := mime.ParseMediaType(tipo)
+	if err != nil {
+		return "", false
+	}
+	for _, permitido := range TiposAnexoPermitidos {
+		if base == permitido {
+			return base, true
+		}
+	}
+	return base, false
+}
+
+// NomeAnexo limpa o nome do arquivo enviado: descarta diretórios e
+// caracteres de controle. Um nome vazio vira "arquivo".
+func NomeAnexo(nome string) string {
+	nome = nome[strings.LastIndexAny(nome, `/\`)+1:]
+	nome = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, nome)
+	if nome = strings.TrimSpace(nome); nome == "" || nome == "." || nome == ".." {
+		return "arquivo"
+	}
+	return nome
+}
@@ -0,0 +1,42 @@
+package dominio
+
+import "testing"
+
+func TestTipoAnexo(t *testing.T) {
+	casos := []struct {
+		tipo     string
+		esperado string
+		aceito   bool
+	}{
+		{"image/png", "image/png", true},
+		{"text/plain; charset=utf-8", "text/plain", true},
+		{"TEXT/CSV", "text/csv", true},
+		{"text/html", "text/html", false},
+		{"application/x-msdownload", "application/x-msdownload", false},
+		{"", "", false},
+	}
+	for _, c := range casos {
+		tipo, aceito := TipoAnexo(c.tipo)
+		if tipo != c.esperado || aceito != c.aceito {
+			t.Errorf("TipoAnexo(%q) = %q, %v; esperado %q, %v", c.tipo, tipo, aceito, c.esperado, c.aceito)
+		}
+	}
+}
+
+func TestNomeAnexo(t *testing.T) {
+	casos := map[string]string{
+		"relatorio.pdf":         "relatorio.pdf",
+		"../../etc/passwd":      "passwd",
+		`C:\Users\ana\foto.png`: "foto.png",
+		"nota\r\nfiscal.txt":    "notafiscal.txt",
+		"  ":                    "arquivo",
+		"pasta/":                "arquivo",
+		"..":                    "arquivo",
+		"orçamento 2024.csv":    "orçamento 2024.csv",
+	}
+	for nome, esperado := range casos {
+		if obtido := NomeAnexo(nome); obtido != esperado {
+			t.Errorf("NomeAnexo(%q) = %q, esperado %q", nome, obtido, esperado)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package dominio
+
+import (
+	"slices"
+	"time"
+)
+
+// Tipos de atividade na linha do tempo de uma tarefa
+const (
+	AtividadeCriada     = "criada"
+	AtividadeEstado     = "estado"
+	AtividadeRenomeada  = "renomeada"
+	AtividadeComentario = "comentario"
+)
+
+// Renomeacao registra a troca do título de uma tarefa
+type Renomeacao struct {
+	ID       string    `json:"id"`
+	TarefaID string    `json:"tarefa_id"`
+	De       string    `json:"de"`
+	Para     string    `json:"para"`
+	Em       time.Time `json:"em"`
+	Dono     string    `json:"dono,omitempty"`
+}
+
+// Atividade é um item da linha do tempo de uma tarefa: um evento do sistema
+// ou um comentário. De e Para trazem os estados, nas mudanças de estado, ou
+// os títulos, nas renomeações; na criação, Para é o estado inicial.
+type Atividade struct {
+	Tipo       string      `json:"tipo"`
+	Em         time.Time   `json:"em"`
+	De         string      `json:"de,omitempty"`
+	Para       string      `json:"para,omitempty"`
+	Comentario *Comentario `json:"comentario,omitempty"`
+}
+
+// LinhaDoTempo intercala as mudanças de estado, as renomeações e os
+// comentários de uma tarefa, do mais antigo para o mais recente. Eventos
+// no mesmo instante mantêm essa ordem, com a criação sempre primeiro.
+func LinhaDoTempo(estados []MudancaEstado, renomeacoes []Renomeacao, comentarios []Comentario) []Atividade {
+	atividades := []Atividade{}
+	for _, m := range estados {
+		tipo := AtividadeEstado
+		if m.De == "" {
+			tipo = AtividadeCriada
+		}
+		atividades = append(atividades, Atividade{Tipo: tipo, Em: m.Em, De: m.De, Para: m.Para})
+	}
+	for _, r := range renomeacoes {
+		atividades = append(atividades, Atividade{Tipo: AtividadeRenomeada, Em: r.Em, De: r.De, Para: r.Para})
+	}
+	for i := range comentarios {
+		c := &comentarios[i]
+		atividades = append(atividades, Atividade{Tipo: AtividadeComentario, Em: c.CriadoEm, Comentario: c})
+	}
+	slices.SortStableFunc(atividades, func(a, b Atividade) int {
+		return a.Em.Compare(b.Em)
+	})
+	return atividades
+}
@@ -0,0 +1,36 @@
+package dominio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinhaDoTempo(t *testing.T) {
+	inicio := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	em := func(minutos int) time.Time { return inicio.Add(time.Duration(minutos) * time.Minute) }
+
+	atividades := LinhaDoTempo(
+		[]MudancaEstado{{De: "", Para: "backlog", Em: em(0)}, {De: "backlog", Para: "revisao", Em: em(30)}},
+		[]Renomeacao{{De: "Login", Para: "Login social", Em: em(10)}},
+		[]Comentario{{Texto: "Começando", CriadoEm: em(0)}, {Texto: "Pronto para revisão", CriadoEm: em(30)}},
+	)
+
+	esperado := []string{AtividadeCriada, AtividadeComentario, AtividadeRenomeada, AtividadeEstado, AtividadeComentario}
+	if len(atividades) != len(esperado) {
+		t.Fatalf("obtidas %d atividades, esperadas %d: %+v", len(atividades), len(esperado), atividades)
+	}
+	for i, tipo := range esperado {
+		if atividades[i].Tipo != tipo {
+			t.Errorf("atividade %d: obtido %q, esperado %q", i, atividades[i].Tipo, tipo)
+		}
+	}
+	if c := atividades[1].Comentario; c == nil || c.Texto != "Começando" {
+		t.Errorf("primeiro comentário da linha do tempo: %+v", c)
+	}
+	if c := atividades[4].Comentario; c == nil || c.Texto != "Pronto para revisão" {
+		t.Errorf("comentário da linha do tempo: %+v", c)
+	}
+	if atividades[2].De != "Login" || atividades[2].Para != "Login social" {
+		t.Errorf("renomeação na linha do tempo: %+v", atividades[2])
+	}
+}
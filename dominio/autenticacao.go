@@ -0,0 +1,57 @@
+package dominio
+
+import "time"
+
+// Escopos de acesso concedidos a tokens e chaves de API
+const (
+	EscopoTarefasLeitura = "tarefas:read"
+	EscopoTarefasEscrita = "tarefas:write"
+)
+
+// EscoposValidos lista todos os escopos existentes
+var EscoposValidos = []string{EscopoTarefasLeitura, EscopoTarefasEscrita}
+
+// Códigos de erro de autenticação, enviados no campo code das respostas de erro
+const (
+	CodigoNaoAutenticado       = "nao_autenticado"
+	CodigoCredenciaisInvalidas = "credenciais_invalidas"
+	CodigoAcessoNegado         = "acesso_negado"
+	CodigoChaveNaoEncontrada   = "chave_nao_encontrada"
+)
+
+// Login é o corpo de POST /api/auth/login
+type Login struct {
+	Usuario string `json:"usuario"`
+	Senha   string `json:"senha"`
+}
+
+// Sessao é a resposta de um login bem-sucedido. O token deve ser enviado no
+// cabeçalho Authorization: Bearer até expirar.
+type Sessao struct {
+	Token    string    `json:"token"`
+	Tipo     string    `json:"tipo"`
+	ExpiraEm time.Time `json:"expira_em"`
+	Usuario  string    `json:"usuario"`
+	Escopos  []string  `json:"escopos"`
+}
+
+// ChaveAPI é uma credencial de longa duração com escopos limitados. O
+// segredo em Chave só é retornado na criação; depois disso a API guarda
+// apenas seu hash.
+type ChaveAPI struct {
+	ID       string    `json:"id"`
+	Nome     string    `json:"nome"`
+	Escopos  []string  `json:"escopos"`
+	CriadaEm time.Time `json:"criada_em"`
+	Chave    string    `json:"chave,omitempty"`
+}
+
+// EscopoValido informa se o escopo existe
+func EscopoValido(escopo string) bool {
+	for _, e := range EscoposValidos {
+		if e == escopo {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,653 @@
+// Package cliente implementa um cliente Go tipado para a API de tarefas.
+package cliente
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// API descreve as operações da API de tarefas. É implementada por Cliente,
+// que fala HTTP, e por Falso, que guarda as tarefas em memória para testes.
+// As operações de tarefas usam a credencial guardada no contexto com
+// ComCredencial.
+type API interface {
+	// Entrar troca usuário e senha por uma sessão com token de acesso
+	Entrar(ctx context.Context, usuario, senha string) (dominio.Sessao, error)
+	// Listar retorna uma página de tarefas conforme a consulta
+	Listar(ctx context.Context, c Consulta) (dominio.PaginaTarefas, error)
+	// Buscar retorna a tarefa com o ID informado
+	Buscar(ctx context.Context, id string) (dominio.Tarefa, error)
+	// Criar cria uma tarefa; ID, versão e datas são definidos pelo servidor
+	Criar(ctx context.Context, t dominio.Tarefa) (dominio.Tarefa, error)
+	// Atualizar substitui a tarefa com o ID informado (PUT), se ela ainda
+	// estiver em t.Versao; senão retorna ErrConflito. QualquerVersao
+	// sobrescreve qualquer versão e zero retorna ErrVersaoAusente.
+	Atualizar(ctx context.Context, id string, t dominio.Tarefa) (dominio.Tarefa, error)
+	// Alterar modifica apenas os campos preenchidos em a (PATCH), se a tarefa
+	// ainda estiver em a.Versao (veja Atualizar)
+	Alterar(ctx context.Context, id string, a Alteracao) (dominio.Tarefa, error)
+	// Remover move para a lixeira a tarefa com o ID informado, se ela ainda
+	// estiver na versão informada (veja Atualizar); suas subtarefas passam
+	// para o pai dela
+	Remover(ctx context.Context, id string, versao int) error
+	// RemoverEmCascata é Remover levando para a lixeira todas as subtarefas
+	// junto com a tarefa
+	RemoverEmCascata(ctx context.Context, id string, versao int) error
+	// Lixeira retorna as tarefas removidas, das mais recentes para as mais
+	// antigas, cada uma com as subtarefas removidas junto com ela
+	Lixeira(ctx context.Context) ([]dominio.ItemLixeira, error)
+	// Restaurar tira da lixeira a tarefa e as subtarefas removidas junto com
+	// ela, retirando as referências a recursos que deixaram de existir
+	Restaurar(ctx context.Context, id string) (dominio.Tarefa, error)
+	// Purgar exclui de vez da lixeira a tarefa e as subtarefas removidas
+	// junto com ela
+	Purgar(ctx context.Context, id string) error
+	// HistoricoEstados retorna as mudanças de estado da tarefa, da mais
+	// antiga para a mais recente
+	HistoricoEstados(ctx context.Context, id string) ([]dominio.MudancaEstado, error)
+	// Revisoes retorna as revisões da tarefa, da mais antiga para a mais
+	// recente, com a diferença de cada campo alterado
+	Revisoes(ctx context.Context, id string) ([]dominio.Revisao, error)
+	// Reverter devolve a tarefa ao que era na revisão informada, gravando
+	// uma nova revisão, se ela ainda estiver na versão informada (veja
+	// Atualizar)
+	Reverter(ctx context.Context, id string, revisao, versao int) (dominio.Tarefa, error)
+	// Atividade retorna a linha do tempo da tarefa: comentários intercalados
+	// com a criação, as mudanças de estado e as renomeações
+	Atividade(ctx context.Context, id string) ([]dominio.Atividade, error)
+	// Comentar adiciona à tarefa um comentário com o texto em Markdown
+	Comentar(ctx context.Context, tarefaID, texto string) (dominio.Comentario, error)
+	// EditarComentario troca o texto do comentário, que passa a ser marcado
+	// como editado
+	EditarComentario(ctx context.Context, tarefaID, id, texto string) (dominio.Comentario, error)
+	// RemoverComentario exclui o comentário da tarefa
+	RemoverComentario(ctx context.Context, tarefaID, id string) error
+	// Anexos retorna os anexos da tarefa na ordem de envio
+	Anexos(ctx context.Context, tarefaID string) ([]dominio.Anexo, error)
+	// Anexar envia o arquivo para a tarefa; tipo vazio deixa a API detectar
+	// o tipo pelo conteúdo
+	Anexar(ctx context.Context, tarefaID, nome, tipo string, conteudo []byte) (dominio.Anexo, error)
+	// BaixarAnexo retorna o anexo com o seu conteúdo
+	BaixarAnexo(ctx context.Context, tarefaID, id string) (dominio.Anexo, []byte, error)
+	// RemoverAnexo exclui o anexo da tarefa
+	RemoverAnexo(ctx context.Context, tarefaID, id string) error
+
+	// ListarProjetos retorna os projetos com a contagem de suas tarefas
+	ListarProjetos(ctx context.Context) ([]dominio.Projeto, error)
+	// BuscarProjeto retorna o projeto com o ID informado
+	BuscarProjeto(ctx context.Context, id string) (dominio.Projeto, error)
+	// CriarProjeto cria um projeto com o nome informado
+	CriarProjeto(ctx context.Context, nome string) (dominio.Projeto, error)
+	// RenomearProjeto troca o nome do projeto com o ID informado (PUT)
+	RenomearProjeto(ctx context.Context, id, nome string) (dominio.Projeto, error)
+	// DefinirFluxo troca o fluxo de trabalho do projeto; nil volta ao fluxo
+	// padrão. As tarefas em estados removidos passam ao estado equivalente.
+	DefinirFluxo(ctx context.Context, id string, fluxo *dominio.Fluxo) (dominio.Projeto, error)
+	// RemoverProjeto exclui o projeto; suas tarefas ficam sem projeto
+	RemoverProjeto(ctx context.Context, id string) error
+
+	// ListarEtiquetas retorna as etiquetas na ordem de criação
+	ListarEtiquetas(ctx context.Context) ([]dominio.Etiqueta, error)
+	// BuscarEtiqueta retorna a etiqueta com o ID informado
+	BuscarEtiqueta(ctx context.Context, id string) (dominio.Etiqueta, error)
+	// CriarEtiqueta cria uma etiqueta; cor vazia usa a cor padrão
+	CriarEtiqueta(ctx context.Context, nome, cor string) (dominio.Etiqueta, error)
+	// AlterarEtiqueta renomeia ou troca a cor da etiqueta (PATCH)
+	AlterarEtiqueta(ctx context.Context, id string, a AlteracaoEtiqueta) (dominio.Etiqueta, error)
+	// RemoverEtiqueta exclui a etiqueta e a retira das tarefas
+	RemoverEtiqueta(ctx context.Context, id string) error
+}
+
+// chaveCredencial é a chave da credencial no contexto
+type chaveCredencial struct{}
+
+// ComCredencial retorna um contexto cujas chamadas à API se autenticam com a
+// credencial informada: um token de Entrar ou uma chave de API
+func ComCredencial(ctx context.Context, credencial string) context.Context {
+	return context.WithValue(ctx, chaveCredencial{}, credencial)
+}
+
+// credencialDe retorna a credencial guardada no contexto, ou vazio
+func credencialDe(ctx context.Context) string {
+	credencial, _ := ctx.Value(chaveCredencial{}).(string)
+	return credencial
+}
+
+// Consulta reúne os filtros, a ordenação e a paginação de Listar.
+// Campos com valor zero não são enviados. Com ModoEtiquetas vazio ou
+// "todas", as tarefas precisam ter todas as Etiquetas; com "alguma", basta
+// uma delas. Pais restringe a listagem às subtarefas diretas dessas
+// tarefas, e Raiz às tarefas sem pai. Acionaveis deixa apenas as tarefas
+// pendentes sem dependências pendentes.
+type Consulta struct {
+	Concluida     *bool
+	Prioridade    dominio.Prioridade
+	Projeto       string
+	Etiquetas     []string
+	ModoEtiquetas string
+	Pais          []string
+	Raiz          bool
+	Acionaveis    bool
+	Texto         string
+	Ordem         string
+	Limite        int
+	Cursor        string
+}
+
+// valores converte a consulta nos parâmetros de URL da API
+func (c Consulta) valores() url.Values {
+	v := url.Values{}
+	if c.Concluida != nil {
+		v.Set("concluida", strconv.FormatBool(*c.Concluida))
+	}
+	if c.Prioridade != "" {
+		v.Set("prioridade", string(c.Prioridade))
+	}
+	if c.Projeto != "" {
+		v.Set("projeto", c.Projeto)
+	}
+	if len(c.Etiquetas) > 0 {
+		v.Set("etiquetas", strings.Join(c.Etiquetas, ","))
+	}
+	if c.ModoEtiquetas != "" {
+		v.Set("modo_etiquetas", c.ModoEtiquetas)
+	}
+	if len(c.Pais) > 0 {
+		v.Set("pai", strings.Join(c.Pais, ","))
+	}
+	if c.Raiz {
+		v.Set("raiz", "true")
+	}
+	if c.Acionaveis {
+		v.Set("acionaveis", "true")
+	}
+	if c.Texto != "" {
+		v.Set("q", c.Texto)
+	}
+	if c.Ordem != "" {
+		v.Set("sort", c.Ordem)
+	}
+	if c.Limite > 0 {
+		v.Set("limit", strconv.Itoa(c.Limite))
+	}
+	if c.Cursor != "" {
+		v.Set("cursor", c.Cursor)
+	}
+	return v
+}
+
+// Alteracao representa o corpo de um PATCH; campos nil não são enviados.
+// ProjetoID apontando para "" tira a tarefa do projeto, Etiquetas
+// substitui todas as etiquetas da tarefa, PaiID apontando para "" torna a
+// tarefa independente, Recorrencia apontando para "" encerra a repetição e
+// BloqueadaPor substitui todas as dependências da tarefa.
+//
+// Versao é a versão da tarefa sobre a qual a alteração foi feita, enviada
+// em If-Match; se a tarefa mudou desde então, Alterar retorna ErrConflito.
+// QualquerVersao aplica a alteração sobre qualquer versão; zero não é
+// enviado e resulta em ErrVersaoAusente.
+type Alteracao struct {
+	Versao                int                 `json:"-"`
+	Titulo                *string             `json:"titulo,omitempty"`
+	Concluida             *bool               `json:"concluida,omitempty"`
+	Estado                *string             `json:"estado,omitempty"`
+	Descricao             *string             `json:"descricao,omitempty"`
+	Prioridade            *dominio.Prioridade `json:"prioridade,omitempty"`
+	Prazo                 *time.Time          `json:"prazo,omitempty"`
+	Recorrencia           *string             `json:"recorrencia,omitempty"`
+	FusoHorario           *string             `json:"fuso_horario,omitempty"`
+	ProjetoID             *string             `json:"projeto_id,omitempty"`
+	Etiquetas             *[]string           `json:"etiquetas,omitempty"`
+	PaiID                 *string             `json:"pai_id,omitempty"`
+	ConcluirComSubtarefas *bool               `json:"concluir_com_subtarefas,omitempty"`
+	BloqueadaPor          *[]string           `json:"bloqueada_por,omitempty"`
+}
+
+// Aplicar copia os campos preenchidos para a tarefa
+func (a Alteracao) Aplicar(t *dominio.Tarefa) {
+	if a.Titulo != nil {
+		t.Titulo = *a.Titulo
+	}
+	if a.Concluida != nil {
+		t.Concluida = *a.Concluida
+	}
+	if a.Estado != nil {
+		t.Estado = *a.Estado
+	}
+	if a.Descricao != nil {
+		t.Descricao = *a.Descricao
+	}
+	if a.Prioridade != nil {
+		t.Prioridade = *a.Prioridade
+	}
+	if a.Prazo != nil {
+		t.Prazo = a.Prazo
+	}
+	if a.Recorrencia != nil {
+		t.Recorrencia = *a.Recorrencia
+	}
+	if a.FusoHorario != nil {
+		t.FusoHorario = *a.FusoHorario
+	}
+	if a.ProjetoID != nil {
+		t.ProjetoID = *a.ProjetoID
+	}
+	if a.Etiquetas != nil {
+		t.Etiquetas = append([]string(nil), *a.Etiquetas...)
+	}
+	if a.PaiID != nil {
+		t.PaiID = *a.PaiID
+	}
+	if a.ConcluirComSubtarefas != nil {
+		t.ConcluirComSubtarefas = *a.ConcluirComSubtarefas
+	}
+	if a.BloqueadaPor != nil {
+		t.BloqueadaPor = append([]string(nil), *a.BloqueadaPor...)
+	}
+}
+
+// AlteracaoEtiqueta representa o corpo do PATCH de uma etiqueta; campos nil
+// não são enviados
+type AlteracaoEtiqueta struct {
+	Nome *string `json:"nome,omitempty"`
+	Cor  *string `json:"cor,omitempty"`
+}
+
+// aplicar copia os campos preenchidos para a etiqueta
+func (a AlteracaoEtiqueta) aplicar(e *dominio.Etiqueta) {
+	if a.Nome != nil {
+		e.Nome = *a.Nome
+	}
+	if a.Cor != nil {
+		e.Cor = *a.Cor
+	}
+}
+
+// Cliente acessa a API de tarefas por HTTP
+type Cliente struct {
+	baseURL string
+	http    *http.Client
+}
+
+// Novo cria um cliente para a API no endereço informado (ex.: http://localhost:8080).
+// Se httpClient for nil, é usado um cliente com tempo limite de 10 segundos.
+func Novo(baseURL string, httpClient *http.Client) *Cliente {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Cliente{baseURL: strings.TrimSuffix(baseURL, "/"), http: httpClient}
+}
+
+func (c *Cliente) Entrar(ctx context.Context, usuario, senha string) (dominio.Sessao, error) {
+	var sessao dominio.Sessao
+	err := c.fazer(ctx, http.MethodPost, "/api/auth/login", dominio.Login{Usuario: usuario, Senha: senha}, &sessao)
+	return sessao, err
+}
+
+func (c *Cliente) Listar(ctx context.Context, consulta Consulta) (dominio.PaginaTarefas, error) {
+	var pagina dominio.PaginaTarefas
+	caminho := "/api/tarefas"
+	if q := consulta.valores().Encode(); q != "" {
+		caminho += "?" + q
+	}
+	err := c.fazer(ctx, http.MethodGet, caminho, nil, &pagina)
+	return pagina, err
+}
+
+func (c *Cliente) Buscar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	var t dominio.Tarefa
+	err := c.fazer(ctx, http.MethodGet, caminhoTarefa(id), nil, &t)
+	return t, err
+}
+
+func (c *Cliente) Criar(ctx context.Context, t dominio.Tarefa) (dominio.Tarefa, error) {
+	var criada dominio.Tarefa
+	err := c.fazer(ctx, http.MethodPost, "/api/tarefas", t, &criada)
+	return criada, err
+}
+
+func (c *Cliente) Atualizar(ctx context.Context, id string, t dominio.Tarefa) (dominio.Tarefa, error) {
+	var atualizada dominio.Tarefa
+	err := c.fazerCondicional(ctx, http.MethodPut, caminhoTarefa(id), t.Versao, t, &atualizada)
+	return atualizada, err
+}
+
+func (c *Cliente) Alterar(ctx context.Context, id string, a Alteracao) (dominio.Tarefa, error) {
+	var alterada dominio.Tarefa
+	err := c.fazerCondicional(ctx, http.MethodPatch, caminhoTarefa(id), a.Versao, a, &alterada)
+	return alterada, err
+}
+
+func (c *Cliente) Remover(ctx context.Context, id string, versao int) error {
+	return c.fazerCondicional(ctx, http.MethodDelete, caminhoTarefa(id), versao, nil, nil)
+}
+
+func (c *Cliente) RemoverEmCascata(ctx context.Context, id string, versao int) error {
+	caminho := caminhoTarefa(id) + "?subtarefas=" + dominio.SubtarefasRemover
+	return c.fazerCondicional(ctx, http.MethodDelete, caminho, versao, nil, nil)
+}
+
+func (c *Cliente) Lixeira(ctx context.Context) ([]dominio.ItemLixeira, error) {
+	var itens []dominio.ItemLixeira
+	err := c.fazer(ctx, http.MethodGet, "/api/lixeira", nil, &itens)
+	return itens, err
+}
+
+func (c *Cliente) Restaurar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	var restaurada dominio.Tarefa
+	err := c.fazer(ctx, http.MethodPost, caminhoLixeira(id)+"/restaurar", nil, &restaurada)
+	return restaurada, err
+}
+
+func (c *Cliente) Purgar(ctx context.Context, id string) error {
+	return c.fazer(ctx, http.MethodDelete, caminhoLixeira(id), nil, nil)
+}
+
+func (c *Cliente) HistoricoEstados(ctx context.Context, id string) ([]dominio.MudancaEstado, error) {
+	var mudancas []dominio.MudancaEstado
+	err := c.fazer(ctx, http.MethodGet, caminhoTarefa(id)+"/historico/estados", nil, &mudancas)
+	return mudancas, err
+}
+
+func (c *Cliente) Revisoes(ctx context.Context, id string) ([]dominio.Revisao, error) {
+	var revisoes []dominio.Revisao
+	err := c.fazer(ctx, http.MethodGet, caminhoTarefa(id)+"/historico", nil, &revisoes)
+	return revisoes, err
+}
+
+func (c *Cliente) Reverter(ctx context.Context, id string, revisao, versao int) (dominio.Tarefa, error) {
+	var revertida dominio.Tarefa
+	caminho := caminhoTarefa(id) + "/reverter?revisao=" + strconv.Itoa(revisao)
+	err := c.fazerCondicional(ctx, http.MethodPost, caminho, versao, nil, &revertida)
+	return revertida, err
+}
+
+func (c *Cliente) Atividade(ctx context.Context, id string) ([]dominio.Atividade, error) {
+	var atividades []dominio.Atividade
+	err := c.fazer(ctx, http.MethodGet, caminhoTarefa(id)+"/atividade", nil, &atividades)
+	return atividades, err
+}
+
+// corpoComentario é o corpo de POST e PATCH de um comentário
+type corpoComentario struct {
+	Texto string `json:"texto"`
+}
+
+func (c *Cliente) Comentar(ctx context.Context, tarefaID, texto string) (dominio.Comentario, error) {
+	var criado dominio.Comentario
+	err := c.fazer(ctx, http.MethodPost, caminhoTarefa(tarefaID)+"/comentarios", corpoComentario{texto}, &criado)
+	return criado, err
+}
+
+func (c *Cliente) EditarComentario(ctx context.Context, tarefaID, id, texto string) (dominio.Comentario, error) {
+	var editado dominio.Comentario
+	err := c.fazer(ctx, http.MethodPatch, caminhoComentario(tarefaID, id), corpoComentario{texto}, &editado)
+	return editado, err
+}
+
+func (c *Cliente) RemoverComentario(ctx context.Context, tarefaID, id string) error {
+	return c.fazer(ctx, http.MethodDelete, caminhoComentario(tarefaID, id), nil, nil)
+}
+
+func (c *Cliente) Anexos(ctx context.Context, tarefaID string) ([]dominio.Anexo, error) {
+	var anexos []dominio.Anexo
+	err := c.fazer(ctx, http.MethodGet, caminhoTarefa(tarefaID)+"/anexos", nil, &anexos)
+	return anexos, err
+}
+
+func (c *Cliente) Anexar(ctx context.Context, tarefaID, nome, tipo string, conteudo []byte) (dominio.Anexo, error) {
+	var corpo bytes.Buffer
+	escritor := multipart.NewWriter(&corpo)
+	cabecalho := textproto.MIMEHeader{}
+	cabecalho.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "arquivo", "filename": nome}))
+	if tipo != "" {
+		cabecalho.Set("Content-Type", tipo)
+	}
+	parte, err := escritor.CreatePart(cabecalho)
+	if err != nil {
+		return dominio.Anexo{}, err
+	}
+	parte.Write(conteudo)
+	if err := escritor.Close(); err != nil {
+		return dominio.Anexo{}, err
+	}
+
+	resp, err := c.enviar(ctx, http.MethodPost, caminhoTarefa(tarefaID)+"/anexos", &corpo, escritor.FormDataContentType(), "")
+	if err != nil {
+		return dominio.Anexo{}, err
+	}
+	defer resp.Body.Close()
+	var criado dominio.Anexo
+	if err := json.NewDecoder(resp.Body).Decode(&criado); err != nil {
+		return dominio.Anexo{}, fmt.Errorf("decodificar anexo enviado: %w", err)
+	}
+	return criado, nil
+}
+
+// BaixarAnexo lê o anexo dos cabeçalhos do download: o tipo do
+// Content-Type, o nome do Content-Disposition e o hash do ETag
+func (c *Cliente) BaixarAnexo(ctx context.Context, tarefaID, id string) (dominio.Anexo, []byte, error) {
+	resp, err := c.enviar(ctx, http.MethodGet, caminhoAnexo(tarefaID, id)+"/conteudo", nil, "", "")
+	if err != nil {
+		return dominio.Anexo{}, nil, err
+	}
+	defer resp.Body.Close()
+	conteudo, err := io.ReadAll(io.LimitReader(resp.Body, dominio.TamanhoMaximoAnexo+1))
+	if err != nil {
+		return dominio.Anexo{}, nil, err
+	}
+
+	a := dominio.Anexo{
+		ID:       id,
+		TarefaID: tarefaID,
+		Tipo:     resp.Header.Get("Content-Type"),
+		Tamanho:  int64(len(conteudo)),
+		Hash:     strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if _, parametros, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		a.Nome = parametros["filename"]
+	}
+	return a, conteudo, nil
+}
+
+func (c *Cliente) RemoverAnexo(ctx context.Context, tarefaID, id string) error {
+	return c.fazer(ctx, http.MethodDelete, caminhoAnexo(tarefaID, id), nil, nil)
+}
+
+func (c *Cliente) ListarProjetos(ctx context.Context) ([]dominio.Projeto, error) {
+	var projetos []dominio.Projeto
+	err := c.fazer(ctx, http.MethodGet, "/api/projetos", nil, &projetos)
+	return projetos, err
+}
+
+func (c *Cliente) BuscarProjeto(ctx context.Context, id string) (dominio.Projeto, error) {
+	var p dominio.Projeto
+	err := c.fazer(ctx, http.MethodGet, caminhoProjeto(id), nil, &p)
+	return p, err
+}
+
+func (c *Cliente) CriarProjeto(ctx context.Context, nome string) (dominio.Projeto, error) {
+	var criado dominio.Projeto
+	err := c.fazer(ctx, http.MethodPost, "/api/projetos", dominio.Projeto{Nome: nome}, &criado)
+	return criado, err
+}
+
+func (c *Cliente) RenomearProjeto(ctx context.Context, id, nome string) (dominio.Projeto, error) {
+	var renomeado dominio.Projeto
+	err := c.fazer(ctx, http.MethodPut, caminhoProjeto(id), dominio.Projeto{Nome: nome}, &renomeado)
+	return renomeado, err
+}
+
+func (c *Cliente) DefinirFluxo(ctx context.Context, id string, fluxo *dominio.Fluxo) (dominio.Projeto, error) {
+	// O PUT exige o nome, que é mantido; fluxo null volta ao fluxo padrão
+	p, err := c.BuscarProjeto(ctx, id)
+	if err != nil {
+		return dominio.Projeto{}, err
+	}
+	corpo := struct {
+		Nome  string         `json:"nome"`
+		Fluxo *dominio.Fluxo `json:"fluxo"`
+	}{p.Nome, fluxo}
+	var alterado dominio.Projeto
+	err = c.fazer(ctx, http.MethodPut, caminhoProjeto(id), corpo, &alterado)
+	return alterado, err
+}
+
+func (c *Cliente) RemoverProjeto(ctx context.Context, id string) error {
+	return c.fazer(ctx, http.MethodDelete, caminhoProjeto(id), nil, nil)
+}
+
+func (c *Cliente) ListarEtiquetas(ctx context.Context) ([]dominio.Etiqueta, error) {
+	var etiquetas []dominio.Etiqueta
+	err := c.fazer(ctx, http.MethodGet, "/api/etiquetas", nil, &etiquetas)
+	return etiquetas, err
+}
+
+func (c *Cliente) BuscarEtiqueta(ctx context.Context, id string) (dominio.Etiqueta, error) {
+	var e dominio.Etiqueta
+	err := c.fazer(ctx, http.MethodGet, caminhoEtiqueta(id), nil, &e)
+	return e, err
+}
+
+func (c *Cliente) CriarEtiqueta(ctx context.Context, nome, cor string) (dominio.Etiqueta, error) {
+	var criada dominio.Etiqueta
+	err := c.fazer(ctx, http.MethodPost, "/api/etiquetas", dominio.Etiqueta{Nome: nome, Cor: cor}, &criada)
+	return criada, err
+}
+
+func (c *Cliente) AlterarEtiqueta(ctx context.Context, id string, a AlteracaoEtiqueta) (dominio.Etiqueta, error) {
+	var alterada dominio.Etiqueta
+	err := c.fazer(ctx, http.MethodPatch, caminhoEtiqueta(id), a, &alterada)
+	return alterada, err
+}
+
+func (c *Cliente) RemoverEtiqueta(ctx context.Context, id string) error {
+	return c.fazer(ctx, http.MethodDelete, caminhoEtiqueta(id), nil, nil)
+}
+
+// caminhoTarefa monta o caminho de uma tarefa individual
+func caminhoTarefa(id string) string {
+	return "/api/tarefas/" + url.PathEscape(id)
+}
+
+// caminhoLixeira monta o caminho de um item da lixeira
+func caminhoLixeira(id string) string {
+	return "/api/lixeira/" + url.PathEscape(id)
+}
+
+// caminhoComentario monta o caminho de um comentário da tarefa
+func caminhoComentario(tarefaID, id string) string {
+	return caminhoTarefa(tarefaID) + "/comentarios/" + url.PathEscape(id)
+}
+
+// caminhoProjeto monta o caminho de um projeto individual
+func caminhoProjeto(id string) string {
+	return "/api/projetos/" + url.PathEscape(id)
+}
+
+// caminhoAnexo monta o caminho de um anexo da tarefa
+func caminhoAnexo(tarefaID, id string) string {
+	return caminhoTarefa(tarefaID) + "/anexos/" + url.PathEscape(id)
+}
+
+// caminhoEtiqueta monta o caminho de uma etiqueta individual
+func caminhoEtiqueta(id string) string {
+	return "/api/etiquetas/" + url.PathEscape(id)
+}
+
+// fazer envia a requisição com corpo JSON opcional e decodifica a resposta
+// em resposta, quando não for nil. Respostas 4xx e 5xx viram *ErroAPI.
+func (c *Cliente) fazer(ctx context.Context, metodo, caminho string, corpo, resposta any) error {
+	return c.fazerRequisicao(ctx, metodo, caminho, "", corpo, resposta)
+}
+
+// QualquerVersao, passada como versão a Atualizar, Alterar ou Remover, aplica
+// a escrita sobre qualquer versão da tarefa, com If-Match: *. Serve para
+// escritas que não partem de uma leitura, como scripts de manutenção.
+const QualquerVersao = -1
+
+// fazerCondicional é fazer para as escritas em uma tarefa, que levam em
+// If-Match o ETag da versão informada, ou * com QualquerVersao
+func (c *Cliente) fazerCondicional(ctx context.Context, metodo, caminho string, versao int, corpo, resposta any) error {
+	var ifMatch string
+	switch {
+	case versao == QualquerVersao:
+		ifMatch = "*"
+	case versao > 0:
+		ifMatch = dominio.ETagVersao(versao)
+	default:
+		return ErrVersaoAusente
+	}
+	return c.fazerRequisicao(ctx, metodo, caminho, ifMatch, corpo, resposta)
+}
+
+// fazerRequisicao implementa fazer, enviando ifMatch, se não for vazio, no
+// cabeçalho If-Match
+func (c *Cliente) fazerRequisicao(ctx context.Context, metodo, caminho, ifMatch string, corpo, resposta any) error {
+	var leitor io.Reader
+	tipo := ""
+	if corpo != nil {
+		b, err := json.Marshal(corpo)
+		if err != nil {
+			return err
+		}
+		leitor, tipo = bytes.NewReader(b), "application/json"
+	}
+
+	resp, err := c.enviar(ctx, metodo, caminho, leitor, tipo, ifMatch)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resposta == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(resposta); err != nil {
+		return fmt.Errorf("decodificar resposta de %s %s: %w", metodo, caminho, err)
+	}
+	return nil
+}
+
+// enviar faz a requisição com o corpo do tipo informado e o If-Match, se
+// houver, e retorna a resposta, que o chamador deve fechar. Respostas 4xx e
+// 5xx viram *ErroAPI.
+func (c *Cliente) enviar(ctx context.Context, metodo, caminho string, corpo io.Reader, tipo, ifMatch string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, metodo, c.baseURL+caminho, corpo)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if tipo != "" {
+		req.Header.Set("Content-Type", tipo)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	if credencial := credencialDe(ctx); credencial != "" {
+		req.Header.Set("Authorization", "Bearer "+credencial)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, lerErroAPI(resp)
+	}
+	return resp, nil
+}
@@ -0,0 +1,796 @@
+package cliente
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// Ambas as implementações devem satisfazer a interface
+var (
+	_ API = (*Cliente)(nil)
+	_ API = (*Falso)(nil)
+)
+
+// requisicaoRecebida guarda o que o servidor de teste recebeu
+type requisicaoRecebida struct {
+	metodo      string
+	url         string
+	corpo       string
+	autorizacao string
+	ifMatch     string
+}
+
+// servidorTeste responde sempre com o status e o corpo informados
+func servidorTeste(t *testing.T, status int, resposta string) (*Cliente, *requisicaoRecebida) {
+	t.Helper()
+	recebida := &requisicaoRecebida{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		corpo, _ := io.ReadAll(r.Body)
+		*recebida = requisicaoRecebida{r.Method, r.URL.String(), string(corpo), r.Header.Get("Authorization"), r.Header.Get("If-Match")}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, resposta)
+	}))
+	t.Cleanup(srv.Close)
+	return Novo(srv.URL+"/", nil), recebida
+}
+
+func TestClienteListar(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK,
+		`{"tarefas":[{"id":"1","titulo":"Aprender Go"}],"limit":10,"next_cursor":"abc"}`)
+
+	pendentes := false
+	pagina, err := c.Listar(context.Background(), Consulta{Concluida: &pendentes, Ordem: "-criada_em", Limite: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "GET" || recebida.url != "/api/tarefas?concluida=false&limit=10&sort=-criada_em" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	if len(pagina.Tarefas) != 1 || pagina.Tarefas[0].Titulo != "Aprender Go" || pagina.NextCursor != "abc" {
+		t.Errorf("página inesperada: %+v", pagina)
+	}
+}
+
+func TestClienteEntrarEnviaCredencial(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK,
+		`{"token":"abc.def.ghi","tipo":"Bearer","expira_em":"2024-06-01T18:00:00Z","usuario":"ana","escopos":["tarefas:read"]}`)
+
+	sessao, err := c.Entrar(context.Background(), "ana", "segredo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "POST" || recebida.url != "/api/auth/login" || recebida.corpo != `{"usuario":"ana","senha":"segredo"}` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	if sessao.Token != "abc.def.ghi" || sessao.Usuario != "ana" {
+		t.Errorf("sessão inesperada: %+v", sessao)
+	}
+
+	// Sem credencial no contexto não há Authorization; com ela, vai como Bearer
+	if recebida.autorizacao != "" {
+		t.Errorf("login enviou Authorization %q", recebida.autorizacao)
+	}
+	c.Buscar(ComCredencial(context.Background(), sessao.Token), "1")
+	if recebida.autorizacao != "Bearer abc.def.ghi" {
+		t.Errorf("Authorization: obtido %q", recebida.autorizacao)
+	}
+}
+
+func TestClienteAlterar(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `{"id":"7","titulo":"Deploy","concluida":true,"versao":2}`)
+
+	concluida := true
+	tarefa, err := c.Alterar(context.Background(), "7", Alteracao{Concluida: &concluida, Versao: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "PATCH" || recebida.url != "/api/tarefas/7" || recebida.corpo != `{"concluida":true}` || recebida.ifMatch != `"1"` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	if !tarefa.Concluida || tarefa.Versao != 2 {
+		t.Errorf("tarefa inesperada: %+v", tarefa)
+	}
+}
+
+func TestClienteRemover(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusNoContent, "")
+	if err := c.Remover(context.Background(), "7", 3); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "DELETE" || recebida.url != "/api/tarefas/7" || recebida.ifMatch != `"3"` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	// QualquerVersao remove em qualquer versão; sem versão, nada é enviado
+	c.Remover(context.Background(), "7", QualquerVersao)
+	if recebida.ifMatch != "*" {
+		t.Errorf("If-Match com QualquerVersao: %q", recebida.ifMatch)
+	}
+	*recebida = requisicaoRecebida{}
+	if err := c.Remover(context.Background(), "7", 0); !errors.Is(err, ErrVersaoAusente) || recebida.metodo != "" {
+		t.Errorf("Remover sem versão: %v, requisição %+v", err, recebida)
+	}
+	if _, err := c.Alterar(context.Background(), "7", Alteracao{}); !errors.Is(err, ErrVersaoAusente) || recebida.metodo != "" {
+		t.Errorf("Alterar sem versão: %v, requisição %+v", err, recebida)
+	}
+}
+
+func TestClienteErros(t *testing.T) {
+	casos := []struct {
+		status     int
+		sentinela  error
+		doServidor bool
+	}{
+		{http.StatusBadRequest, ErrRequisicaoInvalida, false},
+		{http.StatusNotFound, ErrNaoEncontrada, false},
+		{http.StatusConflict, ErrConflito, false},
+		{http.StatusPreconditionFailed, ErrConflito, false},
+		{http.StatusUnauthorized, ErrNaoAutenticado, false},
+		{http.StatusForbidden, ErrAcessoNegado, false},
+		{http.StatusInternalServerError, nil, true},
+	}
+	for _, caso := range casos {
+		c, _ := servidorTeste(t, caso.status, `{"status":`+strconv.Itoa(caso.status)+`,"code":"teste",`+
+			`"messages":{"pt-BR":"falhou","en":"failed"},"errors":[{"field":"titulo","code":"obrigatorio"}],"request_id":"r1"}`)
+		_, err := c.Buscar(context.Background(), "1")
+
+		var erroAPI *ErroAPI
+		if !errors.As(err, &erroAPI) {
+			t.Fatalf("status %d: esperado *ErroAPI, obtido %v", caso.status, err)
+		}
+		if erroAPI.Status != caso.status || erroAPI.Codigo != "teste" || erroAPI.Mensagem != "falhou" ||
+			erroAPI.Mensagens.En != "failed" || erroAPI.RequestID != "r1" || len(erroAPI.Campos) != 1 {
+			t.Errorf("status %d: erro inesperado %+v", caso.status, erroAPI)
+		}
+		if caso.sentinela != nil && !errors.Is(err, caso.sentinela) {
+			t.Errorf("status %d: esperado errors.Is(%v)", caso.status, caso.sentinela)
+		}
+		if erroAPI.DoServidor() != caso.doServidor || erroAPI.DoCliente() == caso.doServidor {
+			t.Errorf("status %d: classificação 4xx/5xx incorreta", caso.status)
+		}
+	}
+}
+
+func TestClienteRespeitaContexto(t *testing.T) {
+	c, _ := servidorTeste(t, http.StatusOK, `{}`)
+	ctx, cancelar := context.WithCancel(context.Background())
+	cancelar()
+	if _, err := c.Buscar(ctx, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("esperado context.Canceled, obtido %v", err)
+	}
+}
+
+func TestFalso(t *testing.T) {
+	ctx := context.Background()
+	f := NovoFalso(dominio.Tarefa{Titulo: "Existente"})
+
+	criada, err := f.Criar(ctx, dominio.Tarefa{Titulo: "Nova"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if criada.ID == "" || criada.Versao != 1 || criada.Prioridade != dominio.PrioridadeMedia {
+		t.Errorf("tarefa criada inesperada: %+v", criada)
+	}
+	if _, err := f.Criar(ctx, dominio.Tarefa{}); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("Criar sem título: esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+
+	titulo := "Renomeada"
+	alterada, err := f.Alterar(ctx, criada.ID, Alteracao{Titulo: &titulo, Versao: criada.Versao})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alterada.Titulo != titulo || alterada.Versao != 2 {
+		t.Errorf("tarefa alterada inesperada: %+v", alterada)
+	}
+
+	// Paginação com cursor opaco
+	pagina, err := f.Listar(ctx, Consulta{Limite: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagina2, err := f.Listar(ctx, Consulta{Limite: 1, Cursor: pagina.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina.Tarefas) != 1 || len(pagina2.Tarefas) != 1 || pagina2.NextCursor != "" ||
+		pagina2.Tarefas[0].ID != criada.ID {
+		t.Errorf("páginas inesperadas: %+v %+v", pagina, pagina2)
+	}
+	if _, err := f.Listar(ctx, Consulta{Cursor: "1"}); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("cursor inválido: esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+
+	if err := f.Remover(ctx, criada.ID, QualquerVersao); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Buscar(ctx, criada.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("Buscar após Remover: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+
+	// Erro injetado
+	f.Erro = errors.New("fora do ar")
+	if _, err := f.Listar(ctx, Consulta{}); err != f.Erro {
+		t.Errorf("esperado erro injetado, obtido %v", err)
+	}
+}
+
+func TestFalsoExigeLogin(t *testing.T) {
+	f := NovoFalso(dominio.Tarefa{Titulo: "Da Ana", Dono: "ana"}, dominio.Tarefa{Titulo: "Do Bruno", Dono: "bruno"})
+	f.Usuarios = map[string]string{"ana": "segredo", "bruno": "outra"}
+
+	if _, err := f.Listar(context.Background(), Consulta{}); !errors.Is(err, ErrNaoAutenticado) {
+		t.Errorf("Listar sem credencial: esperado ErrNaoAutenticado, obtido %v", err)
+	}
+	if _, err := f.Entrar(context.Background(), "ana", "errada"); !errors.Is(err, ErrNaoAutenticado) {
+		t.Errorf("Entrar com senha errada: esperado ErrNaoAutenticado, obtido %v", err)
+	}
+
+	sessao, err := f.Entrar(context.Background(), "ana", "segredo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ComCredencial(context.Background(), sessao.Token)
+	pagina, err := f.Listar(ctx, Consulta{})
+	if err != nil || len(pagina.Tarefas) != 1 || pagina.Tarefas[0].Titulo != "Da Ana" {
+		t.Fatalf("Listar com credencial: %v %+v", err, pagina)
+	}
+
+	// Tarefas criadas pertencem ao usuário do token; as alheias não existem
+	criada, err := f.Criar(ctx, dominio.Tarefa{Titulo: "Nova"})
+	if err != nil || criada.Dono != "ana" {
+		t.Errorf("Criar: %v %+v", err, criada)
+	}
+	if err := f.Remover(ctx, "2", QualquerVersao); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("Remover tarefa alheia: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+}
+
+func TestClienteRenomearProjeto(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `{"id":"p/1","nome":"Lar","total_tarefas":2,"tarefas_pendentes":1}`)
+
+	p, err := c.RenomearProjeto(context.Background(), "p/1", "Lar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "PUT" || recebida.url != "/api/projetos/p%2F1" || !strings.Contains(recebida.corpo, `"nome":"Lar"`) {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	if p.TotalTarefas != 2 || p.TarefasPendentes != 1 {
+		t.Errorf("projeto inesperado: %+v", p)
+	}
+}
+
+func TestFalsoProjetos(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+
+	casa, err := f.CriarProjeto(ctx, "Casa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.CriarProjeto(ctx, " "); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("CriarProjeto sem nome: esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+	tarefa, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Lavar louça", ProjetoID: casa.ID})
+	f.Criar(ctx, dominio.Tarefa{Titulo: "Fora do projeto"})
+
+	pagina, _ := f.Listar(ctx, Consulta{Projeto: casa.ID})
+	if len(pagina.Tarefas) != 1 || pagina.Tarefas[0].ID != tarefa.ID {
+		t.Errorf("filtro por projeto: %+v", pagina.Tarefas)
+	}
+	if p, err := f.RenomearProjeto(ctx, casa.ID, "Lar"); err != nil || p.Nome != "Lar" {
+		t.Errorf("RenomearProjeto: %+v %v", p, err)
+	}
+
+	if err := f.RemoverProjeto(ctx, casa.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.BuscarProjeto(ctx, casa.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("BuscarProjeto removido: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+}
+
+func TestClienteAlterarEtiqueta(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `{"id":"e1","nome":"Casa","cor":"#27ae60"}`)
+
+	cor := "#27AE60"
+	e, err := c.AlterarEtiqueta(context.Background(), "e1", AlteracaoEtiqueta{Cor: &cor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "PATCH" || recebida.url != "/api/etiquetas/e1" || recebida.corpo != `{"cor":"#27AE60"}` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	if e.Cor != "#27ae60" {
+		t.Errorf("etiqueta inesperada: %+v", e)
+	}
+
+	// O filtro por etiquetas vai como uma lista separada por vírgulas
+	c.Listar(context.Background(), Consulta{Etiquetas: []string{"e1", "e2"}, ModoEtiquetas: "alguma"})
+	if recebida.url != "/api/tarefas?etiquetas=e1%2Ce2&modo_etiquetas=alguma" {
+		t.Errorf("consulta inesperada: %s", recebida.url)
+	}
+}
+
+func TestFalsoEtiquetas(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+
+	casa, err := f.CriarEtiqueta(ctx, "Casa", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	urgente, _ := f.CriarEtiqueta(ctx, "Urgente", "#E74C3C")
+	if casa.Cor != dominio.CorPadraoEtiqueta || urgente.Cor != "#e74c3c" {
+		t.Errorf("cores inesperadas: %q %q", casa.Cor, urgente.Cor)
+	}
+	f.Criar(ctx, dominio.Tarefa{Titulo: "Consertar pia", Etiquetas: []string{casa.ID, urgente.ID}})
+	f.Criar(ctx, dominio.Tarefa{Titulo: "Varrer", Etiquetas: []string{casa.ID}})
+
+	if pagina, _ := f.Listar(ctx, Consulta{Etiquetas: []string{casa.ID, urgente.ID}}); len(pagina.Tarefas) != 1 {
+		t.Errorf("filtro com todas as etiquetas: %+v", pagina.Tarefas)
+	}
+	if pagina, _ := f.Listar(ctx, Consulta{Etiquetas: []string{casa.ID, urgente.ID}, ModoEtiquetas: "alguma"}); len(pagina.Tarefas) != 2 {
+		t.Errorf("filtro com alguma etiqueta: %+v", pagina.Tarefas)
+	}
+
+	// Renomear mantém a cor
+	nome := "Lar"
+	if e, err := f.AlterarEtiqueta(ctx, casa.ID, AlteracaoEtiqueta{Nome: &nome}); err != nil || e.Nome != "Lar" || e.Cor != casa.Cor {
+		t.Errorf("AlterarEtiqueta: %+v %v", e, err)
+	}
+	if err := f.RemoverEtiqueta(ctx, urgente.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.BuscarEtiqueta(ctx, urgente.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("BuscarEtiqueta removida: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+}
+
+func TestClienteSubtarefas(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusNoContent, "")
+
+	if err := c.RemoverEmCascata(context.Background(), "7", QualquerVersao); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "DELETE" || recebida.url != "/api/tarefas/7?subtarefas=remover" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c.Listar(context.Background(), Consulta{Pais: []string{"1", "2"}, Raiz: true})
+	if recebida.url != "/api/tarefas?pai=1%2C2&raiz=true" {
+		t.Errorf("consulta inesperada: %s", recebida.url)
+	}
+}
+
+func TestFalsoSubtarefas(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+
+	pai, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Mudança"})
+	caixas, err := f.Criar(ctx, dominio.Tarefa{Titulo: "Embalar caixas", PaiID: pai.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Criar(ctx, dominio.Tarefa{Titulo: "Contratar frete", PaiID: pai.ID})
+	fita, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Comprar fita", PaiID: caixas.ID})
+
+	if pagina, _ := f.Listar(ctx, Consulta{Raiz: true}); len(pagina.Tarefas) != 1 || pagina.Tarefas[0].ID != pai.ID {
+		t.Errorf("Listar raízes: %+v", pagina.Tarefas)
+	}
+	if pagina, _ := f.Listar(ctx, Consulta{Pais: []string{pai.ID}}); len(pagina.Tarefas) != 2 {
+		t.Errorf("Listar subtarefas: %+v", pagina.Tarefas)
+	}
+
+	// Em cascata, as descendentes vão para a lixeira junto com a tarefa
+	if err := f.RemoverEmCascata(ctx, caixas.ID, QualquerVersao); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Buscar(ctx, fita.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("Buscar descendente removida: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+	if itens, _ := f.Lixeira(ctx); len(itens) != 1 || len(itens[0].Subtarefas) != 1 || itens[0].Subtarefas[0].ID != fita.ID {
+		t.Errorf("itens da lixeira: %+v", itens)
+	}
+	if pagina, _ := f.Listar(ctx, Consulta{}); len(pagina.Tarefas) != 2 {
+		t.Errorf("restaram tarefas inesperadas: %+v", pagina.Tarefas)
+	}
+}
+
+func TestClienteDependencias(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusConflict, `{"status":409,"code":"tarefa_bloqueada",`+
+		`"messages":{"pt-BR":"a tarefa depende de tarefas pendentes","en":"the task depends on pending tasks"}}`)
+
+	concluida := true
+	_, err := c.Alterar(context.Background(), "3", Alteracao{Concluida: &concluida, BloqueadaPor: &[]string{"1", "2"}, Versao: 1})
+	if !errors.Is(err, ErrTarefaBloqueada) || errors.Is(err, ErrConflito) {
+		t.Errorf("esperado apenas ErrTarefaBloqueada, obtido %v", err)
+	}
+	if recebida.corpo != `{"concluida":true,"bloqueada_por":["1","2"]}` {
+		t.Errorf("corpo inesperado: %s", recebida.corpo)
+	}
+
+	c.Listar(context.Background(), Consulta{Acionaveis: true})
+	if recebida.url != "/api/tarefas?acionaveis=true" {
+		t.Errorf("consulta inesperada: %s", recebida.url)
+	}
+}
+
+func TestClienteEstados(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusConflict, `{"status":409,"code":"transicao_invalida",`+
+		`"messages":{"pt-BR":"o fluxo do projeto não permite esta mudança de estado","en":"the project workflow does not allow this state change"}}`)
+
+	estado := "concluida"
+	_, err := c.Alterar(context.Background(), "3", Alteracao{Estado: &estado, Versao: 1})
+	if !errors.Is(err, ErrTransicaoInvalida) || errors.Is(err, ErrConflito) {
+		t.Errorf("esperado apenas ErrTransicaoInvalida, obtido %v", err)
+	}
+	if recebida.corpo != `{"estado":"concluida"}` {
+		t.Errorf("corpo inesperado: %s", recebida.corpo)
+	}
+
+	c.HistoricoEstados(context.Background(), "3")
+	if recebida.metodo != "GET" || recebida.url != "/api/tarefas/3/historico/estados" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c, _ = servidorTeste(t, http.StatusConflict, `{"status":409,"code":"limite_estado",`+
+		`"messages":{"pt-BR":"a coluna já atingiu o seu limite","en":"the column has reached its limit"}}`)
+	if _, err := c.Alterar(context.Background(), "3", Alteracao{Estado: &estado, Versao: 1}); !errors.Is(err, ErrLimiteEstado) || errors.Is(err, ErrConflito) {
+		t.Errorf("esperado apenas ErrLimiteEstado, obtido %v", err)
+	}
+
+	// DefinirFluxo mantém o nome do projeto e envia null para voltar ao padrão
+	c, recebida = servidorTeste(t, http.StatusOK, `{"id":"p1","nome":"Casa","total_tarefas":0,"tarefas_pendentes":0}`)
+	if _, err := c.DefinirFluxo(context.Background(), "p1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "PUT" || recebida.corpo != `{"nome":"Casa","fluxo":null}` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+}
+
+func TestFalsoEstados(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+	projeto, _ := f.CriarProjeto(ctx, "Produto")
+	fluxo := &dominio.Fluxo{
+		Estados: []dominio.EstadoFluxo{
+			{ID: "a_fazer", Nome: "A fazer"},
+			{ID: "revisao", Nome: "Revisão"},
+			{ID: "feita", Nome: "Feita", Final: true},
+		},
+		Transicoes: []dominio.Transicao{{De: "a_fazer", Para: "revisao"}, {De: "revisao", Para: "feita"}},
+	}
+	if _, err := f.DefinirFluxo(ctx, projeto.ID, &dominio.Fluxo{}); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("fluxo vazio: esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+	if p, err := f.DefinirFluxo(ctx, projeto.ID, fluxo); err != nil || p.Fluxo == nil || len(p.Fluxo.Estados) != 3 {
+		t.Fatalf("DefinirFluxo: %+v %v", p, err)
+	}
+
+	// O estado é gravado como enviado e cada mudança entra no histórico
+	tarefa, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Release", ProjetoID: projeto.ID, Estado: "a_fazer"})
+	revisao := "revisao"
+	f.Alterar(ctx, tarefa.ID, Alteracao{Estado: &revisao, Versao: QualquerVersao})
+	titulo := "Release 2"
+	f.Alterar(ctx, tarefa.ID, Alteracao{Titulo: &titulo, Versao: QualquerVersao})
+	historico, _ := f.HistoricoEstados(ctx, tarefa.ID)
+	var estados []string
+	for _, m := range historico {
+		estados = append(estados, m.Para)
+	}
+	if !slices.Equal(estados, []string{"a_fazer", "revisao"}) {
+		t.Errorf("histórico de estados: %v", estados)
+	}
+}
+
+func TestAlteracaoOmiteCamposNulos(t *testing.T) {
+	b, err := json.Marshal(Alteracao{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{}" {
+		t.Errorf("alteração vazia serializada como %s", b)
+	}
+}
+
+func TestClienteComentarios(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusCreated, `{"id":"c1","tarefa_id":"3","texto":"Feito","criado_em":"2024-05-01T10:00:00Z","editado":false}`)
+	comentario, err := c.Comentar(context.Background(), "3", "Feito")
+	if err != nil || comentario.ID != "c1" {
+		t.Fatalf("Comentar: %+v %v", comentario, err)
+	}
+	if recebida.metodo != "POST" || recebida.url != "/api/tarefas/3/comentarios" || recebida.corpo != `{"texto":"Feito"}` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c.EditarComentario(context.Background(), "3", "c1", "Feito e testado")
+	if recebida.metodo != "PATCH" || recebida.url != "/api/tarefas/3/comentarios/c1" || recebida.corpo != `{"texto":"Feito e testado"}` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	c.RemoverComentario(context.Background(), "3", "c1")
+	if recebida.metodo != "DELETE" || recebida.url != "/api/tarefas/3/comentarios/c1" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+	c.Atividade(context.Background(), "3")
+	if recebida.metodo != "GET" || recebida.url != "/api/tarefas/3/atividade" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+}
+
+func TestFalsoComentarios(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+	tarefa, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Deploy"})
+
+	if _, err := f.Comentar(ctx, tarefa.ID, " "); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("comentário vazio: esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+	if _, err := f.Comentar(ctx, "inexistente", "Oi"); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("comentário em tarefa inexistente: obtido %v", err)
+	}
+	comentario, _ := f.Comentar(ctx, tarefa.ID, "Aguardando janela")
+	if editado, _ := f.EditarComentario(ctx, tarefa.ID, comentario.ID, "Janela às 22h"); !editado.Editado || editado.EditadoEm == nil {
+		t.Errorf("comentário editado: %+v", editado)
+	}
+	titulo := "Deploy v2"
+	f.Alterar(ctx, tarefa.ID, Alteracao{Titulo: &titulo, Versao: QualquerVersao})
+
+	atividades, _ := f.Atividade(ctx, tarefa.ID)
+	var tipos []string
+	for _, a := range atividades {
+		tipos = append(tipos, a.Tipo)
+	}
+	esperado := []string{dominio.AtividadeCriada, dominio.AtividadeComentario, dominio.AtividadeRenomeada}
+	if !slices.Equal(tipos, esperado) || atividades[1].Comentario.Texto != "Janela às 22h" {
+		t.Errorf("atividades obtidas %v esperadas %v", tipos, esperado)
+	}
+
+	if err := f.RemoverComentario(ctx, tarefa.ID, comentario.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.RemoverComentario(ctx, tarefa.ID, comentario.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("comentário removido: esperado ErrNaoEncontrada, obtido %v", err)
+	}
+}
+
+func TestClienteAnexos(t *testing.T) {
+	var nome, tipo, conteudo string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/tarefas/3/anexos":
+			arquivo, cabecalho, err := r.FormFile("arquivo")
+			if err != nil {
+				t.Errorf("corpo multipart inválido: %v", err)
+				return
+			}
+			b, _ := io.ReadAll(arquivo)
+			nome, tipo, conteudo = cabecalho.Filename, cabecalho.Header.Get("Content-Type"), string(b)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id":"a1","tarefa_id":"3","nome":"ata.txt","tipo":"text/plain","tamanho":5,"sha256":"abc","criado_em":"2024-05-01T10:00:00Z"}`)
+		case r.Method == "GET" && r.URL.Path == "/api/tarefas/3/anexos/a1/conteudo":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Disposition", `attachment; filename*=utf-8''reuni%C3%A3o.txt`)
+			w.Header().Set("ETag", `"abc"`)
+			io.WriteString(w, "Olá!")
+		default:
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c := Novo(srv.URL, nil)
+
+	anexo, err := c.Anexar(context.Background(), "3", "ata.txt", "text/plain", []byte("Oi, 1"))
+	if err != nil || anexo.ID != "a1" {
+		t.Fatalf("Anexar: %+v %v", anexo, err)
+	}
+	if nome != "ata.txt" || tipo != "text/plain" || conteudo != "Oi, 1" {
+		t.Errorf("arquivo recebido: %q %q %q", nome, tipo, conteudo)
+	}
+
+	anexo, b, err := c.BaixarAnexo(context.Background(), "3", "a1")
+	if err != nil || string(b) != "Olá!" {
+		t.Fatalf("BaixarAnexo: %q %v", b, err)
+	}
+	if anexo.Nome != "reunião.txt" || anexo.Tipo != "text/plain" || anexo.Hash != "abc" || anexo.Tamanho != int64(len(b)) {
+		t.Errorf("anexo baixado: %+v", anexo)
+	}
+}
+
+func TestFalsoAnexos(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+	tarefa, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+
+	if _, err := f.Anexar(ctx, "inexistente", "notas.txt", "text/plain", []byte("Notas")); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("anexo em tarefa inexistente: obtido %v", err)
+	}
+	anexo, err := f.Anexar(ctx, tarefa.ID, "notas.txt", "text/plain", []byte("Notas"))
+	if err != nil || anexo.Tipo != "text/plain" || anexo.Tamanho != 5 {
+		t.Fatalf("Anexar: %+v %v", anexo, err)
+	}
+	if _, b, err := f.BaixarAnexo(ctx, tarefa.ID, anexo.ID); err != nil || string(b) != "Notas" {
+		t.Errorf("BaixarAnexo: %q %v", b, err)
+	}
+
+	// Purgar a tarefa da lixeira remove os seus anexos
+	f.Remover(ctx, tarefa.ID, QualquerVersao)
+	f.Purgar(ctx, tarefa.ID)
+	f.mu.Lock()
+	restantes := len(f.anexos) + len(f.conteudos)
+	f.mu.Unlock()
+	if restantes != 0 {
+		t.Errorf("anexos da tarefa removida continuam guardados")
+	}
+}
+
+func TestClienteRevisoes(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `[{"id":"r1","tarefa_id":"3","numero":2,"autor":"ana","em":"2024-05-01T10:00:00Z","diferencas":[{"campo":"titulo","de":"Velho","para":"Novo"}],"tarefa":{"id":"3","titulo":"Novo","concluida":false,"versao":2}}]`)
+	revisoes, err := c.Revisoes(context.Background(), "3")
+	if err != nil || len(revisoes) != 1 || revisoes[0].Numero != 2 || string(revisoes[0].Diferencas[0].Para) != `"Novo"` {
+		t.Fatalf("Revisoes: %+v %v", revisoes, err)
+	}
+	if recebida.metodo != "GET" || recebida.url != "/api/tarefas/3/historico" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c, recebida = servidorTeste(t, http.StatusOK, `{"id":"3","titulo":"Velho","concluida":false,"versao":3}`)
+	if _, err := c.Reverter(context.Background(), "3", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "POST" || recebida.url != "/api/tarefas/3/reverter?revisao=1" || recebida.ifMatch != `"2"` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+}
+
+func TestFalsoRevisoes(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+	tarefa, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+	concluida := true
+	f.Alterar(ctx, tarefa.ID, Alteracao{Concluida: &concluida, Versao: QualquerVersao})
+
+	revisoes, _ := f.Revisoes(ctx, tarefa.ID)
+	if len(revisoes) != 2 || revisoes[1].Numero != 2 || revisoes[1].Diferencas[0].Campo != "concluida" {
+		t.Fatalf("revisões: %+v", revisoes)
+	}
+	if _, err := f.Reverter(ctx, tarefa.ID, 1, 1); !errors.Is(err, ErrConflito) {
+		t.Errorf("Reverter com versão desatualizada: obtido %v", err)
+	}
+	revertida, err := f.Reverter(ctx, tarefa.ID, 1, 2)
+	if err != nil || revertida.Concluida || revertida.Versao != 3 {
+		t.Errorf("Reverter: %+v %v", revertida, err)
+	}
+	if _, err := f.Reverter(ctx, tarefa.ID, 9, 3); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("revisão inexistente: obtido %v", err)
+	}
+}
+
+func TestClienteLixeira(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `[{"tarefa":{"id":"3","titulo":"Velha","concluida":false,"versao":2,"excluida_em":"2024-05-01T10:00:00Z"},"subtarefas":[],"excluida_em":"2024-05-01T10:00:00Z","purga_em":"2024-05-31T10:00:00Z"}]`)
+	itens, err := c.Lixeira(context.Background())
+	if err != nil || len(itens) != 1 || itens[0].Tarefa.ID != "3" || itens[0].PurgaEm.Day() != 31 {
+		t.Fatalf("Lixeira: %+v %v", itens, err)
+	}
+	if recebida.metodo != "GET" || recebida.url != "/api/lixeira" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c, recebida = servidorTeste(t, http.StatusOK, `{"id":"3","titulo":"Velha","concluida":false,"versao":3}`)
+	if _, err := c.Restaurar(context.Background(), "3"); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "POST" || recebida.url != "/api/lixeira/3/restaurar" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+
+	c, recebida = servidorTeste(t, http.StatusNoContent, "")
+	if err := c.Purgar(context.Background(), "3"); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "DELETE" || recebida.url != "/api/lixeira/3" {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+}
+
+func TestFalsoLixeira(t *testing.T) {
+	f := NovoFalso()
+	ctx := context.Background()
+	pai, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Mudança"})
+	filha, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Caixas", PaiID: pai.ID})
+	f.Comentar(ctx, filha.ID, "Comprar fita")
+
+	if err := f.RemoverEmCascata(ctx, pai.ID, QualquerVersao); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Buscar(ctx, filha.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("subtarefa na lixeira: obtido %v", err)
+	}
+	itens, _ := f.Lixeira(ctx)
+	if len(itens) != 1 || itens[0].Tarefa.ID != pai.ID || len(itens[0].Subtarefas) != 1 {
+		t.Fatalf("itens da lixeira: %+v", itens)
+	}
+	if _, err := f.Restaurar(ctx, filha.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("restaurar subtarefa sozinha: obtido %v", err)
+	}
+
+	restaurada, err := f.Restaurar(ctx, pai.ID)
+	if err != nil || restaurada.ExcluidaEm != nil {
+		t.Fatalf("Restaurar: %+v %v", restaurada, err)
+	}
+	if obtida, err := f.Buscar(ctx, filha.ID); err != nil || obtida.PaiID != pai.ID {
+		t.Errorf("subtarefa restaurada: %+v %v", obtida, err)
+	}
+	if comentarios, _ := f.Atividade(ctx, filha.ID); len(comentarios) == 0 {
+		t.Errorf("atividade da subtarefa restaurada se perdeu")
+	}
+
+	f.RemoverEmCascata(ctx, pai.ID, QualquerVersao)
+	if err := f.Purgar(ctx, pai.ID); err != nil {
+		t.Fatal(err)
+	}
+	if itens, _ := f.Lixeira(ctx); len(itens) != 0 {
+		t.Errorf("lixeira depois de purgar: %+v", itens)
+	}
+	if err := f.Purgar(ctx, pai.ID); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("purgar de novo: obtido %v", err)
+	}
+}
+
+func TestClienteAtualizarEnviaVersao(t *testing.T) {
+	c, recebida := servidorTeste(t, http.StatusOK, `{"id":"7","titulo":"Deploy","versao":5}`)
+	if _, err := c.Atualizar(context.Background(), "7", dominio.Tarefa{Titulo: "Deploy", Versao: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if recebida.metodo != "PUT" || recebida.ifMatch != `"4"` {
+		t.Errorf("requisição inesperada: %+v", recebida)
+	}
+}
+
+func TestFalsoConflitoVersao(t *testing.T) {
+	ctx := context.Background()
+	f := NovoFalso()
+	criada, _ := f.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+
+	titulo := "Relatório mensal"
+	if _, err := f.Alterar(ctx, criada.ID, Alteracao{Titulo: &titulo, Versao: criada.Versao}); err != nil {
+		t.Fatal(err)
+	}
+	// Quem ainda tem a versão criada não sobrescreve nem remove a tarefa
+	antiga := "Relatório anual"
+	if _, err := f.Alterar(ctx, criada.ID, Alteracao{Titulo: &antiga, Versao: criada.Versao}); !errors.Is(err, ErrConflito) {
+		t.Errorf("Alterar com versão desatualizada: %v", err)
+	}
+	if _, err := f.Atualizar(ctx, criada.ID, criada); !errors.Is(err, ErrConflito) {
+		t.Errorf("Atualizar com versão desatualizada: %v", err)
+	}
+	if err := f.Remover(ctx, criada.ID, criada.Versao); !errors.Is(err, ErrConflito) {
+		t.Errorf("Remover com versão desatualizada: %v", err)
+	}
+	// Sem versão, como no cliente, a escrita é recusada
+	if _, err := f.Alterar(ctx, criada.ID, Alteracao{Titulo: &antiga}); !errors.Is(err, ErrVersaoAusente) {
+		t.Errorf("Alterar sem versão: %v", err)
+	}
+	if err := f.Remover(ctx, criada.ID, 0); !errors.Is(err, ErrVersaoAusente) {
+		t.Errorf("Remover sem versão: %v", err)
+	}
+	if atual, _ := f.Buscar(ctx, criada.ID); atual.Titulo != titulo || atual.Versao != 2 {
+		t.Errorf("tarefa depois dos conflitos: %+v", atual)
+	}
+	if err := f.Remover(ctx, criada.ID, 2); err != nil {
+		t.Errorf("Remover com a versão atual: %v", err)
+	}
+}
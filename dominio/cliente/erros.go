@@ -0,0 +1,123 @@
+package cliente
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// Erros que podem ser comparados com errors.Is contra um *ErroAPI
+var (
+	ErrRequisicaoInvalida = errors.New("requisição inválida")
+	ErrNaoEncontrada      = errors.New("tarefa não encontrada")
+	ErrConflito           = errors.New("conflito de versão")
+	ErrTarefaBloqueada    = errors.New("a tarefa depende de tarefas pendentes")
+	ErrTransicaoInvalida  = errors.New("o fluxo do projeto não permite a mudança de estado")
+	ErrLimiteEstado       = errors.New("a coluna do estado já atingiu o seu limite")
+	ErrNaoAutenticado     = errors.New("credencial ausente, inválida ou expirada")
+	ErrAcessoNegado       = errors.New("acesso negado")
+	ErrAnexoGrande        = errors.New("o arquivo passa do tamanho máximo")
+	ErrTipoAnexoRecusado  = errors.New("o tipo do arquivo não é aceito nos anexos")
+)
+
+// ErrVersaoAusente é retornado, sem consultar a API, pelas escritas em
+// tarefa feitas com versão zero: elas precisam da versão lida ou,
+// explicitamente, de QualquerVersao
+var ErrVersaoAusente = errors.New("versão da tarefa não informada")
+
+// ErroAPI é retornado quando a API responde com status 4xx ou 5xx
+type ErroAPI struct {
+	// Status é o código HTTP da resposta
+	Status int
+	// Codigo é o código estável do erro, como dominio.CodigoValidacao
+	Codigo string
+	// Mensagem é a descrição do erro em português, se houver
+	Mensagem string
+	// Mensagens traz a descrição do erro em português e em inglês
+	Mensagens dominio.Mensagens
+	// Campos lista os campos ou parâmetros inválidos
+	Campos []dominio.ErroValidacao
+	// RequestID identifica a requisição nos logs da API
+	RequestID string
+}
+
+// novoErroAPI constrói um *ErroAPI a partir do corpo problem+json da resposta
+func novoErroAPI(p dominio.Problema) *ErroAPI {
+	return &ErroAPI{
+		Status:    p.Status,
+		Codigo:    p.Codigo,
+		Mensagem:  p.Mensagens.PtBR,
+		Mensagens: p.Mensagens,
+		Campos:    p.Campos,
+		RequestID: p.RequestID,
+	}
+}
+
+func (e *ErroAPI) Error() string {
+	msg := "API respondeu com status " + strconv.Itoa(e.Status)
+	if e.Codigo != "" {
+		msg += " (" + e.Codigo + ")"
+	}
+	if e.Mensagem != "" {
+		msg += ": " + e.Mensagem
+	}
+	return msg
+}
+
+// Is permite usar errors.Is com os erros sentinela do pacote
+func (e *ErroAPI) Is(alvo error) bool {
+	switch alvo {
+	case ErrRequisicaoInvalida:
+		return e.Status == http.StatusBadRequest
+	case ErrNaoEncontrada:
+		return e.Status == http.StatusNotFound
+	case ErrConflito:
+		if e.Status == http.StatusPreconditionFailed {
+			return true
+		}
+		return e.Status == http.StatusConflict && e.Codigo != dominio.CodigoTarefaBloqueada &&
+			e.Codigo != dominio.CodigoTransicaoInvalida && e.Codigo != dominio.CodigoLimiteEstado
+	case ErrTarefaBloqueada:
+		return e.Status == http.StatusConflict && e.Codigo == dominio.CodigoTarefaBloqueada
+	case ErrTransicaoInvalida:
+		return e.Status == http.StatusConflict && e.Codigo == dominio.CodigoTransicaoInvalida
+	case ErrLimiteEstado:
+		return e.Status == http.StatusConflict && e.Codigo == dominio.CodigoLimiteEstado
+	case ErrNaoAutenticado:
+		return e.Status == http.StatusUnauthorized
+	case ErrAcessoNegado:
+		return e.Status == http.StatusForbidden
+	case ErrAnexoGrande:
+		return e.Status == http.StatusRequestEntityTooLarge
+	case ErrTipoAnexoRecusado:
+		return e.Status == http.StatusUnsupportedMediaType
+	}
+	return false
+}
+
+// DoCliente informa se o erro foi causado pela requisição (4xx)
+func (e *ErroAPI) DoCliente() bool {
+	return e.Status >= 400 && e.Status < 500
+}
+
+// DoServidor informa se o erro ocorreu no servidor (5xx)
+func (e *ErroAPI) DoServidor() bool {
+	return e.Status >= 500
+}
+
+// lerErroAPI constrói um *ErroAPI a partir de uma resposta de erro. Um corpo
+// que não seja problem+json, como o de um proxy, resulta apenas no status.
+func lerErroAPI(resp *http.Response) *ErroAPI {
+	corpo, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var p dominio.Problema
+	json.Unmarshal(corpo, &p)
+	p.Status = resp.StatusCode
+	if p.RequestID == "" {
+		p.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	return novoErroAPI(p)
+}
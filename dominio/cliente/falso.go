@@ -0,0 +1,1099 @@
+package cliente
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// prefixoTokenFalso forma os tokens emitidos por Falso.Entrar
+const prefixoTokenFalso = "falso:"
+
+// prefixoCursorFalso identifica os cursores gerados por Falso.Listar
+const prefixoCursorFalso = "falso:"
+
+// Falso implementa API em memória, para testes de quem consome a API.
+//
+// Ele se comporta como o armazenamento da API, não como o servidor: gera IDs,
+// versões e datas, valida os campos com as regras de dominio, recusa escritas
+// sobre versões desatualizadas e guarda o histórico do que recebe. As regras
+// do servidor não são reproduzidas: fluxos de trabalho, dependências,
+// subtarefas, recorrência e limites do quadro não alteram nem recusam nada.
+// Campos calculados, como Bloqueada, Subtarefas e as contagens dos projetos,
+// não são escritos pelas alterações e mantêm o valor semeado em NovoFalso.
+// Testes que dependem da resposta dessas regras envolvem o Falso e retornam
+// o erro que a API daria.
+//
+// Listar pagina com um cursor opaco, como a API, e ordena apenas por data de
+// criação.
+type Falso struct {
+	mu sync.Mutex
+	// tarefas inclui as que estão na lixeira, marcadas com ExcluidaEm
+	tarefas   []dominio.Tarefa
+	projetos  []dominio.Projeto
+	etiquetas []dominio.Etiqueta
+	historico []dominio.MudancaEstado
+	revisoes  []dominio.Revisao
+	// renomeacoes e comentarios formam, com historico, a atividade das tarefas
+	renomeacoes []dominio.Renomeacao
+	comentarios []dominio.Comentario
+	// anexos são os metadados dos anexos e conteudos, o conteúdo de cada um por ID
+	anexos    []dominio.Anexo
+	conteudos map[string][]byte
+	proximo   int
+
+	// Erro, quando definido, é retornado por todas as operações
+	Erro error
+	// Usuarios, quando definido, mapeia login para senha; as operações de
+	// tarefas passam a exigir no contexto um token emitido por Entrar e veem
+	// apenas as tarefas cujo Dono é o usuário do token
+	Usuarios map[string]string
+}
+
+// NovoFalso cria um Falso com as tarefas informadas, que recebem IDs
+// sequenciais caso não tenham um e mantêm o Dono informado
+func NovoFalso(tarefas ...dominio.Tarefa) *Falso {
+	f := &Falso{}
+	for _, t := range tarefas {
+		if t.ID == "" {
+			t.ID = f.novoID()
+		}
+		if t.Versao == 0 {
+			t.Versao = 1
+		}
+		t.Normalizar()
+		f.tarefas = append(f.tarefas, t)
+	}
+	return f
+}
+
+// novoID gera o próximo ID sequencial; o chamador deve possuir o bloqueio
+func (f *Falso) novoID() string {
+	f.proximo++
+	return strconv.Itoa(f.proximo)
+}
+
+// indice retorna a posição da tarefa do dono fora da lixeira ou -1; o
+// chamador deve possuir o bloqueio
+func (f *Falso) indice(dono, id string) int {
+	for i, t := range f.tarefas {
+		if t.ID == id && t.Dono == dono && t.ExcluidaEm == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *Falso) Entrar(ctx context.Context, usuario, senha string) (dominio.Sessao, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Erro != nil {
+		return dominio.Sessao{}, f.Erro
+	}
+	if esperada, ok := f.Usuarios[usuario]; !ok || esperada != senha {
+		return dominio.Sessao{}, novoErroAPI(dominio.Problema{
+			Status:    http.StatusUnauthorized,
+			Codigo:    dominio.CodigoCredenciaisInvalidas,
+			Mensagens: dominio.Mensagens{PtBR: "usuário ou senha inválidos", En: "invalid username or password"},
+		})
+	}
+	return dominio.Sessao{
+		Token:    prefixoTokenFalso + usuario,
+		Tipo:     "Bearer",
+		ExpiraEm: time.Now().UTC().Add(time.Hour),
+		Usuario:  usuario,
+		Escopos:  dominio.EscoposValidos,
+	}, nil
+}
+
+// verificar retorna Erro ou, se há usuários, exige um token de Entrar de um
+// usuário existente e retorna esse usuário; o chamador deve possuir o bloqueio
+func (f *Falso) verificar(ctx context.Context) (string, error) {
+	if f.Erro != nil || f.Usuarios == nil {
+		return "", f.Erro
+	}
+	usuario, ok := strings.CutPrefix(credencialDe(ctx), prefixoTokenFalso)
+	if _, existe := f.Usuarios[usuario]; !ok || !existe {
+		return "", novoErroAPI(dominio.Problema{
+			Status:    http.StatusUnauthorized,
+			Codigo:    dominio.CodigoNaoAutenticado,
+			Mensagens: dominio.Mensagens{PtBR: "credencial ausente ou inválida", En: "missing or invalid credential"},
+		})
+	}
+	return usuario, nil
+}
+
+// Listar aplica os filtros que dependem só dos campos gravados. Acionaveis
+// deixa de fora apenas as tarefas concluídas, porque o Falso não calcula
+// bloqueios.
+func (f *Falso) Listar(ctx context.Context, c Consulta) (dominio.PaginaTarefas, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pagina := dominio.PaginaTarefas{Limit: c.Limite}
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return pagina, err
+	}
+	if pagina.Limit == 0 {
+		pagina.Limit = 50
+	}
+
+	// O cursor guarda o ID da última tarefa entregue; a página seguinte
+	// começa depois dela na ordem de criação
+	depois := -1
+	if c.Cursor != "" {
+		depois = f.posicaoDoCursor(c.Cursor)
+		if depois < 0 {
+			return pagina, erroValidacao(&dominio.ErroValidacao{Campo: "cursor", Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{
+				PtBR: "cursor inválido para esta ordenação",
+				En:   "cursor does not match this sort order",
+			}})
+		}
+	}
+
+	pagina.Tarefas = []dominio.Tarefa{}
+	for i, t := range f.tarefas {
+		if i <= depois || t.Dono != dono || t.ExcluidaEm != nil {
+			continue
+		}
+		if c.Concluida != nil && t.Concluida != *c.Concluida {
+			continue
+		}
+		if c.Acionaveis && t.Concluida {
+			continue
+		}
+		if c.Prioridade != "" && t.Prioridade != c.Prioridade {
+			continue
+		}
+		if c.Projeto != "" && t.ProjetoID != c.Projeto {
+			continue
+		}
+		if len(c.Etiquetas) > 0 && !temEtiquetas(t, c.Etiquetas, c.ModoEtiquetas == "alguma") {
+			continue
+		}
+		if c.Raiz && t.PaiID != "" || len(c.Pais) > 0 && !slices.Contains(c.Pais, t.PaiID) {
+			continue
+		}
+		if c.Texto != "" && !strings.Contains(strings.ToLower(t.Titulo), strings.ToLower(c.Texto)) {
+			continue
+		}
+		if len(pagina.Tarefas) == pagina.Limit {
+			pagina.NextCursor = codificarCursorFalso(pagina.Tarefas[len(pagina.Tarefas)-1].ID)
+			break
+		}
+		pagina.Tarefas = append(pagina.Tarefas, t)
+	}
+	return pagina, nil
+}
+
+// codificarCursorFalso gera o cursor opaco que aponta para a tarefa informada
+func codificarCursorFalso(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(prefixoCursorFalso + id))
+}
+
+// posicaoDoCursor retorna a posição da tarefa apontada pelo cursor, ou -1 se
+// ele não foi gerado por Listar; o chamador deve possuir o bloqueio
+func (f *Falso) posicaoDoCursor(cursor string) int {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return -1
+	}
+	id, ok := strings.CutPrefix(string(b), prefixoCursorFalso)
+	if !ok {
+		return -1
+	}
+	return slices.IndexFunc(f.tarefas, func(t dominio.Tarefa) bool { return t.ID == id })
+}
+
+func (f *Falso) Buscar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Tarefa{}, err
+	}
+	i := f.indice(dono, id)
+	if i < 0 {
+		return dominio.Tarefa{}, erroNaoEncontrada()
+	}
+	return f.tarefas[i], nil
+}
+
+func (f *Falso) Criar(ctx context.Context, t dominio.Tarefa) (dominio.Tarefa, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Tarefa{}, err
+	}
+	if err := t.Validar(); err != nil {
+		return dominio.Tarefa{}, erroValidacao(err)
+	}
+
+	instante := time.Now().UTC()
+	t.ID = f.novoID()
+	t.Dono = dono
+	t.Versao = 1
+	t.CriadaEm, t.AtualizadaEm, t.ConcluidaEm = instante, instante, nil
+	if t.Concluida {
+		t.ConcluidaEm = &instante
+	}
+	t.Subtarefas, t.Bloqueada, t.ExcluidaEm, t.ExcluidaCom = nil, false, nil, ""
+	t.Normalizar()
+	f.tarefas = append(f.tarefas, t)
+	f.registrarEstado(dono, t.ID, "", t.Estado)
+	f.registrarRevisao(dono, nil, t)
+	return t, nil
+}
+
+func (f *Falso) Atualizar(ctx context.Context, id string, t dominio.Tarefa) (dominio.Tarefa, error) {
+	// Como na API, o estado ausente mantém o atual
+	return f.alterar(ctx, id, t.Versao, func(atual *dominio.Tarefa) {
+		if t.Estado == "" {
+			t.Estado = atual.Estado
+		}
+		*atual = t
+	})
+}
+
+func (f *Falso) Alterar(ctx context.Context, id string, a Alteracao) (dominio.Tarefa, error) {
+	return f.alterar(ctx, id, a.Versao, a.Aplicar)
+}
+
+// alterar aplica mudar à tarefa, se ela estiver na versão informada
+// (QualquerVersao aceita qualquer uma), e atualiza os campos controlados
+// pelo servidor
+func (f *Falso) alterar(ctx context.Context, id string, versao int, mudar func(t *dominio.Tarefa)) (dominio.Tarefa, error) {
+	if versao == 0 {
+		return dominio.Tarefa{}, ErrVersaoAusente
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Tarefa{}, err
+	}
+	i := f.indice(dono, id)
+	if i < 0 {
+		return dominio.Tarefa{}, erroNaoEncontrada()
+	}
+	if versao != QualquerVersao && f.tarefas[i].Versao != versao {
+		return dominio.Tarefa{}, erroConflitoVersao()
+	}
+
+	antes := f.tarefas[i]
+	t := antes
+	mudar(&t)
+	if err := t.Validar(); err != nil {
+		return dominio.Tarefa{}, erroValidacao(err)
+	}
+
+	instante := time.Now().UTC()
+	t.ID, t.Dono, t.Versao, t.CriadaEm, t.AtualizadaEm = antes.ID, antes.Dono, antes.Versao+1, antes.CriadaEm, instante
+	switch {
+	case !t.Concluida:
+		t.ConcluidaEm = nil
+	case !antes.Concluida:
+		t.ConcluidaEm = &instante
+	default:
+		t.ConcluidaEm = antes.ConcluidaEm
+	}
+	t.Subtarefas, t.Bloqueada, t.ExcluidaEm, t.ExcluidaCom = antes.Subtarefas, antes.Bloqueada, nil, ""
+	t.Normalizar()
+	f.tarefas[i] = t
+	f.registrarEstado(dono, id, antes.Estado, t.Estado)
+	f.registrarRevisao(dono, &antes, t)
+	if t.Titulo != antes.Titulo {
+		f.renomeacoes = append(f.renomeacoes, dominio.Renomeacao{
+			ID: f.novoID(), TarefaID: id, De: antes.Titulo, Para: t.Titulo, Em: instante, Dono: dono,
+		})
+	}
+	return t, nil
+}
+
+func (f *Falso) Remover(ctx context.Context, id string, versao int) error {
+	return f.remover(ctx, id, versao, false)
+}
+
+func (f *Falso) RemoverEmCascata(ctx context.Context, id string, versao int) error {
+	return f.remover(ctx, id, versao, true)
+}
+
+// remover move para a lixeira a tarefa e, se cascata, suas descendentes,
+// marcadas como removidas junto com ela. As subtarefas de uma remoção sem
+// cascata ficam como estão.
+func (f *Falso) remover(ctx context.Context, id string, versao int, cascata bool) error {
+	if versao == 0 {
+		return ErrVersaoAusente
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return err
+	}
+	i := f.indice(dono, id)
+	if i < 0 {
+		return erroNaoEncontrada()
+	}
+	if versao != QualquerVersao && f.tarefas[i].Versao != versao {
+		return erroConflitoVersao()
+	}
+
+	removidas := map[string]bool{id: true}
+	for mudou := cascata; mudou; {
+		mudou = false
+		for _, t := range f.tarefas {
+			if t.Dono == dono && t.ExcluidaEm == nil && removidas[t.PaiID] && !removidas[t.ID] {
+				removidas[t.ID] = true
+				mudou = true
+			}
+		}
+	}
+	instante := time.Now().UTC()
+	for j := range f.tarefas {
+		t := &f.tarefas[j]
+		if t.Dono != dono || t.ExcluidaEm != nil || !removidas[t.ID] {
+			continue
+		}
+		t.Versao++
+		t.AtualizadaEm, t.ExcluidaEm = instante, &instante
+		if t.ID != id {
+			t.ExcluidaCom = id
+		}
+	}
+	return nil
+}
+
+func (f *Falso) Lixeira(ctx context.Context) ([]dominio.ItemLixeira, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	itens := []dominio.ItemLixeira{}
+	for _, t := range f.tarefas {
+		if t.Dono != dono || t.ExcluidaEm == nil || t.ExcluidaCom != "" {
+			continue
+		}
+		item := dominio.ItemLixeira{
+			Tarefa:     t,
+			Subtarefas: []dominio.Tarefa{},
+			ExcluidaEm: *t.ExcluidaEm,
+			PurgaEm:    t.ExcluidaEm.Add(dominio.RetencaoLixeiraPadrao),
+		}
+		for _, sub := range f.tarefas {
+			if sub.Dono == dono && sub.ExcluidaEm != nil && sub.ExcluidaCom == t.ID {
+				item.Subtarefas = append(item.Subtarefas, sub)
+			}
+		}
+		itens = append(itens, item)
+	}
+	// Como na API, das removidas mais recentemente para as mais antigas
+	slices.SortStableFunc(itens, func(a, b dominio.ItemLixeira) int { return b.ExcluidaEm.Compare(a.ExcluidaEm) })
+	return itens, nil
+}
+
+func (f *Falso) Restaurar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Tarefa{}, err
+	}
+	grupo := f.grupoLixeira(dono, id)
+	if grupo == nil {
+		return dominio.Tarefa{}, erroNaoEncontrada()
+	}
+	instante := time.Now().UTC()
+	for j := range f.tarefas {
+		t := &f.tarefas[j]
+		if t.Dono == dono && t.ExcluidaEm != nil && grupo[t.ID] {
+			t.Versao++
+			t.AtualizadaEm, t.ExcluidaEm, t.ExcluidaCom = instante, nil, ""
+		}
+	}
+	return f.tarefas[f.indice(dono, id)], nil
+}
+
+func (f *Falso) Purgar(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return err
+	}
+	removidas := f.grupoLixeira(dono, id)
+	if removidas == nil {
+		return erroNaoEncontrada()
+	}
+	f.tarefas = slices.DeleteFunc(f.tarefas, func(t dominio.Tarefa) bool {
+		return t.Dono == dono && t.ExcluidaEm != nil && removidas[t.ID]
+	})
+	f.historico = slices.DeleteFunc(f.historico, func(m dominio.MudancaEstado) bool {
+		return m.Dono == dono && removidas[m.TarefaID]
+	})
+	f.revisoes = slices.DeleteFunc(f.revisoes, func(r dominio.Revisao) bool {
+		return r.Dono == dono && removidas[r.TarefaID]
+	})
+	f.renomeacoes = slices.DeleteFunc(f.renomeacoes, func(r dominio.Renomeacao) bool {
+		return r.Dono == dono && removidas[r.TarefaID]
+	})
+	f.comentarios = slices.DeleteFunc(f.comentarios, func(c dominio.Comentario) bool {
+		return c.Dono == dono && removidas[c.TarefaID]
+	})
+	f.anexos = slices.DeleteFunc(f.anexos, func(a dominio.Anexo) bool {
+		if a.Dono == dono && removidas[a.TarefaID] {
+			delete(f.conteudos, a.ID)
+			return true
+		}
+		return false
+	})
+	return nil
+}
+
+// grupoLixeira retorna os IDs da tarefa removida diretamente com o ID
+// informado e das subtarefas removidas junto com ela, ou nil se ela não
+// está na lixeira; o chamador deve possuir o bloqueio
+func (f *Falso) grupoLixeira(dono, id string) map[string]bool {
+	var grupo map[string]bool
+	for _, t := range f.tarefas {
+		if t.Dono == dono && t.ID == id && t.ExcluidaEm != nil && t.ExcluidaCom == "" {
+			grupo = map[string]bool{id: true}
+		}
+	}
+	for _, t := range f.tarefas {
+		if grupo != nil && t.Dono == dono && t.ExcluidaEm != nil && t.ExcluidaCom == id {
+			grupo[t.ID] = true
+		}
+	}
+	return grupo
+}
+
+func (f *Falso) HistoricoEstados(ctx context.Context, id string) ([]dominio.MudancaEstado, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if f.indice(dono, id) < 0 {
+		return nil, erroNaoEncontrada()
+	}
+	mudancas := []dominio.MudancaEstado{}
+	for _, m := range f.historico {
+		if m.Dono == dono && m.TarefaID == id {
+			mudancas = append(mudancas, m)
+		}
+	}
+	return mudancas, nil
+}
+
+func (f *Falso) Revisoes(ctx context.Context, id string) ([]dominio.Revisao, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if f.indice(dono, id) < 0 {
+		return nil, erroNaoEncontrada()
+	}
+	revisoes := []dominio.Revisao{}
+	for _, r := range f.revisoes {
+		if r.Dono == dono && r.TarefaID == id {
+			revisoes = append(revisoes, r)
+		}
+	}
+	return revisoes, nil
+}
+
+// Reverter grava de volta os campos da revisão, como uma alteração comum
+func (f *Falso) Reverter(ctx context.Context, id string, revisao, versao int) (dominio.Tarefa, error) {
+	if versao == 0 {
+		return dominio.Tarefa{}, ErrVersaoAusente
+	}
+	rev, err := f.revisao(ctx, id, revisao)
+	if err != nil {
+		return dominio.Tarefa{}, err
+	}
+	return f.alterar(ctx, id, versao, func(t *dominio.Tarefa) {
+		*t = rev.Tarefa
+	})
+}
+
+// revisao retorna a revisão da tarefa com o número informado
+func (f *Falso) revisao(ctx context.Context, id string, numero int) (dominio.Revisao, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Revisao{}, err
+	}
+	if f.indice(dono, id) < 0 {
+		return dominio.Revisao{}, erroNaoEncontrada()
+	}
+	for _, r := range f.revisoes {
+		if r.Dono == dono && r.TarefaID == id && r.Numero == numero {
+			return r, nil
+		}
+	}
+	return dominio.Revisao{}, erroRevisaoNaoEncontrada()
+}
+
+func (f *Falso) Atividade(ctx context.Context, id string) ([]dominio.Atividade, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if f.indice(dono, id) < 0 {
+		return nil, erroNaoEncontrada()
+	}
+	da := func(tarefaID, donoRegistro string) bool { return tarefaID == id && donoRegistro == dono }
+	var estados []dominio.MudancaEstado
+	for _, m := range f.historico {
+		if da(m.TarefaID, m.Dono) {
+			estados = append(estados, m)
+		}
+	}
+	var renomeacoes []dominio.Renomeacao
+	for _, r := range f.renomeacoes {
+		if da(r.TarefaID, r.Dono) {
+			renomeacoes = append(renomeacoes, r)
+		}
+	}
+	var comentarios []dominio.Comentario
+	for _, c := range f.comentarios {
+		if da(c.TarefaID, c.Dono) {
+			comentarios = append(comentarios, c)
+		}
+	}
+	return dominio.LinhaDoTempo(estados, renomeacoes, comentarios), nil
+}
+
+func (f *Falso) Comentar(ctx context.Context, tarefaID, texto string) (dominio.Comentario, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Comentario{}, err
+	}
+	if f.indice(dono, tarefaID) < 0 {
+		return dominio.Comentario{}, erroNaoEncontrada()
+	}
+	c := dominio.Comentario{ID: f.novoID(), TarefaID: tarefaID, Texto: texto, Dono: dono, CriadoEm: time.Now().UTC()}
+	if err := c.Validar(); err != nil {
+		return dominio.Comentario{}, erroValidacao(err)
+	}
+	c.Normalizar()
+	f.comentarios = append(f.comentarios, c)
+	return c, nil
+}
+
+func (f *Falso) EditarComentario(ctx context.Context, tarefaID, id, texto string) (dominio.Comentario, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, err := f.indiceComentario(ctx, tarefaID, id)
+	if err != nil {
+		return dominio.Comentario{}, err
+	}
+	if err := (dominio.Comentario{Texto: texto}).Validar(); err != nil {
+		return dominio.Comentario{}, erroValidacao(err)
+	}
+	instante := time.Now().UTC()
+	c := &f.comentarios[i]
+	c.Texto, c.Editado, c.EditadoEm = texto, true, &instante
+	c.Normalizar()
+	return *c, nil
+}
+
+func (f *Falso) RemoverComentario(ctx context.Context, tarefaID, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, err := f.indiceComentario(ctx, tarefaID, id)
+	if err != nil {
+		return err
+	}
+	f.comentarios = slices.Delete(f.comentarios, i, i+1)
+	return nil
+}
+
+func (f *Falso) Anexos(ctx context.Context, tarefaID string) ([]dominio.Anexo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if f.indice(dono, tarefaID) < 0 {
+		return nil, erroNaoEncontrada()
+	}
+	anexos := []dominio.Anexo{}
+	for _, a := range f.anexos {
+		if a.Dono == dono && a.TarefaID == tarefaID {
+			anexos = append(anexos, a)
+		}
+	}
+	return anexos, nil
+}
+
+// Anexar guarda o arquivo com o tipo informado, sem conferir o tamanho nem
+// os tipos aceitos pela API
+func (f *Falso) Anexar(ctx context.Context, tarefaID, nome, tipo string, conteudo []byte) (dominio.Anexo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Anexo{}, err
+	}
+	if f.indice(dono, tarefaID) < 0 {
+		return dominio.Anexo{}, erroNaoEncontrada()
+	}
+	hash := sha256.Sum256(conteudo)
+	a := dominio.Anexo{
+		ID:       f.novoID(),
+		TarefaID: tarefaID,
+		Nome:     dominio.NomeAnexo(nome),
+		Tipo:     tipo,
+		Tamanho:  int64(len(conteudo)),
+		Hash:     hex.EncodeToString(hash[:]),
+		Dono:     dono,
+		CriadoEm: time.Now().UTC(),
+	}
+	if f.conteudos == nil {
+		f.conteudos = make(map[string][]byte)
+	}
+	f.anexos = append(f.anexos, a)
+	f.conteudos[a.ID] = slices.Clone(conteudo)
+	return a, nil
+}
+
+func (f *Falso) BaixarAnexo(ctx context.Context, tarefaID, id string) (dominio.Anexo, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, err := f.indiceAnexo(ctx, tarefaID, id)
+	if err != nil {
+		return dominio.Anexo{}, nil, err
+	}
+	return f.anexos[i], slices.Clone(f.conteudos[id]), nil
+}
+
+func (f *Falso) RemoverAnexo(ctx context.Context, tarefaID, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i, err := f.indiceAnexo(ctx, tarefaID, id)
+	if err != nil {
+		return err
+	}
+	f.anexos = slices.Delete(f.anexos, i, i+1)
+	delete(f.conteudos, id)
+	return nil
+}
+
+// indiceAnexo retorna a posição do anexo da tarefa do usuário do contexto,
+// ou o erro que a API retornaria; o chamador deve possuir o bloqueio
+func (f *Falso) indiceAnexo(ctx context.Context, tarefaID, id string) (int, error) {
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if f.indice(dono, tarefaID) < 0 {
+		return -1, erroNaoEncontrada()
+	}
+	for i, a := range f.anexos {
+		if a.ID == id && a.Dono == dono && a.TarefaID == tarefaID {
+			return i, nil
+		}
+	}
+	return -1, erroAnexoNaoEncontrado()
+}
+
+// indiceComentario retorna a posição do comentário da tarefa do usuário do
+// contexto, ou o erro que a API retornaria; o chamador deve possuir o bloqueio
+func (f *Falso) indiceComentario(ctx context.Context, tarefaID, id string) (int, error) {
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if f.indice(dono, tarefaID) < 0 {
+		return -1, erroNaoEncontrada()
+	}
+	for i, c := range f.comentarios {
+		if c.ID == id && c.TarefaID == tarefaID && c.Dono == dono {
+			return i, nil
+		}
+	}
+	return -1, erroComentarioNaoEncontrado()
+}
+
+// registrarEstado grava a mudança de estado da tarefa no histórico, se o
+// estado mudou; o chamador deve possuir o bloqueio
+func (f *Falso) registrarEstado(dono, id, de, para string) {
+	if de != para {
+		f.historico = append(f.historico, dominio.MudancaEstado{
+			ID: f.novoID(), TarefaID: id, De: de, Para: para, Em: time.Now().UTC(), Dono: dono,
+		})
+	}
+}
+
+// registrarRevisao grava a revisão da tarefa, se algum campo revisado mudou;
+// o chamador deve possuir o bloqueio
+func (f *Falso) registrarRevisao(dono string, antes *dominio.Tarefa, depois dominio.Tarefa) {
+	diferencas := dominio.DiferencasTarefa(antes, depois)
+	if len(diferencas) == 0 {
+		return
+	}
+	f.revisoes = append(f.revisoes, dominio.Revisao{
+		ID: f.novoID(), TarefaID: depois.ID, Numero: depois.Versao, Autor: dono,
+		Em: depois.AtualizadaEm, Diferencas: diferencas, Tarefa: depois, Dono: dono,
+	})
+}
+
+func (f *Falso) ListarProjetos(ctx context.Context) ([]dominio.Projeto, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	projetos := []dominio.Projeto{}
+	for _, p := range f.projetos {
+		if p.Dono == dono {
+			projetos = append(projetos, p)
+		}
+	}
+	return projetos, nil
+}
+
+func (f *Falso) BuscarProjeto(ctx context.Context, id string) (dominio.Projeto, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Projeto{}, err
+	}
+	i := f.indiceProjeto(dono, id)
+	if i < 0 {
+		return dominio.Projeto{}, erroProjetoNaoEncontrado()
+	}
+	return f.projetos[i], nil
+}
+
+func (f *Falso) CriarProjeto(ctx context.Context, nome string) (dominio.Projeto, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Projeto{}, err
+	}
+	p := dominio.Projeto{Nome: strings.TrimSpace(nome)}
+	if err := p.Validar(); err != nil {
+		return dominio.Projeto{}, erroValidacao(err)
+	}
+	instante := time.Now().UTC()
+	p.ID, p.Dono, p.CriadoEm, p.AtualizadoEm = f.novoID(), dono, instante, instante
+	f.projetos = append(f.projetos, p)
+	return p, nil
+}
+
+func (f *Falso) RenomearProjeto(ctx context.Context, id, nome string) (dominio.Projeto, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Projeto{}, err
+	}
+	i := f.indiceProjeto(dono, id)
+	if i < 0 {
+		return dominio.Projeto{}, erroProjetoNaoEncontrado()
+	}
+	p := f.projetos[i]
+	p.Nome = strings.TrimSpace(nome)
+	if err := p.Validar(); err != nil {
+		return dominio.Projeto{}, erroValidacao(err)
+	}
+	p.AtualizadoEm = time.Now().UTC()
+	f.projetos[i] = p
+	return p, nil
+}
+
+// RemoverProjeto exclui o projeto; as tarefas continuam apontando para ele
+func (f *Falso) RemoverProjeto(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return err
+	}
+	i := f.indiceProjeto(dono, id)
+	if i < 0 {
+		return erroProjetoNaoEncontrado()
+	}
+	f.projetos = slices.Delete(f.projetos, i, i+1)
+	return nil
+}
+
+// DefinirFluxo grava o fluxo do projeto sem mover as tarefas de estado
+func (f *Falso) DefinirFluxo(ctx context.Context, id string, fluxo *dominio.Fluxo) (dominio.Projeto, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Projeto{}, err
+	}
+	i := f.indiceProjeto(dono, id)
+	if i < 0 {
+		return dominio.Projeto{}, erroProjetoNaoEncontrado()
+	}
+	p := f.projetos[i]
+	p.Fluxo = nil
+	if fluxo != nil {
+		copia := dominio.Fluxo{Estados: slices.Clone(fluxo.Estados), Transicoes: slices.Clone(fluxo.Transicoes)}
+		p.Fluxo = &copia
+	}
+	if err := p.Validar(); err != nil {
+		return dominio.Projeto{}, erroValidacao(err)
+	}
+	p.AtualizadoEm = time.Now().UTC()
+	f.projetos[i] = p
+	return p, nil
+}
+
+// indiceProjeto retorna a posição do projeto do dono ou -1; o chamador deve
+// possuir o bloqueio
+func (f *Falso) indiceProjeto(dono, id string) int {
+	for i, p := range f.projetos {
+		if p.ID == id && p.Dono == dono {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *Falso) ListarEtiquetas(ctx context.Context) ([]dominio.Etiqueta, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	etiquetas := []dominio.Etiqueta{}
+	for _, e := range f.etiquetas {
+		if e.Dono == dono {
+			etiquetas = append(etiquetas, e)
+		}
+	}
+	return etiquetas, nil
+}
+
+func (f *Falso) BuscarEtiqueta(ctx context.Context, id string) (dominio.Etiqueta, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Etiqueta{}, err
+	}
+	i := f.indiceEtiqueta(dono, id)
+	if i < 0 {
+		return dominio.Etiqueta{}, erroEtiquetaNaoEncontrada()
+	}
+	return f.etiquetas[i], nil
+}
+
+func (f *Falso) CriarEtiqueta(ctx context.Context, nome, cor string) (dominio.Etiqueta, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Etiqueta{}, err
+	}
+	e := dominio.Etiqueta{Nome: nome, Cor: cor}
+	if err := e.Validar(); err != nil {
+		return dominio.Etiqueta{}, erroValidacao(err)
+	}
+	e.Normalizar()
+	instante := time.Now().UTC()
+	e.ID, e.Dono, e.CriadaEm, e.AtualizadaEm = f.novoID(), dono, instante, instante
+	f.etiquetas = append(f.etiquetas, e)
+	return e, nil
+}
+
+func (f *Falso) AlterarEtiqueta(ctx context.Context, id string, a AlteracaoEtiqueta) (dominio.Etiqueta, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return dominio.Etiqueta{}, err
+	}
+	i := f.indiceEtiqueta(dono, id)
+	if i < 0 {
+		return dominio.Etiqueta{}, erroEtiquetaNaoEncontrada()
+	}
+	e := f.etiquetas[i]
+	a.aplicar(&e)
+	e.Nome = strings.TrimSpace(e.Nome)
+	if err := e.Validar(); err != nil {
+		return dominio.Etiqueta{}, erroValidacao(err)
+	}
+	e.Normalizar()
+	e.AtualizadaEm = time.Now().UTC()
+	f.etiquetas[i] = e
+	return e, nil
+}
+
+// RemoverEtiqueta exclui a etiqueta; as tarefas continuam com o ID dela
+func (f *Falso) RemoverEtiqueta(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dono, err := f.verificar(ctx)
+	if err != nil {
+		return err
+	}
+	i := f.indiceEtiqueta(dono, id)
+	if i < 0 {
+		return erroEtiquetaNaoEncontrada()
+	}
+	f.etiquetas = slices.Delete(f.etiquetas, i, i+1)
+	return nil
+}
+
+// indiceEtiqueta retorna a posição da etiqueta do dono ou -1; o chamador
+// deve possuir o bloqueio
+func (f *Falso) indiceEtiqueta(dono, id string) int {
+	for i, e := range f.etiquetas {
+		if e.ID == id && e.Dono == dono {
+			return i
+		}
+	}
+	return -1
+}
+
+// temEtiquetas aplica o filtro de etiquetas de Listar: todas as etiquetas
+// ou, se alguma, pelo menos uma delas
+func temEtiquetas(t dominio.Tarefa, ids []string, alguma bool) bool {
+	for _, id := range ids {
+		if t.TemEtiqueta(id) == alguma {
+			return alguma
+		}
+	}
+	return !alguma
+}
+
+// erroEtiquetaNaoEncontrada reproduz o erro da API para uma etiqueta inexistente
+func erroEtiquetaNaoEncontrada() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoEtiquetaNaoEncontrada,
+		Mensagens: dominio.Mensagens{PtBR: "etiqueta não encontrada", En: "tag not found"},
+	})
+}
+
+// erroComentarioNaoEncontrado reproduz o erro da API para um comentário inexistente
+func erroComentarioNaoEncontrado() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoComentarioNaoEncontrado,
+		Mensagens: dominio.Mensagens{PtBR: "comentário não encontrado", En: "comment not found"},
+	})
+}
+
+// erroAnexoNaoEncontrado reproduz o erro da API para um anexo inexistente
+func erroAnexoNaoEncontrado() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoAnexoNaoEncontrado,
+		Mensagens: dominio.Mensagens{PtBR: "anexo não encontrado", En: "attachment not found"},
+	})
+}
+
+// erroRevisaoNaoEncontrada reproduz o erro da API para uma revisão inexistente
+func erroRevisaoNaoEncontrada() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoRevisaoNaoEncontrada,
+		Mensagens: dominio.Mensagens{PtBR: "revisão não encontrada", En: "revision not found"},
+	})
+}
+
+// erroProjetoNaoEncontrado reproduz o erro da API para um projeto inexistente
+func erroProjetoNaoEncontrado() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoProjetoNaoEncontrado,
+		Mensagens: dominio.Mensagens{PtBR: "projeto não encontrado", En: "project not found"},
+	})
+}
+
+// erroConflitoVersao reproduz o erro da API para uma escrita feita sobre
+// uma versão desatualizada da tarefa
+func erroConflitoVersao() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusPreconditionFailed,
+		Codigo:    dominio.CodigoConflitoVersao,
+		Mensagens: dominio.Mensagens{PtBR: "a tarefa foi alterada por outra requisição", En: "the task was changed by another request"},
+	})
+}
+
+// erroNaoEncontrada reproduz o erro da API para uma tarefa inexistente
+func erroNaoEncontrada() *ErroAPI {
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusNotFound,
+		Codigo:    dominio.CodigoTarefaNaoEncontrada,
+		Mensagens: dominio.Mensagens{PtBR: "tarefa não encontrada", En: "task not found"},
+	})
+}
+
+// erroValidacao reproduz o erro da API para uma tarefa, um projeto, uma
+// etiqueta ou um parâmetro inválidos
+func erroValidacao(err error) *ErroAPI {
+	validacao := err.(*dominio.ErroValidacao)
+	return novoErroAPI(dominio.Problema{
+		Status:    http.StatusBadRequest,
+		Codigo:    dominio.CodigoValidacao,
+		Mensagens: validacao.Mensagens,
+		Campos:    []dominio.ErroValidacao{*validacao},
+	})
+}
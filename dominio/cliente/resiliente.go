@@ -0,0 +1,466 @@
+package cliente
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// ErrDisjuntorAberto é retornado sem chamar a API enquanto o disjuntor está aberto
+var ErrDisjuntorAberto = errors.New("API indisponível: disjuntor aberto")
+
+// ConfigResiliencia define novas tentativas e disjuntor de Resiliente.
+// Campos com valor zero usam os padrões indicados.
+type ConfigResiliencia struct {
+	// Tentativas é o número máximo de tentativas de chamadas idempotentes (padrão 3)
+	Tentativas int
+	// Espera é a base do recuo exponencial entre tentativas (padrão 100ms);
+	// a espera real é sorteada entre zero e Espera*2^n
+	Espera time.Duration
+	// FalhasParaAbrir é o número de falhas seguidas que abre o disjuntor (padrão 5)
+	FalhasParaAbrir int
+	// Pausa é o tempo que o disjuntor fica aberto antes de permitir uma
+	// chamada de teste (padrão 30s)
+	Pausa time.Duration
+}
+
+// Resiliente decora uma API com novas tentativas e um disjuntor.
+// As leituras, os PUT e os DELETE são idempotentes e repetidos em falhas de
+// rede ou respostas 5xx, assim como AlterarEtiqueta, que só define valores;
+// Entrar, Criar, Alterar, CriarProjeto e CriarEtiqueta são tentados uma
+// única vez. Erros 4xx nunca são repetidos nem contam como falha.
+//
+// Uma tentativa que falhou pode ter sido aplicada e perdido só a resposta.
+// Por isso um Atualizar com versão não é repetido: a tarefa teria passado
+// à versão seguinte e a repetição responderia ErrConflito. Já um DELETE
+// repetido que responde ErrNaoEncontrada conta como sucesso, pois o recurso
+// pode ter sido removido pela tentativa anterior.
+type Resiliente struct {
+	api    API
+	config ConfigResiliencia
+
+	mu           sync.Mutex
+	falhas       int
+	abertoAte    time.Time
+	testeEmCurso bool
+
+	// agora e dormir são variáveis para que os testes controlem o tempo
+	agora  func() time.Time
+	dormir func(ctx context.Context, d time.Duration) error
+}
+
+// NovoResiliente cria o decorador sobre api com a configuração informada
+func NovoResiliente(api API, config ConfigResiliencia) *Resiliente {
+	if config.Tentativas <= 0 {
+		config.Tentativas = 3
+	}
+	if config.Espera <= 0 {
+		config.Espera = 100 * time.Millisecond
+	}
+	if config.FalhasParaAbrir <= 0 {
+		config.FalhasParaAbrir = 5
+	}
+	if config.Pausa <= 0 {
+		config.Pausa = 30 * time.Second
+	}
+	return &Resiliente{api: api, config: config, agora: time.Now, dormir: dormir}
+}
+
+func (r *Resiliente) Entrar(ctx context.Context, usuario, senha string) (dominio.Sessao, error) {
+	var s dominio.Sessao
+	err := r.executar(ctx, false, func() (err error) {
+		s, err = r.api.Entrar(ctx, usuario, senha)
+		return err
+	})
+	return s, err
+}
+
+func (r *Resiliente) Listar(ctx context.Context, c Consulta) (dominio.PaginaTarefas, error) {
+	var pagina dominio.PaginaTarefas
+	err := r.executar(ctx, true, func() (err error) {
+		pagina, err = r.api.Listar(ctx, c)
+		return err
+	})
+	return pagina, err
+}
+
+func (r *Resiliente) Buscar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	var t dominio.Tarefa
+	err := r.executar(ctx, true, func() (err error) {
+		t, err = r.api.Buscar(ctx, id)
+		return err
+	})
+	return t, err
+}
+
+func (r *Resiliente) Criar(ctx context.Context, t dominio.Tarefa) (dominio.Tarefa, error) {
+	var criada dominio.Tarefa
+	err := r.executar(ctx, false, func() (err error) {
+		criada, err = r.api.Criar(ctx, t)
+		return err
+	})
+	return criada, err
+}
+
+func (r *Resiliente) Atualizar(ctx context.Context, id string, t dominio.Tarefa) (dominio.Tarefa, error) {
+	var atualizada dominio.Tarefa
+	err := r.executar(ctx, t.Versao == QualquerVersao, func() (err error) {
+		atualizada, err = r.api.Atualizar(ctx, id, t)
+		return err
+	})
+	return atualizada, err
+}
+
+func (r *Resiliente) Alterar(ctx context.Context, id string, a Alteracao) (dominio.Tarefa, error) {
+	var alterada dominio.Tarefa
+	err := r.executar(ctx, false, func() (err error) {
+		alterada, err = r.api.Alterar(ctx, id, a)
+		return err
+	})
+	return alterada, err
+}
+
+func (r *Resiliente) Remover(ctx context.Context, id string, versao int) error {
+	return r.remover(ctx, func() error {
+		return r.api.Remover(ctx, id, versao)
+	})
+}
+
+func (r *Resiliente) RemoverEmCascata(ctx context.Context, id string, versao int) error {
+	return r.remover(ctx, func() error {
+		return r.api.RemoverEmCascata(ctx, id, versao)
+	})
+}
+
+func (r *Resiliente) Lixeira(ctx context.Context) ([]dominio.ItemLixeira, error) {
+	var itens []dominio.ItemLixeira
+	err := r.executar(ctx, true, func() (err error) {
+		itens, err = r.api.Lixeira(ctx)
+		return err
+	})
+	return itens, err
+}
+
+// Restaurar não é repetido: depois de uma restauração que chegou a ser
+// aplicada, a tentativa seguinte não encontraria a tarefa na lixeira
+func (r *Resiliente) Restaurar(ctx context.Context, id string) (dominio.Tarefa, error) {
+	var restaurada dominio.Tarefa
+	err := r.executar(ctx, false, func() (err error) {
+		restaurada, err = r.api.Restaurar(ctx, id)
+		return err
+	})
+	return restaurada, err
+}
+
+func (r *Resiliente) Purgar(ctx context.Context, id string) error {
+	return r.remover(ctx, func() error {
+		return r.api.Purgar(ctx, id)
+	})
+}
+
+func (r *Resiliente) HistoricoEstados(ctx context.Context, id string) ([]dominio.MudancaEstado, error) {
+	var mudancas []dominio.MudancaEstado
+	err := r.executar(ctx, true, func() (err error) {
+		mudancas, err = r.api.HistoricoEstados(ctx, id)
+		return err
+	})
+	return mudancas, err
+}
+
+func (r *Resiliente) Revisoes(ctx context.Context, id string) ([]dominio.Revisao, error) {
+	var revisoes []dominio.Revisao
+	err := r.executar(ctx, true, func() (err error) {
+		revisoes, err = r.api.Revisoes(ctx, id)
+		return err
+	})
+	return revisoes, err
+}
+
+// Reverter não é repetido: uma reversão que chegou a ser aplicada passou a
+// tarefa para a versão seguinte, e a repetição responderia ErrConflito
+func (r *Resiliente) Reverter(ctx context.Context, id string, revisao, versao int) (dominio.Tarefa, error) {
+	var revertida dominio.Tarefa
+	err := r.executar(ctx, false, func() (err error) {
+		revertida, err = r.api.Reverter(ctx, id, revisao, versao)
+		return err
+	})
+	return revertida, err
+}
+
+func (r *Resiliente) Atividade(ctx context.Context, id string) ([]dominio.Atividade, error) {
+	var atividades []dominio.Atividade
+	err := r.executar(ctx, true, func() (err error) {
+		atividades, err = r.api.Atividade(ctx, id)
+		return err
+	})
+	return atividades, err
+}
+
+func (r *Resiliente) Comentar(ctx context.Context, tarefaID, texto string) (dominio.Comentario, error) {
+	var criado dominio.Comentario
+	err := r.executar(ctx, false, func() (err error) {
+		criado, err = r.api.Comentar(ctx, tarefaID, texto)
+		return err
+	})
+	return criado, err
+}
+
+func (r *Resiliente) EditarComentario(ctx context.Context, tarefaID, id, texto string) (dominio.Comentario, error) {
+	var editado dominio.Comentario
+	err := r.executar(ctx, true, func() (err error) {
+		editado, err = r.api.EditarComentario(ctx, tarefaID, id, texto)
+		return err
+	})
+	return editado, err
+}
+
+func (r *Resiliente) RemoverComentario(ctx context.Context, tarefaID, id string) error {
+	return r.remover(ctx, func() error {
+		return r.api.RemoverComentario(ctx, tarefaID, id)
+	})
+}
+
+func (r *Resiliente) Anexos(ctx context.Context, tarefaID string) ([]dominio.Anexo, error) {
+	var anexos []dominio.Anexo
+	err := r.executar(ctx, true, func() (err error) {
+		anexos, err = r.api.Anexos(ctx, tarefaID)
+		return err
+	})
+	return anexos, err
+}
+
+func (r *Resiliente) Anexar(ctx context.Context, tarefaID, nome, tipo string, conteudo []byte) (dominio.Anexo, error) {
+	var criado dominio.Anexo
+	err := r.executar(ctx, false, func() (err error) {
+		criado, err = r.api.Anexar(ctx, tarefaID, nome, tipo, conteudo)
+		return err
+	})
+	return criado, err
+}
+
+func (r *Resiliente) BaixarAnexo(ctx context.Context, tarefaID, id string) (dominio.Anexo, []byte, error) {
+	var a dominio.Anexo
+	var conteudo []byte
+	err := r.executar(ctx, true, func() (err error) {
+		a, conteudo, err = r.api.BaixarAnexo(ctx, tarefaID, id)
+		return err
+	})
+	return a, conteudo, err
+}
+
+func (r *Resiliente) RemoverAnexo(ctx context.Context, tarefaID, id string) error {
+	return r.remover(ctx, func() error {
+		return r.api.RemoverAnexo(ctx, tarefaID, id)
+	})
+}
+
+func (r *Resiliente) ListarProjetos(ctx context.Context) ([]dominio.Projeto, error) {
+	var projetos []dominio.Projeto
+	err := r.executar(ctx, true, func() (err error) {
+		projetos, err = r.api.ListarProjetos(ctx)
+		return err
+	})
+	return projetos, err
+}
+
+func (r *Resiliente) BuscarProjeto(ctx context.Context, id string) (dominio.Projeto, error) {
+	var p dominio.Projeto
+	err := r.executar(ctx, true, func() (err error) {
+		p, err = r.api.BuscarProjeto(ctx, id)
+		return err
+	})
+	return p, err
+}
+
+func (r *Resiliente) CriarProjeto(ctx context.Context, nome string) (dominio.Projeto, error) {
+	var criado dominio.Projeto
+	err := r.executar(ctx, false, func() (err error) {
+		criado, err = r.api.CriarProjeto(ctx, nome)
+		return err
+	})
+	return criado, err
+}
+
+func (r *Resiliente) RenomearProjeto(ctx context.Context, id, nome string) (dominio.Projeto, error) {
+	var renomeado dominio.Projeto
+	err := r.executar(ctx, true, func() (err error) {
+		renomeado, err = r.api.RenomearProjeto(ctx, id, nome)
+		return err
+	})
+	return renomeado, err
+}
+
+func (r *Resiliente) DefinirFluxo(ctx context.Context, id string, fluxo *dominio.Fluxo) (dominio.Projeto, error) {
+	var alterado dominio.Projeto
+	err := r.executar(ctx, true, func() (err error) {
+		alterado, err = r.api.DefinirFluxo(ctx, id, fluxo)
+		return err
+	})
+	return alterado, err
+}
+
+func (r *Resiliente) RemoverProjeto(ctx context.Context, id string) error {
+	return r.remover(ctx, func() error {
+		return r.api.RemoverProjeto(ctx, id)
+	})
+}
+
+func (r *Resiliente) ListarEtiquetas(ctx context.Context) ([]dominio.Etiqueta, error) {
+	var etiquetas []dominio.Etiqueta
+	err := r.executar(ctx, true, func() (err error) {
+		etiquetas, err = r.api.ListarEtiquetas(ctx)
+		return err
+	})
+	return etiquetas, err
+}
+
+func (r *Resiliente) BuscarEtiqueta(ctx context.Context, id string) (dominio.Etiqueta, error) {
+	var e dominio.Etiqueta
+	err := r.executar(ctx, true, func() (err error) {
+		e, err = r.api.BuscarEtiqueta(ctx, id)
+		return err
+	})
+	return e, err
+}
+
+func (r *Resiliente) CriarEtiqueta(ctx context.Context, nome, cor string) (dominio.Etiqueta, error) {
+	var criada dominio.Etiqueta
+	err := r.executar(ctx, false, func() (err error) {
+		criada, err = r.api.CriarEtiqueta(ctx, nome, cor)
+		return err
+	})
+	return criada, err
+}
+
+func (r *Resiliente) AlterarEtiqueta(ctx context.Context, id string, a AlteracaoEtiqueta) (dominio.Etiqueta, error) {
+	var alterada dominio.Etiqueta
+	err := r.executar(ctx, true, func() (err error) {
+		alterada, err = r.api.AlterarEtiqueta(ctx, id, a)
+		return err
+	})
+	return alterada, err
+}
+
+func (r *Resiliente) RemoverEtiqueta(ctx context.Context, id string) error {
+	return r.remover(ctx, func() error {
+		return r.api.RemoverEtiqueta(ctx, id)
+	})
+}
+
+// remover executa um DELETE com novas tentativas. Uma repetição que
+// responde ErrNaoEncontrada conta como sucesso: a tentativa anterior pode ter
+// removido o recurso e perdido só a resposta.
+func (r *Resiliente) remover(ctx context.Context, chamada func() error) error {
+	repeticao := false
+	return r.executar(ctx, true, func() error {
+		err := chamada()
+		if repeticao && errors.Is(err, ErrNaoEncontrada) {
+			return nil
+		}
+		repeticao = true
+		return err
+	})
+}
+
+// executar passa a chamada pelo disjuntor e, se idempotente, a repete com
+// recuo exponencial e jitter enquanto a falha for transitória
+func (r *Resiliente) executar(ctx context.Context, idempotente bool, chamada func() error) error {
+	tentativas := 1
+	if idempotente {
+		tentativas = r.config.Tentativas
+	}
+
+	var err error
+	for n := 0; n < tentativas; n++ {
+		if n > 0 {
+			limite := r.config.Espera << (n - 1)
+			if errEspera := r.dormir(ctx, time.Duration(rand.Int63n(int64(limite)+1))); errEspera != nil {
+				return err
+			}
+		}
+
+		if !r.permitir() {
+			return ErrDisjuntorAberto
+		}
+		err = chamada()
+		if ctx.Err() != nil {
+			// Cancelamento pelo chamador não diz nada sobre a saúde da API
+			r.liberarTeste()
+			return err
+		}
+		transitoria := falhaTransitoria(err)
+		r.registrar(!transitoria)
+		if !transitoria {
+			return err
+		}
+	}
+	return err
+}
+
+// permitir informa se o disjuntor deixa a chamada passar. Depois da pausa,
+// apenas uma chamada de teste é liberada até que seu resultado seja registrado.
+func (r *Resiliente) permitir() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.falhas < r.config.FalhasParaAbrir {
+		return true
+	}
+	if r.agora().Before(r.abertoAte) || r.testeEmCurso {
+		return false
+	}
+	r.testeEmCurso = true
+	return true
+}
+
+// registrar contabiliza o resultado de uma chamada no disjuntor
+func (r *Resiliente) registrar(sucesso bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.testeEmCurso = false
+	if sucesso {
+		r.falhas = 0
+		return
+	}
+	r.falhas++
+	if r.falhas >= r.config.FalhasParaAbrir {
+		r.abertoAte = r.agora().Add(r.config.Pausa)
+	}
+}
+
+// liberarTeste permite uma nova chamada de teste sem registrar resultado
+func (r *Resiliente) liberarTeste() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.testeEmCurso = false
+}
+
+// falhaTransitoria informa se vale a pena repetir a chamada: erros de rede e
+// respostas 5xx sim; erros 4xx não
+func falhaTransitoria(err error) bool {
+	if err == nil {
+		return false
+	}
+	var erroAPI *ErroAPI
+	if errors.As(err, &erroAPI) {
+		return erroAPI.DoServidor()
+	}
+	return true
+}
+
+// dormir espera d ou até o contexto ser cancelado
+func dormir(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,196 @@
+package cliente
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// apiInstavel devolve, em ordem, os erros programados e depois delega ao Falso
+type apiInstavel struct {
+	*Falso
+	erros    []error
+	chamadas int
+}
+
+func (a *apiInstavel) proximoErro() error {
+	a.chamadas++
+	if len(a.erros) == 0 {
+		return nil
+	}
+	err := a.erros[0]
+	a.erros = a.erros[1:]
+	return err
+}
+
+func (a *apiInstavel) Listar(ctx context.Context, c Consulta) (dominio.PaginaTarefas, error) {
+	if err := a.proximoErro(); err != nil {
+		return dominio.PaginaTarefas{}, err
+	}
+	return a.Falso.Listar(ctx, c)
+}
+
+func (a *apiInstavel) Criar(ctx context.Context, t dominio.Tarefa) (dominio.Tarefa, error) {
+	if err := a.proximoErro(); err != nil {
+		return dominio.Tarefa{}, err
+	}
+	return a.Falso.Criar(ctx, t)
+}
+
+// Remover e Atualizar chegam ao Falso antes do erro programado, como uma
+// escrita gravada cuja resposta se perdeu na rede
+
+func (a *apiInstavel) Remover(ctx context.Context, id string, versao int) error {
+	err := a.Falso.Remover(ctx, id, versao)
+	if erro := a.proximoErro(); erro != nil {
+		return erro
+	}
+	return err
+}
+
+func (a *apiInstavel) Atualizar(ctx context.Context, id string, t dominio.Tarefa) (dominio.Tarefa, error) {
+	atualizada, err := a.Falso.Atualizar(ctx, id, t)
+	if erro := a.proximoErro(); erro != nil {
+		return dominio.Tarefa{}, erro
+	}
+	return atualizada, err
+}
+
+// novoResilienteTeste cria o decorador com relógio controlado e sem esperas reais
+func novoResilienteTeste(api API, relogio *time.Time) *Resiliente {
+	r := NovoResiliente(api, ConfigResiliencia{Tentativas: 3, FalhasParaAbrir: 2, Pausa: time.Minute})
+	r.agora = func() time.Time { return *relogio }
+	r.dormir = func(context.Context, time.Duration) error { return nil }
+	return r
+}
+
+var (
+	errRede         = errors.New("conexão recusada")
+	errIndisponivel = &ErroAPI{Status: http.StatusServiceUnavailable}
+)
+
+func TestResilienteRepeteChamadasIdempotentes(t *testing.T) {
+	relogio := time.Now()
+	api := &apiInstavel{Falso: NovoFalso(dominio.Tarefa{Titulo: "Ok"}), erros: []error{errRede, errIndisponivel}}
+	r := NovoResiliente(api, ConfigResiliencia{Tentativas: 3, FalhasParaAbrir: 10})
+	r.agora = func() time.Time { return relogio }
+	r.dormir = func(context.Context, time.Duration) error { return nil }
+
+	pagina, err := r.Listar(context.Background(), Consulta{})
+	if err != nil {
+		t.Fatalf("esperado sucesso na terceira tentativa, obtido %v", err)
+	}
+	if api.chamadas != 3 || len(pagina.Tarefas) != 1 {
+		t.Errorf("chamadas: obtidas %d esperadas 3", api.chamadas)
+	}
+}
+
+func TestResilienteNaoRepeteCriar(t *testing.T) {
+	relogio := time.Now()
+	api := &apiInstavel{Falso: NovoFalso(), erros: []error{errIndisponivel}}
+	r := novoResilienteTeste(api, &relogio)
+
+	if _, err := r.Criar(context.Background(), dominio.Tarefa{Titulo: "Única"}); err != errIndisponivel {
+		t.Errorf("esperado o erro original, obtido %v", err)
+	}
+	if api.chamadas != 1 {
+		t.Errorf("POST não é idempotente e não deve ser repetido: %d chamadas", api.chamadas)
+	}
+}
+
+func TestResilienteRemocaoComRespostaPerdida(t *testing.T) {
+	relogio := time.Now()
+	falso := NovoFalso(dominio.Tarefa{Titulo: "Removida"})
+	api := &apiInstavel{Falso: falso, erros: []error{errRede}}
+	r := novoResilienteTeste(api, &relogio)
+	ctx := context.Background()
+	tarefa := falso.tarefas[0]
+
+	// A repetição encontra a tarefa já removida e responde 404
+	if err := r.Remover(ctx, tarefa.ID, tarefa.Versao); err != nil {
+		t.Fatalf("remoção feita na primeira tentativa informada como falha: %v", err)
+	}
+	if api.chamadas != 2 {
+		t.Errorf("chamadas: obtidas %d esperadas 2", api.chamadas)
+	}
+
+	// Sem repetição, o 404 continua sendo erro
+	if err := r.Remover(ctx, tarefa.ID, tarefa.Versao); !errors.Is(err, ErrNaoEncontrada) {
+		t.Errorf("esperado ErrNaoEncontrada, obtido %v", err)
+	}
+}
+
+func TestResilienteNaoRepeteAtualizarComVersao(t *testing.T) {
+	relogio := time.Now()
+	falso := NovoFalso(dominio.Tarefa{Titulo: "Antes"})
+	api := &apiInstavel{Falso: falso, erros: []error{errRede}}
+	r := NovoResiliente(api, ConfigResiliencia{Tentativas: 3, FalhasParaAbrir: 10})
+	r.agora = func() time.Time { return relogio }
+	r.dormir = func(context.Context, time.Duration) error { return nil }
+	ctx := context.Background()
+	tarefa := falso.tarefas[0]
+
+	tarefa.Titulo = "Depois"
+	if _, err := r.Atualizar(ctx, tarefa.ID, tarefa); err != errRede || api.chamadas != 1 {
+		t.Fatalf("PUT com versão repetido: %v, %d chamadas", err, api.chamadas)
+	}
+
+	// Com QualquerVersao a repetição não tem conflito a temer
+	api.erros = []error{errRede}
+	tarefa.Versao = QualquerVersao
+	if _, err := r.Atualizar(ctx, tarefa.ID, tarefa); err != nil || api.chamadas != 3 {
+		t.Errorf("PUT sem versão não repetido: %v, %d chamadas", err, api.chamadas)
+	}
+}
+
+func TestResilienteNaoRepeteErros4xx(t *testing.T) {
+	relogio := time.Now()
+	api := &apiInstavel{Falso: NovoFalso(), erros: []error{&ErroAPI{Status: http.StatusBadRequest}}}
+	r := novoResilienteTeste(api, &relogio)
+
+	if _, err := r.Listar(context.Background(), Consulta{}); !errors.Is(err, ErrRequisicaoInvalida) {
+		t.Errorf("esperado ErrRequisicaoInvalida, obtido %v", err)
+	}
+	if api.chamadas != 1 {
+		t.Errorf("erros 4xx não devem ser repetidos: %d chamadas", api.chamadas)
+	}
+}
+
+func TestResilienteDisjuntor(t *testing.T) {
+	relogio := time.Now()
+	api := &apiInstavel{Falso: NovoFalso(), erros: []error{errRede, errRede, errRede}}
+	r := novoResilienteTeste(api, &relogio)
+	ctx := context.Background()
+
+	// Duas falhas seguidas abrem o disjuntor no meio das tentativas
+	if _, err := r.Listar(ctx, Consulta{}); !errors.Is(err, ErrDisjuntorAberto) {
+		t.Fatalf("esperado ErrDisjuntorAberto, obtido %v", err)
+	}
+	if api.chamadas != 2 {
+		t.Fatalf("chamadas antes de abrir: obtidas %d esperadas 2", api.chamadas)
+	}
+
+	// Enquanto aberto, a API não é chamada
+	if _, err := r.Listar(ctx, Consulta{}); !errors.Is(err, ErrDisjuntorAberto) || api.chamadas != 2 {
+		t.Fatalf("disjuntor aberto deixou a chamada passar: %v, %d chamadas", err, api.chamadas)
+	}
+
+	// Após a pausa, uma chamada de teste que falha reabre o disjuntor
+	relogio = relogio.Add(time.Minute)
+	if _, err := r.Listar(ctx, Consulta{}); !errors.Is(err, ErrDisjuntorAberto) || api.chamadas != 3 {
+		t.Fatalf("chamada de teste inesperada: %v, %d chamadas", err, api.chamadas)
+	}
+
+	// Na pausa seguinte a API voltou: o teste passa e o disjuntor fecha
+	relogio = relogio.Add(time.Minute)
+	if _, err := r.Listar(ctx, Consulta{}); err != nil {
+		t.Fatalf("esperado sucesso após a API voltar, obtido %v", err)
+	}
+	if _, err := r.Listar(ctx, Consulta{}); err != nil || api.chamadas != 5 {
+		t.Errorf("disjuntor deveria estar fechado: %v, %d chamadas", err, api.chamadas)
+	}
+}
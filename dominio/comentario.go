@@ -0,0 +1,49 @@
+package dominio
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CodigoComentarioNaoEncontrado é o código de erro de um comentário inexistente
+const CodigoComentarioNaoEncontrado = "comentario_nao_encontrado"
+
+// TamanhoMaximoComentario é o número máximo de caracteres do texto de um comentário
+const TamanhoMaximoComentario = 10000
+
+// Comentario é uma mensagem na discussão de uma tarefa, com o texto em
+// Markdown. Editado marca os comentários alterados depois de criados.
+type Comentario struct {
+	ID       string    `json:"id"`
+	TarefaID string    `json:"tarefa_id"`
+	Texto    string    `json:"texto"`
+	Dono     string    `json:"dono,omitempty"`
+	CriadoEm time.Time `json:"criado_em"`
+	Editado  bool      `json:"editado"`
+	// EditadoEm é a data da última edição; nil em comentários não editados
+	EditadoEm *time.Time `json:"editado_em,omitempty"`
+}
+
+// Validar verifica o texto do comentário
+func (c Comentario) Validar() error {
+	if strings.TrimSpace(c.Texto) == "" {
+		return &ErroValidacao{"texto", CodigoObrigatorio, Mensagens{
+			PtBR: "o texto do comentário é obrigatório",
+			En:   "comment text is required",
+		}}
+	}
+	if utf8.RuneCountInString(c.Texto) > TamanhoMaximoComentario {
+		return &ErroValidacao{"texto", CodigoInvalido, Mensagens{
+			PtBR: "o comentário deve ter no máximo " + strconv.Itoa(TamanhoMaximoComentario) + " caracteres",
+			En:   "comment must have at most " + strconv.Itoa(TamanhoMaximoComentario) + " characters",
+		}}
+	}
+	return nil
+}
+
+// Normalizar remove os espaços em volta do texto
+func (c *Comentario) Normalizar() {
+	c.Texto = strings.TrimSpace(c.Texto)
+}
@@ -0,0 +1,54 @@
+package dominio
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CodigoEtiquetaNaoEncontrada é o código de erro de uma etiqueta inexistente
+const CodigoEtiquetaNaoEncontrada = "etiqueta_nao_encontrada"
+
+// CorPadraoEtiqueta é a cor das etiquetas criadas sem cor
+const CorPadraoEtiqueta = "#7f8c8d"
+
+// formatoCor aceita cores hexadecimais no formato #rrggbb
+var formatoCor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Etiqueta classifica tarefas por contexto, como casa ou trabalho. Uma
+// tarefa pode ter várias etiquetas, referenciadas pelo ID em Tarefa.Etiquetas.
+type Etiqueta struct {
+	ID           string    `json:"id"`
+	Nome         string    `json:"nome"`
+	Cor          string    `json:"cor"`
+	Dono         string    `json:"dono,omitempty"`
+	CriadaEm     time.Time `json:"criada_em"`
+	AtualizadaEm time.Time `json:"atualizada_em"`
+}
+
+// Validar verifica os campos editáveis pelo cliente
+func (e Etiqueta) Validar() error {
+	if strings.TrimSpace(e.Nome) == "" {
+		return &ErroValidacao{"nome", CodigoObrigatorio, Mensagens{
+			PtBR: "o nome da etiqueta é obrigatório",
+			En:   "tag name is required",
+		}}
+	}
+	if e.Cor != "" && !formatoCor.MatchString(e.Cor) {
+		return &ErroValidacao{"cor", CodigoInvalido, Mensagens{
+			PtBR: "a cor deve estar no formato #rrggbb",
+			En:   "color must be in the #rrggbb format",
+		}}
+	}
+	return nil
+}
+
+// Normalizar remove espaços do nome e preenche a cor padrão, guardando a
+// cor em minúsculas
+func (e *Etiqueta) Normalizar() {
+	e.Nome = strings.TrimSpace(e.Nome)
+	if e.Cor == "" {
+		e.Cor = CorPadraoEtiqueta
+	}
+	e.Cor = strings.ToLower(e.Cor)
+}
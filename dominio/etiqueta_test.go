@@ -0,0 +1,42 @@
+package dominio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidarEtiqueta(t *testing.T) {
+	casos := []struct {
+		etiqueta Etiqueta
+		campo    string
+	}{
+		{Etiqueta{Nome: "casa"}, ""},
+		{Etiqueta{Nome: "deploy", Cor: "#E67E22"}, ""},
+		{Etiqueta{Nome: " "}, "nome"},
+		{Etiqueta{Nome: "trabalho", Cor: "azul"}, "cor"},
+		{Etiqueta{Nome: "trabalho", Cor: "#fff"}, "cor"},
+	}
+	for _, caso := range casos {
+		err := caso.etiqueta.Validar()
+		var validacao *ErroValidacao
+		switch {
+		case caso.campo == "" && err != nil:
+			t.Errorf("%+v: erro inesperado %v", caso.etiqueta, err)
+		case caso.campo != "" && (!errors.As(err, &validacao) || validacao.Campo != caso.campo):
+			t.Errorf("%+v: esperado erro no campo %q, obtido %v", caso.etiqueta, caso.campo, err)
+		}
+	}
+}
+
+func TestNormalizarEtiqueta(t *testing.T) {
+	e := Etiqueta{Nome: "  casa  "}
+	e.Normalizar()
+	if e.Nome != "casa" || e.Cor != CorPadraoEtiqueta {
+		t.Errorf("etiqueta normalizada: %+v", e)
+	}
+	e.Cor = "#E67E22"
+	e.Normalizar()
+	if e.Cor != "#e67e22" {
+		t.Errorf("cor não foi convertida para minúsculas: %q", e.Cor)
+	}
+}
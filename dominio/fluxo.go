@@ -0,0 +1,299 @@
+package dominio
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estados do fluxo padrão, usado pelas tarefas sem projeto e pelos projetos
+// que não definem um fluxo próprio
+const (
+	EstadoPendente  = "pendente"
+	EstadoConcluida = "concluida"
+)
+
+// CodigoTransicaoInvalida é o código de erro de uma mudança de estado que o
+// fluxo do projeto não permite
+const CodigoTransicaoInvalida = "transicao_invalida"
+
+// CodigoLimiteEstado é o código de erro de uma mudança de estado para uma
+// coluna do quadro que já atingiu o seu limite
+const CodigoLimiteEstado = "limite_estado"
+
+// EstadoFluxo é um estado do fluxo de trabalho de um projeto
+type EstadoFluxo struct {
+	// ID identifica o estado nas tarefas, como "em_andamento"
+	ID   string `json:"id"`
+	Nome string `json:"nome"`
+	// Final indica que as tarefas neste estado estão concluídas
+	Final bool `json:"final,omitempty"`
+	// Limite é o número máximo de tarefas na coluna do estado no quadro
+	// (limite de trabalho em andamento); zero é sem limite
+	Limite int `json:"limite,omitempty"`
+}
+
+// Transicao é uma mudança de estado permitida pelo fluxo
+type Transicao struct {
+	De   string `json:"de"`
+	Para string `json:"para"`
+}
+
+// Fluxo é o fluxo de trabalho das tarefas de um projeto: os estados, na
+// ordem em que são exibidos, e as transições permitidas entre eles. O
+// primeiro estado é o das tarefas novas e não pode ser final. Sem
+// transições, qualquer mudança de estado é permitida.
+type Fluxo struct {
+	Estados    []EstadoFluxo `json:"estados"`
+	Transicoes []Transicao   `json:"transicoes,omitempty"`
+}
+
+// FluxoPadrao retorna o fluxo das tarefas sem projeto e dos projetos sem
+// fluxo próprio, equivalente ao antigo campo concluida
+func FluxoPadrao() Fluxo {
+	return Fluxo{Estados: []EstadoFluxo{
+		{ID: EstadoPendente, Nome: "Pendente"},
+		{ID: EstadoConcluida, Nome: "Concluída", Final: true},
+	}}
+}
+
+// MudancaEstado registra uma mudança de estado de uma tarefa. De é vazio no
+// registro da criação da tarefa.
+type MudancaEstado struct {
+	ID       string    `json:"id"`
+	TarefaID string    `json:"tarefa_id"`
+	De       string    `json:"de,omitempty"`
+	Para     string    `json:"para"`
+	Em       time.Time `json:"em"`
+	Dono     string    `json:"dono,omitempty"`
+}
+
+// ErroTransicao indica uma mudança de estado que o fluxo não permite. Para
+// é vazio quando nenhum estado final (ou não final, ao reabrir a tarefa) é
+// alcançável a partir de De.
+type ErroTransicao struct {
+	De, Para string
+	// Final indica, com Para vazio, que se tentava concluir a tarefa
+	Final bool
+}
+
+func (e *ErroTransicao) Error() string {
+	return e.Campo().Mensagens.PtBR
+}
+
+// Campo descreve a transição recusada como um erro do campo estado
+func (e *ErroTransicao) Campo() ErroValidacao {
+	m := Mensagens{
+		PtBR: "o fluxo do projeto não permite passar de " + strconv.Quote(e.De) + " para " + strconv.Quote(e.Para),
+		En:   "the project workflow does not allow moving from " + strconv.Quote(e.De) + " to " + strconv.Quote(e.Para),
+	}
+	switch {
+	case e.Para == "" && e.Final:
+		m = Mensagens{
+			PtBR: "nenhum estado final pode ser alcançado a partir de " + strconv.Quote(e.De),
+			En:   "no final state can be reached from " + strconv.Quote(e.De),
+		}
+	case e.Para == "":
+		m = Mensagens{
+			PtBR: "nenhum estado pendente pode ser alcançado a partir de " + strconv.Quote(e.De),
+			En:   "no pending state can be reached from " + strconv.Quote(e.De),
+		}
+	}
+	return ErroValidacao{"estado", CodigoTransicaoInvalida, m}
+}
+
+// ErroLimiteEstado indica uma mudança para um estado cuja coluna no quadro já
+// tem Ocupadas tarefas, o limite do estado
+type ErroLimiteEstado struct {
+	Estado   EstadoFluxo
+	Ocupadas int
+}
+
+func (e *ErroLimiteEstado) Error() string {
+	return e.Campo().Mensagens.PtBR
+}
+
+// Campo descreve a mudança recusada como um erro do campo estado
+func (e *ErroLimiteEstado) Campo() ErroValidacao {
+	return ErroValidacao{"estado", CodigoLimiteEstado, Mensagens{
+		PtBR: "a coluna " + strconv.Quote(e.Estado.Nome) + " já tem " + strconv.Itoa(e.Ocupadas) + " tarefas, o seu limite",
+		En:   "the " + strconv.Quote(e.Estado.Nome) + " column already has " + strconv.Itoa(e.Ocupadas) + " tasks, its limit",
+	}}
+}
+
+// ConferirLimite verifica se a tarefa t cabe no seu estado, dado que as
+// demais tarefas do projeto são outras. Subtarefas também ocupam a coluna.
+func (f Fluxo) ConferirLimite(t Tarefa, outras []Tarefa) error {
+	estado, ok := f.Estado(t.Estado)
+	if !ok || estado.Limite == 0 {
+		return nil
+	}
+	ocupadas := 0
+	for _, o := range outras {
+		if o.ID != t.ID && o.ProjetoID == t.ProjetoID && f.Resolver(o) == estado.ID {
+			ocupadas++
+		}
+	}
+	if ocupadas >= estado.Limite {
+		return &ErroLimiteEstado{Estado: estado, Ocupadas: ocupadas}
+	}
+	return nil
+}
+
+// padraoIDEstado restringe os IDs de estado a letras minúsculas, dígitos, _ e -
+var padraoIDEstado = regexp.MustCompile(`^[a-z0-9_-]{1,40}$`)
+
+// Validar verifica os estados e as transições do fluxo
+func (f Fluxo) Validar() error {
+	invalido := func(ptBR, en string) error {
+		return &ErroValidacao{"fluxo", CodigoInvalido, Mensagens{PtBR: ptBR, En: en}}
+	}
+	if len(f.Estados) < 2 {
+		return invalido("o fluxo precisa de ao menos dois estados", "the workflow needs at least two states")
+	}
+	vistos := map[string]bool{}
+	temFinal := false
+	for _, e := range f.Estados {
+		if !padraoIDEstado.MatchString(e.ID) {
+			return invalido("o ID de estado "+strconv.Quote(e.ID)+" deve ter apenas letras minúsculas, dígitos, _ e -",
+				"state ID "+strconv.Quote(e.ID)+" must have only lowercase letters, digits, _ and -")
+		}
+		if vistos[e.ID] {
+			return invalido("o estado "+strconv.Quote(e.ID)+" está repetido", "state "+strconv.Quote(e.ID)+" is repeated")
+		}
+		if strings.TrimSpace(e.Nome) == "" {
+			return invalido("o estado "+strconv.Quote(e.ID)+" precisa de um nome", "state "+strconv.Quote(e.ID)+" needs a name")
+		}
+		if e.Limite < 0 {
+			return invalido("o limite do estado "+strconv.Quote(e.ID)+" não pode ser negativo", "the limit of state "+strconv.Quote(e.ID)+" cannot be negative")
+		}
+		vistos[e.ID] = true
+		temFinal = temFinal || e.Final
+	}
+	if f.Estados[0].Final {
+		return invalido("o primeiro estado, das tarefas novas, não pode ser final", "the first state, of new tasks, cannot be final")
+	}
+	if !temFinal {
+		return invalido("o fluxo precisa de ao menos um estado final", "the workflow needs at least one final state")
+	}
+	for _, t := range f.Transicoes {
+		if !vistos[t.De] || !vistos[t.Para] || t.De == t.Para {
+			return invalido("a transição de "+strconv.Quote(t.De)+" para "+strconv.Quote(t.Para)+" não liga dois estados do fluxo",
+				"the transition from "+strconv.Quote(t.De)+" to "+strconv.Quote(t.Para)+" does not link two workflow states")
+		}
+	}
+	return nil
+}
+
+// Estado retorna o estado com o ID informado
+func (f Fluxo) Estado(id string) (EstadoFluxo, bool) {
+	for _, e := range f.Estados {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return EstadoFluxo{}, false
+}
+
+// Final informa se o estado existe no fluxo e é final
+func (f Fluxo) Final(id string) bool {
+	e, ok := f.Estado(id)
+	return ok && e.Final
+}
+
+// Permite informa se o fluxo permite passar do estado de para o estado para.
+// Permanecer no mesmo estado é sempre permitido.
+func (f Fluxo) Permite(de, para string) bool {
+	if _, ok := f.Estado(para); !ok {
+		return false
+	}
+	if de == para || len(f.Transicoes) == 0 {
+		return true
+	}
+	for _, t := range f.Transicoes {
+		if t.De == de && t.Para == para {
+			return true
+		}
+	}
+	return false
+}
+
+// Proximos lista, na ordem do fluxo, os estados alcançáveis a partir de de
+func (f Fluxo) Proximos(de string) []EstadoFluxo {
+	var proximos []EstadoFluxo
+	for _, e := range f.Estados {
+		if e.ID != de && f.Permite(de, e.ID) {
+			proximos = append(proximos, e)
+		}
+	}
+	return proximos
+}
+
+// Resolver retorna o estado da tarefa neste fluxo: o próprio, se existir
+// nele, ou o equivalente ao campo concluida, que é o primeiro estado final
+// para tarefas concluídas e o inicial para as pendentes
+func (f Fluxo) Resolver(t Tarefa) string {
+	if _, ok := f.Estado(t.Estado); ok {
+		return t.Estado
+	}
+	for _, e := range f.Estados {
+		if e.Final == t.Concluida {
+			return e.ID
+		}
+	}
+	return f.Estados[0].ID
+}
+
+// AplicarEstado reconcilia o estado e o campo concluida da tarefa t, que
+// antes estava como antes (nil em tarefas novas). Um estado enviado precisa
+// existir no fluxo e, em tarefas existentes, ser permitido pelas transições.
+// Sem um estado novo, mudar concluida leva ao primeiro estado final (ou não
+// final, ao reabrir) permitido. Uma tarefa cujo estado não existe no fluxo,
+// como depois de mudar de projeto, passa ao estado equivalente. Por fim,
+// concluida passa a refletir o estado.
+func (f Fluxo) AplicarEstado(antes, t *Tarefa) error {
+	desconhecido := func() error {
+		return &ErroValidacao{"estado", CodigoInvalido, Mensagens{
+			PtBR: "o estado " + strconv.Quote(t.Estado) + " não existe no fluxo do projeto",
+			En:   "state " + strconv.Quote(t.Estado) + " does not exist in the project workflow",
+		}}
+	}
+
+	if antes == nil {
+		if t.Estado == "" {
+			t.Estado = f.Resolver(*t)
+		} else if _, ok := f.Estado(t.Estado); !ok {
+			return desconhecido()
+		}
+		t.Concluida = f.Final(t.Estado)
+		return nil
+	}
+
+	_, conhecido := f.Estado(antes.Estado)
+	switch {
+	case t.Estado != antes.Estado:
+		if _, ok := f.Estado(t.Estado); !ok {
+			return desconhecido()
+		}
+		if conhecido && !f.Permite(antes.Estado, t.Estado) {
+			return &ErroTransicao{De: antes.Estado, Para: t.Estado}
+		}
+	case !conhecido:
+		t.Estado = f.Resolver(Tarefa{Concluida: t.Concluida})
+	case t.Concluida != antes.Concluida:
+		para := ""
+		for _, e := range f.Proximos(antes.Estado) {
+			if e.Final == t.Concluida {
+				para = e.ID
+				break
+			}
+		}
+		if para == "" {
+			return &ErroTransicao{De: antes.Estado, Final: t.Concluida}
+		}
+		t.Estado = para
+	}
+	t.Concluida = f.Final(t.Estado)
+	return nil
+}
@@ -0,0 +1,183 @@
+package dominio
+
+import (
+	"errors"
+	"testing"
+)
+
+// fluxoRevisao é um fluxo com revisão obrigatória antes da conclusão
+func fluxoRevisao() Fluxo {
+	return Fluxo{
+		Estados: []EstadoFluxo{
+			{ID: "backlog", Nome: "Backlog"},
+			{ID: "em_andamento", Nome: "Em andamento"},
+			{ID: "revisao", Nome: "Revisão"},
+			{ID: "concluida", Nome: "Concluída", Final: true},
+			{ID: "cancelada", Nome: "Cancelada", Final: true},
+		},
+		Transicoes: []Transicao{
+			{"backlog", "em_andamento"},
+			{"backlog", "cancelada"},
+			{"em_andamento", "revisao"},
+			{"em_andamento", "backlog"},
+			{"revisao", "concluida"},
+			{"revisao", "em_andamento"},
+			{"concluida", "em_andamento"},
+		},
+	}
+}
+
+func TestValidarFluxo(t *testing.T) {
+	if err := fluxoRevisao().Validar(); err != nil {
+		t.Errorf("fluxo válido recusado: %v", err)
+	}
+	if err := FluxoPadrao().Validar(); err != nil {
+		t.Errorf("fluxo padrão recusado: %v", err)
+	}
+
+	estados := func(e ...EstadoFluxo) Fluxo { return Fluxo{Estados: e} }
+	a, b := EstadoFluxo{ID: "a", Nome: "A"}, EstadoFluxo{ID: "b", Nome: "B", Final: true}
+	invalidos := map[string]Fluxo{
+		"um estado":        estados(a),
+		"sem final":        estados(a, EstadoFluxo{ID: "b", Nome: "B"}),
+		"inicial final":    estados(b, a),
+		"ID inválido":      estados(a, EstadoFluxo{ID: "Em Andamento", Nome: "B", Final: true}),
+		"ID repetido":      estados(a, b, EstadoFluxo{ID: "a", Nome: "C"}),
+		"sem nome":         estados(a, EstadoFluxo{ID: "b", Nome: " ", Final: true}),
+		"limite negativo":  estados(EstadoFluxo{ID: "a", Nome: "A", Limite: -1}, b),
+		"transição solta":  {Estados: []EstadoFluxo{a, b}, Transicoes: []Transicao{{"a", "c"}}},
+		"transição parada": {Estados: []EstadoFluxo{a, b}, Transicoes: []Transicao{{"a", "a"}}},
+	}
+	for nome, fluxo := range invalidos {
+		var validacao *ErroValidacao
+		if err := fluxo.Validar(); !errors.As(err, &validacao) || validacao.Campo != "fluxo" {
+			t.Errorf("%s: esperado erro no campo fluxo, obtido %v", nome, err)
+		}
+	}
+}
+
+func TestTransicoesDoFluxo(t *testing.T) {
+	f := fluxoRevisao()
+	casos := []struct {
+		de, para string
+		permite  bool
+	}{
+		{"backlog", "em_andamento", true},
+		{"backlog", "concluida", false},
+		{"revisao", "concluida", true},
+		{"revisao", "revisao", true},
+		{"backlog", "inexistente", false},
+	}
+	for _, caso := range casos {
+		if f.Permite(caso.de, caso.para) != caso.permite {
+			t.Errorf("Permite(%q, %q) deveria ser %v", caso.de, caso.para, caso.permite)
+		}
+	}
+
+	var ids []string
+	for _, e := range f.Proximos("em_andamento") {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 2 || ids[0] != "backlog" || ids[1] != "revisao" {
+		t.Errorf("próximos de em_andamento: %v", ids)
+	}
+
+	// Sem transições, qualquer estado é alcançável
+	if p := FluxoPadrao().Proximos(EstadoPendente); len(p) != 1 || p[0].ID != EstadoConcluida {
+		t.Errorf("próximos no fluxo padrão: %+v", p)
+	}
+}
+
+func TestAplicarEstado(t *testing.T) {
+	f := fluxoRevisao()
+	casos := []struct {
+		nome      string
+		antes     *Tarefa
+		depois    Tarefa
+		estado    string
+		concluida bool
+		erro      string
+	}{
+		{"nova sem estado", nil, Tarefa{}, "backlog", false, ""},
+		{"nova concluída", nil, Tarefa{Concluida: true}, "concluida", true, ""},
+		{"nova em um estado", nil, Tarefa{Estado: "revisao"}, "revisao", false, ""},
+		{"nova em estado desconhecido", nil, Tarefa{Estado: "pronta"}, "", false, CodigoInvalido},
+		{"transição permitida", &Tarefa{Estado: "revisao"}, Tarefa{Estado: "concluida"}, "concluida", true, ""},
+		{"transição recusada", &Tarefa{Estado: "backlog"}, Tarefa{Estado: "concluida"}, "", false, CodigoTransicaoInvalida},
+		{"estado desconhecido", &Tarefa{Estado: "backlog"}, Tarefa{Estado: "pronta"}, "", false, CodigoInvalido},
+		// O estado enviado prevalece sobre concluida
+		{"estado e concluida", &Tarefa{Estado: "backlog"}, Tarefa{Estado: "cancelada", Concluida: false}, "cancelada", true, ""},
+		{"concluir pelo campo antigo", &Tarefa{Estado: "revisao"}, Tarefa{Estado: "revisao", Concluida: true}, "concluida", true, ""},
+		{"concluir sem estado final alcançável", &Tarefa{Estado: "em_andamento"}, Tarefa{Estado: "em_andamento", Concluida: true}, "", false, CodigoTransicaoInvalida},
+		{"reabrir pelo campo antigo", &Tarefa{Estado: "concluida", Concluida: true}, Tarefa{Estado: "concluida"}, "em_andamento", false, ""},
+		{"reabrir cancelada", &Tarefa{Estado: "cancelada", Concluida: true}, Tarefa{Estado: "cancelada"}, "", false, CodigoTransicaoInvalida},
+		// Vinda de outro fluxo, a tarefa vai para o estado equivalente
+		{"mudança de projeto", &Tarefa{Estado: EstadoPendente}, Tarefa{Estado: EstadoPendente}, "backlog", false, ""},
+		{"mudança de projeto concluída", &Tarefa{Estado: "pronta", Concluida: true}, Tarefa{Estado: "pronta", Concluida: true}, "concluida", true, ""},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			tarefa := caso.depois
+			err := f.AplicarEstado(caso.antes, &tarefa)
+			if caso.erro != "" {
+				var validacao *ErroValidacao
+				var transicao *ErroTransicao
+				switch {
+				case errors.As(err, &transicao):
+					if transicao.Campo().Codigo != caso.erro {
+						t.Errorf("erro de transição inesperado: %v", err)
+					}
+				case errors.As(err, &validacao):
+					if validacao.Codigo != caso.erro || validacao.Campo != "estado" {
+						t.Errorf("erro de validação inesperado: %+v", validacao)
+					}
+				default:
+					t.Errorf("esperado erro %s, obtido %v", caso.erro, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tarefa.Estado != caso.estado || tarefa.Concluida != caso.concluida {
+				t.Errorf("obtido estado %q concluida %v, esperado %q %v", tarefa.Estado, tarefa.Concluida, caso.estado, caso.concluida)
+			}
+		})
+	}
+}
+
+func TestNormalizarEstadoDeTarefaAntiga(t *testing.T) {
+	pendente, concluida := Tarefa{Titulo: "A"}, Tarefa{Titulo: "B", Concluida: true}
+	pendente.Normalizar()
+	concluida.Normalizar()
+	if pendente.Estado != EstadoPendente || concluida.Estado != EstadoConcluida {
+		t.Errorf("estados de tarefas sem estado: %q e %q", pendente.Estado, concluida.Estado)
+	}
+}
+
+func TestConferirLimite(t *testing.T) {
+	f := Fluxo{Estados: []EstadoFluxo{
+		{ID: "backlog", Nome: "Backlog"},
+		{ID: "fazendo", Nome: "Fazendo", Limite: 2},
+		{ID: "feito", Nome: "Feito", Final: true},
+	}}
+	outras := []Tarefa{
+		{ID: "1", ProjetoID: "p", Estado: "fazendo"},
+		// Subtarefas ocupam a coluna como as demais tarefas
+		{ID: "2", ProjetoID: "p", Estado: "fazendo", PaiID: "1"},
+		{ID: "3", ProjetoID: "outro", Estado: "fazendo"},
+	}
+
+	if err := f.ConferirLimite(Tarefa{ID: "4", ProjetoID: "p", Estado: "backlog"}, outras); err != nil {
+		t.Errorf("estado sem limite: %v", err)
+	}
+	err := f.ConferirLimite(Tarefa{ID: "4", ProjetoID: "p", Estado: "fazendo"}, outras)
+	var limite *ErroLimiteEstado
+	if !errors.As(err, &limite) || limite.Ocupadas != 2 || limite.Campo().Codigo != CodigoLimiteEstado {
+		t.Errorf("coluna cheia: esperado ErroLimiteEstado, obtido %v", err)
+	}
+	// A própria tarefa não conta contra o limite
+	if err := f.ConferirLimite(outras[0], outras); err != nil {
+		t.Errorf("tarefa que já está na coluna: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/seu-usuario/ci-cd-demo/dominio
+
+go 1.21
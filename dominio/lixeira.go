@@ -0,0 +1,17 @@
+package dominio
+
+import "time"
+
+// RetencaoLixeiraPadrao é por quanto tempo uma tarefa removida fica na
+// lixeira antes de ser purgada, se a configuração não disser outro valor
+const RetencaoLixeiraPadrao = 30 * 24 * time.Hour
+
+// ItemLixeira é uma tarefa removida, como listada em GET /api/lixeira.
+// Subtarefas traz as descendentes removidas junto com ela, que voltam ou são
+// purgadas com a tarefa; PurgaEm é quando a purga automática a remove de vez.
+type ItemLixeira struct {
+	Tarefa     Tarefa    `json:"tarefa"`
+	Subtarefas []Tarefa  `json:"subtarefas"`
+	ExcluidaEm time.Time `json:"excluida_em"`
+	PurgaEm    time.Time `json:"purga_em"`
+}
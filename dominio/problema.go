@@ -0,0 +1,62 @@
+package dominio
+
+import "strings"
+
+// TipoConteudoProblema é o Content-Type das respostas de erro da API (RFC 7807)
+const TipoConteudoProblema = "application/problem+json"
+
+// Códigos estáveis de erro, enviados no campo code das respostas de erro e
+// dos erros de campo. Clientes devem decidir pelo código, nunca pelo texto.
+const (
+	CodigoCorpoInvalido       = "corpo_invalido"
+	CodigoValidacao           = "validacao"
+	CodigoParametroInvalido   = "parametro_invalido"
+	CodigoTarefaNaoEncontrada = "tarefa_nao_encontrada"
+	CodigoRotaNaoEncontrada   = "rota_nao_encontrada"
+	CodigoMetodoNaoPermitido  = "metodo_nao_permitido"
+	CodigoConflitoVersao      = "conflito_versao"
+	CodigoPrecondicaoAusente  = "precondicao_ausente"
+	CodigoErroInterno         = "erro_interno"
+
+	// Códigos de erros de campo
+	CodigoObrigatorio = "obrigatorio"
+	CodigoInvalido    = "invalido"
+)
+
+// Mensagens é um texto para o usuário em português e em inglês
+type Mensagens struct {
+	PtBR string `json:"pt-BR"`
+	En   string `json:"en"`
+}
+
+// Em retorna a mensagem no idioma informado; qualquer idioma diferente de
+// inglês recebe a mensagem em português
+func (m Mensagens) Em(idioma string) string {
+	if strings.HasPrefix(strings.ToLower(idioma), "en") {
+		return m.En
+	}
+	return m.PtBR
+}
+
+// Problema é o corpo das respostas de erro da API, no formato
+// application/problem+json da RFC 7807 com membros de extensão
+type Problema struct {
+	// Tipo é um URI que identifica o tipo de problema
+	Tipo string `json:"type"`
+	// Titulo é um resumo curto e estável do tipo de problema, em inglês
+	Titulo string `json:"title"`
+	Status int    `json:"status"`
+	// Detalhe explica esta ocorrência no idioma pedido em Accept-Language
+	Detalhe string `json:"detail"`
+	// Instancia é o caminho da requisição que falhou
+	Instancia string `json:"instance,omitempty"`
+
+	// Codigo é o código estável do erro, como CodigoValidacao
+	Codigo string `json:"code"`
+	// Mensagens traz o detalhe nos dois idiomas
+	Mensagens Mensagens `json:"messages"`
+	// Campos lista os campos ou parâmetros inválidos
+	Campos []ErroValidacao `json:"errors,omitempty"`
+	// RequestID identifica a requisição nos logs do servidor
+	RequestID string `json:"request_id,omitempty"`
+}
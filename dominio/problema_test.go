@@ -0,0 +1,46 @@
+package dominio
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// contratoProblema é a representação JSON esperada de uma resposta de erro
+const contratoProblema = `{"type":"urn:ci-cd-demo:problema:validacao","title":"Validation failed",` +
+	`"status":400,"detail":"o título é obrigatório","instance":"/api/tarefas","code":"validacao",` +
+	`"messages":{"pt-BR":"o título é obrigatório","en":"title is required"},` +
+	`"errors":[{"field":"titulo","code":"obrigatorio","messages":{"pt-BR":"o título é obrigatório","en":"title is required"}}],` +
+	`"request_id":"abc123"}`
+
+func TestContratoJSONProblema(t *testing.T) {
+	validacao := Tarefa{}.Validar().(*ErroValidacao)
+	p := Problema{
+		Tipo:      "urn:ci-cd-demo:problema:validacao",
+		Titulo:    "Validation failed",
+		Status:    400,
+		Detalhe:   validacao.Mensagens.PtBR,
+		Instancia: "/api/tarefas",
+		Codigo:    CodigoValidacao,
+		Mensagens: validacao.Mensagens,
+		Campos:    []ErroValidacao{*validacao},
+		RequestID: "abc123",
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != contratoProblema {
+		t.Errorf("contrato JSON mudou:\nobtido   %s\nesperado %s", b, contratoProblema)
+	}
+}
+
+func TestMensagensEm(t *testing.T) {
+	m := Mensagens{PtBR: "olá", En: "hello"}
+	casos := map[string]string{"en": "hello", "en-US": "hello", "pt-BR": "olá", "": "olá", "fr": "olá"}
+	for idioma, esperada := range casos {
+		if obtida := m.Em(idioma); obtida != esperada {
+			t.Errorf("Em(%q): obtida %q esperada %q", idioma, obtida, esperada)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package dominio
+
+import (
+	"strings"
+	"time"
+)
+
+// CodigoProjetoNaoEncontrado é o código de erro de um projeto inexistente
+const CodigoProjetoNaoEncontrado = "projeto_nao_encontrado"
+
+// Projeto agrupa tarefas de um mesmo usuário. As contagens de tarefas são
+// calculadas pelo servidor a cada leitura e ignoradas na entrada. Sem Fluxo,
+// as tarefas do projeto seguem o fluxo padrão.
+type Projeto struct {
+	ID               string    `json:"id"`
+	Nome             string    `json:"nome"`
+	Fluxo            *Fluxo    `json:"fluxo,omitempty"`
+	Dono             string    `json:"dono,omitempty"`
+	CriadoEm         time.Time `json:"criado_em"`
+	AtualizadoEm     time.Time `json:"atualizado_em"`
+	TotalTarefas     int       `json:"total_tarefas"`
+	TarefasPendentes int       `json:"tarefas_pendentes"`
+}
+
+// Validar verifica os campos editáveis pelo cliente
+func (p Projeto) Validar() error {
+	if strings.TrimSpace(p.Nome) == "" {
+		return &ErroValidacao{"nome", CodigoObrigatorio, Mensagens{
+			PtBR: "o nome do projeto é obrigatório",
+			En:   "project name is required",
+		}}
+	}
+	if p.Fluxo != nil {
+		return p.Fluxo.Validar()
+	}
+	return nil
+}
+
+// FluxoDeTrabalho retorna o fluxo das tarefas do projeto
+func (p Projeto) FluxoDeTrabalho() Fluxo {
+	if p.Fluxo != nil {
+		return *p.Fluxo
+	}
+	return FluxoPadrao()
+}
@@ -0,0 +1,490 @@
+package dominio
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	// Os fusos horários das tarefas não dependem da base de fusos do sistema,
+	// ausente em imagens mínimas de contêiner
+	_ "time/tzdata"
+)
+
+// Frequencia é a unidade de repetição de uma regra de recorrência (FREQ)
+type Frequencia string
+
+const (
+	FrequenciaDiaria  Frequencia = "DAILY"
+	FrequenciaSemanal Frequencia = "WEEKLY"
+	FrequenciaMensal  Frequencia = "MONTHLY"
+	FrequenciaAnual   Frequencia = "YEARLY"
+)
+
+// DiaSemana é um item de BYDAY: o dia da semana e, em regras mensais e
+// anuais, sua posição no mês (1 é o primeiro, -1 o último e 0 qualquer um)
+type DiaSemana struct {
+	Dia     time.Weekday
+	Posicao int
+}
+
+// siglasDias são os dias da semana como escritos em BYDAY, a partir de domingo
+var siglasDias = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// nomesDias são os dias da semana como exibidos, a partir de domingo
+var nomesDias = [...]string{"domingo", "segunda", "terça", "quarta", "quinta", "sexta", "sábado"}
+
+// nomesMeses são os meses como exibidos, a partir de janeiro
+var nomesMeses = [...]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+	"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}
+
+// maximoPeriodos limita a busca pela próxima ocorrência, para que regras
+// sem nenhuma data possível, como o dia 30 de fevereiro, não travem o servidor
+const maximoPeriodos = 10000
+
+// RegraRecorrencia é uma regra de repetição no formato RRULE do iCalendar
+// (RFC 5545), com as partes FREQ, INTERVAL, BYDAY, BYMONTHDAY, BYMONTH,
+// COUNT e UNTIL e semanas começando na segunda-feira. Como na RFC, datas
+// inexistentes são ignoradas: "todo dia 31" pula os meses mais curtos, e o
+// último dia do mês é BYMONTHDAY=-1.
+//
+// Nas regras anuais, BYDAY e BYMONTHDAY valem dentro dos meses de BYMONTH,
+// que passa a ser obrigatório.
+type RegraRecorrencia struct {
+	Frequencia Frequencia
+	// Intervalo é o número de períodos entre as repetições (INTERVAL)
+	Intervalo  int
+	DiasSemana []DiaSemana
+	DiasMes    []int
+	Meses      []time.Month
+	// Contagem é o número de ocorrências que restam, contando a atual; zero
+	// é ilimitado
+	Contagem int
+	// Ate é o último instante que pode ter uma ocorrência; zero é ilimitado.
+	// Com AteData, vale o dia inteiro de Ate no fuso da tarefa.
+	Ate     time.Time
+	AteData bool
+}
+
+// erroRecorrencia descreve uma regra de recorrência inválida
+func erroRecorrencia(ptBR, en string) *ErroValidacao {
+	return &ErroValidacao{"recorrencia", CodigoInvalido, Mensagens{
+		PtBR: "recorrência inválida: " + ptBR,
+		En:   "invalid recurrence: " + en,
+	}}
+}
+
+// LerRegraRecorrencia interpreta uma regra no formato RRULE, como
+// "FREQ=WEEKLY;BYDAY=MO", com ou sem o prefixo "RRULE:". Regras inválidas
+// resultam em *ErroValidacao no campo recorrencia.
+func LerRegraRecorrencia(s string) (RegraRecorrencia, error) {
+	r := RegraRecorrencia{Intervalo: 1}
+	texto := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	vistas := map[string]bool{}
+	for _, parte := range strings.Split(texto, ";") {
+		nome, valor, ok := strings.Cut(parte, "=")
+		if !ok || valor == "" {
+			return r, erroRecorrencia("parte "+strconv.Quote(parte)+" sem valor", "part "+strconv.Quote(parte)+" has no value")
+		}
+		if vistas[nome] {
+			return r, erroRecorrencia(nome+" repetido", "repeated "+nome)
+		}
+		vistas[nome] = true
+
+		var err error
+		switch nome {
+		case "FREQ":
+			r.Frequencia = Frequencia(valor)
+			switch r.Frequencia {
+			case FrequenciaDiaria, FrequenciaSemanal, FrequenciaMensal, FrequenciaAnual:
+			default:
+				return r, erroRecorrencia("use FREQ=DAILY, WEEKLY, MONTHLY ou YEARLY", "use FREQ=DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			r.Intervalo, err = lerInteiro(nome, valor, 1, 1000)
+		case "COUNT":
+			r.Contagem, err = lerInteiro(nome, valor, 1, 1000)
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(valor, ",") {
+				dia, err := lerInteiro(nome, v, -31, 31)
+				if err != nil || dia == 0 {
+					return r, erroRecorrencia("use dias de 1 a 31 ou de -31 a -1 em BYMONTHDAY", "use days from 1 to 31 or -31 to -1 in BYMONTHDAY")
+				}
+				r.DiasMes = append(r.DiasMes, dia)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(valor, ",") {
+				mes, err := lerInteiro(nome, v, 1, 12)
+				if err != nil {
+					return r, err
+				}
+				r.Meses = append(r.Meses, time.Month(mes))
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(valor, ",") {
+				dia, err := lerDiaSemana(v)
+				if err != nil {
+					return r, err
+				}
+				r.DiasSemana = append(r.DiasSemana, dia)
+			}
+		case "UNTIL":
+			r.Ate, r.AteData, err = lerAte(valor)
+		default:
+			return r, erroRecorrencia("parte "+nome+" não suportada", "unsupported part "+nome)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+	return r, r.validar()
+}
+
+// validar confere as combinações de partes que a RFC proíbe ou que não são
+// suportadas
+func (r RegraRecorrencia) validar() error {
+	switch {
+	case r.Frequencia == "":
+		return erroRecorrencia("FREQ é obrigatório", "FREQ is required")
+	case r.Contagem > 0 && !r.Ate.IsZero():
+		return erroRecorrencia("use COUNT ou UNTIL, não ambos", "use COUNT or UNTIL, not both")
+	case r.Frequencia == FrequenciaSemanal && len(r.DiasMes) > 0:
+		return erroRecorrencia("BYMONTHDAY não vale em regras semanais", "BYMONTHDAY is not allowed in weekly rules")
+	case r.Frequencia == FrequenciaAnual && len(r.Meses) == 0 && (len(r.DiasSemana) > 0 || len(r.DiasMes) > 0):
+		return erroRecorrencia("nas regras anuais, BYDAY e BYMONTHDAY exigem BYMONTH", "in yearly rules, BYDAY and BYMONTHDAY require BYMONTH")
+	}
+	for _, d := range r.DiasSemana {
+		if d.Posicao != 0 && (r.Frequencia == FrequenciaDiaria || r.Frequencia == FrequenciaSemanal) {
+			return erroRecorrencia("posições em BYDAY só valem em regras mensais e anuais", "BYDAY positions are only allowed in monthly and yearly rules")
+		}
+	}
+	return nil
+}
+
+// lerInteiro lê o valor de uma parte numérica entre minimo e maximo
+func lerInteiro(nome, valor string, minimo, maximo int) (int, error) {
+	n, err := strconv.Atoi(valor)
+	if err != nil || n < minimo || n > maximo {
+		faixa := strconv.Itoa(minimo) + " e " + strconv.Itoa(maximo)
+		return 0, erroRecorrencia("use um número entre "+faixa+" em "+nome,
+			"use a number between "+strconv.Itoa(minimo)+" and "+strconv.Itoa(maximo)+" in "+nome)
+	}
+	return n, nil
+}
+
+// lerDiaSemana lê um item de BYDAY, como MO, 1MO ou -1FR
+func lerDiaSemana(v string) (DiaSemana, error) {
+	invalido := erroRecorrencia("use dias como MO, 2TU ou -1FR em BYDAY", "use days like MO, 2TU or -1FR in BYDAY")
+	if len(v) < 2 {
+		return DiaSemana{}, invalido
+	}
+	i := slices.Index(siglasDias[:], v[len(v)-2:])
+	if i < 0 {
+		return DiaSemana{}, invalido
+	}
+	d := DiaSemana{Dia: time.Weekday(i)}
+	if posicao := v[:len(v)-2]; posicao != "" {
+		n, err := strconv.Atoi(posicao)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return DiaSemana{}, invalido
+		}
+		d.Posicao = n
+	}
+	return d, nil
+}
+
+// lerAte lê UNTIL como data (AAAAMMDD) ou instante em UTC (AAAAMMDDTHHMMSSZ)
+func lerAte(valor string) (time.Time, bool, error) {
+	if ate, err := time.Parse("20060102", valor); err == nil {
+		return ate, true, nil
+	}
+	if ate, err := time.Parse("20060102T150405Z", valor); err == nil {
+		return ate, false, nil
+	}
+	return time.Time{}, false, erroRecorrencia("use UNTIL no formato AAAAMMDD ou AAAAMMDDTHHMMSSZ", "use UNTIL as YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+// String escreve a regra no formato RRULE, sem o prefixo, com as partes
+// sempre na mesma ordem
+func (r RegraRecorrencia) String() string {
+	partes := []string{"FREQ=" + string(r.Frequencia)}
+	if r.Intervalo > 1 {
+		partes = append(partes, "INTERVAL="+strconv.Itoa(r.Intervalo))
+	}
+	if len(r.DiasSemana) > 0 {
+		dias := make([]string, len(r.DiasSemana))
+		for i, d := range r.DiasSemana {
+			dias[i] = siglasDias[d.Dia]
+			if d.Posicao != 0 {
+				dias[i] = strconv.Itoa(d.Posicao) + dias[i]
+			}
+		}
+		partes = append(partes, "BYDAY="+strings.Join(dias, ","))
+	}
+	if len(r.DiasMes) > 0 {
+		dias := make([]string, len(r.DiasMes))
+		for i, d := range r.DiasMes {
+			dias[i] = strconv.Itoa(d)
+		}
+		partes = append(partes, "BYMONTHDAY="+strings.Join(dias, ","))
+	}
+	if len(r.Meses) > 0 {
+		meses := make([]string, len(r.Meses))
+		for i, m := range r.Meses {
+			meses[i] = strconv.Itoa(int(m))
+		}
+		partes = append(partes, "BYMONTH="+strings.Join(meses, ","))
+	}
+	if r.Contagem > 0 {
+		partes = append(partes, "COUNT="+strconv.Itoa(r.Contagem))
+	}
+	if r.AteData {
+		partes = append(partes, "UNTIL="+r.Ate.Format("20060102"))
+	} else if !r.Ate.IsZero() {
+		partes = append(partes, "UNTIL="+r.Ate.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(partes, ";")
+}
+
+// Proxima retorna a primeira ocorrência da regra depois de atual, que é a
+// ocorrência atual e fornece o horário e, quando a regra não os informa, o
+// dia da semana, o dia e o mês. As datas são calculadas no fuso de atual,
+// então o horário local se mantém nas mudanças de horário de verão. ok é
+// falso quando a série termina em atual.
+func (r RegraRecorrencia) Proxima(atual time.Time) (proxima time.Time, ok bool) {
+	if r.Contagem == 1 {
+		return time.Time{}, false
+	}
+	intervalo := max(r.Intervalo, 1)
+	for periodo := 0; periodo < maximoPeriodos; periodo++ {
+		for _, candidata := range r.candidatas(atual, periodo*intervalo) {
+			if !candidata.After(atual) {
+				continue
+			}
+			if r.terminouAntes(candidata) {
+				return time.Time{}, false
+			}
+			return candidata, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Seguinte retorna a regra que vale a partir da próxima ocorrência, com uma
+// ocorrência a menos em Contagem
+func (r RegraRecorrencia) Seguinte() RegraRecorrencia {
+	if r.Contagem > 1 {
+		r.Contagem--
+	}
+	return r
+}
+
+// terminouAntes informa se a ocorrência fica depois do fim definido em UNTIL
+func (r RegraRecorrencia) terminouAntes(ocorrencia time.Time) bool {
+	if r.Ate.IsZero() {
+		return false
+	}
+	if r.AteData {
+		ano, mes, dia := ocorrencia.Date()
+		return time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC).After(r.Ate)
+	}
+	return ocorrencia.After(r.Ate)
+}
+
+// candidatas lista, em ordem, as ocorrências do período que fica deslocamento
+// períodos depois do período de atual
+func (r RegraRecorrencia) candidatas(atual time.Time, deslocamento int) []time.Time {
+	ano, mes, dia := atual.Date()
+	hora, minuto, segundo := atual.Clock()
+	em := func(ano int, mes time.Month, dia int) time.Time {
+		data := time.Date(ano, mes, dia, hora, minuto, segundo, atual.Nanosecond(), atual.Location())
+		// Um horário que não existe por causa do horário de verão é lido com o
+		// deslocamento anterior à mudança, como manda a RFC, e cai logo depois dela
+		if falta := time.Duration(hora-data.Hour())*time.Hour + time.Duration(minuto-data.Minute())*time.Minute; falta > 0 && data.Day() == dia {
+			data = data.Add(falta)
+		}
+		return data
+	}
+
+	var datas []time.Time
+	switch r.Frequencia {
+	case FrequenciaDiaria:
+		data := em(ano, mes, dia+deslocamento)
+		if r.aceitaDia(data) {
+			datas = append(datas, data)
+		}
+	case FrequenciaSemanal:
+		// A semana começa na segunda-feira (WKST=MO)
+		segunda := dia - (int(atual.Weekday())+6)%7 + 7*deslocamento
+		for i := 0; i < 7; i++ {
+			data := em(ano, mes, segunda+i)
+			if r.aceitaDiaDaSemana(data.Weekday(), atual.Weekday()) && r.aceitaMes(data.Month()) {
+				datas = append(datas, data)
+			}
+		}
+	case FrequenciaMensal:
+		inicio := time.Date(ano, mes+time.Month(deslocamento), 1, 0, 0, 0, 0, time.UTC)
+		if r.aceitaMes(inicio.Month()) {
+			for _, d := range r.diasDoMes(inicio.Year(), inicio.Month(), dia) {
+				datas = append(datas, em(inicio.Year(), inicio.Month(), d))
+			}
+		}
+	case FrequenciaAnual:
+		meses := r.Meses
+		if len(meses) == 0 {
+			meses = []time.Month{mes}
+		}
+		meses = slices.Clone(meses)
+		slices.Sort(meses)
+		for _, m := range slices.Compact(meses) {
+			for _, d := range r.diasDoMes(ano+deslocamento, m, dia) {
+				datas = append(datas, em(ano+deslocamento, m, d))
+			}
+		}
+	}
+	return datas
+}
+
+// aceitaDia aplica às regras diárias os filtros BYDAY, BYMONTHDAY e BYMONTH
+func (r RegraRecorrencia) aceitaDia(data time.Time) bool {
+	if !r.aceitaMes(data.Month()) {
+		return false
+	}
+	if len(r.DiasSemana) > 0 && !r.aceitaDiaDaSemana(data.Weekday(), -1) {
+		return false
+	}
+	return len(r.DiasMes) == 0 || slices.Contains(r.diasDoMes(data.Year(), data.Month(), 0), data.Day())
+}
+
+// aceitaDiaDaSemana informa se o dia da semana está em BYDAY ou, sem BYDAY,
+// se é o dia padrão
+func (r RegraRecorrencia) aceitaDiaDaSemana(d, padrao time.Weekday) bool {
+	if len(r.DiasSemana) == 0 {
+		return d == padrao
+	}
+	for _, ds := range r.DiasSemana {
+		if ds.Dia == d {
+			return true
+		}
+	}
+	return false
+}
+
+// aceitaMes informa se o mês está em BYMONTH, quando informado
+func (r RegraRecorrencia) aceitaMes(m time.Month) bool {
+	return len(r.Meses) == 0 || slices.Contains(r.Meses, m)
+}
+
+// diasDoMes lista, em ordem, os dias do mês que atendem a BYMONTHDAY e
+// BYDAY; sem nenhum deles, apenas diaPadrao, se o mês o tiver
+func (r RegraRecorrencia) diasDoMes(ano int, mes time.Month, diaPadrao int) []int {
+	ultimo := time.Date(ano, mes+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var dias []int
+	switch {
+	case len(r.DiasMes) > 0:
+		for _, d := range r.DiasMes {
+			if d < 0 {
+				d = ultimo + d + 1
+			}
+			if d >= 1 && d <= ultimo && (len(r.DiasSemana) == 0 || r.temPosicao(ano, mes, d, ultimo)) {
+				dias = append(dias, d)
+			}
+		}
+	case len(r.DiasSemana) > 0:
+		for d := 1; d <= ultimo; d++ {
+			if r.temPosicao(ano, mes, d, ultimo) {
+				dias = append(dias, d)
+			}
+		}
+	case diaPadrao >= 1 && diaPadrao <= ultimo:
+		dias = append(dias, diaPadrao)
+	}
+	slices.Sort(dias)
+	return slices.Compact(dias)
+}
+
+// temPosicao informa se o dia atende a algum item de BYDAY, considerando a
+// posição do dia da semana no mês
+func (r RegraRecorrencia) temPosicao(ano int, mes time.Month, dia, ultimo int) bool {
+	semana := time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC).Weekday()
+	for _, ds := range r.DiasSemana {
+		switch {
+		case ds.Dia != semana:
+		case ds.Posicao == 0,
+			ds.Posicao > 0 && (dia-1)/7+1 == ds.Posicao,
+			ds.Posicao < 0 && (ultimo-dia)/7+1 == -ds.Posicao:
+			return true
+		}
+	}
+	return false
+}
+
+// Descrever resume a regra em português, como "toda semana (segunda, quarta)"
+// ou "a cada 2 meses (último dia)"
+func (r RegraRecorrencia) Descrever() string {
+	unidades := map[Frequencia][2]string{
+		FrequenciaDiaria:  {"todo dia", "dias"},
+		FrequenciaSemanal: {"toda semana", "semanas"},
+		FrequenciaMensal:  {"todo mês", "meses"},
+		FrequenciaAnual:   {"todo ano", "anos"},
+	}[r.Frequencia]
+	texto := unidades[0]
+	if r.Intervalo > 1 {
+		texto = "a cada " + strconv.Itoa(r.Intervalo) + " " + unidades[1]
+	}
+
+	var detalhes []string
+	for _, m := range r.Meses {
+		detalhes = append(detalhes, nomesMeses[m-1])
+	}
+	for _, d := range r.DiasSemana {
+		detalhes = append(detalhes, descreverDiaSemana(d))
+	}
+	for _, d := range r.DiasMes {
+		switch {
+		case d == -1:
+			detalhes = append(detalhes, "último dia")
+		case d < 0:
+			detalhes = append(detalhes, strconv.Itoa(-d)+"º dia antes do fim")
+		default:
+			detalhes = append(detalhes, "dia "+strconv.Itoa(d))
+		}
+	}
+	if len(detalhes) > 0 {
+		texto += " (" + strings.Join(detalhes, ", ") + ")"
+	}
+
+	switch {
+	case r.Contagem == 1:
+		texto += ", última ocorrência"
+	case r.Contagem > 1:
+		texto += ", restam " + strconv.Itoa(r.Contagem) + " ocorrências"
+	case r.AteData:
+		texto += ", até " + r.Ate.Format("02/01/2006")
+	case !r.Ate.IsZero():
+		texto += ", até " + r.Ate.UTC().Format("02/01/2006 15:04") + " UTC"
+	}
+	return texto
+}
+
+// descreverDiaSemana escreve um item de BYDAY, como "segunda" ou "última sexta"
+func descreverDiaSemana(d DiaSemana) string {
+	nome := nomesDias[d.Dia]
+	masculino := d.Dia == time.Saturday || d.Dia == time.Sunday
+	switch {
+	case d.Posicao == 0:
+		return nome
+	case d.Posicao == -1 && masculino:
+		return "último " + nome
+	case d.Posicao == -1:
+		return "última " + nome
+	}
+	ordinal := strconv.Itoa(d.Posicao) + "ª "
+	if masculino {
+		ordinal = strconv.Itoa(d.Posicao) + "º "
+	}
+	if d.Posicao < 0 {
+		return strings.Replace(ordinal, "-", "", 1) + nome + " antes do fim"
+	}
+	return ordinal + nome
+}
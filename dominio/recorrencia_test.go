@@ -0,0 +1,267 @@
+package dominio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sequencia segue a regra a partir de atual e retorna as próximas
+// ocorrências no fuso de atual, no formato AAAA-MM-DD HH:MM
+func sequencia(t *testing.T, regra string, atual time.Time, n int) []string {
+	t.Helper()
+	r, err := LerRegraRecorrencia(regra)
+	if err != nil {
+		t.Fatalf("%s: %v", regra, err)
+	}
+	var datas []string
+	for len(datas) < n {
+		proxima, ok := r.Proxima(atual)
+		if !ok {
+			break
+		}
+		if proxima.Location() != atual.Location() {
+			t.Fatalf("%s: fuso mudou de %v para %v", regra, atual.Location(), proxima.Location())
+		}
+		datas = append(datas, proxima.Format("2006-01-02 15:04"))
+		atual, r = proxima, r.Seguinte()
+	}
+	return datas
+}
+
+// fuso carrega um fuso horário IANA
+func fuso(t *testing.T, nome string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(nome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestRegraRecorrenciaProximas(t *testing.T) {
+	// 2024-01-01 foi uma segunda-feira; 2024 é bissexto
+	segunda := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	casos := []struct {
+		nome     string
+		regra    string
+		atual    time.Time
+		esperado string
+	}{
+		{"todo dia", "FREQ=DAILY", segunda, "2024-01-02 09:00, 2024-01-03 09:00, 2024-01-04 09:00"},
+		{"a cada 3 dias", "FREQ=DAILY;INTERVAL=3", segunda, "2024-01-04 09:00, 2024-01-07 09:00, 2024-01-10 09:00"},
+		{"dias úteis", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC), "2024-01-05 09:00, 2024-01-08 09:00, 2024-01-09 09:00"},
+		{"toda segunda", "FREQ=WEEKLY;BYDAY=MO", segunda, "2024-01-08 09:00, 2024-01-15 09:00, 2024-01-22 09:00"},
+		{"toda semana no dia de atual", "FREQ=WEEKLY", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), "2024-01-10 09:00, 2024-01-17 09:00, 2024-01-24 09:00"},
+		{"a cada 2 semanas", "FREQ=WEEKLY;INTERVAL=2", segunda, "2024-01-15 09:00, 2024-01-29 09:00, 2024-02-12 09:00"},
+		{"a cada 2 semanas em dois dias", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", segunda, "2024-01-03 09:00, 2024-01-15 09:00, 2024-01-17 09:00, 2024-01-29 09:00"},
+		{"semana que vira o ano", "FREQ=WEEKLY;BYDAY=TU,FR", time.Date(2024, 12, 27, 9, 0, 0, 0, time.UTC), "2024-12-31 09:00, 2025-01-03 09:00"},
+		{"todo dia 5", "FREQ=MONTHLY;BYMONTHDAY=5", segunda, "2024-01-05 09:00, 2024-02-05 09:00, 2024-03-05 09:00"},
+		{"todo mês no dia de atual", "FREQ=MONTHLY", time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), "2024-02-15 09:00, 2024-03-15 09:00"},
+		{"dia 31 pula meses curtos", "FREQ=MONTHLY", time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), "2024-03-31 09:00, 2024-05-31 09:00, 2024-07-31 09:00, 2024-08-31 09:00"},
+		{"dia 30 pula fevereiro", "FREQ=MONTHLY;BYMONTHDAY=30", time.Date(2024, 1, 30, 9, 0, 0, 0, time.UTC), "2024-03-30 09:00, 2024-04-30 09:00"},
+		{"último dia do mês", "FREQ=MONTHLY;BYMONTHDAY=-1", time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), "2024-02-29 09:00, 2024-03-31 09:00, 2024-04-30 09:00"},
+		{"penúltimo dia do mês", "FREQ=MONTHLY;BYMONTHDAY=-2", time.Date(2023, 1, 30, 9, 0, 0, 0, time.UTC), "2023-02-27 09:00, 2023-03-30 09:00"},
+		{"dias 1 e 15", "FREQ=MONTHLY;BYMONTHDAY=15,1", time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), "2024-01-15 09:00, 2024-02-01 09:00, 2024-02-15 09:00"},
+		{"primeira segunda", "FREQ=MONTHLY;BYDAY=1MO", segunda, "2024-02-05 09:00, 2024-03-04 09:00, 2024-04-01 09:00"},
+		{"última sexta", "FREQ=MONTHLY;BYDAY=-1FR", segunda, "2024-01-26 09:00, 2024-02-23 09:00, 2024-03-29 09:00"},
+		{"sexta-feira 13", "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", segunda, "2024-09-13 09:00, 2024-12-13 09:00, 2025-06-13 09:00"},
+		{"a cada 3 meses", "FREQ=MONTHLY;INTERVAL=3", time.Date(2024, 11, 30, 9, 0, 0, 0, time.UTC), "2025-05-30 09:00, 2025-08-30 09:00"},
+		{"meses escolhidos", "FREQ=MONTHLY;BYMONTH=1,7;BYMONTHDAY=10", segunda, "2024-01-10 09:00, 2024-07-10 09:00, 2025-01-10 09:00"},
+		{"todo ano", "FREQ=YEARLY", time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC), "2025-03-15 09:00, 2026-03-15 09:00"},
+		{"29 de fevereiro", "FREQ=YEARLY", time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), "2028-02-29 09:00, 2032-02-29 09:00"},
+		{"último domingo de outubro", "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU", segunda, "2024-10-27 09:00, 2025-10-26 09:00"},
+		{"COUNT conta a ocorrência atual", "FREQ=DAILY;COUNT=3", segunda, "2024-01-02 09:00, 2024-01-03 09:00"},
+		{"UNTIL com data inclui o dia", "FREQ=DAILY;UNTIL=20240103", segunda, "2024-01-02 09:00, 2024-01-03 09:00"},
+		{"UNTIL com instante", "FREQ=DAILY;UNTIL=20240103T085959Z", segunda, "2024-01-02 09:00"},
+		{"regra sem datas possíveis", "FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30", segunda, ""},
+	}
+	for _, c := range casos {
+		n := strings.Count(c.esperado, ", ") + 1
+		if c.esperado == "" {
+			n = 4
+		}
+		obtido := strings.Join(sequencia(t, c.regra, c.atual, n), ", ")
+		if obtido != c.esperado {
+			t.Errorf("%s (%s):\nobtido   %s\nesperado %s", c.nome, c.regra, obtido, c.esperado)
+		}
+	}
+}
+
+func TestRegraRecorrenciaFusoHorario(t *testing.T) {
+	// O horário local se mantém na mudança para o horário de verão de Nova
+	// York, em 10/03/2024, mesmo com o deslocamento UTC mudando
+	novaYork := fuso(t, "America/New_York")
+	datas := sequencia(t, "FREQ=WEEKLY;BYDAY=MO", time.Date(2024, 3, 4, 9, 0, 0, 0, novaYork), 2)
+	if strings.Join(datas, ", ") != "2024-03-11 09:00, 2024-03-18 09:00" {
+		t.Errorf("horário local não mantido no horário de verão: %v", datas)
+	}
+	r, _ := LerRegraRecorrencia("FREQ=WEEKLY;BYDAY=MO")
+	proxima, _ := r.Proxima(time.Date(2024, 3, 4, 9, 0, 0, 0, novaYork))
+	if proxima.UTC().Hour() != 13 {
+		t.Errorf("ocorrência em UTC: obtida %v, esperada 13:00", proxima.UTC())
+	}
+
+	// Um horário que não existe no dia da mudança passa para logo depois dela
+	datas = sequencia(t, "FREQ=DAILY", time.Date(2024, 3, 9, 2, 30, 0, 0, novaYork), 1)
+	if strings.Join(datas, ", ") != "2024-03-10 03:30" {
+		t.Errorf("horário inexistente na mudança: %v", datas)
+	}
+
+	// O dia do mês é o do fuso da tarefa, não o de UTC: 23h do dia 4 em São
+	// Paulo já é dia 5 em UTC
+	saoPaulo := fuso(t, "America/Sao_Paulo")
+	atual := time.Date(2024, 1, 5, 2, 0, 0, 0, time.UTC).In(saoPaulo)
+	datas = sequencia(t, "FREQ=MONTHLY;BYMONTHDAY=5", atual, 2)
+	if strings.Join(datas, ", ") != "2024-01-05 23:00, 2024-02-05 23:00" {
+		t.Errorf("dia do mês no fuso da tarefa: %v", datas)
+	}
+}
+
+func TestLerRegraRecorrencia(t *testing.T) {
+	// A forma canônica tem as partes sempre na mesma ordem, em maiúsculas
+	canonicas := map[string]string{
+		"RRULE:freq=weekly;byday=mo":                 "FREQ=WEEKLY;BYDAY=MO",
+		"BYDAY=MO,WE;INTERVAL=2;FREQ=WEEKLY":         "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		"FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=-1":      "FREQ=MONTHLY;BYMONTHDAY=-1",
+		"FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU;COUNT=5":  "FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10;COUNT=5",
+		"FREQ=DAILY;UNTIL=20241231":                  "FREQ=DAILY;UNTIL=20241231",
+		"FREQ=DAILY;UNTIL=20241231T235959Z":          "FREQ=DAILY;UNTIL=20241231T235959Z",
+		" FREQ=MONTHLY;BYDAY=2TU,4TU;BYMONTH=3,6,9 ": "FREQ=MONTHLY;BYDAY=2TU,4TU;BYMONTH=3,6,9",
+	}
+	for entrada, esperada := range canonicas {
+		r, err := LerRegraRecorrencia(entrada)
+		if err != nil {
+			t.Errorf("%q: erro inesperado %v", entrada, err)
+			continue
+		}
+		if r.String() != esperada {
+			t.Errorf("%q: obtida %q, esperada %q", entrada, r.String(), esperada)
+		}
+	}
+
+	invalidas := []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20241231",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=5",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=DAILY;UNTIL=31/12/2024",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;COUNT",
+	}
+	for _, entrada := range invalidas {
+		_, err := LerRegraRecorrencia(entrada)
+		var validacao *ErroValidacao
+		if !errors.As(err, &validacao) || validacao.Campo != "recorrencia" {
+			t.Errorf("%q: esperado erro no campo recorrencia, obtido %v", entrada, err)
+		}
+	}
+}
+
+func TestDescreverRegraRecorrencia(t *testing.T) {
+	casos := map[string]string{
+		"FREQ=WEEKLY;BYDAY=MO":                          "toda semana (segunda)",
+		"FREQ=MONTHLY;BYMONTHDAY=5":                     "todo mês (dia 5)",
+		"FREQ=WEEKLY;INTERVAL=2":                        "a cada 2 semanas",
+		"FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3":            "todo mês (último dia), restam 3 ocorrências",
+		"FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU":             "todo ano (outubro, último domingo)",
+		"FREQ=MONTHLY;BYDAY=1MO,3FR":                    "todo mês (1ª segunda, 3ª sexta)",
+		"FREQ=DAILY;INTERVAL=3;UNTIL=20241231":          "a cada 3 dias, até 31/12/2024",
+		"FREQ=MONTHLY;BYDAY=2SA;UNTIL=20241231T150000Z": "todo mês (2º sábado), até 31/12/2024 15:00 UTC",
+	}
+	for regra, esperada := range casos {
+		r, err := LerRegraRecorrencia(regra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if obtida := r.Descrever(); obtida != esperada {
+			t.Errorf("%s: obtida %q, esperada %q", regra, obtida, esperada)
+		}
+	}
+}
+
+func TestProximaOcorrenciaDaTarefa(t *testing.T) {
+	prazo := time.Date(2024, 1, 29, 12, 0, 0, 0, time.UTC)
+	tarefa := Tarefa{
+		ID:          "1",
+		Titulo:      "Checklist de operações",
+		Concluida:   true,
+		Prioridade:  PrioridadeAlta,
+		Prazo:       &prazo,
+		Recorrencia: "FREQ=WEEKLY;BYDAY=MO;COUNT=2",
+		FusoHorario: "America/Sao_Paulo",
+		Etiquetas:   []string{"ops"},
+		Versao:      4,
+	}
+
+	proxima, ok := tarefa.ProximaOcorrencia()
+	if !ok {
+		t.Fatal("tarefa recorrente sem próxima ocorrência")
+	}
+	if tarefa.Recorrencia != "" {
+		t.Errorf("regra mantida na ocorrência concluída: %q", tarefa.Recorrencia)
+	}
+	esperado := time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC)
+	if proxima.Prazo == nil || !proxima.Prazo.Equal(esperado) || proxima.Prazo.Location() != time.UTC {
+		t.Errorf("prazo da próxima ocorrência: obtido %v, esperado %v", proxima.Prazo, esperado)
+	}
+	if proxima.ID != "" || proxima.Concluida || proxima.Versao != 0 || proxima.Titulo != tarefa.Titulo ||
+		proxima.Prioridade != PrioridadeAlta || proxima.FusoHorario != tarefa.FusoHorario || len(proxima.Etiquetas) != 1 {
+		t.Errorf("próxima ocorrência inesperada: %+v", proxima)
+	}
+	if proxima.Recorrencia != "FREQ=WEEKLY;BYDAY=MO;COUNT=1" {
+		t.Errorf("regra da próxima ocorrência: %q", proxima.Recorrencia)
+	}
+
+	// A última ocorrência da série não gera outra e perde a regra
+	if _, ok := proxima.ProximaOcorrencia(); ok || proxima.Recorrencia != "" {
+		t.Errorf("série com COUNT esgotado continuou: %+v", proxima)
+	}
+
+	// Tarefas sem recorrência não são alteradas
+	simples := Tarefa{Titulo: "Única", Prazo: &prazo}
+	if _, ok := simples.ProximaOcorrencia(); ok {
+		t.Errorf("tarefa sem recorrência gerou ocorrência")
+	}
+}
+
+func TestValidarRecorrenciaDaTarefa(t *testing.T) {
+	prazo := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	casos := []struct {
+		tarefa Tarefa
+		campo  string
+	}{
+		{Tarefa{Titulo: "Ok", Prazo: &prazo, Recorrencia: "FREQ=DAILY", FusoHorario: "America/Sao_Paulo"}, ""},
+		{Tarefa{Titulo: "Ok", Prazo: &prazo, Recorrencia: "FREQ=SOMETIMES"}, "recorrencia"},
+		{Tarefa{Titulo: "Ok", Recorrencia: "FREQ=DAILY"}, "prazo"},
+		{Tarefa{Titulo: "Ok", FusoHorario: "America/Atlantida"}, "fuso_horario"},
+		{Tarefa{Titulo: "Ok", FusoHorario: "Local"}, "fuso_horario"},
+	}
+	for _, caso := range casos {
+		err := caso.tarefa.Validar()
+		var validacao *ErroValidacao
+		switch {
+		case caso.campo == "" && err != nil:
+			t.Errorf("%+v: erro inesperado %v", caso.tarefa, err)
+		case caso.campo != "" && (!errors.As(err, &validacao) || validacao.Campo != caso.campo):
+			t.Errorf("%+v: esperado erro no campo %q, obtido %v", caso.tarefa, caso.campo, err)
+		}
+	}
+
+	// A regra é guardada na forma canônica
+	tarefa := Tarefa{Titulo: "Ok", Prazo: &prazo, Recorrencia: "rrule:byday=mo;freq=weekly"}
+	tarefa.Normalizar()
+	if tarefa.Recorrencia != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("recorrência normalizada: %q", tarefa.Recorrencia)
+	}
+}
@@ -0,0 +1,90 @@
+package dominio
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// CodigoRevisaoNaoEncontrada é o código de erro de uma revisão inexistente
+const CodigoRevisaoNaoEncontrada = "revisao_nao_encontrada"
+
+// CamposRevisados são os campos JSON da tarefa comparados nas revisões, na
+// ordem em que as diferenças são listadas. Os demais são controlados pelo
+// servidor e não entram nas revisões.
+var CamposRevisados = []string{
+	"titulo",
+	"concluida",
+	"estado",
+	"descricao",
+	"prioridade",
+	"prazo",
+	"recorrencia",
+	"fuso_horario",
+	"projeto_id",
+	"etiquetas",
+	"pai_id",
+	"concluir_com_subtarefas",
+	"bloqueada_por",
+}
+
+// nulo é o valor JSON de um campo ausente
+var nulo = json.RawMessage("null")
+
+// Diferenca é a mudança de um campo da tarefa em uma revisão. De e Para são
+// os valores JSON do campo, null quando o campo estava ausente.
+type Diferenca struct {
+	Campo string          `json:"campo"`
+	De    json.RawMessage `json:"de"`
+	Para  json.RawMessage `json:"para"`
+}
+
+// Revisao é o registro imutável de uma alteração de uma tarefa: quem a fez,
+// quando e a diferença de cada campo. Numero é a versão que a alteração deu
+// à tarefa; Tarefa é a tarefa como ficou, para que se possa voltar a ela.
+type Revisao struct {
+	ID         string      `json:"id"`
+	TarefaID   string      `json:"tarefa_id"`
+	Numero     int         `json:"numero"`
+	Autor      string      `json:"autor"`
+	Em         time.Time   `json:"em"`
+	Diferencas []Diferenca `json:"diferencas"`
+	Tarefa     Tarefa      `json:"tarefa"`
+	Dono       string      `json:"dono,omitempty"`
+}
+
+// DiferencasTarefa compara os CamposRevisados da tarefa antes e depois de
+// uma alteração. Sem antes, na criação, lista os campos preenchidos.
+func DiferencasTarefa(antes *Tarefa, depois Tarefa) []Diferenca {
+	var de map[string]json.RawMessage
+	if antes != nil {
+		de = camposJSON(*antes)
+	}
+	para := camposJSON(depois)
+
+	diferencas := []Diferenca{}
+	for _, campo := range CamposRevisados {
+		valorDe, valorPara := valorCampo(de, campo), valorCampo(para, campo)
+		if bytes.Equal(valorDe, valorPara) {
+			continue
+		}
+		diferencas = append(diferencas, Diferenca{Campo: campo, De: valorDe, Para: valorPara})
+	}
+	return diferencas
+}
+
+// camposJSON retorna os campos da tarefa como gravados em JSON
+func camposJSON(t Tarefa) map[string]json.RawMessage {
+	var campos map[string]json.RawMessage
+	doc, _ := json.Marshal(t)
+	json.Unmarshal(doc, &campos)
+	return campos
+}
+
+// valorCampo retorna o valor JSON do campo, ou null se ele estiver ausente
+func valorCampo(campos map[string]json.RawMessage, campo string) json.RawMessage {
+	if valor, ok := campos[campo]; ok {
+		return valor
+	}
+	return nulo
+}
@@ -0,0 +1,64 @@
+package dominio
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiferencasTarefa(t *testing.T) {
+	antes := Tarefa{ID: "1", Titulo: "Relatório", Estado: EstadoPendente, Prioridade: PrioridadeMedia, Versao: 3}
+	depois := antes
+	depois.Titulo = "Relatório final"
+	depois.Concluida = true
+	depois.Estado = EstadoConcluida
+	depois.Etiquetas = []string{"e1"}
+	// Campos controlados pelo servidor não entram na comparação
+	depois.Versao = 4
+	depois.AtualizadaEm = time.Now()
+
+	var obtido []string
+	for _, d := range DiferencasTarefa(&antes, depois) {
+		obtido = append(obtido, d.Campo+":"+string(d.De)+">"+string(d.Para))
+	}
+	esperado := []string{
+		`titulo:"Relatório">"Relatório final"`,
+		`concluida:false>true`,
+		`estado:"pendente">"concluida"`,
+		`etiquetas:null>["e1"]`,
+	}
+	if !slices.Equal(obtido, esperado) {
+		t.Errorf("diferenças obtidas %v esperadas %v", obtido, esperado)
+	}
+
+	if diferencas := DiferencasTarefa(&antes, antes); len(diferencas) != 0 {
+		t.Errorf("tarefa sem mudanças gerou diferenças: %+v", diferencas)
+	}
+
+	// Na criação, só os campos preenchidos aparecem, sem valor anterior
+	var campos []string
+	for _, d := range DiferencasTarefa(nil, antes) {
+		if string(d.De) != "null" {
+			t.Errorf("valor anterior na criação: %+v", d)
+		}
+		campos = append(campos, d.Campo)
+	}
+	if !slices.Equal(campos, []string{"titulo", "concluida", "estado", "prioridade"}) {
+		t.Errorf("campos da criação: %v", campos)
+	}
+}
+
+func TestCamposRevisadosCobremATarefa(t *testing.T) {
+	// Todo campo da tarefa ou é revisado ou é controlado pelo servidor, para
+	// que um campo novo não fique de fora das revisões por esquecimento
+	controlados := []string{"id", "subtarefas", "bloqueada", "versao", "criada_em", "atualizada_em", "concluida_em", "excluida_em", "excluida_com", "subtarefas_promovidas", "dono"}
+	tipo := reflect.TypeOf(Tarefa{})
+	for i := 0; i < tipo.NumField(); i++ {
+		campo, _, _ := strings.Cut(tipo.Field(i).Tag.Get("json"), ",")
+		if !slices.Contains(CamposRevisados, campo) && !slices.Contains(controlados, campo) {
+			t.Errorf("campo %q não é revisado nem controlado pelo servidor", campo)
+		}
+	}
+}
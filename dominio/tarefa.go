@@ -0,0 +1,269 @@
+// Package dominio contém os tipos, as regras de validação e o contrato JSON
+// de tarefas compartilhados entre a API e o frontend.
+package dominio
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prioridade indica a urgência de uma tarefa
+type Prioridade string
+
+const (
+	PrioridadeBaixa   Prioridade = "baixa"
+	PrioridadeMedia   Prioridade = "media"
+	PrioridadeAlta    Prioridade = "alta"
+	PrioridadeUrgente Prioridade = "urgente"
+)
+
+// pesoPrioridade define a ordem das prioridades, da menor para a maior
+var pesoPrioridade = map[Prioridade]int{
+	PrioridadeBaixa:   1,
+	PrioridadeMedia:   2,
+	PrioridadeAlta:    3,
+	PrioridadeUrgente: 4,
+}
+
+// Valida informa se p é uma das prioridades conhecidas
+func (p Prioridade) Valida() bool {
+	_, ok := pesoPrioridade[p]
+	return ok
+}
+
+// Peso retorna a posição da prioridade na ordem crescente de urgência,
+// ou zero para valores desconhecidos
+func (p Prioridade) Peso() int {
+	return pesoPrioridade[p]
+}
+
+// Tarefa representa uma tarefa no sistema.
+// Os campos id, titulo e concluida formam o contrato original da API; os demais
+// são opcionais na entrada, para que clientes antigos continuem funcionando.
+// Versão, dono, progresso das subtarefas, bloqueio, remoção e datas de criação,
+// atualização e conclusão são controlados pelo servidor.
+//
+// Uma tarefa com PaiID é subtarefa da tarefa com esse ID. Subtarefas traz o
+// progresso das subtarefas diretas e é omitido quando não há nenhuma; com
+// ConcluirComSubtarefas, a tarefa é concluída junto com a última delas.
+//
+// Uma tarefa com Recorrencia, uma regra RRULE, se repete: ao ser concluída,
+// gera a próxima ocorrência com o prazo seguinte, calculado no FusoHorario
+// da tarefa (UTC se vazio).
+//
+// Estado é o estado da tarefa no fluxo de trabalho do seu projeto (veja
+// Fluxo); Concluida é mantido pelo servidor como compatibilidade e indica se
+// o estado é final.
+//
+// BloqueadaPor lista os IDs das tarefas que precisam ser concluídas antes
+// desta. Bloqueada, calculado na leitura, indica que alguma delas ainda está
+// pendente; uma tarefa bloqueada não pode ser concluída.
+//
+// ExcluidaEm marca uma tarefa que está na lixeira (veja ItemLixeira);
+// ExcluidaCom traz o ID da tarefa cuja remoção levou esta junto para a
+// lixeira, vazio na tarefa removida diretamente. SubtarefasPromovidas lista
+// as subtarefas diretas que passaram para o pai da tarefa removida e voltam
+// para ela se for restaurada.
+type Tarefa struct {
+	ID                    string               `json:"id"`
+	Titulo                string               `json:"titulo"`
+	Concluida             bool                 `json:"concluida"`
+	Estado                string               `json:"estado,omitempty"`
+	Descricao             string               `json:"descricao,omitempty"`
+	Prioridade            Prioridade           `json:"prioridade,omitempty"`
+	Prazo                 *time.Time           `json:"prazo,omitempty"`
+	Recorrencia           string               `json:"recorrencia,omitempty"`
+	FusoHorario           string               `json:"fuso_horario,omitempty"`
+	ProjetoID             string               `json:"projeto_id,omitempty"`
+	Etiquetas             []string             `json:"etiquetas,omitempty"`
+	PaiID                 string               `json:"pai_id,omitempty"`
+	ConcluirComSubtarefas bool                 `json:"concluir_com_subtarefas,omitempty"`
+	Subtarefas            *ProgressoSubtarefas `json:"subtarefas,omitempty"`
+	BloqueadaPor          []string             `json:"bloqueada_por,omitempty"`
+	Bloqueada             bool                 `json:"bloqueada,omitempty"`
+	Versao                int                  `json:"versao"`
+	CriadaEm              time.Time            `json:"criada_em"`
+	AtualizadaEm          time.Time            `json:"atualizada_em"`
+	ConcluidaEm           *time.Time           `json:"concluida_em,omitempty"`
+	ExcluidaEm            *time.Time           `json:"excluida_em,omitempty"`
+	ExcluidaCom           string               `json:"excluida_com,omitempty"`
+	SubtarefasPromovidas  []string             `json:"subtarefas_promovidas,omitempty"`
+	Dono                  string               `json:"dono,omitempty"`
+}
+
+// ETag retorna a etiqueta de entidade da tarefa, derivada da versão. A API
+// a envia no cabeçalho ETag e a espera em If-Match nas escritas.
+func (t Tarefa) ETag() string {
+	return ETagVersao(t.Versao)
+}
+
+// ETagVersao retorna a etiqueta de entidade forte de uma tarefa na versão
+// informada
+func ETagVersao(versao int) string {
+	return `"` + strconv.Itoa(versao) + `"`
+}
+
+// ProgressoSubtarefas conta as subtarefas diretas de uma tarefa
+type ProgressoSubtarefas struct {
+	Total      int `json:"total"`
+	Concluidas int `json:"concluidas"`
+}
+
+// Completo informa se todas as subtarefas foram concluídas
+func (p ProgressoSubtarefas) Completo() bool {
+	return p.Total > 0 && p.Concluidas == p.Total
+}
+
+// Destinos das subtarefas ao remover uma tarefa, no parâmetro ?subtarefas de
+// DELETE /api/tarefas/{id}
+const (
+	// SubtarefasPromover passa as subtarefas para o pai da tarefa removida,
+	// ou as deixa sem pai; é o padrão
+	SubtarefasPromover = "promover"
+	// SubtarefasRemover remove as subtarefas, em cascata, junto com a tarefa
+	SubtarefasRemover = "remover"
+)
+
+// CodigoTarefaBloqueada é o código de erro ao concluir uma tarefa que ainda
+// depende de tarefas pendentes
+const CodigoTarefaBloqueada = "tarefa_bloqueada"
+
+// PaginaTarefas é o envelope de resposta de GET /api/tarefas
+type PaginaTarefas struct {
+	Tarefas    []Tarefa `json:"tarefas"`
+	Limit      int      `json:"limit"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// ErroValidacao descreve um campo inválido de uma tarefa. Também é o
+// formato dos itens de errors nas respostas de erro da API.
+type ErroValidacao struct {
+	Campo     string    `json:"field"`
+	Codigo    string    `json:"code"`
+	Mensagens Mensagens `json:"messages"`
+}
+
+func (e *ErroValidacao) Error() string {
+	return e.Mensagens.PtBR
+}
+
+// Validar verifica os campos editáveis pelo cliente
+func (t Tarefa) Validar() error {
+	if strings.TrimSpace(t.Titulo) == "" {
+		return &ErroValidacao{"titulo", CodigoObrigatorio, Mensagens{
+			PtBR: "o título é obrigatório",
+			En:   "title is required",
+		}}
+	}
+	if t.Prioridade != "" && !t.Prioridade.Valida() {
+		return &ErroValidacao{"prioridade", CodigoInvalido, Mensagens{
+			PtBR: "a prioridade deve ser baixa, media, alta ou urgente",
+			En:   "priority must be baixa, media, alta or urgente",
+		}}
+	}
+	if t.FusoHorario != "" {
+		if _, err := time.LoadLocation(t.FusoHorario); err != nil || t.FusoHorario == "Local" {
+			return &ErroValidacao{"fuso_horario", CodigoInvalido, Mensagens{
+				PtBR: "use um fuso horário IANA, como America/Sao_Paulo",
+				En:   "use an IANA time zone, such as America/Sao_Paulo",
+			}}
+		}
+	}
+	if t.Recorrencia != "" {
+		if _, err := LerRegraRecorrencia(t.Recorrencia); err != nil {
+			return err
+		}
+		if t.Prazo == nil {
+			return &ErroValidacao{"prazo", CodigoObrigatorio, Mensagens{
+				PtBR: "o prazo é obrigatório em tarefas recorrentes",
+				En:   "due date is required for recurring tasks",
+			}}
+		}
+	}
+	return nil
+}
+
+// Normalizar preenche os valores padrão de campos opcionais, inclusive em
+// tarefas gravadas ou enviadas antes de esses campos existirem, remove
+// etiquetas e bloqueios vazios ou repetidos e reescreve a recorrência na
+// forma canônica. Tarefas sem estado recebem o do fluxo padrão equivalente
+// a concluida.
+func (t *Tarefa) Normalizar() {
+	if t.Prioridade == "" {
+		t.Prioridade = PrioridadeMedia
+	}
+	if t.Estado == "" {
+		t.Estado = FluxoPadrao().Resolver(*t)
+	}
+	if regra, err := LerRegraRecorrencia(t.Recorrencia); err == nil {
+		t.Recorrencia = regra.String()
+	}
+	t.Etiquetas = semRepeticoes(t.Etiquetas)
+	t.BloqueadaPor = semRepeticoes(t.BloqueadaPor)
+}
+
+// semRepeticoes retorna os IDs sem os vazios e os repetidos, na ordem original
+func semRepeticoes(ids []string) []string {
+	var unicos []string
+	vistos := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != "" && !vistos[id] {
+			vistos[id] = true
+			unicos = append(unicos, id)
+		}
+	}
+	return unicos
+}
+
+// TemEtiqueta informa se a tarefa tem a etiqueta com o ID informado
+func (t Tarefa) TemEtiqueta(id string) bool {
+	for _, e := range t.Etiquetas {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Fuso retorna o fuso horário da tarefa, ou UTC se não houver um válido
+func (t Tarefa) Fuso() *time.Location {
+	if loc, err := time.LoadLocation(t.FusoHorario); err == nil && t.FusoHorario != "Local" {
+		return loc
+	}
+	return time.UTC
+}
+
+// ProximaOcorrencia passa a recorrência da tarefa, que acaba de ser
+// concluída, para a ocorrência seguinte: retira a regra da tarefa e retorna
+// uma nova tarefa pendente, com os mesmos dados, o prazo seguinte e a regra.
+// ok é falso se a tarefa não é recorrente ou se a série terminou nela; no
+// segundo caso, a regra também é retirada.
+func (t *Tarefa) ProximaOcorrencia() (proxima Tarefa, ok bool) {
+	if t.Recorrencia == "" || t.Prazo == nil {
+		return Tarefa{}, false
+	}
+	regra, err := LerRegraRecorrencia(t.Recorrencia)
+	if err != nil {
+		return Tarefa{}, false
+	}
+	t.Recorrencia = ""
+	prazo, ok := regra.Proxima(t.Prazo.In(t.Fuso()))
+	if !ok {
+		return Tarefa{}, false
+	}
+	prazo = prazo.UTC()
+	return Tarefa{
+		Titulo:                t.Titulo,
+		Descricao:             t.Descricao,
+		Prioridade:            t.Prioridade,
+		Prazo:                 &prazo,
+		Recorrencia:           regra.Seguinte().String(),
+		FusoHorario:           t.FusoHorario,
+		ProjetoID:             t.ProjetoID,
+		Etiquetas:             slices.Clone(t.Etiquetas),
+		PaiID:                 t.PaiID,
+		ConcluirComSubtarefas: t.ConcluirComSubtarefas,
+	}, true
+}
@@ -0,0 +1,140 @@
+package dominio
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// contratoTarefa é a representação JSON esperada de uma tarefa completa.
+// Alterar o nome ou a presença de um campo quebra este teste de propósito:
+// o contrato é consumido pela API, pelo frontend e por clientes externos.
+const contratoTarefa = `{"id":"1","titulo":"Implementar CI/CD","concluida":true,"estado":"revisado",` +
+	`"descricao":"Pipeline completo","prioridade":"alta","prazo":"2024-06-01T18:00:00Z",` +
+	`"recorrencia":"FREQ=WEEKLY;BYDAY=MO","fuso_horario":"America/Sao_Paulo",` +
+	`"projeto_id":"p1","etiquetas":["casa","deploy"],"pai_id":"0","concluir_com_subtarefas":true,` +
+	`"subtarefas":{"total":2,"concluidas":2},"bloqueada_por":["2"],"bloqueada":true,` +
+	`"versao":3,"criada_em":"2024-05-01T09:00:00Z","atualizada_em":"2024-05-02T10:00:00Z",` +
+	`"concluida_em":"2024-05-02T10:00:00Z","dono":"ana"}`
+
+func TestContratoJSONTarefa(t *testing.T) {
+	prazo := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	concluidaEm := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	tarefa := Tarefa{
+		ID:                    "1",
+		Titulo:                "Implementar CI/CD",
+		Concluida:             true,
+		Estado:                "revisado",
+		Descricao:             "Pipeline completo",
+		Prioridade:            PrioridadeAlta,
+		Prazo:                 &prazo,
+		Recorrencia:           "FREQ=WEEKLY;BYDAY=MO",
+		FusoHorario:           "America/Sao_Paulo",
+		ProjetoID:             "p1",
+		Etiquetas:             []string{"casa", "deploy"},
+		PaiID:                 "0",
+		ConcluirComSubtarefas: true,
+		Subtarefas:            &ProgressoSubtarefas{Total: 2, Concluidas: 2},
+		BloqueadaPor:          []string{"2"},
+		Bloqueada:             true,
+		Versao:                3,
+		CriadaEm:              time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		AtualizadaEm:          concluidaEm,
+		ConcluidaEm:           &concluidaEm,
+		Dono:                  "ana",
+	}
+
+	b, err := json.Marshal(tarefa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != contratoTarefa {
+		t.Errorf("contrato JSON mudou:\nobtido   %s\nesperado %s", b, contratoTarefa)
+	}
+}
+
+func TestContratoJSONClienteAntigo(t *testing.T) {
+	// Clientes antigos conhecem apenas id, titulo e concluida
+	var tarefa Tarefa
+	if err := json.Unmarshal([]byte(`{"id":"1","titulo":"Aprender Go","concluida":false}`), &tarefa); err != nil {
+		t.Fatal(err)
+	}
+	tarefa.Normalizar()
+	if err := tarefa.Validar(); err != nil {
+		t.Errorf("tarefa de cliente antigo deveria ser válida: %v", err)
+	}
+	if tarefa.Prioridade != PrioridadeMedia {
+		t.Errorf("prioridade padrão: obtida %q esperada %q", tarefa.Prioridade, PrioridadeMedia)
+	}
+}
+
+func TestValidarTarefa(t *testing.T) {
+	casos := []struct {
+		tarefa Tarefa
+		campo  string
+	}{
+		{Tarefa{Titulo: "Ok"}, ""},
+		{Tarefa{Titulo: "Ok", Prioridade: PrioridadeUrgente}, ""},
+		{Tarefa{Titulo: "   "}, "titulo"},
+		{Tarefa{Titulo: "Ok", Prioridade: "maxima"}, "prioridade"},
+	}
+	for _, caso := range casos {
+		err := caso.tarefa.Validar()
+		var validacao *ErroValidacao
+		switch {
+		case caso.campo == "" && err != nil:
+			t.Errorf("%+v: erro inesperado %v", caso.tarefa, err)
+		case caso.campo != "" && (!errors.As(err, &validacao) || validacao.Campo != caso.campo):
+			t.Errorf("%+v: esperado erro no campo %q, obtido %v", caso.tarefa, caso.campo, err)
+		}
+	}
+}
+
+func TestNormalizarEtiquetasDaTarefa(t *testing.T) {
+	tarefa := Tarefa{Titulo: "Ok", Etiquetas: []string{"casa", "", "deploy", "casa"}}
+	tarefa.Normalizar()
+	if len(tarefa.Etiquetas) != 2 || tarefa.Etiquetas[0] != "casa" || tarefa.Etiquetas[1] != "deploy" {
+		t.Errorf("etiquetas normalizadas: %v", tarefa.Etiquetas)
+	}
+	if !tarefa.TemEtiqueta("deploy") || tarefa.TemEtiqueta("trabalho") {
+		t.Errorf("TemEtiqueta inesperado para %v", tarefa.Etiquetas)
+	}
+
+	// Sem etiquetas válidas o campo é omitido do JSON
+	tarefa.Etiquetas = []string{""}
+	tarefa.Normalizar()
+	if tarefa.Etiquetas != nil {
+		t.Errorf("etiquetas vazias mantidas: %#v", tarefa.Etiquetas)
+	}
+}
+
+func TestNormalizarBloqueiosDaTarefa(t *testing.T) {
+	tarefa := Tarefa{Titulo: "Ok", BloqueadaPor: []string{"b", "", "a", "b"}}
+	tarefa.Normalizar()
+	if len(tarefa.BloqueadaPor) != 2 || tarefa.BloqueadaPor[0] != "b" || tarefa.BloqueadaPor[1] != "a" {
+		t.Errorf("bloqueios normalizados: %v", tarefa.BloqueadaPor)
+	}
+}
+
+func TestProgressoSubtarefasCompleto(t *testing.T) {
+	casos := []struct {
+		progresso ProgressoSubtarefas
+		completo  bool
+	}{
+		{ProgressoSubtarefas{}, false},
+		{ProgressoSubtarefas{Total: 3, Concluidas: 2}, false},
+		{ProgressoSubtarefas{Total: 3, Concluidas: 3}, true},
+	}
+	for _, caso := range casos {
+		if caso.progresso.Completo() != caso.completo {
+			t.Errorf("%+v: Completo() deveria ser %v", caso.progresso, caso.completo)
+		}
+	}
+}
+
+func TestETagDaTarefa(t *testing.T) {
+	if etag := (Tarefa{Versao: 12}).ETag(); etag != `"12"` {
+		t.Errorf("ETag = %s", etag)
+	}
+}
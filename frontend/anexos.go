@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// folgaFormularioAnexo é o espaço do formulário de envio além do arquivo,
+// somado a dominio.TamanhoMaximoAnexo no limite do corpo das requisições
+const folgaFormularioAnexo = 1 << 20
+
+// anexoVisao é um anexo como exibido na página da tarefa
+type anexoVisao struct {
+	dominio.Anexo
+	// TamanhoLegivel é o tamanho em B, KB ou MB
+	TamanhoLegivel string
+}
+
+// anexosVisao prepara os anexos para a página da tarefa
+func anexosVisao(anexos []dominio.Anexo) []anexoVisao {
+	visoes := make([]anexoVisao, len(anexos))
+	for i, anexo := range anexos {
+		visoes[i] = anexoVisao{Anexo: anexo, TamanhoLegivel: tamanhoLegivel(anexo.Tamanho)}
+	}
+	return visoes
+}
+
+// tamanhoLegivel formata um tamanho em bytes para exibição
+func tamanhoLegivel(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+// enviarAnexo atende POST /tarefas/:id/anexos, o formulário de envio de
+// arquivo da página da tarefa
+func (a *aplicacao) enviarAnexo(c *fiber.Ctx) error {
+	id := c.Params("id")
+	form := &formularioInvalido{anexo: true}
+	arquivo, err := c.FormFile("arquivo")
+	if err != nil {
+		form.mensagem = "Escolha um arquivo para anexar."
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+	if arquivo.Size > dominio.TamanhoMaximoAnexo {
+		form.mensagem = "O arquivo passa do tamanho máximo de " + tamanhoLegivel(dominio.TamanhoMaximoAnexo) + "."
+		return a.renderizarTarefa(c, fiber.StatusRequestEntityTooLarge, form, "")
+	}
+	f, err := arquivo.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	conteudo, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	_, err = a.api.Anexar(ctx, id, arquivo.Filename, arquivo.Header.Get("Content-Type"), conteudo)
+	switch {
+	case err == nil:
+		return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+	case errors.Is(err, cliente.ErrAnexoGrande):
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefa(c, fiber.StatusRequestEntityTooLarge, form, "")
+	case errors.Is(err, cliente.ErrTipoAnexoRecusado):
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefa(c, fiber.StatusUnsupportedMediaType, form, "")
+	}
+	return a.responderErroPaginaTarefa(c, form, err)
+}
+
+// baixarAnexo atende GET /tarefas/:id/anexos/:anexo, repassando o conteúdo
+// do anexo com o nome original
+func (a *aplicacao) baixarAnexo(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	anexo, conteudo, err := a.api.BaixarAnexo(ctx, c.Params("id"), c.Params("anexo"))
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return c.Status(fiber.StatusNotFound).SendString("Anexo não encontrado.")
+	case err != nil:
+		log.Printf("erro ao baixar anexo: %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).SendString("Não foi possível baixar o anexo. Tente novamente em instantes.")
+	}
+
+	disposicao := mime.FormatMediaType("attachment", map[string]string{"filename": anexo.Nome})
+	if disposicao == "" {
+		disposicao = "attachment"
+	}
+	c.Set(fiber.HeaderContentType, anexo.Tipo)
+	c.Set(fiber.HeaderContentDisposition, disposicao)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.Send(conteudo)
+}
+
+// removerAnexo atende POST /tarefas/:id/anexos/:anexo/remover
+func (a *aplicacao) removerAnexo(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	err := a.api.RemoverAnexo(ctx, id, c.Params("anexo"))
+	if err != nil && !errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.responderErroPaginaTarefa(c, nil, err)
+	}
+	// Um anexo que já não existe não impede de voltar à tarefa
+	return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+}
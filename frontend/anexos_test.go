@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// enviarArquivo envia o formulário de anexo da tarefa com o arquivo informado
+func enviarArquivo(t *testing.T, app *fiber.App, tarefaID, nome, tipo, conteudo string) *http.Response {
+	t.Helper()
+	var corpo bytes.Buffer
+	escritor := multipart.NewWriter(&corpo)
+	cabecalho := textproto.MIMEHeader{}
+	cabecalho.Set("Content-Disposition", `form-data; name="arquivo"; filename="`+nome+`"`)
+	cabecalho.Set("Content-Type", tipo)
+	parte, _ := escritor.CreatePart(cabecalho)
+	io.WriteString(parte, conteudo)
+	escritor.Close()
+
+	req := httptest.NewRequest("POST", "/tarefas/"+tarefaID+"/anexos", &corpo)
+	req.Header.Set("Content-Type", escritor.FormDataContentType())
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	return resp
+}
+
+func TestAnexosNaPaginaDaTarefa(t *testing.T) {
+	api := &apiComRegras{Falso: cliente.NovoFalso(), recusarAnexo: func(nome, tipo string) error {
+		if tipo == "text/html" {
+			return erroRegra(http.StatusUnsupportedMediaType, dominio.CodigoTipoAnexoNaoPermitido, "o tipo do arquivo não é aceito nos anexos")
+		}
+		return nil
+	}}
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+	pagina := "/tarefas/" + tarefa.ID
+
+	resp := enviarArquivo(t, app, tarefa.ID, "ata da reunião.txt", "text/plain", "Decidimos usar Go")
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != pagina {
+		t.Fatalf("Anexar: obtido %d", resp.StatusCode)
+	}
+	anexos, _ := api.Anexos(ctx, tarefa.ID)
+	if len(anexos) != 1 {
+		t.Fatalf("Anexos gravados: %+v", anexos)
+	}
+	download := pagina + "/anexos/" + anexos[0].ID
+	corpo := obterPagina(t, app, pagina)
+	if !strings.Contains(corpo, `href="`+download+`"`) || !strings.Contains(corpo, "ata da reunião.txt") || !strings.Contains(corpo, "17 B") {
+		t.Errorf("Anexo não listado na página da tarefa")
+	}
+
+	// O download repassa o conteúdo com o nome original
+	req := httptest.NewRequest("GET", download, nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conteudo, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || string(conteudo) != "Decidimos usar Go" {
+		t.Errorf("Download: obtido %d %q", resp.StatusCode, conteudo)
+	}
+	if disposicao := resp.Header.Get("Content-Disposition"); disposicao != "attachment; filename*=utf-8''ata%20da%20reuni%C3%A3o.txt" {
+		t.Errorf("Content-Disposition do download: %q", disposicao)
+	}
+
+	// Um tipo recusado pela API volta ao formulário com a mensagem
+	resp = enviarArquivo(t, app, tarefa.ID, "pagina.html", "text/html", "<p>Oi</p>")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnsupportedMediaType || !strings.Contains(string(body), "não é aceito nos anexos") {
+		t.Errorf("Tipo recusado: obtido %d", resp.StatusCode)
+	}
+
+	resp = enviarFormulario(t, app, download+"/remover", nil)
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Remover anexo: obtido %d", resp.StatusCode)
+	}
+	if corpo := obterPagina(t, app, pagina); !strings.Contains(corpo, "Nenhum arquivo anexado.") {
+		t.Errorf("Anexo removido ainda listado")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+)
+
+// maximoPaginasEmCache limita a memória usada pelo cache de páginas
+const maximoPaginasEmCache = 100
+
+// paginaEmCache é uma página de tarefas, as subtarefas exibidas sob elas, os
+// projetos e as etiquetas exibidos ao lado e o instante em que foram obtidos
+type paginaEmCache struct {
+	pagina     dominio.PaginaTarefas
+	subtarefas []dominio.Tarefa
+	projetos   []dominio.Projeto
+	etiquetas  []dominio.Etiqueta
+	obtidaEm   time.Time
+}
+
+// chavePagina identifica uma página em cache. As páginas são separadas pelo
+// token da sessão, e não pelo login, porque com a API fora do ar o frontend
+// não tem como confirmar quem é o usuário; só quem tem o token vê a página.
+type chavePagina struct {
+	sessao string
+	// filtro é o filtro da lista codificado como na URL
+	filtro string
+	cursor string
+}
+
+// cacheTarefas guarda a última página obtida com sucesso para cada sessão,
+// filtro e cursor, para que o frontend continue exibindo as tarefas quando a API cair
+type cacheTarefas struct {
+	mu      sync.Mutex
+	paginas map[chavePagina]paginaEmCache
+}
+
+// novoCacheTarefas cria um cache vazio
+func novoCacheTarefas() *cacheTarefas {
+	return &cacheTarefas{paginas: make(map[chavePagina]paginaEmCache)}
+}
+
+// guardar registra a página obtida para a chave, marcando o instante atual
+func (c *cacheTarefas) guardar(chave chavePagina, p paginaEmCache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, existe := c.paginas[chave]; !existe && len(c.paginas) >= maximoPaginasEmCache {
+		c.paginas = make(map[chavePagina]paginaEmCache)
+	}
+	p.obtidaEm = time.Now()
+	c.paginas[chave] = p
+}
+
+// obter retorna a última página guardada para a chave, se houver
+func (c *cacheTarefas) obter(chave chavePagina) (paginaEmCache, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.paginas[chave]
+	return p, ok
+}
+
+// descartar remove as páginas da sessão, ao sair ou quando a API recusa o token
+func (c *cacheTarefas) descartar(sessao string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for chave := range c.paginas {
+		if chave.sessao == sessao {
+			delete(c.paginas, chave)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// formatoDataAtividade é o formato das datas na linha do tempo da tarefa
+const formatoDataAtividade = "02/01/2006 15:04"
+
+// atividadeVisao é um item da linha do tempo na página da tarefa: um
+// comentário ou a descrição de um evento do sistema
+type atividadeVisao struct {
+	Em string
+	// Evento descreve o evento do sistema, como "Estado alterado de Backlog
+	// para Revisão"; vazio nos comentários
+	Evento     string
+	Comentario *comentarioVisao
+}
+
+// comentarioVisao é um comentário como exibido na linha do tempo
+type comentarioVisao struct {
+	dominio.Comentario
+	// HTML é o texto convertido de Markdown
+	HTML string
+	// TextoEditado e ErroEdicao guardam o formulário de edição que falhou
+	// na validação
+	TextoEditado string
+	ErroEdicao   string
+}
+
+// descreverAtividade descreve o evento do sistema com os nomes dos estados
+// no fluxo da tarefa
+func descreverAtividade(a dominio.Atividade, fluxo dominio.Fluxo) string {
+	nome := func(estado string) string {
+		if e, ok := fluxo.Estado(estado); ok {
+			return e.Nome
+		}
+		return estado
+	}
+	switch a.Tipo {
+	case dominio.AtividadeCriada:
+		return "Tarefa criada em " + nome(a.Para)
+	case dominio.AtividadeEstado:
+		return "Estado alterado de " + nome(a.De) + " para " + nome(a.Para)
+	case dominio.AtividadeRenomeada:
+		return "Renomeada de “" + a.De + "” para “" + a.Para + "”"
+	}
+	return a.Tipo
+}
+
+// paginaTarefa atende GET /tarefas/:id, a página da tarefa com a linha do
+// tempo de comentários e eventos
+func (a *aplicacao) paginaTarefa(c *fiber.Ctx) error {
+	return a.renderizarTarefa(c, fiber.StatusOK, nil, "")
+}
+
+// renderizarTarefa busca a tarefa, sua atividade, seus anexos e seu
+// histórico de alterações e renderiza a página da
+// tarefa com o status, o formulário inválido (se houver) e o aviso informados
+func (a *aplicacao) renderizarTarefa(c *fiber.Ctx, status int, form *formularioInvalido, aviso string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	id := c.Params("id")
+	dados := fiber.Map{"Titulo": "Tarefa", "Aviso": aviso}
+	if form != nil && form.anexo {
+		dados["ErroAnexo"] = form.mensagem
+	} else if form != nil && form.id == "" {
+		dados["NovoComentario"] = fiber.Map{"Texto": form.titulo, "Erro": form.mensagem}
+	}
+
+	tarefa, err := a.api.Buscar(ctx, id)
+	var atividades []dominio.Atividade
+	var projetos []dominio.Projeto
+	var anexos []dominio.Anexo
+	var revisoes []dominio.Revisao
+	var etiquetas []dominio.Etiqueta
+	if err == nil {
+		atividades, err = a.api.Atividade(ctx, id)
+	}
+	if err == nil {
+		anexos, err = a.api.Anexos(ctx, id)
+	}
+	if err == nil {
+		revisoes, err = a.api.Revisoes(ctx, id)
+	}
+	if err == nil {
+		projetos, err = a.api.ListarProjetos(ctx)
+	}
+	if err == nil {
+		etiquetas, err = a.api.ListarEtiquetas(ctx)
+	}
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		dados["Erro"] = "A tarefa não existe mais; ela pode ter sido removida."
+		return c.Status(fiber.StatusNotFound).Render("tarefa", dados)
+	case err != nil:
+		log.Printf("erro ao buscar a tarefa: %v", err)
+		dados["Erro"] = "Não foi possível carregar a tarefa. Tente novamente em instantes."
+		return c.Status(fiber.StatusServiceUnavailable).Render("tarefa", dados)
+	}
+
+	visao := tarefaVisao{Tarefa: tarefa}
+	estadoDe(&visao, projetos)
+	for _, p := range projetos {
+		if p.ID == tarefa.ProjetoID {
+			dados["NomeProjeto"] = p.Nome
+		}
+	}
+	fluxo, _ := fluxoDoProjeto(projetos, tarefa.ProjetoID)
+
+	linha := make([]atividadeVisao, len(atividades))
+	for i, at := range atividades {
+		linha[i].Em = at.Em.Local().Format(formatoDataAtividade)
+		if at.Comentario == nil {
+			linha[i].Evento = descreverAtividade(at, fluxo)
+			continue
+		}
+		comentario := &comentarioVisao{Comentario: *at.Comentario, HTML: markdownParaHTML(at.Comentario.Texto), TextoEditado: at.Comentario.Texto}
+		if form != nil && form.id == comentario.ID {
+			comentario.TextoEditado, comentario.ErroEdicao = form.titulo, form.mensagem
+		}
+		linha[i].Comentario = comentario
+	}
+	dados["Tarefa"] = visao
+	dados["Atividades"] = linha
+	dados["Anexos"] = anexosVisao(anexos)
+	dados["TamanhoMaximoAnexo"] = tamanhoLegivel(dominio.TamanhoMaximoAnexo)
+	dados["Revisoes"] = revisoesVisao(revisoes, tarefa, projetos, etiquetas)
+	return c.Status(status).Render("tarefa", dados)
+}
+
+// enderecoTarefa retorna o endereço da página da tarefa
+func enderecoTarefa(id string) string {
+	return "/tarefas/" + id
+}
+
+// comentar atende POST /tarefas/:id/comentarios
+func (a *aplicacao) comentar(c *fiber.Ctx) error {
+	id := c.Params("id")
+	texto := c.FormValue("texto")
+	form := &formularioInvalido{titulo: texto}
+	if err := (dominio.Comentario{Texto: texto}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.Comentar(ctx, id, texto); err != nil {
+		return a.responderErroPaginaTarefa(c, form, err)
+	}
+	return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+}
+
+// editarComentario atende POST /tarefas/:id/comentarios/:comentario/editar
+func (a *aplicacao) editarComentario(c *fiber.Ctx) error {
+	id, comentario := c.Params("id"), c.Params("comentario")
+	texto := c.FormValue("texto")
+	form := &formularioInvalido{id: comentario, titulo: texto}
+	if err := (dominio.Comentario{Texto: texto}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.EditarComentario(ctx, id, comentario, texto); err != nil {
+		return a.responderErroPaginaTarefa(c, form, err)
+	}
+	return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+}
+
+// removerComentario atende POST /tarefas/:id/comentarios/:comentario/remover
+func (a *aplicacao) removerComentario(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	err := a.api.RemoverComentario(ctx, id, c.Params("comentario"))
+	if err != nil && !errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.responderErroPaginaTarefa(c, nil, err)
+	}
+	// Um comentário que já não existe não impede de voltar à tarefa
+	return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+}
+
+// responderErroPaginaTarefa trata o erro da API nos formulários da página da
+// tarefa, mantendo o texto digitado no formulário
+func (a *aplicacao) responderErroPaginaTarefa(c *fiber.Ctx, form *formularioInvalido, err error) error {
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrRequisicaoInvalida) && form != nil:
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, form, "")
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		// renderizarTarefa explica se foi a tarefa que deixou de existir
+		return a.renderizarTarefa(c, fiber.StatusNotFound, nil, "O comentário ou anexo não existe mais; ele pode ter sido removido.")
+	}
+
+	log.Printf("erro ao salvar na página da tarefa: %v", err)
+	return a.renderizarTarefa(c, fiber.StatusServiceUnavailable, form, avisoFalhaAPI(err))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestPaginaDaTarefaComComentarios(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Login"})
+	titulo := "Login social"
+	api.Alterar(ctx, tarefa.ID, cliente.Alteracao{Titulo: &titulo, Versao: tarefa.Versao})
+	pagina := "/tarefas/" + tarefa.ID
+
+	// O comentário é salvo e exibido como Markdown, com o HTML escapado
+	resp := enviarFormulario(t, app, pagina+"/comentarios", url.Values{"texto": {"Usar **OAuth** <script>x</script>"}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != pagina {
+		t.Fatalf("Comentar: obtido %d", resp.StatusCode)
+	}
+	corpo := obterPagina(t, app, pagina)
+	if !strings.Contains(corpo, "<strong>OAuth</strong> &lt;script&gt;") || strings.Contains(corpo, "<script>x") {
+		t.Errorf("Comentário não exibido como Markdown seguro")
+	}
+	// Os eventos do sistema ficam intercalados com os comentários
+	criada := strings.Index(corpo, "Tarefa criada em Pendente")
+	renomeada := strings.Index(corpo, "Renomeada de “Login” para “Login social”")
+	comentario := strings.Index(corpo, "<strong>OAuth</strong>")
+	if criada < 0 || renomeada < criada || comentario < renomeada {
+		t.Errorf("Linha do tempo fora de ordem: %d %d %d", criada, renomeada, comentario)
+	}
+
+	// Um comentário vazio é recusado mantendo a página
+	resp = enviarFormulario(t, app, pagina+"/comentarios", url.Values{"texto": {"  "}})
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Errorf("Comentário vazio: obtido %d", resp.StatusCode)
+	}
+
+	atividades, _ := api.Atividade(ctx, tarefa.ID)
+	id := atividades[len(atividades)-1].Comentario.ID
+	resp = enviarFormulario(t, app, pagina+"/comentarios/"+id+"/editar", url.Values{"texto": {"Usar OAuth e SAML"}})
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Editar comentário: obtido %d", resp.StatusCode)
+	}
+	corpo = obterPagina(t, app, pagina)
+	if !strings.Contains(corpo, "Usar OAuth e SAML") || !strings.Contains(corpo, "(editado)") {
+		t.Errorf("Comentário editado sem o marcador")
+	}
+
+	resp = enviarFormulario(t, app, pagina+"/comentarios/"+id+"/remover", nil)
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Remover comentário: obtido %d", resp.StatusCode)
+	}
+	if corpo := obterPagina(t, app, pagina); strings.Contains(corpo, "SAML") {
+		t.Errorf("Comentário removido ainda exibido")
+	}
+
+	req := httptest.NewRequest("GET", "/tarefas/inexistente", nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Tarefa inexistente: obtido %v %v", resp, err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// campoConflito é um campo em que a versão do usuário e a atual diferem,
+// como exibido na página de conflito
+type campoConflito struct {
+	Rotulo string
+	Sua    string
+	Atual  string
+	// AlteradoPorVoce marca os campos que a alteração do usuário mudou; os
+	// demais foram mudados por outra pessoa
+	AlteradoPorVoce bool
+}
+
+// campoOculto é um campo do formulário original, reenviado pelo botão que
+// mantém a versão do usuário
+type campoOculto struct {
+	Nome  string
+	Valor string
+}
+
+// versaoDoFormulario retorna a versão da tarefa que o usuário via ao enviar
+// o formulário, do campo oculto versao. Sem ela retorna zero, que o cliente
+// recusa com cliente.ErrVersaoAusente em vez de escrever sobre qualquer
+// versão.
+func versaoDoFormulario(c *fiber.Ctx) int {
+	versao, err := strconv.Atoi(c.FormValue("versao"))
+	if err != nil || versao < 0 {
+		return 0
+	}
+	return versao
+}
+
+// precisaConfirmar informa se a escrita recusada vai para a página de
+// conflito: a tarefa mudou desde a versão do formulário, ou o formulário não
+// trouxe a versão e o usuário confirma sobre a atual
+func precisaConfirmar(err error) bool {
+	return errors.Is(err, cliente.ErrConflito) || errors.Is(err, cliente.ErrVersaoAusente)
+}
+
+// renderizarConflito exibe a página de conflito de uma alteração recusada
+// porque outra pessoa mudou a tarefa depois da versão que o usuário via. A
+// página compara à atual a versão do usuário: a que estava na tela, com a
+// alteração aplicada. Com alteracao nil, o formulário era o de remoção.
+//
+// O usuário escolhe entre reenviar o formulário sobre a versão atual,
+// mantendo a sua, e voltar para destino, mantendo a atual.
+func (a *aplicacao) renderizarConflito(c *fiber.Ctx, id string, alteracao *cliente.Alteracao, destino string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	atual, err := a.api.Buscar(ctx, id)
+	var revisoes []dominio.Revisao
+	var projetos []dominio.Projeto
+	var etiquetas []dominio.Etiqueta
+	if err == nil {
+		revisoes, err = a.api.Revisoes(ctx, id)
+	}
+	if err == nil {
+		projetos, err = a.api.ListarProjetos(ctx)
+	}
+	if err == nil {
+		etiquetas, err = a.api.ListarEtiquetas(ctx)
+	}
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return a.renderizarTarefas(c, fiber.StatusNotFound, nil, "A tarefa não existe mais; ela pode ter sido removida.")
+	case err != nil:
+		log.Printf("erro ao buscar a tarefa em conflito: %v", err)
+		return a.renderizarTarefas(c, fiber.StatusServiceUnavailable, nil, avisoFalhaAPI(err))
+	}
+
+	// A versão que o usuário via é a da última revisão até ela; sem
+	// revisão, a comparação parte da atual
+	versao := versaoDoFormulario(c)
+	vista := atual
+	for _, rev := range revisoes {
+		if rev.Numero <= versao {
+			vista = rev.Tarefa
+		}
+	}
+	sua := vista
+	if alteracao != nil {
+		alteracao.Aplicar(&sua)
+		fluxo, _ := fluxoDoProjeto(projetos, sua.ProjetoID)
+		if fluxo.AplicarEstado(&vista, &sua) != nil {
+			sua.Estado = vista.Estado
+		}
+	}
+
+	nomes := novosNomesHistorico(projetos, etiquetas, atual.ProjetoID)
+	alteradosPorVoce := map[string]bool{}
+	for _, d := range dominio.DiferencasTarefa(&vista, sua) {
+		alteradosPorVoce[d.Campo] = true
+	}
+	campos := []campoConflito{}
+	for _, d := range dominio.DiferencasTarefa(&sua, atual) {
+		campos = append(campos, campoConflito{
+			Rotulo:          rotuloCampo(d.Campo),
+			Sua:             nomes.valor(d.Campo, d.De),
+			Atual:           nomes.valor(d.Campo, d.Para),
+			AlteradoPorVoce: alteradosPorVoce[d.Campo],
+		})
+	}
+
+	// O botão de manter a sua versão reenvia o mesmo formulário, agora
+	// sobre a versão atual
+	ocultos := []campoOculto{}
+	c.Request().PostArgs().VisitAll(func(nome, valor []byte) {
+		if string(nome) != "versao" {
+			ocultos = append(ocultos, campoOculto{string(nome), string(valor)})
+		}
+	})
+	ocultos = append(ocultos, campoOculto{"versao", strconv.Itoa(atual.Versao)})
+
+	return c.Status(fiber.StatusConflict).Render("conflito", fiber.Map{
+		"Titulo":      "Conflito de edição",
+		"Tarefa":      atual.Titulo,
+		"TarefaID":    atual.ID,
+		"Remocao":     alteracao == nil,
+		"Campos":      campos,
+		"TemCampos":   len(campos) > 0,
+		"Ocultos":     ocultos,
+		"Acao":        c.OriginalURL(),
+		"Voltar":      destino,
+		"VersaoVista": vista.Versao,
+		"VersaoAtual": atual.Versao,
+	})
+}
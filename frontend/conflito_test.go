@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestConflitoDeEdicao(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+
+	if corpo := obterPagina(t, app, "/"); !strings.Contains(corpo, `name="versao" value="1"`) {
+		t.Errorf("Formulários sem a versão da tarefa")
+	}
+
+	// Outra pessoa renomeia a tarefa e conclui depois que a página foi exibida
+	outro, concluida := "Relatório anual", true
+	api.Alterar(ctx, tarefa.ID, cliente.Alteracao{Titulo: &outro, Concluida: &concluida, Versao: tarefa.Versao})
+
+	resp := enviarFormulario(t, app, "/tarefas/"+tarefa.ID+"/renomear", url.Values{"titulo": {"Relatório mensal"}, "versao": {"1"}})
+	body, _ := io.ReadAll(resp.Body)
+	corpo := string(body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(corpo, "Conflito de edição") {
+		t.Fatalf("Conflito: obtido %d", resp.StatusCode)
+	}
+	if !strings.Contains(corpo, "“Relatório mensal” <small>(sua alteração)</small>") || !strings.Contains(corpo, "“Relatório anual”") {
+		t.Errorf("Títulos das duas versões não exibidos")
+	}
+	if !strings.Contains(corpo, "<th>Concluída</th>") {
+		t.Errorf("Campo alterado pela outra pessoa não exibido")
+	}
+	if !strings.Contains(corpo, `name="titulo" value="Relatório mensal"`) || !strings.Contains(corpo, `name="versao" value="2"`) {
+		t.Errorf("Formulário de manter a versão não reenvia a alteração sobre a atual")
+	}
+	if atual, _ := api.Buscar(ctx, tarefa.ID); atual.Titulo != outro {
+		t.Errorf("Alteração desatualizada gravada: %+v", atual)
+	}
+
+	// Manter a própria versão reenvia o formulário com a versão atual
+	resp = enviarFormulario(t, app, "/tarefas/"+tarefa.ID+"/renomear", url.Values{"titulo": {"Relatório mensal"}, "versao": {"2"}})
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Manter a minha versão: obtido %d", resp.StatusCode)
+	}
+	if atual, _ := api.Buscar(ctx, tarefa.ID); atual.Titulo != "Relatório mensal" || !atual.Concluida {
+		t.Errorf("Tarefa depois de resolver o conflito: %+v", atual)
+	}
+}
+
+func TestConflitoAoRemover(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+	descricao := "Incluir as vendas de junho"
+	api.Alterar(ctx, tarefa.ID, cliente.Alteracao{Descricao: &descricao, Versao: tarefa.Versao})
+
+	resp := enviarFormulario(t, app, "/tarefas/"+tarefa.ID+"/remover", url.Values{"versao": {"1"}})
+	body, _ := io.ReadAll(resp.Body)
+	corpo := string(body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(corpo, "Excluir mesmo assim") || !strings.Contains(corpo, "“Incluir as vendas de junho”") {
+		t.Fatalf("Conflito ao remover: obtido %d", resp.StatusCode)
+	}
+	if _, err := api.Buscar(ctx, tarefa.ID); err != nil {
+		t.Errorf("Tarefa removida apesar do conflito: %v", err)
+	}
+}
+
+func TestFormularioSemVersaoPedeConfirmacao(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+
+	// Sem o campo versao nada é gravado às cegas: a página de conflito
+	// oferece reenviar a alteração sobre a versão atual
+	for _, rota := range []string{"/renomear", "/remover"} {
+		resp := enviarFormulario(t, app, "/tarefas/"+tarefa.ID+rota, url.Values{"titulo": {"Relatório mensal"}})
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusConflict || !strings.Contains(string(body), `name="versao" value="1"`) {
+			t.Errorf("%s sem versão: obtido %d", rota, resp.StatusCode)
+		}
+	}
+	if atual, err := api.Buscar(ctx, tarefa.ID); err != nil || atual.Titulo != "Relatório" {
+		t.Errorf("Tarefa alterada sem versão: %+v, %v", atual, err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// opcaoDependencia é uma tarefa no formulário de dependências de outra
+type opcaoDependencia struct {
+	ID     string
+	Titulo string
+	// Marcada indica que a tarefa do formulário depende desta
+	Marcada bool
+}
+
+// dependenciasDe monta as opções do formulário de dependências da tarefa t:
+// as demais tarefas exibidas na página e, para não perdê-las ao salvar, as
+// dependências atuais que não estão nela, identificadas pelo ID
+func dependenciasDe(t dominio.Tarefa, exibidas []dominio.Tarefa) []opcaoDependencia {
+	var opcoes []opcaoDependencia
+	for _, e := range exibidas {
+		if e.ID != t.ID {
+			opcoes = append(opcoes, opcaoDependencia{ID: e.ID, Titulo: e.Titulo, Marcada: slices.Contains(t.BloqueadaPor, e.ID)})
+		}
+	}
+	for _, id := range t.BloqueadaPor {
+		if !slices.ContainsFunc(exibidas, func(e dominio.Tarefa) bool { return e.ID == id }) {
+			opcoes = append(opcoes, opcaoDependencia{ID: id, Titulo: id, Marcada: true})
+		}
+	}
+	return opcoes
+}
+
+// TemDependencias informa se o formulário de dependências tem alguma opção
+func (v tarefaVisao) TemDependencias() bool {
+	return len(v.Dependencias) > 0
+}
+
+// pendenciasDe lista, separados por vírgula, os títulos das dependências
+// pendentes da tarefa que aparecem na página
+func pendenciasDe(t dominio.Tarefa, exibidas []dominio.Tarefa) string {
+	var titulos []string
+	for _, e := range exibidas {
+		if !e.Concluida && slices.Contains(t.BloqueadaPor, e.ID) {
+			titulos = append(titulos, e.Titulo)
+		}
+	}
+	return strings.Join(titulos, ", ")
+}
+
+// definirDependencias atende POST /tarefas/:id/dependencias, substituindo as
+// tarefas de que a tarefa depende pelas marcadas no formulário
+func (a *aplicacao) definirDependencias(c *fiber.Ctx) error {
+	marcadas := []string{}
+	for _, id := range c.Request().PostArgs().PeekMulti("bloqueadora") {
+		marcadas = append(marcadas, string(id))
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{BloqueadaPor: &marcadas, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, c.Params("id"), alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if errors.Is(err, cliente.ErrRequisicaoInvalida) {
+		// A dependência formaria um ciclo ou foi removida depois que a página
+		// foi exibida
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, nil, mensagemUsuario(err))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestFormularioDeDependencias(t *testing.T) {
+	// A API calcula Bloqueada; o Falso mantém o valor semeado
+	falso := cliente.NovoFalso(dominio.Tarefa{Titulo: "Rodar testes"}, dominio.Tarefa{Titulo: "Deploy", Bloqueada: true})
+	pagina, _ := falso.Listar(context.Background(), cliente.Consulta{})
+	testes, deploy := pagina.Tarefas[0].ID, pagina.Tarefas[1].ID
+	api := &apiComRegras{Falso: falso, recusarAlteracao: func(id string, a cliente.Alteracao) error {
+		switch {
+		case id == deploy && a.Concluida != nil && *a.Concluida:
+			return erroRegra(http.StatusConflict, dominio.CodigoTarefaBloqueada, "a tarefa depende de tarefas pendentes")
+		case id == testes && a.BloqueadaPor != nil && slices.Contains(*a.BloqueadaPor, deploy):
+			return erroCampo("bloqueada_por", "as dependências formariam um ciclo")
+		}
+		return nil
+	}}
+	app := novoApp(api)
+
+	// Marcar a dependência grava a tarefa bloqueadora, que a página mostra
+	resp := enviarFormulario(t, app, "/tarefas/"+deploy+"/dependencias", comVersao(t, api, deploy, url.Values{"bloqueadora": {testes}}))
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Definir dependências: obtido %d", resp.StatusCode)
+	}
+	if tarefa, _ := api.Buscar(context.Background(), deploy); !slices.Equal(tarefa.BloqueadaPor, []string{testes}) {
+		t.Errorf("Dependências gravadas: %+v", tarefa)
+	}
+	corpo := obterPagina(t, app, "/")
+	if !strings.Contains(corpo, "Bloqueada por Rodar testes") || !strings.Contains(corpo, `value="`+testes+`" checked`) {
+		t.Errorf("Dependência não exibida na tarefa bloqueada")
+	}
+
+	// Concluir a tarefa bloqueada explica o motivo da recusa
+	resp = enviarFormulario(t, app, "/tarefas/"+deploy+"/alternar", comVersao(t, api, deploy, url.Values{"concluida": {"true"}}))
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(string(body), "depende de tarefas pendentes") {
+		t.Errorf("Concluir tarefa bloqueada: obtido %d", resp.StatusCode)
+	}
+
+	// Uma dependência circular é recusada com a mensagem da API
+	resp = enviarFormulario(t, app, "/tarefas/"+testes+"/dependencias", comVersao(t, api, testes, url.Values{"bloqueadora": {deploy}}))
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "As dependências formariam um ciclo.") {
+		t.Errorf("Dependência circular: obtido %d", resp.StatusCode)
+	}
+
+	// Desmarcar todas as dependências envia a lista vazia
+	enviarFormulario(t, app, "/tarefas/"+deploy+"/dependencias", comVersao(t, api, deploy, url.Values{}))
+	if tarefa, _ := api.Buscar(context.Background(), deploy); len(tarefa.BloqueadaPor) != 0 {
+		t.Errorf("Dependências não removidas: %+v", tarefa)
+	}
+}
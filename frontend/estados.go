@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// opcaoEstado é um estado para o qual a tarefa pode passar, oferecido como
+// botão na tarefa
+type opcaoEstado struct {
+	ID   string
+	Nome string
+	// Desabilitada indica um estado que a tarefa não pode alcançar agora,
+	// como um estado final para a tarefa bloqueada; Motivo explica por quê
+	Desabilitada bool
+	Motivo       string
+}
+
+// motivoBloqueada explica por que a tarefa bloqueada não pode ser concluída
+const motivoBloqueada = "Conclua antes as tarefas de que esta depende"
+
+// proximosEstados lista os estados para os quais a tarefa pode passar no
+// fluxo, desabilitados os finais enquanto ela estiver bloqueada
+func proximosEstados(t dominio.Tarefa, fluxo dominio.Fluxo) []opcaoEstado {
+	var opcoes []opcaoEstado
+	for _, e := range fluxo.Proximos(t.Estado) {
+		opcao := opcaoEstado{ID: e.ID, Nome: e.Nome}
+		if e.Final && t.Bloqueada {
+			opcao.Desabilitada, opcao.Motivo = true, motivoBloqueada
+		}
+		opcoes = append(opcoes, opcao)
+	}
+	return opcoes
+}
+
+// fluxoDoProjeto retorna o fluxo de trabalho do projeto com o ID informado,
+// ou o padrão para tarefas sem projeto
+func fluxoDoProjeto(projetos []dominio.Projeto, projetoID string) (dominio.Fluxo, bool) {
+	for _, p := range projetos {
+		if p.ID == projetoID && p.Fluxo != nil {
+			return *p.Fluxo, true
+		}
+	}
+	return dominio.FluxoPadrao(), false
+}
+
+// estadoDe preenche na visão da tarefa o nome do seu estado e, nos projetos
+// com fluxo próprio, os estados para os quais ela pode passar. No fluxo
+// padrão a tarefa mantém o botão de concluir e reabrir.
+func estadoDe(v *tarefaVisao, projetos []dominio.Projeto) {
+	fluxo, proprio := fluxoDoProjeto(projetos, v.ProjetoID)
+	v.NomeEstado = v.Estado
+	if estado, ok := fluxo.Estado(v.Estado); ok {
+		v.NomeEstado = estado.Nome
+	}
+	if !proprio {
+		return
+	}
+	v.FluxoProprio = true
+	v.Proximos = proximosEstados(v.Tarefa, fluxo)
+}
+
+// definirEstado atende POST /tarefas/:id/estado, passando a tarefa para o
+// estado escolhido, se o fluxo do seu projeto permitir
+func (a *aplicacao) definirEstado(c *fiber.Ctx) error {
+	estado := c.FormValue("estado")
+	if estado == "" {
+		return a.renderizarTarefas(c, fiber.StatusBadRequest, nil, "Formulário inválido.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{Estado: &estado, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, c.Params("id"), alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if errors.Is(err, cliente.ErrRequisicaoInvalida) {
+		// O estado foi removido do fluxo depois que a página foi exibida
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, nil, mensagemUsuario(err))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
+
+// TemProximos informa se a tarefa pode passar para algum outro estado
+func (v tarefaVisao) TemProximos() bool {
+	return len(v.Proximos) > 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestEstadosDoFluxoDoProjeto(t *testing.T) {
+	api := &apiComRegras{Falso: cliente.NovoFalso(dominio.Tarefa{Titulo: "Sem projeto"})}
+	app := novoApp(api)
+	ctx := context.Background()
+	projeto, _ := api.CriarProjeto(ctx, "Produto")
+	api.DefinirFluxo(ctx, projeto.ID, &dominio.Fluxo{
+		Estados: []dominio.EstadoFluxo{
+			{ID: "backlog", Nome: "Backlog"},
+			{ID: "em_andamento", Nome: "Em andamento"},
+			{ID: "revisao", Nome: "Revisão"},
+			{ID: "concluida", Nome: "Concluída", Final: true},
+		},
+		Transicoes: []dominio.Transicao{
+			{De: "backlog", Para: "em_andamento"},
+			{De: "em_andamento", Para: "revisao"},
+			{De: "revisao", Para: "concluida"},
+		},
+	})
+	// A API põe a tarefa no estado inicial do fluxo e recusa saltos nele
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Login social", ProjetoID: projeto.ID, Estado: "backlog"})
+	api.recusarAlteracao = func(id string, a cliente.Alteracao) error {
+		if a.Estado != nil && *a.Estado == "concluida" {
+			return erroRegra(http.StatusConflict, dominio.CodigoTransicaoInvalida, "o fluxo do projeto não permite esta mudança de estado")
+		}
+		return nil
+	}
+
+	// A tarefa do projeto mostra o nome do estado e só os estados seguintes;
+	// a tarefa sem projeto continua com Pendente e o botão de concluir
+	corpo := obterPagina(t, app, "/")
+	if !strings.Contains(corpo, `<span class="tarefa-status">Backlog</span>`) || !strings.Contains(corpo, `<span class="tarefa-status">Pendente</span>`) {
+		t.Errorf("Nomes dos estados não exibidos")
+	}
+	if !strings.Contains(corpo, `value="em_andamento">&rarr; Em andamento`) || strings.Contains(corpo, `value="revisao">`) {
+		t.Errorf("Estados seguintes exibidos incorretamente")
+	}
+
+	resp := enviarFormulario(t, app, "/tarefas/"+tarefa.ID+"/estado", comVersao(t, api, tarefa.ID, url.Values{"estado": {"em_andamento"}}))
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Mudar estado: obtido %d", resp.StatusCode)
+	}
+	if alterada, _ := api.Buscar(ctx, tarefa.ID); alterada.Estado != "em_andamento" {
+		t.Errorf("Estado não alterado: %q", alterada.Estado)
+	}
+
+	// Pular a revisão é recusado com a explicação da API
+	resp = enviarFormulario(t, app, "/tarefas/"+tarefa.ID+"/estado", comVersao(t, api, tarefa.ID, url.Values{"estado": {"concluida"}}))
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(string(body), "aviso-erro") {
+		t.Errorf("Transição não permitida: obtido %d", resp.StatusCode)
+	}
+	if historico, _ := api.HistoricoEstados(ctx, tarefa.ID); len(historico) != 2 {
+		t.Errorf("Histórico de estados: %+v", historico)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// etiquetaVisao é uma etiqueta como exibida nos chips e nos formulários
+type etiquetaVisao struct {
+	dominio.Etiqueta
+	// CorTexto é a cor do texto do chip, escolhida para contrastar com Cor
+	CorTexto string
+	// Marcada indica a etiqueta escolhida no filtro ou presente na tarefa
+	Marcada bool
+	// NomeEditado e CorEditada são os valores enviados no formulário que
+	// falhou na validação
+	NomeEditado string
+	CorEditada  string
+	// ErroEdicao é a mensagem de validação do formulário da etiqueta
+	ErroEdicao string
+}
+
+// novaEtiquetaVisao prepara a etiqueta para exibição
+func novaEtiquetaVisao(e dominio.Etiqueta, marcada bool) etiquetaVisao {
+	return etiquetaVisao{Etiqueta: e, CorTexto: corDoTexto(e.Cor), Marcada: marcada, NomeEditado: e.Nome, CorEditada: e.Cor}
+}
+
+// corDoTexto escolhe texto escuro sobre cores claras e branco sobre cores
+// escuras, pelo brilho percebido da cor no formato #rrggbb
+func corDoTexto(cor string) string {
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(cor, "#"), 16, 32)
+	if err != nil || len(cor) != 7 {
+		return "#ffffff"
+	}
+	r, g, b := rgb>>16, rgb>>8&0xff, rgb&0xff
+	if (r*299+g*587+b*114)/1000 >= 150 {
+		return "#2c3e50"
+	}
+	return "#ffffff"
+}
+
+// paginaEtiquetas atende GET /etiquetas, a página de gerenciar etiquetas
+func (a *aplicacao) paginaEtiquetas(c *fiber.Ctx) error {
+	return a.renderizarEtiquetas(c, fiber.StatusOK, nil, "")
+}
+
+// renderizarEtiquetas busca as etiquetas e renderiza a página de gerenciá-las
+// com o status informado, o formulário inválido (se houver) e um aviso
+func (a *aplicacao) renderizarEtiquetas(c *fiber.Ctx, status int, form *formularioInvalido, aviso string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	dados := fiber.Map{
+		"Titulo": "Etiquetas",
+		"Aviso":  aviso,
+		"Nova":   fiber.Map{"Cor": dominio.CorPadraoEtiqueta},
+	}
+	if form != nil && form.id == "" {
+		dados["Nova"] = fiber.Map{"Nome": form.titulo, "Cor": form.cor, "Erro": form.mensagem}
+	}
+
+	etiquetas, err := a.api.ListarEtiquetas(ctx)
+	if errors.Is(err, cliente.ErrNaoAutenticado) {
+		return a.encerrarSessao(c)
+	}
+	if err != nil {
+		log.Printf("erro ao buscar etiquetas: %v", err)
+		dados["Erro"] = "Não foi possível carregar as etiquetas. Tente novamente em instantes."
+		return c.Status(fiber.StatusServiceUnavailable).Render("etiquetas", dados)
+	}
+
+	visoes := make([]etiquetaVisao, len(etiquetas))
+	for i, e := range etiquetas {
+		visoes[i] = novaEtiquetaVisao(e, false)
+		if form != nil && form.id == e.ID {
+			visoes[i].NomeEditado, visoes[i].CorEditada, visoes[i].ErroEdicao = form.titulo, form.cor, form.mensagem
+		}
+	}
+	dados["Etiquetas"] = visoes
+	return c.Status(status).Render("etiquetas", dados)
+}
+
+// criarEtiqueta atende POST /etiquetas
+func (a *aplicacao) criarEtiqueta(c *fiber.Ctx) error {
+	nome := strings.TrimSpace(c.FormValue("nome"))
+	cor := c.FormValue("cor")
+	form := &formularioInvalido{titulo: nome, cor: cor}
+
+	if err := (dominio.Etiqueta{Nome: nome, Cor: cor}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarEtiquetas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.CriarEtiqueta(ctx, nome, cor); err != nil {
+		return a.responderErroEtiqueta(c, form, err)
+	}
+	return c.Redirect("/etiquetas", fiber.StatusSeeOther)
+}
+
+// alterarEtiqueta atende POST /etiquetas/:id/alterar, que renomeia e troca a
+// cor da etiqueta de uma vez
+func (a *aplicacao) alterarEtiqueta(c *fiber.Ctx) error {
+	id := c.Params("id")
+	nome := strings.TrimSpace(c.FormValue("nome"))
+	cor := c.FormValue("cor")
+	form := &formularioInvalido{id: id, titulo: nome, cor: cor}
+
+	if err := (dominio.Etiqueta{Nome: nome, Cor: cor}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarEtiquetas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.AlteracaoEtiqueta{Nome: &nome}
+	if cor != "" {
+		alteracao.Cor = &cor
+	}
+	if _, err := a.api.AlterarEtiqueta(ctx, id, alteracao); err != nil {
+		return a.responderErroEtiqueta(c, form, err)
+	}
+	return c.Redirect("/etiquetas", fiber.StatusSeeOther)
+}
+
+// removerEtiqueta atende POST /etiquetas/:id/remover; a API retira a
+// etiqueta das tarefas que a usavam
+func (a *aplicacao) removerEtiqueta(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	err := a.api.RemoverEtiqueta(ctx, c.Params("id"))
+	if err != nil && !errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.responderErroEtiqueta(c, nil, err)
+	}
+	return c.Redirect("/etiquetas", fiber.StatusSeeOther)
+}
+
+// responderErroEtiqueta renderiza a página de etiquetas com a mensagem
+// adequada a um erro da API ao salvar um formulário
+func (a *aplicacao) responderErroEtiqueta(c *fiber.Ctx, form *formularioInvalido, err error) error {
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrRequisicaoInvalida) && form != nil:
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarEtiquetas(c, fiber.StatusUnprocessableEntity, form, "")
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return a.renderizarEtiquetas(c, fiber.StatusNotFound, nil, "A etiqueta não existe mais; ela pode ter sido removida.")
+	}
+
+	log.Printf("erro ao salvar etiqueta: %v", err)
+	return a.renderizarEtiquetas(c, fiber.StatusServiceUnavailable, form, avisoFalhaAPI(err))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestFormulariosDeEtiquetas(t *testing.T) {
+	api := &apiComRegras{Falso: cliente.NovoFalso(dominio.Tarefa{Titulo: "Consertar pia"}, dominio.Tarefa{Titulo: "Pagar boleto"})}
+	app := novoApp(api)
+
+	// Criar etiquetas pela página de gerenciamento; a cor é validada
+	resp := enviarFormulario(t, app, "/etiquetas", url.Values{"nome": {"Casa"}, "cor": {"verde"}})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "A cor deve estar no formato #rrggbb.") {
+		t.Errorf("Criar etiqueta com cor inválida: obtido %d", resp.StatusCode)
+	}
+	for _, campos := range []url.Values{{"nome": {"Casa"}, "cor": {"#f1c40f"}}, {"nome": {"Urgente"}, "cor": {"#c0392b"}}} {
+		if resp := enviarFormulario(t, app, "/etiquetas", campos); resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/etiquetas" {
+			t.Fatalf("Criar etiqueta: obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+		}
+	}
+	etiquetas, _ := api.ListarEtiquetas(context.Background())
+	if len(etiquetas) != 2 {
+		t.Fatalf("Etiquetas não criadas: %+v", etiquetas)
+	}
+	casa, urgente := etiquetas[0].ID, etiquetas[1].ID
+	if !strings.Contains(obterPagina(t, app, "/etiquetas"), "Urgente") {
+		t.Errorf("Etiqueta criada não aparece na página de gerenciamento")
+	}
+
+	// Etiquetar as tarefas: a pia com as duas, o boleto só com urgente
+	pagina, _ := api.Listar(context.Background(), cliente.Consulta{})
+	pia, boleto := pagina.Tarefas[0].ID, pagina.Tarefas[1].ID
+	resp = enviarFormulario(t, app, "/tarefas/"+pia+"/etiquetas", comVersao(t, api, pia, url.Values{"etiqueta": {casa, urgente}, "etiquetas": {urgente}}))
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/?etiquetas="+urgente {
+		t.Errorf("Etiquetar: obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	enviarFormulario(t, app, "/tarefas/"+boleto+"/etiquetas", comVersao(t, api, boleto, url.Values{"etiqueta": {urgente}}))
+
+	// Os chips usam a cor da etiqueta, com texto que contrasta com ela
+	corpo := obterPagina(t, app, "/")
+	if !strings.Contains(corpo, `style="background-color: #f1c40f; color: #2c3e50">Casa</a>`) ||
+		!strings.Contains(corpo, `style="background-color: #c0392b; color: #ffffff">Urgente</a>`) {
+		t.Errorf("Chips das etiquetas não exibidos")
+	}
+
+	// O filtro exige todas as etiquetas ou, no modo alguma, qualquer uma
+	corpo = obterPagina(t, app, "/?etiquetas="+casa+"&etiquetas="+urgente)
+	if !strings.Contains(corpo, "Consertar pia") || strings.Contains(corpo, "Pagar boleto") {
+		t.Errorf("Filtro com todas as etiquetas não aplicado")
+	}
+	corpo = obterPagina(t, app, "/?etiquetas="+casa+","+urgente+"&modo=alguma")
+	if !strings.Contains(corpo, "Consertar pia") || !strings.Contains(corpo, "Pagar boleto") {
+		t.Errorf("Filtro com alguma etiqueta não aplicado")
+	}
+
+	// Uma tarefa criada com o filtro ativo recebe as etiquetas dele
+	enviarFormulario(t, app, "/tarefas", url.Values{"titulo": {"Trocar lâmpada"}, "etiquetas": {casa}})
+	pagina, _ = api.Listar(context.Background(), cliente.Consulta{Etiquetas: []string{casa}})
+	if len(pagina.Tarefas) != 2 {
+		t.Errorf("Tarefa criada sem as etiquetas do filtro: %+v", pagina.Tarefas)
+	}
+
+	// Renomear e trocar a cor; remover exclui a etiqueta
+	enviarFormulario(t, app, "/etiquetas/"+casa+"/alterar", url.Values{"nome": {"Lar"}, "cor": {"#27ae60"}})
+	if e, _ := api.BuscarEtiqueta(context.Background(), casa); e.Nome != "Lar" || e.Cor != "#27ae60" {
+		t.Errorf("Etiqueta não alterada: %+v", e)
+	}
+	resp = enviarFormulario(t, app, "/etiquetas/"+urgente+"/remover", nil)
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Errorf("Remover etiqueta: obtido %d", resp.StatusCode)
+	}
+	if _, err := api.BuscarEtiqueta(context.Background(), urgente); err == nil {
+		t.Errorf("Etiqueta não removida")
+	}
+
+	// Etiquetar com uma etiqueta que não existe mais exibe o motivo da API
+	api.recusarAlteracao = func(id string, a cliente.Alteracao) error {
+		if a.Etiquetas != nil && slices.Contains(*a.Etiquetas, urgente) {
+			return erroCampo("etiquetas", "a etiqueta "+urgente+" não existe")
+		}
+		return nil
+	}
+	resp = enviarFormulario(t, app, "/tarefas/"+boleto+"/etiquetas", comVersao(t, api, boleto, url.Values{"etiqueta": {urgente}}))
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "não existe") {
+		t.Errorf("Etiquetar com etiqueta removida: obtido %d", resp.StatusCode)
+	}
+}
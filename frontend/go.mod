@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gofiber/fiber/v2 v2.52.6
 	github.com/gofiber/template/mustache/v2 v2.0.13
+	github.com/seu-usuario/ci-cd-demo/dominio v0.0.0
 )
 
 require (
@@ -23,3 +24,5 @@ require (
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 )
+
+replace github.com/seu-usuario/ci-cd-demo/dominio => ../dominio
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// rotulosCampos são os nomes dos campos da tarefa no histórico de alterações
+var rotulosCampos = map[string]string{
+	"titulo":                  "Título",
+	"concluida":               "Concluída",
+	"estado":                  "Estado",
+	"descricao":               "Descrição",
+	"prioridade":              "Prioridade",
+	"prazo":                   "Prazo",
+	"recorrencia":             "Recorrência",
+	"fuso_horario":            "Fuso horário",
+	"projeto_id":              "Projeto",
+	"etiquetas":               "Etiquetas",
+	"pai_id":                  "Tarefa pai",
+	"concluir_com_subtarefas": "Concluir com as subtarefas",
+	"bloqueada_por":           "Bloqueada por",
+}
+
+// revisaoVisao é uma revisão como exibida no histórico da tarefa
+type revisaoVisao struct {
+	TarefaID string
+	Numero   int
+	Autor    string
+	Em       string
+	// VersaoAtual é a versão da tarefa na página, enviada com o pedido de
+	// restaurar
+	VersaoAtual int
+	// Alteracoes descreve cada campo alterado, como "Título: “A” → “B”"
+	Alteracoes []string
+	// Atual marca a revisão com a versão atual da tarefa, que não tem o que
+	// restaurar
+	Atual bool
+}
+
+// nomesHistorico traduz estados e IDs de projetos e etiquetas em nomes
+type nomesHistorico struct {
+	fluxo     dominio.Fluxo
+	projetos  map[string]string
+	etiquetas map[string]string
+}
+
+// novosNomesHistorico prepara os nomes dos projetos e etiquetas e dos
+// estados do fluxo do projeto informado
+func novosNomesHistorico(projetos []dominio.Projeto, etiquetas []dominio.Etiqueta, projetoID string) nomesHistorico {
+	nomes := nomesHistorico{projetos: map[string]string{}, etiquetas: map[string]string{}}
+	nomes.fluxo, _ = fluxoDoProjeto(projetos, projetoID)
+	for _, p := range projetos {
+		nomes.projetos[p.ID] = p.Nome
+	}
+	for _, e := range etiquetas {
+		nomes.etiquetas[e.ID] = e.Nome
+	}
+	return nomes
+}
+
+// rotuloCampo retorna o nome do campo da tarefa para exibição
+func rotuloCampo(campo string) string {
+	if rotulo, ok := rotulosCampos[campo]; ok {
+		return rotulo
+	}
+	return campo
+}
+
+// revisoesVisao prepara as revisões para o histórico da página da tarefa
+func revisoesVisao(revisoes []dominio.Revisao, tarefa dominio.Tarefa, projetos []dominio.Projeto, etiquetas []dominio.Etiqueta) []revisaoVisao {
+	nomes := novosNomesHistorico(projetos, etiquetas, tarefa.ProjetoID)
+	visoes := make([]revisaoVisao, len(revisoes))
+	for i, rev := range revisoes {
+		visoes[i] = revisaoVisao{
+			TarefaID:    rev.TarefaID,
+			Numero:      rev.Numero,
+			Autor:       rev.Autor,
+			Em:          rev.Em.Local().Format(formatoDataAtividade),
+			VersaoAtual: tarefa.Versao,
+			Atual:       rev.Numero == tarefa.Versao,
+		}
+		for _, d := range rev.Diferencas {
+			visoes[i].Alteracoes = append(visoes[i].Alteracoes,
+				rotuloCampo(d.Campo)+": "+nomes.valor(d.Campo, d.De)+" → "+nomes.valor(d.Campo, d.Para))
+		}
+	}
+	return visoes
+}
+
+// valor descreve o valor JSON do campo para exibição
+func (n nomesHistorico) valor(campo string, bruto json.RawMessage) string {
+	var v any
+	if json.Unmarshal(bruto, &v) != nil || v == nil || v == "" {
+		return "vazio"
+	}
+	texto, _ := v.(string)
+	switch campo {
+	case "estado":
+		if e, ok := n.fluxo.Estado(texto); ok {
+			return e.Nome
+		}
+	case "projeto_id":
+		if nome, ok := n.projetos[texto]; ok {
+			return nome
+		}
+	case "prazo":
+		if prazo, err := time.Parse(time.RFC3339, texto); err == nil {
+			return prazo.Local().Format(formatoDataAtividade)
+		}
+	case "etiquetas":
+		ids, _ := v.([]any)
+		rotulos := make([]string, len(ids))
+		for i, id := range ids {
+			rotulos[i] = fmt.Sprint(id)
+			if nome, ok := n.etiquetas[rotulos[i]]; ok {
+				rotulos[i] = nome
+			}
+		}
+		return strings.Join(rotulos, ", ")
+	}
+
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "sim"
+		}
+		return "não"
+	case string:
+		return "“" + v + "”"
+	case []any:
+		itens := make([]string, len(v))
+		for i, item := range v {
+			itens[i] = fmt.Sprint(item)
+		}
+		return strings.Join(itens, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// reverterTarefa atende POST /tarefas/:id/reverter, o botão que restaura a
+// tarefa à versão de uma revisão do histórico. Se a tarefa mudou desde a
+// versão da página, a página de conflito compara a revisão com a atual.
+func (a *aplicacao) reverterTarefa(c *fiber.Ctx) error {
+	id := c.Params("id")
+	revisao, err := strconv.Atoi(c.FormValue("revisao"))
+	if err != nil || revisao < 1 {
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, nil, "Escolha no histórico a versão a restaurar.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	_, err = a.api.Reverter(ctx, id, revisao, versaoDoFormulario(c))
+	falha := "Não foi possível restaurar a revisão " + strconv.Itoa(revisao) + ". "
+	if precisaConfirmar(err) {
+		var revisoes []dominio.Revisao
+		revisoes, err = a.api.Revisoes(ctx, id)
+		for _, rev := range revisoes {
+			if rev.Numero == revisao {
+				alteracao := alteracaoDaRevisao(rev.Tarefa)
+				return a.renderizarConflito(c, id, &alteracao, enderecoTarefa(id))
+			}
+		}
+		if err == nil {
+			err = cliente.ErrNaoEncontrada
+		}
+	}
+	switch {
+	case err == nil:
+		return c.Redirect(enderecoTarefa(id), fiber.StatusSeeOther)
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		// renderizarTarefa explica se foi a tarefa que deixou de existir
+		return a.renderizarTarefa(c, fiber.StatusNotFound, nil, falha+"Ela não existe no histórico da tarefa.")
+	case errors.Is(err, cliente.ErrRequisicaoInvalida):
+		// A revisão pode citar projetos ou etiquetas que já foram removidos
+		return a.renderizarTarefa(c, fiber.StatusUnprocessableEntity, nil, falha+mensagemUsuario(err))
+	case errors.Is(err, cliente.ErrTransicaoInvalida), errors.Is(err, cliente.ErrLimiteEstado), errors.Is(err, cliente.ErrTarefaBloqueada):
+		return a.renderizarTarefa(c, fiber.StatusConflict, nil, falha+mensagemUsuario(err))
+	}
+	log.Printf("erro ao reverter tarefa: %v", err)
+	return a.renderizarTarefa(c, fiber.StatusServiceUnavailable, nil, avisoFalhaAPI(err))
+}
+
+// alteracaoDaRevisao descreve a reversão como a alteração que devolve à
+// tarefa os campos da revisão, para compará-la com a atual na página de
+// conflito
+func alteracaoDaRevisao(t dominio.Tarefa) cliente.Alteracao {
+	return cliente.Alteracao{
+		Titulo:                &t.Titulo,
+		Concluida:             &t.Concluida,
+		Estado:                &t.Estado,
+		Descricao:             &t.Descricao,
+		Prioridade:            &t.Prioridade,
+		Prazo:                 t.Prazo,
+		Recorrencia:           &t.Recorrencia,
+		FusoHorario:           &t.FusoHorario,
+		ProjetoID:             &t.ProjetoID,
+		Etiquetas:             &t.Etiquetas,
+		PaiID:                 &t.PaiID,
+		ConcluirComSubtarefas: &t.ConcluirComSubtarefas,
+		BloqueadaPor:          &t.BloqueadaPor,
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestHistoricoNaPaginaDaTarefa(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	tarefa, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Relatório"})
+	titulo, concluida, estado := "Relatório anual", true, dominio.EstadoConcluida
+	api.Alterar(ctx, tarefa.ID, cliente.Alteracao{Titulo: &titulo, Versao: tarefa.Versao})
+	api.Alterar(ctx, tarefa.ID, cliente.Alteracao{Concluida: &concluida, Estado: &estado, Versao: tarefa.Versao + 1})
+	pagina := "/tarefas/" + tarefa.ID
+
+	corpo := obterPagina(t, app, pagina)
+	for _, esperado := range []string{
+		"Revisão 2</strong>",
+		"Título: “Relatório” → “Relatório anual”",
+		"Concluída: não → sim",
+		"Estado: Pendente → Concluída",
+		`<input type="hidden" name="revisao" value="1">`,
+		`<input type="hidden" name="versao" value="3">`,
+	} {
+		if !strings.Contains(corpo, esperado) {
+			t.Errorf("Histórico sem %q", esperado)
+		}
+	}
+	// A versão atual não tem o que restaurar
+	if strings.Contains(corpo, `name="revisao" value="3"`) {
+		t.Errorf("Botão de restaurar na revisão atual")
+	}
+
+	// Quem abriu a página antes da conclusão vê o conflito em vez de
+	// desfazê-la sem saber
+	resp := enviarFormulario(t, app, pagina+"/reverter", url.Values{"revisao": {"1"}, "versao": {"2"}})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(string(body), "Conflito de edição") || !strings.Contains(string(body), `name="versao" value="3"`) {
+		t.Fatalf("Reverter sobre versão antiga: obtido %d", resp.StatusCode)
+	}
+	if atual, _ := api.Buscar(ctx, tarefa.ID); !atual.Concluida || atual.Versao != 3 {
+		t.Errorf("Reversão desatualizada gravada: %+v", atual)
+	}
+
+	resp = enviarFormulario(t, app, pagina+"/reverter", url.Values{"revisao": {"1"}, "versao": {"3"}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != pagina {
+		t.Fatalf("Reverter: obtido %d", resp.StatusCode)
+	}
+	if revertida, _ := api.Buscar(ctx, tarefa.ID); revertida.Titulo != "Relatório" || revertida.Concluida {
+		t.Errorf("Tarefa revertida: %+v", revertida)
+	}
+
+	resp = enviarFormulario(t, app, pagina+"/reverter", url.Values{"revisao": {"9"}, "versao": {"4"}})
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusNotFound || !strings.Contains(string(body), "Não foi possível restaurar a revisão 9.") {
+		t.Errorf("Revisão inexistente: obtido %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// itemLixeiraVisao é uma tarefa removida como exibida na página da lixeira
+type itemLixeiraVisao struct {
+	ID     string
+	Titulo string
+	// Subtarefas lista os títulos das subtarefas removidas junto com a tarefa
+	Subtarefas string
+	ExcluidaEm string
+	PurgaEm    string
+}
+
+// itensLixeiraVisao prepara os itens da lixeira para exibição
+func itensLixeiraVisao(itens []dominio.ItemLixeira) []itemLixeiraVisao {
+	visoes := make([]itemLixeiraVisao, len(itens))
+	for i, item := range itens {
+		visoes[i] = itemLixeiraVisao{
+			ID:         item.Tarefa.ID,
+			Titulo:     item.Tarefa.Titulo,
+			ExcluidaEm: item.ExcluidaEm.Local().Format(formatoDataAtividade),
+			PurgaEm:    item.PurgaEm.Local().Format(formatoDataAtividade),
+		}
+		titulos := make([]string, len(item.Subtarefas))
+		for j, sub := range item.Subtarefas {
+			titulos[j] = sub.Titulo
+		}
+		visoes[i].Subtarefas = strings.Join(titulos, ", ")
+	}
+	return visoes
+}
+
+// paginaLixeira atende GET /lixeira, a página das tarefas removidas
+func (a *aplicacao) paginaLixeira(c *fiber.Ctx) error {
+	return a.renderizarLixeira(c, fiber.StatusOK, "")
+}
+
+// renderizarLixeira busca a lixeira e renderiza a página com o status e o
+// aviso informados
+func (a *aplicacao) renderizarLixeira(c *fiber.Ctx, status int, aviso string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	dados := fiber.Map{
+		"Titulo": "Lixeira",
+		"Aviso":  aviso,
+	}
+	itens, err := a.api.Lixeira(ctx)
+	if errors.Is(err, cliente.ErrNaoAutenticado) {
+		return a.encerrarSessao(c)
+	}
+	if err != nil {
+		log.Printf("erro ao buscar a lixeira: %v", err)
+		dados["Erro"] = "Não foi possível carregar a lixeira. Tente novamente em instantes."
+		return c.Status(fiber.StatusServiceUnavailable).Render("lixeira", dados)
+	}
+	dados["Itens"] = itensLixeiraVisao(itens)
+	return c.Status(status).Render("lixeira", dados)
+}
+
+// restaurarDaLixeira atende POST /lixeira/:id/restaurar, que devolve a
+// tarefa e as subtarefas removidas junto com ela à lista
+func (a *aplicacao) restaurarDaLixeira(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	_, err := a.api.Restaurar(ctx, c.Params("id"))
+	switch {
+	case err == nil:
+		return c.Redirect("/lixeira", fiber.StatusSeeOther)
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return a.renderizarLixeira(c, fiber.StatusNotFound, "A tarefa não está mais na lixeira; ela pode ter sido restaurada ou excluída de vez.")
+	}
+	log.Printf("erro ao restaurar tarefa: %v", err)
+	return a.renderizarLixeira(c, fiber.StatusServiceUnavailable, avisoFalhaAPI(err))
+}
+
+// purgarDaLixeira atende POST /lixeira/:id/purgar, que exclui a tarefa de vez
+func (a *aplicacao) purgarDaLixeira(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	// Purgar uma tarefa que já saiu da lixeira deixa a página no estado desejado
+	err := a.api.Purgar(ctx, c.Params("id"))
+	switch {
+	case err == nil, errors.Is(err, cliente.ErrNaoEncontrada):
+		return c.Redirect("/lixeira", fiber.StatusSeeOther)
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	}
+	log.Printf("erro ao purgar tarefa: %v", err)
+	return a.renderizarLixeira(c, fiber.StatusServiceUnavailable, avisoFalhaAPI(err))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestPaginaLixeira(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+	ctx := context.Background()
+	pai, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Mudança"})
+	api.Criar(ctx, dominio.Tarefa{Titulo: "Caixas", PaiID: pai.ID})
+	velha, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Rascunho"})
+
+	if corpo := obterPagina(t, app, "/lixeira"); !strings.Contains(corpo, "A lixeira está vazia.") {
+		t.Errorf("Lixeira vazia sem aviso")
+	}
+
+	// Excluir pela lista leva as tarefas para a lixeira
+	resp := enviarFormulario(t, app, "/tarefas/"+pai.ID+"/remover", comVersao(t, api, pai.ID, url.Values{"subtarefas": {"remover"}}))
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Remover: obtido %d", resp.StatusCode)
+	}
+	api.Remover(ctx, velha.ID, velha.Versao)
+	corpo := obterPagina(t, app, "/lixeira")
+	for _, esperado := range []string{
+		"<strong>Mudança</strong>",
+		"Com as subtarefas: Caixas",
+		`action="/lixeira/` + pai.ID + `/restaurar"`,
+		`action="/lixeira/` + velha.ID + `/purgar"`,
+	} {
+		if !strings.Contains(corpo, esperado) {
+			t.Errorf("Lixeira sem %q", esperado)
+		}
+	}
+
+	resp = enviarFormulario(t, app, "/lixeira/"+pai.ID+"/restaurar", nil)
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/lixeira" {
+		t.Fatalf("Restaurar: obtido %d", resp.StatusCode)
+	}
+	if _, err := api.Buscar(ctx, pai.ID); err != nil {
+		t.Errorf("Tarefa não foi restaurada: %v", err)
+	}
+
+	resp = enviarFormulario(t, app, "/lixeira/"+velha.ID+"/purgar", nil)
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Purgar: obtido %d", resp.StatusCode)
+	}
+	if itens, _ := api.Lixeira(ctx); len(itens) != 0 {
+		t.Errorf("Lixeira depois de restaurar e purgar: %+v", itens)
+	}
+
+	resp = enviarFormulario(t, app, "/lixeira/"+velha.ID+"/restaurar", nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusNotFound || !strings.Contains(string(body), "A tarefa não está mais na lixeira") {
+		t.Errorf("Restaurar tarefa purgada: obtido %d", resp.StatusCode)
+	}
+}
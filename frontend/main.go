@@ -1,56 +1,121 @@
 package main
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/template/mustache/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
 )
 
-// Tarefa representa uma tarefa no sistema
-type Tarefa struct {
-	ID        string `json:"id"`
-	Titulo    string `json:"titulo"`
-	Concluida bool   `json:"concluida"`
-}
-
 // Função principal da aplicação
 // Teste de CI/CD - Verificando se o fluxo está funcionando corretamente
 func main() {
+	// Obter o endereço da API do ambiente ou usar o padrão
+	apiURL := os.Getenv("API_URL")
+	if apiURL == "" {
+		apiURL = "http://localhost:8080"
+	}
+
+	// Cada tentativa tem tempo limite próprio; chamadas idempotentes são
+	// repetidas e um disjuntor evita insistir enquanto a API está fora do ar
+	http := &http.Client{Timeout: tempoLimiteTentativa}
+	api := cliente.NovoResiliente(cliente.Novo(apiURL, http), cliente.ConfigResiliencia{})
+
+	app := novoApp(api)
+
+	// Iniciar o servidor
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3000"
+	}
+	log.Println("Servidor Frontend iniciando na porta " + port + "...")
+	log.Fatal(app.Listen(":" + port))
+}
+
+const (
+	// tempoLimiteTentativa limita cada chamada HTTP à API
+	tempoLimiteTentativa = 2 * time.Second
+	// tempoLimiteRequisicao limita o tempo total gasto com a API, incluindo
+	// novas tentativas, ao atender uma requisição do navegador
+	tempoLimiteRequisicao = 5 * time.Second
+)
+
+// novoApp cria a aplicação Fiber com as rotas do frontend, usando api para
+// acessar as tarefas
+func novoApp(api cliente.API) *fiber.App {
 	// Configurar o mecanismo de templates Mustache
 	engine := mustache.New("./views", ".mustache")
 
 	// Criar uma nova instância do Fiber
+	// Immutable copia os valores da requisição: sem isso, as strings de
+	// c.Params, c.FormValue e c.Cookies apontam para um buffer reutilizado e
+	// mudariam depois de guardadas no cache ou na API falsa dos testes
+	// BodyLimit comporta o envio de anexos até o tamanho máximo
 	app := fiber.New(fiber.Config{
-		Views: engine,
+		Views:     engine,
+		Immutable: true,
+		BodyLimit: dominio.TamanhoMaximoAnexo + folgaFormularioAnexo,
 	})
 
 	// Servir arquivos estáticos
 	app.Static("/", "./public")
 
+	// Últimas páginas obtidas, exibidas quando a API está indisponível
+	a := &aplicacao{api: api, cache: novoCacheTarefas()}
+
+	// Login e logout
+	app.Get("/login", a.paginaLogin)
+	app.Post("/login", a.entrar)
+	app.Post("/sair", a.sair)
+
 	// Rota principal
-	app.Get("/", func(c *fiber.Ctx) error {
-		// Obter o endereço da API do ambiente ou usar o padrão
-		apiURL := os.Getenv("API_URL")
-		if apiURL == "" {
-			apiURL = "http://localhost:8080"
-		}
-
-		// Buscar tarefas da API
-		tarefas, err := buscarTarefas(apiURL)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("Erro ao buscar tarefas: " + err.Error())
-		}
-
-		// Renderizar o template com os dados
-		return c.Render("index", fiber.Map{
-			"Titulo":  "Gerenciador de Tarefas",
-			"Tarefas": tarefas,
-		})
-	})
+	app.Get("/", a.exigirSessao, a.paginaInicial)
+
+	// Formulários de tarefas; cada um redireciona de volta à lista
+	app.Post("/tarefas", a.exigirSessao, a.criarTarefa)
+	app.Get("/tarefas/:id", a.exigirSessao, a.paginaTarefa)
+	app.Post("/tarefas/:id/renomear", a.exigirSessao, a.renomearTarefa)
+	app.Post("/tarefas/:id/alternar", a.exigirSessao, a.alternarTarefa)
+	app.Post("/tarefas/:id/estado", a.exigirSessao, a.definirEstado)
+	app.Post("/tarefas/:id/mover", a.exigirSessao, a.moverTarefa)
+	app.Post("/tarefas/:id/etiquetas", a.exigirSessao, a.etiquetarTarefa)
+	app.Post("/tarefas/:id/subtarefas", a.exigirSessao, a.criarSubtarefa)
+	app.Post("/tarefas/:id/conclusao-automatica", a.exigirSessao, a.alternarConclusaoAutomatica)
+	app.Post("/tarefas/:id/dependencias", a.exigirSessao, a.definirDependencias)
+	app.Post("/tarefas/:id/remover", a.exigirSessao, a.removerTarefa)
+	app.Post("/tarefas/:id/reverter", a.exigirSessao, a.reverterTarefa)
+	app.Post("/tarefas/:id/comentarios", a.exigirSessao, a.comentar)
+	app.Post("/tarefas/:id/comentarios/:comentario/editar", a.exigirSessao, a.editarComentario)
+	app.Post("/tarefas/:id/comentarios/:comentario/remover", a.exigirSessao, a.removerComentario)
+	app.Post("/tarefas/:id/anexos", a.exigirSessao, a.enviarAnexo)
+	app.Get("/tarefas/:id/anexos/:anexo", a.exigirSessao, a.baixarAnexo)
+	app.Post("/tarefas/:id/anexos/:anexo/remover", a.exigirSessao, a.removerAnexo)
+
+	// Formulários de projetos
+	app.Post("/projetos", a.exigirSessao, a.criarProjeto)
+	app.Post("/projetos/:id/renomear", a.exigirSessao, a.renomearProjeto)
+	app.Post("/projetos/:id/remover", a.exigirSessao, a.removerProjeto)
+
+	// Página e formulários de etiquetas
+	app.Get("/etiquetas", a.exigirSessao, a.paginaEtiquetas)
+	app.Post("/etiquetas", a.exigirSessao, a.criarEtiqueta)
+	app.Post("/etiquetas/:id/alterar", a.exigirSessao, a.alterarEtiqueta)
+	app.Post("/etiquetas/:id/remover", a.exigirSessao, a.removerEtiqueta)
+
+	// Lixeira e seus formulários
+	app.Get("/lixeira", a.exigirSessao, a.paginaLixeira)
+	app.Post("/lixeira/:id/restaurar", a.exigirSessao, a.restaurarDaLixeira)
+	app.Post("/lixeira/:id/purgar", a.exigirSessao, a.purgarDaLixeira)
+
+	// Quadro kanban e seus formulários
+	app.Get("/quadro", a.exigirSessao, a.paginaQuadro)
+	app.Post("/quadro/limites", a.exigirSessao, a.definirLimites)
+	app.Post("/quadro/tarefas/:id", a.exigirSessao, a.moverCartao)
 
 	// Rota de verificação de saúde
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -59,30 +124,5 @@ func main() {
 		})
 	})
 
-	// Iniciar o servidor
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
-	log.Println("Servidor Frontend iniciando na porta " + port + "...")
-	log.Fatal(app.Listen(":" + port))
-}
-
-// buscarTarefas busca tarefas da API
-func buscarTarefas(apiURL string) ([]Tarefa, error) {
-	// Fazer requisição HTTP para a API
-	resp, err := http.Get(apiURL + "/api/tarefas")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Decodificar a resposta JSON
-	var tarefas []Tarefa
-	err = json.NewDecoder(resp.Body).Decode(&tarefas)
-	if err != nil {
-		return nil, err
-	}
-
-	return tarefas, nil
+	return app
 }
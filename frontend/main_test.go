@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
 )
 
 // MockHTTPClient é um cliente HTTP mock para testes
@@ -61,3 +70,283 @@ func TestHealthEndpoint(t *testing.T) {
 		t.Errorf("Resposta esperada %s, obtida %s", expected, string(body))
 	}
 }
+
+func TestPaginaInicialPaginada(t *testing.T) {
+	// API falsa com mais tarefas do que cabem em uma página
+	api := cliente.NovoFalso()
+	for i := 1; i <= 60; i++ {
+		api.Criar(context.Background(), dominio.Tarefa{Titulo: fmt.Sprintf("Tarefa %02d", i)})
+	}
+	app := novoApp(api)
+
+	// Primeira página: tarefas iniciais e link para a próxima
+	corpo := obterPagina(t, app, "/")
+	if !strings.Contains(corpo, "Tarefa 01") || strings.Contains(corpo, "Tarefa 60") {
+		t.Errorf("Primeira página não contém as tarefas esperadas")
+	}
+	link := regexp.MustCompile(`href="(/\?cursor=[^"]+)"`).FindStringSubmatch(corpo)
+	if link == nil {
+		t.Fatalf("Link para a próxima página não encontrado")
+	}
+
+	// Segunda página: tarefas restantes e nenhum link adicional
+	corpo = obterPagina(t, app, link[1])
+	if !strings.Contains(corpo, "Tarefa 60") || strings.Contains(corpo, "Tarefa 01") {
+		t.Errorf("Segunda página não contém as tarefas esperadas")
+	}
+	if strings.Contains(corpo, "proxima-pagina") {
+		t.Errorf("Última página não deveria ter link para a próxima")
+	}
+}
+
+func TestPaginaInicialComAPIIndisponivel(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Tarefa guardada"})
+	app := novoApp(api)
+
+	// Primeira visita com a API no ar preenche o cache
+	corpo := obterPagina(t, app, "/")
+	if strings.Contains(corpo, "aviso-desatualizado") {
+		t.Errorf("Aviso de dados desatualizados exibido com a API disponível")
+	}
+
+	// Com a API fora do ar, a última versão é exibida com um aviso
+	api.Erro = errors.New("conexão recusada")
+	corpo = obterPagina(t, app, "/")
+	if !strings.Contains(corpo, "Tarefa guardada") || !strings.Contains(corpo, "aviso-desatualizado") {
+		t.Errorf("Página em cache não exibida com o aviso de dados desatualizados")
+	}
+
+	// Uma página nunca obtida não tem cópia e resulta em 503 com mensagem
+	req := httptest.NewRequest("GET", "/?cursor=outra", nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Status esperado %d, obtido %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "aviso-erro") {
+		t.Errorf("Mensagem de erro não exibida")
+	}
+}
+
+func TestFormulariosDeTarefas(t *testing.T) {
+	api := cliente.NovoFalso()
+	app := novoApp(api)
+
+	// Criar redireciona de volta à lista, que passa a exibir a tarefa
+	resp := enviarFormulario(t, app, "/tarefas", url.Values{"titulo": {"  Comprar pão  "}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/" {
+		t.Fatalf("Criar: esperado redirecionamento 303 para /, obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	pagina, _ := api.Listar(context.Background(), cliente.Consulta{})
+	if len(pagina.Tarefas) != 1 || pagina.Tarefas[0].Titulo != "Comprar pão" {
+		t.Fatalf("Tarefa não criada: %+v", pagina.Tarefas)
+	}
+	id := pagina.Tarefas[0].ID
+	if !strings.Contains(obterPagina(t, app, "/"), "Comprar pão") {
+		t.Errorf("Tarefa criada não aparece na lista")
+	}
+
+	// Renomear e concluir mantêm o cursor da página no redirecionamento
+	resp = enviarFormulario(t, app, "/tarefas/"+id+"/renomear", comVersao(t, api, id, url.Values{"titulo": {"Comprar leite"}, "cursor": {"0"}}))
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/?cursor=0" {
+		t.Errorf("Renomear: esperado redirecionamento 303 para /?cursor=0, obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	enviarFormulario(t, app, "/tarefas/"+id+"/alternar", comVersao(t, api, id, url.Values{"concluida": {"true"}}))
+	tarefa, _ := api.Buscar(context.Background(), id)
+	if tarefa.Titulo != "Comprar leite" || !tarefa.Concluida {
+		t.Errorf("Tarefa não foi alterada: %+v", tarefa)
+	}
+	if !strings.Contains(obterPagina(t, app, "/"), "Reabrir") {
+		t.Errorf("Tarefa concluída deveria oferecer a opção de reabrir")
+	}
+
+	// Remover, inclusive uma segunda vez, volta para a lista
+	remocao := comVersao(t, api, id, nil)
+	for i := 0; i < 2; i++ {
+		resp = enviarFormulario(t, app, "/tarefas/"+id+"/remover", remocao)
+		if resp.StatusCode != fiber.StatusSeeOther {
+			t.Errorf("Remover: esperado status %d, obtido %d", fiber.StatusSeeOther, resp.StatusCode)
+		}
+	}
+	if _, err := api.Buscar(context.Background(), id); !errors.Is(err, cliente.ErrNaoEncontrada) {
+		t.Errorf("Tarefa não foi removida: %v", err)
+	}
+}
+
+func TestTarefaRecorrenteNaLista(t *testing.T) {
+	prazo := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	api := cliente.NovoFalso()
+	criada, _ := api.Criar(context.Background(), dominio.Tarefa{Titulo: "Checklist", Prazo: &prazo, Recorrencia: "FREQ=WEEKLY;BYDAY=MO"})
+	app := novoApp(api)
+
+	if !strings.Contains(obterPagina(t, app, "/"), `title="FREQ=WEEKLY;BYDAY=MO">&#8635; toda semana (segunda)</span>`) {
+		t.Errorf("Recorrência não exibida na tarefa")
+	}
+
+	// Concluir pela lista conclui a ocorrência; a próxima é criada pela API
+	enviarFormulario(t, app, "/tarefas/"+criada.ID+"/alternar", comVersao(t, api, criada.ID, url.Values{"concluida": {"true"}}))
+	if obtida, _ := api.Buscar(context.Background(), criada.ID); !obtida.Concluida {
+		t.Errorf("Ocorrência não concluída: %+v", obtida)
+	}
+}
+
+func TestFormulariosExibemErrosDeValidacao(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Original"})
+	app := novoApp(api)
+	pagina, _ := api.Listar(context.Background(), cliente.Consulta{})
+	id := pagina.Tarefas[0].ID
+
+	testes := []struct {
+		nome   string
+		url    string
+		campos url.Values
+		status int
+		texto  string
+	}{
+		{"criar sem título", "/tarefas", url.Values{"titulo": {"   "}}, fiber.StatusUnprocessableEntity, "O título é obrigatório."},
+		{"renomear sem título", "/tarefas/" + id + "/renomear", url.Values{"titulo": {""}}, fiber.StatusUnprocessableEntity, "O título é obrigatório."},
+		{"renomear inexistente", "/tarefas/nao-existe/renomear", url.Values{"titulo": {"Novo"}}, fiber.StatusNotFound, "A tarefa não existe mais"},
+		{"alternar sem valor", "/tarefas/" + id + "/alternar", nil, fiber.StatusBadRequest, "Formulário inválido."},
+	}
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			resp := enviarFormulario(t, app, tt.url, tt.campos)
+			if resp.StatusCode != tt.status {
+				t.Errorf("Status esperado %d, obtido %d", tt.status, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.texto) {
+				t.Errorf("Mensagem %q não encontrada na página", tt.texto)
+			}
+		})
+	}
+
+	// Nenhum formulário inválido alterou a tarefa
+	tarefa, _ := api.Buscar(context.Background(), id)
+	if tarefa.Titulo != "Original" || tarefa.Versao != 1 {
+		t.Errorf("Tarefa alterada por formulário inválido: %+v", tarefa)
+	}
+}
+
+func TestFormularioExibeFalhaDaAPI(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Existente"})
+	app := novoApp(api)
+	obterPagina(t, app, "/")
+
+	// Um erro 5xx da API é exibido com sua mensagem e o ID da requisição
+	api.Erro = &cliente.ErroAPI{Status: 500, Codigo: dominio.CodigoErroInterno, Mensagem: "erro interno", RequestID: "req-42"}
+	resp := enviarFormulario(t, app, "/tarefas", url.Values{"titulo": {"Nova"}})
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Status esperado %d, obtido %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, texto := range []string{"Não foi possível salvar a alteração: erro interno", "req-42", "Existente"} {
+		if !strings.Contains(string(body), texto) {
+			t.Errorf("Texto %q não encontrado na página", texto)
+		}
+	}
+}
+
+// apiComRegras envolve o Falso, que não aplica as regras do servidor, e
+// responde às alterações e aos anexos com o erro que a API daria: recusar
+// recebe o ID e a alteração e retorna o erro, ou nil para deixar passar
+type apiComRegras struct {
+	*cliente.Falso
+	recusarAlteracao func(id string, a cliente.Alteracao) error
+	recusarAnexo     func(nome, tipo string) error
+}
+
+func (a *apiComRegras) Alterar(ctx context.Context, id string, alteracao cliente.Alteracao) (dominio.Tarefa, error) {
+	if a.recusarAlteracao != nil {
+		if err := a.recusarAlteracao(id, alteracao); err != nil {
+			return dominio.Tarefa{}, err
+		}
+	}
+	return a.Falso.Alterar(ctx, id, alteracao)
+}
+
+func (a *apiComRegras) Anexar(ctx context.Context, tarefaID, nome, tipo string, conteudo []byte) (dominio.Anexo, error) {
+	if a.recusarAnexo != nil {
+		if err := a.recusarAnexo(nome, tipo); err != nil {
+			return dominio.Anexo{}, err
+		}
+	}
+	return a.Falso.Anexar(ctx, tarefaID, nome, tipo, conteudo)
+}
+
+// erroRegra monta o erro com que a API recusa uma operação pelas suas regras
+func erroRegra(status int, codigo, mensagem string) *cliente.ErroAPI {
+	return &cliente.ErroAPI{Status: status, Codigo: codigo, Mensagem: mensagem, Mensagens: dominio.Mensagens{PtBR: mensagem}}
+}
+
+// erroCampo monta o erro de validação com que a API recusa o campo informado
+func erroCampo(campo, mensagem string) *cliente.ErroAPI {
+	validacao := dominio.ErroValidacao{Campo: campo, Codigo: dominio.CodigoInvalido, Mensagens: dominio.Mensagens{PtBR: mensagem}}
+	return &cliente.ErroAPI{Status: http.StatusBadRequest, Codigo: dominio.CodigoValidacao, Mensagem: mensagem, Mensagens: validacao.Mensagens, Campos: []dominio.ErroValidacao{validacao}}
+}
+
+// tokenTeste é o token de sessão enviado pelos helpers; o Falso sem usuários
+// aceita qualquer credencial
+const tokenTeste = "falso:teste"
+
+// enviarFormulario executa um POST com os campos informados e o cookie de
+// sessão, sem seguir redirecionamentos
+func enviarFormulario(t *testing.T, app *fiber.App, caminho string, campos url.Values) *http.Response {
+	t.Helper()
+	return enviarFormularioComo(t, app, tokenTeste, caminho, campos)
+}
+
+// comVersao acrescenta aos campos a versão atual da tarefa, como o campo
+// oculto versao dos formulários da página
+func comVersao(t *testing.T, api cliente.API, id string, campos url.Values) url.Values {
+	t.Helper()
+	tarefa, err := api.Buscar(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Buscar %s: %v", id, err)
+	}
+	if campos == nil {
+		campos = url.Values{}
+	}
+	campos.Set("versao", strconv.Itoa(tarefa.Versao))
+	return campos
+}
+
+// enviarFormularioComo é enviarFormulario com o token de sessão informado;
+// vazio, a requisição vai sem cookie
+func enviarFormularioComo(t *testing.T, app *fiber.App, token, caminho string, campos url.Values) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest("POST", caminho, strings.NewReader(campos.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: cookieSessao, Value: token})
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	return resp
+}
+
+// obterPagina executa um GET na aplicação com o cookie de sessão e retorna o
+// corpo da resposta
+func obterPagina(t *testing.T, app *fiber.App, url string) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", url, nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Status esperado %d, obtido %d", fiber.StatusOK, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Falha ao ler o corpo da resposta: %v", err)
+	}
+	return string(body)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Formatação em linha do Markdown dos comentários, aplicada sobre o texto
+// já escapado. A ênfase com _ só vale fora de palavras, para que nomes como
+// nome_do_arquivo fiquem intactos.
+var (
+	padraoCodigo    = regexp.MustCompile("`([^`]+)`")
+	padraoNegrito   = regexp.MustCompile(`\*\*([^*]+)\*\*|\b__([^_]+)__\b`)
+	padraoItalico   = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	padraoLink      = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s()]+)\)`)
+	padraoItemLista = regexp.MustCompile(`^\s*[-*]\s+`)
+)
+
+// markdownParaHTML converte o Markdown dos comentários em HTML seguro para
+// exibir sem escapar. Aceita parágrafos, quebras de linha, listas com - ou
+// *, blocos de código entre ```, `código`, **negrito** ou __negrito__,
+// *itálico* ou _itálico_ e links http e https. Todo o texto é escapado antes da formatação, de modo que
+// HTML escrito no comentário aparece como texto.
+func markdownParaHTML(texto string) string {
+	var b strings.Builder
+	var paragrafo, lista, codigo []string
+	emCodigo := false
+
+	fecharParagrafo := func() {
+		if len(paragrafo) > 0 {
+			b.WriteString("<p>" + strings.Join(paragrafo, "<br>") + "</p>")
+			paragrafo = nil
+		}
+	}
+	fecharLista := func() {
+		if len(lista) > 0 {
+			b.WriteString("<ul><li>" + strings.Join(lista, "</li><li>") + "</li></ul>")
+			lista = nil
+		}
+	}
+
+	for _, linha := range strings.Split(strings.ReplaceAll(texto, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(linha), "```") {
+			if emCodigo {
+				b.WriteString("<pre><code>" + strings.Join(codigo, "\n") + "</code></pre>")
+				codigo = nil
+			} else {
+				fecharParagrafo()
+				fecharLista()
+			}
+			emCodigo = !emCodigo
+			continue
+		}
+		if emCodigo {
+			codigo = append(codigo, html.EscapeString(linha))
+			continue
+		}
+		switch {
+		case strings.TrimSpace(linha) == "":
+			fecharParagrafo()
+			fecharLista()
+		case padraoItemLista.MatchString(linha):
+			fecharParagrafo()
+			lista = append(lista, formatarEmLinha(padraoItemLista.ReplaceAllString(linha, "")))
+		default:
+			fecharLista()
+			paragrafo = append(paragrafo, formatarEmLinha(strings.TrimSpace(linha)))
+		}
+	}
+	// Um bloco de código sem o fechamento vai até o fim do texto
+	if emCodigo {
+		b.WriteString("<pre><code>" + strings.Join(codigo, "\n") + "</code></pre>")
+	}
+	fecharParagrafo()
+	fecharLista()
+	return b.String()
+}
+
+// formatarEmLinha escapa a linha e aplica a formatação em linha. O conteúdo
+// entre crases não recebe as demais formatações.
+func formatarEmLinha(linha string) string {
+	linha = html.EscapeString(linha)
+	var b strings.Builder
+	inicio := 0
+	for _, trecho := range padraoCodigo.FindAllStringSubmatchIndex(linha, -1) {
+		b.WriteString(formatarTexto(linha[inicio:trecho[0]]))
+		b.WriteString("<code>" + linha[trecho[2]:trecho[3]] + "</code>")
+		inicio = trecho[1]
+	}
+	b.WriteString(formatarTexto(linha[inicio:]))
+	return b.String()
+}
+
+// formatarTexto aplica links, negrito e itálico a um trecho já escapado. Os
+// links são separados primeiro, para que * e _ do endereço não virem ênfase;
+// a ênfase vale só para o texto entre eles e para o rótulo.
+func formatarTexto(trecho string) string {
+	var b strings.Builder
+	inicio := 0
+	for _, link := range padraoLink.FindAllStringSubmatchIndex(trecho, -1) {
+		b.WriteString(formatarEnfase(trecho[inicio:link[0]]))
+		b.WriteString(`<a href="` + trecho[link[4]:link[5]] + `" rel="nofollow noopener noreferrer">` + formatarEnfase(trecho[link[2]:link[3]]) + "</a>")
+		inicio = link[1]
+	}
+	b.WriteString(formatarEnfase(trecho[inicio:]))
+	return b.String()
+}
+
+// formatarEnfase aplica negrito e itálico a um trecho já escapado sem links
+func formatarEnfase(trecho string) string {
+	trecho = padraoNegrito.ReplaceAllString(trecho, "<strong>${1}${2}</strong>")
+	return padraoItalico.ReplaceAllString(trecho, "<em>${1}${2}</em>")
+}
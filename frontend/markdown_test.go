@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestMarkdownParaHTML(t *testing.T) {
+	casos := []struct {
+		nome, entrada, esperado string
+	}{
+		{"parágrafos", "Primeira linha\nsegunda\n\nOutro parágrafo", "<p>Primeira linha<br>segunda</p><p>Outro parágrafo</p>"},
+		{"formatação", "Falta **testar** no *Safari*", "<p>Falta <strong>testar</strong> no <em>Safari</em></p>"},
+		{"formatação com _", "Falta __testar__ no _Safari_ e em nome_do_arquivo", "<p>Falta <strong>testar</strong> no <em>Safari</em> e em nome_do_arquivo</p>"},
+		{"código em linha", "Rode `go test ./... **sem** cache`", "<p>Rode <code>go test ./... **sem** cache</code></p>"},
+		{"lista", "Pendências:\n- login\n* logout", "<p>Pendências:</p><ul><li>login</li><li>logout</li></ul>"},
+		{"bloco de código", "```\nif a < b {\n```", "<pre><code>if a &lt; b {</code></pre>"},
+		{"link", "Veja [o PR](https://exemplo.com/pr?a=1&b=2)", `<p>Veja <a href="https://exemplo.com/pr?a=1&amp;b=2" rel="nofollow noopener noreferrer">o PR</a></p>`},
+		{"ênfase ao redor de links", "*Veja* [o **PR**](https://exemplo.com/a*b*c_d_) e [a issue](https://exemplo.com/x**y**) *hoje*",
+			`<p><em>Veja</em> <a href="https://exemplo.com/a*b*c_d_" rel="nofollow noopener noreferrer">o <strong>PR</strong></a> e ` +
+				`<a href="https://exemplo.com/x**y**" rel="nofollow noopener noreferrer">a issue</a> <em>hoje</em></p>`},
+		// HTML e links com outros esquemas aparecem como texto
+		{"HTML escapado", `<script>alert("x")</script>`, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>"},
+		{"link javascript", "[clique](javascript:alert(1))", "<p>[clique](javascript:alert(1))</p>"},
+	}
+	for _, caso := range casos {
+		if obtido := markdownParaHTML(caso.entrada); obtido != caso.esperado {
+			t.Errorf("%s: obtido %q, esperado %q", caso.nome, obtido, caso.esperado)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// projetoVisao é um projeto como exibido na barra lateral, com o estado do
+// formulário de renomear
+type projetoVisao struct {
+	dominio.Projeto
+	// Atual indica o projeto cujas tarefas estão sendo exibidas
+	Atual bool
+	// NomeEditado é o nome enviado no formulário que falhou na validação
+	NomeEditado string
+	// ErroEdicao é a mensagem de validação do formulário do projeto
+	ErroEdicao string
+}
+
+// criarProjeto atende POST /projetos e exibe o projeto criado
+func (a *aplicacao) criarProjeto(c *fiber.Ctx) error {
+	nome := strings.TrimSpace(c.FormValue("nome"))
+	form := &formularioInvalido{projeto: true, titulo: nome}
+
+	if err := (dominio.Projeto{Nome: nome}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	p, err := a.api.CriarProjeto(ctx, nome)
+	if err != nil {
+		return a.responderErroProjeto(c, form, err)
+	}
+	return c.Redirect(enderecoLista(filtroLista{projeto: p.ID}, ""), fiber.StatusSeeOther)
+}
+
+// renomearProjeto atende POST /projetos/:id/renomear
+func (a *aplicacao) renomearProjeto(c *fiber.Ctx) error {
+	id := c.Params("id")
+	nome := strings.TrimSpace(c.FormValue("nome"))
+	form := &formularioInvalido{projeto: true, id: id, titulo: nome}
+
+	if err := (dominio.Projeto{Nome: nome}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.RenomearProjeto(ctx, id, nome); err != nil {
+		return a.responderErroProjeto(c, form, err)
+	}
+	return a.voltar(c)
+}
+
+// removerProjeto atende POST /projetos/:id/remover e volta para a lista de
+// todas as tarefas. As tarefas do projeto continuam existindo, sem projeto.
+func (a *aplicacao) removerProjeto(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	// Como nas tarefas, remover um projeto que já não existe não é erro
+	err := a.api.RemoverProjeto(ctx, c.Params("id"))
+	if err != nil && !errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.responderErroProjeto(c, nil, err)
+	}
+	return c.Redirect("/", fiber.StatusSeeOther)
+}
+
+// responderErroProjeto é responderErroAlteracao com a mensagem de projeto
+// inexistente
+func (a *aplicacao) responderErroProjeto(c *fiber.Ctx, form *formularioInvalido, err error) error {
+	if errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.renderizarTarefas(c, fiber.StatusNotFound, nil, "O projeto não existe mais; ele pode ter sido removido.")
+	}
+	return a.responderErroAlteracao(c, form, err)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestFormulariosDeProjetos(t *testing.T) {
+	api := &apiComRegras{Falso: cliente.NovoFalso(dominio.Tarefa{Titulo: "Avulsa"})}
+	app := novoApp(api)
+
+	// Criar um projeto leva à página dele
+	resp := enviarFormulario(t, app, "/projetos", url.Values{"nome": {" Casa "}})
+	projetos, _ := api.ListarProjetos(context.Background())
+	if len(projetos) != 1 || projetos[0].Nome != "Casa" {
+		t.Fatalf("Projeto não criado: %+v", projetos)
+	}
+	casa := projetos[0].ID
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/?projeto="+casa {
+		t.Fatalf("Criar projeto: obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// Uma tarefa criada na página do projeto entra no projeto
+	resp = enviarFormulario(t, app, "/tarefas", url.Values{"titulo": {"Lavar louça"}, "projeto": {casa}})
+	if resp.Header.Get("Location") != "/?projeto="+casa {
+		t.Errorf("Criar tarefa no projeto: redirecionou para %q", resp.Header.Get("Location"))
+	}
+	corpo := obterPagina(t, app, "/?projeto="+casa)
+	if !strings.Contains(corpo, "Lavar louça") || strings.Contains(corpo, "Avulsa") {
+		t.Errorf("Página do projeto não filtra as tarefas")
+	}
+	// As contagens vêm da API, que o Falso não calcula
+	if !strings.Contains(corpo, `<span class="contagem" title="Pendentes / total">0/0</span>`) {
+		t.Errorf("Contagem de tarefas do projeto não exibida")
+	}
+
+	// Mover a tarefa avulsa para o projeto e tirar a outra dele
+	pagina, _ := api.Listar(context.Background(), cliente.Consulta{})
+	avulsa, lavar := pagina.Tarefas[0].ID, pagina.Tarefas[1].ID
+	enviarFormulario(t, app, "/tarefas/"+avulsa+"/mover", comVersao(t, api, avulsa, url.Values{"projeto_id": {casa}}))
+	enviarFormulario(t, app, "/tarefas/"+lavar+"/mover", comVersao(t, api, lavar, url.Values{"projeto_id": {""}, "projeto": {casa}}))
+	pagina, _ = api.Listar(context.Background(), cliente.Consulta{Projeto: casa})
+	if len(pagina.Tarefas) != 1 || pagina.Tarefas[0].ID != avulsa {
+		t.Errorf("Tarefas não foram movidas: %+v", pagina.Tarefas)
+	}
+
+	// Renomear exige um nome
+	resp = enviarFormulario(t, app, "/projetos/"+casa+"/renomear", url.Values{"nome": {""}, "projeto": {casa}})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "O nome do projeto é obrigatório.") {
+		t.Errorf("Renomear sem nome: obtido %d", resp.StatusCode)
+	}
+	enviarFormulario(t, app, "/projetos/"+casa+"/renomear", url.Values{"nome": {"Lar"}, "projeto": {casa}})
+	if !strings.Contains(obterPagina(t, app, "/"), "Lar") {
+		t.Errorf("Projeto renomeado não aparece na barra lateral")
+	}
+
+	// Remover o projeto volta à lista completa, sem perder as tarefas
+	resp = enviarFormulario(t, app, "/projetos/"+casa+"/remover", nil)
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/" {
+		t.Errorf("Remover projeto: obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if pagina, _ := api.Listar(context.Background(), cliente.Consulta{}); len(pagina.Tarefas) != 2 {
+		t.Errorf("Tarefas perdidas ao remover o projeto: %+v", pagina.Tarefas)
+	}
+
+	// Mover para um projeto que não existe mais exibe o motivo da API
+	api.recusarAlteracao = func(id string, a cliente.Alteracao) error {
+		if a.ProjetoID != nil && *a.ProjetoID == casa {
+			return erroCampo("projeto_id", "o projeto informado não existe")
+		}
+		return nil
+	}
+	resp = enviarFormulario(t, app, "/tarefas/"+avulsa+"/mover", comVersao(t, api, avulsa, url.Values{"projeto_id": {casa}}))
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "O projeto informado não existe.") {
+		t.Errorf("Mover para projeto removido: obtido %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,51 @@
+// Arrastar e soltar no quadro: soltar um cartão em outra coluna envia o
+// mesmo formulário dos botões do cartão, com o estado da coluna. Sem
+// JavaScript, os botões continuam funcionando.
+(function () {
+    var arrastado = null;
+
+    function destinos(cartao) {
+        return (cartao.dataset.destinos || "").split(" ").filter(Boolean);
+    }
+
+    document.querySelectorAll(".cartao").forEach(function (cartao) {
+        cartao.addEventListener("dragstart", function (evento) {
+            arrastado = cartao;
+            evento.dataTransfer.effectAllowed = "move";
+            destinos(cartao).forEach(function (estado) {
+                var coluna = document.querySelector('.coluna[data-estado="' + estado + '"]');
+                if (coluna) {
+                    coluna.classList.add("destino");
+                }
+            });
+        });
+        cartao.addEventListener("dragend", function () {
+            arrastado = null;
+            document.querySelectorAll(".coluna.destino").forEach(function (coluna) {
+                coluna.classList.remove("destino");
+            });
+        });
+    });
+
+    document.querySelectorAll(".coluna").forEach(function (coluna) {
+        // Só as colunas permitidas pelo fluxo e com espaço aceitam o cartão
+        coluna.addEventListener("dragover", function (evento) {
+            if (arrastado && destinos(arrastado).indexOf(coluna.dataset.estado) >= 0) {
+                evento.preventDefault();
+            }
+        });
+        coluna.addEventListener("drop", function (evento) {
+            evento.preventDefault();
+            var form = arrastado && arrastado.querySelector("form.estados");
+            if (!form) {
+                return;
+            }
+            var estado = document.createElement("input");
+            estado.type = "hidden";
+            estado.name = "estado";
+            estado.value = coluna.dataset.estado;
+            form.appendChild(estado);
+            form.submit();
+        });
+    });
+})();
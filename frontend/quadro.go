@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// colunaQuadro é um estado do fluxo exibido como coluna do quadro
+type colunaQuadro struct {
+	dominio.EstadoFluxo
+	Cartoes []cartaoQuadro
+	// Cheia indica que a coluna atingiu seu limite e não aceita mais
+	// cartões; Excedida, que passou dele, como depois de o limite baixar
+	Cheia    bool
+	Excedida bool
+}
+
+// Total é o número de cartões na coluna
+func (c colunaQuadro) Total() int {
+	return len(c.Cartoes)
+}
+
+// cartaoQuadro é uma tarefa exibida como cartão na coluna do seu estado
+type cartaoQuadro struct {
+	dominio.Tarefa
+	// Proximos são os estados para os quais o cartão pode ser movido
+	Proximos []opcaoEstado
+	// Destinos lista, separados por espaço, os estados habilitados de
+	// Proximos, para onde o script do quadro permite arrastar o cartão
+	Destinos string
+}
+
+// TemProximos informa se o cartão pode ser movido para alguma coluna
+func (c cartaoQuadro) TemProximos() bool {
+	return len(c.Proximos) > 0
+}
+
+// enderecoQuadro retorna o endereço do quadro do projeto; com projeto vazio,
+// o quadro das tarefas sem projeto
+func enderecoQuadro(projeto string) string {
+	if projeto == "" {
+		return "/quadro"
+	}
+	return "/quadro?" + url.Values{"projeto": {projeto}}.Encode()
+}
+
+// projetoDoQuadro obtém o projeto do quadro, enviado na URL em GET e como
+// campo oculto nos formulários
+func projetoDoQuadro(c *fiber.Ctx) string {
+	if projeto := c.Query("projeto"); projeto != "" {
+		return projeto
+	}
+	return c.FormValue("projeto")
+}
+
+// tarefasDoQuadro busca todas as tarefas de primeiro nível do projeto ou,
+// com projeto vazio, as que não têm projeto
+func (a *aplicacao) tarefasDoQuadro(ctx context.Context, projeto string) ([]dominio.Tarefa, error) {
+	var tarefas []dominio.Tarefa
+	consulta := cliente.Consulta{Projeto: projeto, Raiz: true, Limite: limiteSubtarefas}
+	for {
+		pagina, err := a.api.Listar(ctx, consulta)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range pagina.Tarefas {
+			if t.ProjetoID == projeto {
+				tarefas = append(tarefas, t)
+			}
+		}
+		if pagina.NextCursor == "" {
+			return tarefas, nil
+		}
+		consulta.Cursor = pagina.NextCursor
+	}
+}
+
+// montarQuadro distribui as tarefas pelas colunas do fluxo. Os destinos de
+// cada cartão excluem as colunas cheias e, para a tarefa bloqueada, os
+// estados finais.
+func montarQuadro(fluxo dominio.Fluxo, tarefas []dominio.Tarefa) []colunaQuadro {
+	colunas := make([]colunaQuadro, len(fluxo.Estados))
+	for i, e := range fluxo.Estados {
+		colunas[i].EstadoFluxo = e
+	}
+	indice := func(estado string) int {
+		return slices.IndexFunc(colunas, func(c colunaQuadro) bool { return c.ID == estado })
+	}
+	for _, t := range tarefas {
+		t.Estado = fluxo.Resolver(t)
+		i := indice(t.Estado)
+		colunas[i].Cartoes = append(colunas[i].Cartoes, cartaoQuadro{Tarefa: t})
+	}
+	for i := range colunas {
+		limite := colunas[i].Limite
+		colunas[i].Cheia = limite > 0 && colunas[i].Total() >= limite
+		colunas[i].Excedida = limite > 0 && colunas[i].Total() > limite
+	}
+
+	for i := range colunas {
+		for j := range colunas[i].Cartoes {
+			cartao := &colunas[i].Cartoes[j]
+			cartao.Proximos = proximosEstados(cartao.Tarefa, fluxo)
+			var destinos []string
+			for k, p := range cartao.Proximos {
+				if !p.Desabilitada && colunas[indice(p.ID)].Cheia {
+					cartao.Proximos[k].Desabilitada = true
+					cartao.Proximos[k].Motivo = "A coluna " + p.Nome + " atingiu o limite de tarefas"
+				}
+				if !cartao.Proximos[k].Desabilitada {
+					destinos = append(destinos, p.ID)
+				}
+			}
+			cartao.Destinos = strings.Join(destinos, " ")
+		}
+	}
+	return colunas
+}
+
+// paginaQuadro atende GET /quadro, o quadro kanban das tarefas de um projeto,
+// com uma coluna por estado do seu fluxo
+func (a *aplicacao) paginaQuadro(c *fiber.Ctx) error {
+	return a.renderizarQuadro(c, fiber.StatusOK, "")
+}
+
+// renderizarQuadro busca o projeto e suas tarefas e renderiza o quadro com o
+// status e o aviso informados
+func (a *aplicacao) renderizarQuadro(c *fiber.Ctx, status int, aviso string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	projeto := projetoDoQuadro(c)
+	dados := fiber.Map{
+		"Titulo":  "Quadro",
+		"Projeto": projeto,
+		"Aviso":   aviso,
+	}
+
+	projetos, err := a.api.ListarProjetos(ctx)
+	var tarefas []dominio.Tarefa
+	if err == nil {
+		tarefas, err = a.tarefasDoQuadro(ctx, projeto)
+	}
+	if errors.Is(err, cliente.ErrNaoAutenticado) {
+		return a.encerrarSessao(c)
+	}
+	if err != nil {
+		log.Printf("erro ao buscar o quadro: %v", err)
+		dados["Erro"] = "Não foi possível carregar o quadro. Tente novamente em instantes."
+		return c.Status(fiber.StatusServiceUnavailable).Render("quadro", dados)
+	}
+
+	fluxo := dominio.FluxoPadrao()
+	opcoes := []opcaoProjeto{{Nome: "Sem projeto", Selecionado: projeto == ""}}
+	for _, p := range projetos {
+		opcoes = append(opcoes, opcaoProjeto{ID: p.ID, Nome: p.Nome, Selecionado: p.ID == projeto})
+		if p.ID == projeto {
+			fluxo = p.FluxoDeTrabalho()
+			dados["ProjetoAtual"] = p
+		}
+	}
+	if projeto != "" && dados["ProjetoAtual"] == nil {
+		dados["Erro"] = "O projeto não existe mais; ele pode ter sido removido."
+		return c.Status(fiber.StatusNotFound).Render("quadro", dados)
+	}
+	dados["Projetos"] = opcoes
+	dados["Colunas"] = montarQuadro(fluxo, tarefas)
+	return c.Status(status).Render("quadro", dados)
+}
+
+// moverCartao atende POST /quadro/tarefas/:id, enviado pelos botões do
+// cartão ou ao soltá-lo em outra coluna. O limite da coluna de destino é
+// conferido pela API.
+func (a *aplicacao) moverCartao(c *fiber.Ctx) error {
+	id := c.Params("id")
+	estado := c.FormValue("estado")
+	projeto := projetoDoQuadro(c)
+	if estado == "" {
+		return a.renderizarQuadro(c, fiber.StatusBadRequest, "Formulário inválido.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{Estado: &estado, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, id, alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, id, &alteracao, enderecoQuadro(projeto))
+	}
+	if err != nil {
+		return a.responderErroQuadro(c, err)
+	}
+	return c.Redirect(enderecoQuadro(projeto), fiber.StatusSeeOther)
+}
+
+// definirLimites atende POST /quadro/limites, que troca de uma vez os
+// limites de todas as colunas do quadro do projeto. Um campo vazio ou zero
+// tira o limite da coluna.
+func (a *aplicacao) definirLimites(c *fiber.Ctx) error {
+	projeto := projetoDoQuadro(c)
+	if projeto == "" {
+		return a.renderizarQuadro(c, fiber.StatusBadRequest, "Escolha um projeto para definir os limites das colunas.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	p, err := a.api.BuscarProjeto(ctx, projeto)
+	if err != nil {
+		return a.responderErroQuadro(c, err)
+	}
+
+	fluxo := p.FluxoDeTrabalho()
+	fluxo.Estados = slices.Clone(fluxo.Estados)
+	for i, e := range fluxo.Estados {
+		valor := strings.TrimSpace(c.FormValue("limite_" + e.ID))
+		if valor == "" {
+			fluxo.Estados[i].Limite = 0
+			continue
+		}
+		limite, err := strconv.Atoi(valor)
+		if err != nil || limite < 0 {
+			return a.renderizarQuadro(c, fiber.StatusUnprocessableEntity,
+				"O limite da coluna "+e.Nome+" deve ser um número inteiro; deixe vazio ou use 0 para não limitar.")
+		}
+		fluxo.Estados[i].Limite = limite
+	}
+
+	if _, err := a.api.DefinirFluxo(ctx, projeto, &fluxo); err != nil {
+		return a.responderErroQuadro(c, err)
+	}
+	return c.Redirect(enderecoQuadro(projeto), fiber.StatusSeeOther)
+}
+
+// responderErroQuadro trata o erro da API ao mover um cartão ou definir os
+// limites, renderizando o quadro com a explicação
+func (a *aplicacao) responderErroQuadro(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrRequisicaoInvalida):
+		// O estado foi removido do fluxo depois que o quadro foi exibido
+		return a.renderizarQuadro(c, fiber.StatusUnprocessableEntity, mensagemUsuario(err))
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return a.renderizarQuadro(c, fiber.StatusNotFound, "A tarefa ou o projeto não existe mais; pode ter sido removido.")
+	case errors.Is(err, cliente.ErrTarefaBloqueada):
+		return a.renderizarQuadro(c, fiber.StatusConflict, "A tarefa depende de tarefas pendentes. Conclua-as antes de concluir esta.")
+	case errors.Is(err, cliente.ErrTransicaoInvalida):
+		return a.renderizarQuadro(c, fiber.StatusConflict, mensagemUsuario(err))
+	case errors.Is(err, cliente.ErrLimiteEstado):
+		return a.renderizarQuadro(c, fiber.StatusConflict, mensagemUsuario(err)+" Termine uma delas antes de mover outra para lá.")
+	case errors.Is(err, cliente.ErrConflito):
+		return a.renderizarQuadro(c, fiber.StatusConflict, "A tarefa foi alterada por outra pessoa. Confira a versão atual e tente novamente.")
+	}
+
+	log.Printf("erro ao alterar o quadro: %v", err)
+	return a.renderizarQuadro(c, fiber.StatusServiceUnavailable, avisoFalhaAPI(err))
+}
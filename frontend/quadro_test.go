@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestQuadroComLimites(t *testing.T) {
+	api := &apiComRegras{Falso: cliente.NovoFalso()}
+	app := novoApp(api)
+	ctx := context.Background()
+	projeto, _ := api.CriarProjeto(ctx, "Sprint")
+	api.DefinirFluxo(ctx, projeto.ID, &dominio.Fluxo{Estados: []dominio.EstadoFluxo{
+		{ID: "backlog", Nome: "Backlog"},
+		{ID: "fazendo", Nome: "Fazendo"},
+		{ID: "feito", Nome: "Feito", Final: true},
+	}})
+	primeira, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Primeira", ProjetoID: projeto.ID, Estado: "backlog"})
+	segunda, _ := api.Criar(ctx, dominio.Tarefa{Titulo: "Segunda", ProjetoID: projeto.ID, Estado: "backlog"})
+	api.Criar(ctx, dominio.Tarefa{Titulo: "Sem projeto"})
+	quadro := "/quadro?projeto=" + projeto.ID
+
+	// Cada estado do fluxo é uma coluna com os cartões das tarefas
+	corpo := obterPagina(t, app, quadro)
+	if !strings.Contains(corpo, `data-estado="fazendo"`) || !strings.Contains(corpo, "Primeira") || strings.Contains(corpo, "Sem projeto</a>") {
+		t.Errorf("Colunas ou cartões do quadro incorretos")
+	}
+	if !strings.Contains(corpo, `data-destinos="fazendo feito"`) {
+		t.Errorf("Destinos do cartão não exibidos")
+	}
+
+	// Um limite inválido é recusado
+	resp := enviarFormulario(t, app, "/quadro/limites", url.Values{"projeto": {projeto.ID}, "limite_fazendo": {"-1"}})
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Errorf("Limite negativo: obtido %d", resp.StatusCode)
+	}
+	resp = enviarFormulario(t, app, "/quadro/limites", url.Values{"projeto": {projeto.ID}, "limite_fazendo": {"1"}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != quadro {
+		t.Fatalf("Definir limites: obtido %d", resp.StatusCode)
+	}
+
+	resp = enviarFormulario(t, app, "/quadro/tarefas/"+primeira.ID, comVersao(t, api, primeira.ID, url.Values{"projeto": {projeto.ID}, "estado": {"fazendo"}}))
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("Mover cartão: obtido %d", resp.StatusCode)
+	}
+	corpo = obterPagina(t, app, quadro)
+	if !strings.Contains(corpo, `coluna cheia" data-estado="fazendo"`) || !strings.Contains(corpo, `value="1" placeholder`) {
+		t.Errorf("Coluna cheia não indicada no quadro")
+	}
+	if !strings.Contains(corpo, `data-destinos="feito"`) {
+		t.Errorf("Coluna cheia oferecida como destino")
+	}
+
+	// A API recusa outro cartão na coluna cheia, e o quadro exibe o motivo
+	api.recusarAlteracao = func(id string, a cliente.Alteracao) error {
+		if id == segunda.ID && a.Estado != nil && *a.Estado == "fazendo" {
+			return erroRegra(http.StatusConflict, dominio.CodigoLimiteEstado, `a coluna "Fazendo" já tem 1 tarefas, o seu limite`)
+		}
+		return nil
+	}
+	resp = enviarFormulario(t, app, "/quadro/tarefas/"+segunda.ID, comVersao(t, api, segunda.ID, url.Values{"projeto": {projeto.ID}, "estado": {"fazendo"}}))
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusConflict || !strings.Contains(string(body), "já tem 1 tarefas, o seu limite") {
+		t.Errorf("Mover para coluna cheia: obtido %d", resp.StatusCode)
+	}
+	if tarefa, _ := api.Buscar(ctx, segunda.ID); tarefa.Estado != "backlog" {
+		t.Errorf("Cartão movido para coluna cheia: %q", tarefa.Estado)
+	}
+}
+
+func TestQuadroSemProjeto(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Avulsa"})
+	app := novoApp(api)
+
+	// Sem projeto, o quadro usa o fluxo padrão e não tem limites
+	corpo := obterPagina(t, app, "/quadro")
+	if !strings.Contains(corpo, `data-estado="pendente"`) || !strings.Contains(corpo, "Avulsa") || strings.Contains(corpo, "Limites das colunas") {
+		t.Errorf("Quadro das tarefas sem projeto incorreto")
+	}
+
+	req := httptest.NewRequest("GET", "/quadro?projeto=inexistente", nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: tokenTeste})
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Quadro de projeto inexistente: obtido %v %v", resp, err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// cookieSessao guarda o token de acesso à API obtido no login
+const cookieSessao = "sessao"
+
+// paginaLogin atende GET /login
+func (a *aplicacao) paginaLogin(c *fiber.Ctx) error {
+	return renderizarLogin(c, fiber.StatusOK, "", "")
+}
+
+// entrar atende POST /login, trocando usuário e senha por um token da API
+// guardado em um cookie HttpOnly
+func (a *aplicacao) entrar(c *fiber.Ctx) error {
+	usuario := strings.TrimSpace(c.FormValue("usuario"))
+	senha := c.FormValue("senha")
+	if usuario == "" || senha == "" {
+		return renderizarLogin(c, fiber.StatusUnprocessableEntity, usuario, "Informe usuário e senha.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	sessao, err := a.api.Entrar(ctx, usuario, senha)
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return renderizarLogin(c, fiber.StatusUnauthorized, usuario, "Usuário ou senha inválidos.")
+	case err != nil:
+		log.Printf("erro ao fazer login: %v", err)
+		return renderizarLogin(c, fiber.StatusServiceUnavailable, usuario, "Não foi possível entrar: a API está indisponível. Tente novamente em instantes.")
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     cookieSessao,
+		Value:    sessao.Token,
+		Path:     "/",
+		Expires:  sessao.ExpiraEm,
+		HTTPOnly: true,
+		Secure:   c.Secure(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect("/", fiber.StatusSeeOther)
+}
+
+// sair atende POST /sair, apagando o cookie da sessão
+func (a *aplicacao) sair(c *fiber.Ctx) error {
+	return a.encerrarSessao(c)
+}
+
+// exigirSessao redireciona para o login quem não tem sessão e repassa o token
+// da sessão às chamadas à API feitas com c.UserContext()
+func (a *aplicacao) exigirSessao(c *fiber.Ctx) error {
+	token := c.Cookies(cookieSessao)
+	if token == "" {
+		return c.Redirect("/login", fiber.StatusSeeOther)
+	}
+	c.SetUserContext(cliente.ComCredencial(c.UserContext(), token))
+	return c.Next()
+}
+
+// encerrarSessao apaga o cookie e as páginas em cache da sessão e redireciona
+// para o login. É usada também quando a API recusa o token, por exemplo
+// porque ele expirou.
+func (a *aplicacao) encerrarSessao(c *fiber.Ctx) error {
+	a.cache.descartar(c.Cookies(cookieSessao))
+	c.Cookie(&fiber.Cookie{
+		Name:     cookieSessao,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   c.Secure(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect("/login", fiber.StatusSeeOther)
+}
+
+// renderizarLogin exibe o formulário de login com o usuário já digitado e
+// uma mensagem de erro, se houver
+func renderizarLogin(c *fiber.Ctx, status int, usuario, erro string) error {
+	return c.Status(status).Render("login", fiber.Map{
+		"Titulo":  "Gerenciador de Tarefas",
+		"Usuario": usuario,
+		"Erro":    erro,
+	})
+}
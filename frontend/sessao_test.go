@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestPaginasExigemLogin(t *testing.T) {
+	app := novoApp(cliente.NovoFalso())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Errorf("GET / sem sessão: esperado redirecionamento para /login, obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	resp = enviarFormularioComo(t, app, "", "/tarefas", url.Values{"titulo": {"Anônima"}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Errorf("POST /tarefas sem sessão: esperado redirecionamento para /login, obtido %d", resp.StatusCode)
+	}
+}
+
+func TestLoginELogout(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Tarefa da Ana", Dono: "ana"})
+	api.Usuarios = map[string]string{"ana": "segredo"}
+	app := novoApp(api)
+
+	// Senha errada mantém o usuário digitado e exibe a mensagem
+	resp := enviarFormularioComo(t, app, "", "/login", url.Values{"usuario": {"ana"}, "senha": {"errada"}})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnauthorized || !strings.Contains(string(body), "Usuário ou senha inválidos.") ||
+		!strings.Contains(string(body), `value="ana"`) {
+		t.Errorf("Login inválido: status %d", resp.StatusCode)
+	}
+
+	// Login correto grava o token em um cookie HttpOnly e volta para a lista
+	resp = enviarFormularioComo(t, app, "", "/login", url.Values{"usuario": {"ana"}, "senha": {"segredo"}})
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/" {
+		t.Fatalf("Login: esperado redirecionamento 303 para /, obtido %d", resp.StatusCode)
+	}
+	sessao := cookieDaResposta(resp, cookieSessao)
+	if sessao == nil || sessao.Value == "" || !sessao.HttpOnly || sessao.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("Cookie de sessão inesperado: %+v", sessao)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: sessao.Value})
+	resp, _ = app.Test(req)
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), "Tarefa da Ana") {
+		t.Errorf("Lista após login: status %d", resp.StatusCode)
+	}
+
+	// Sair apaga o cookie
+	resp = enviarFormularioComo(t, app, sessao.Value, "/sair", nil)
+	if apagado := cookieDaResposta(resp, cookieSessao); apagado == nil || apagado.Value != "" {
+		t.Errorf("Sair não apagou o cookie: %+v", apagado)
+	}
+}
+
+func TestTokenRecusadoPedeNovoLogin(t *testing.T) {
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Tarefa da Ana", Dono: "ana"})
+	api.Usuarios = map[string]string{"ana": "segredo"}
+	app := novoApp(api)
+
+	// Um token que a API não aceita, como um expirado, leva de volta ao login
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: cookieSessao, Value: "expirado"})
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Falha ao testar: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Errorf("Esperado redirecionamento para /login, obtido %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if apagado := cookieDaResposta(resp, cookieSessao); apagado == nil || apagado.Value != "" {
+		t.Errorf("Cookie de sessão recusado não foi apagado")
+	}
+}
+
+// cookieDaResposta retorna o cookie com o nome informado definido pela resposta
+func cookieDaResposta(resp *http.Response, nome string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == nome {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestCadaUsuarioVeApenasSuasTarefas(t *testing.T) {
+	api := cliente.NovoFalso(
+		dominio.Tarefa{Titulo: "Tarefa da Ana", Dono: "ana"},
+		dominio.Tarefa{Titulo: "Tarefa do Bruno", Dono: "bruno"},
+	)
+	api.Usuarios = map[string]string{"ana": "segredo", "bruno": "outra"}
+	app := novoApp(api)
+
+	paginaDe := func(token string) (int, string) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: cookieSessao, Value: token})
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Falha ao testar: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	_, corpo := paginaDe("falso:ana")
+	if !strings.Contains(corpo, "Tarefa da Ana") || strings.Contains(corpo, "Tarefa do Bruno") {
+		t.Errorf("Página da Ana exibe tarefas erradas")
+	}
+	_, corpo = paginaDe("falso:bruno")
+	if !strings.Contains(corpo, "Tarefa do Bruno") || strings.Contains(corpo, "Tarefa da Ana") {
+		t.Errorf("Página do Bruno exibe tarefas erradas")
+	}
+
+	// Com a API fora do ar, cada sessão vê apenas a própria cópia em cache
+	api.Erro = errors.New("conexão recusada")
+	if _, corpo := paginaDe("falso:bruno"); !strings.Contains(corpo, "Tarefa do Bruno") || strings.Contains(corpo, "Tarefa da Ana") {
+		t.Errorf("Cache do Bruno exibe tarefas erradas")
+	}
+	if status, corpo := paginaDe("falso:carla"); status != fiber.StatusServiceUnavailable || strings.Contains(corpo, "Tarefa d") {
+		t.Errorf("Sessão sem cache recebeu páginas de outra sessão: status %d", status)
+	}
+}
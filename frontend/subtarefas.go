@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// limiteSubtarefas é o tamanho das páginas pedidas ao buscar as subtarefas
+// das tarefas exibidas; é o maior limite aceito pela API
+const limiteSubtarefas = 200
+
+// subtarefaVisao é uma subtarefa exibida sob a tarefa pai
+type subtarefaVisao struct {
+	dominio.Tarefa
+	// Progresso resume as subtarefas da própria subtarefa
+	Progresso string
+}
+
+// listarSubtarefas busca, percorrendo todas as páginas, as subtarefas diretas
+// das tarefas que têm alguma
+func (a *aplicacao) listarSubtarefas(ctx context.Context, tarefas []dominio.Tarefa) ([]dominio.Tarefa, error) {
+	var pais []string
+	for _, t := range tarefas {
+		if t.Subtarefas != nil {
+			pais = append(pais, t.ID)
+		}
+	}
+	if len(pais) == 0 {
+		return nil, nil
+	}
+
+	var subtarefas []dominio.Tarefa
+	consulta := cliente.Consulta{Pais: pais, Limite: limiteSubtarefas}
+	for {
+		pagina, err := a.api.Listar(ctx, consulta)
+		if err != nil {
+			return nil, err
+		}
+		subtarefas = append(subtarefas, pagina.Tarefas...)
+		if pagina.NextCursor == "" {
+			return subtarefas, nil
+		}
+		consulta.Cursor = pagina.NextCursor
+	}
+}
+
+// subtarefasDe separa as subtarefas diretas da tarefa com o ID informado
+func subtarefasDe(subtarefas []dominio.Tarefa, id string) []subtarefaVisao {
+	var filhas []subtarefaVisao
+	for _, t := range subtarefas {
+		if t.PaiID == id {
+			filhas = append(filhas, subtarefaVisao{Tarefa: t, Progresso: progresso(t)})
+		}
+	}
+	return filhas
+}
+
+// progresso descreve quantas subtarefas da tarefa foram concluídas, ou
+// retorna vazio se ela não tem subtarefas
+func progresso(t dominio.Tarefa) string {
+	if t.Subtarefas == nil {
+		return ""
+	}
+	return strconv.Itoa(t.Subtarefas.Concluidas) + "/" + strconv.Itoa(t.Subtarefas.Total) + " concluídas"
+}
+
+// criarSubtarefa atende POST /tarefas/:id/subtarefas, criando uma subtarefa
+// da tarefa com o título informado
+func (a *aplicacao) criarSubtarefa(c *fiber.Ctx) error {
+	pai := c.Params("id")
+	titulo := strings.TrimSpace(c.FormValue("titulo"))
+	form := &formularioInvalido{subtarefa: true, id: pai, titulo: titulo}
+
+	t := dominio.Tarefa{Titulo: titulo, PaiID: pai}
+	if err := t.Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.Criar(ctx, t); err != nil {
+		return a.responderErroAlteracao(c, form, err)
+	}
+	return a.voltar(c)
+}
+
+// alternarConclusaoAutomatica atende POST /tarefas/:id/conclusao-automatica.
+// Como em alternarTarefa, o formulário envia o novo valor.
+func (a *aplicacao) alternarConclusaoAutomatica(c *fiber.Ctx) error {
+	ativar, err := strconv.ParseBool(c.FormValue("concluir_com_subtarefas"))
+	if err != nil {
+		return a.renderizarTarefas(c, fiber.StatusBadRequest, nil, "Formulário inválido.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{ConcluirComSubtarefas: &ativar, Versao: versaoDoFormulario(c)}
+	_, err = a.api.Alterar(ctx, c.Params("id"), alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
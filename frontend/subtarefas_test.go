@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+func TestFormulariosDeSubtarefas(t *testing.T) {
+	// O progresso é calculado pela API; o Falso mantém o valor semeado
+	api := cliente.NovoFalso(dominio.Tarefa{Titulo: "Mudança", Subtarefas: &dominio.ProgressoSubtarefas{Total: 2, Concluidas: 1}})
+	app := novoApp(api)
+	pagina, _ := api.Listar(context.Background(), cliente.Consulta{})
+	mudanca := pagina.Tarefas[0].ID
+
+	// Criar subtarefas pelo formulário sob a tarefa; o título é validado
+	resp := enviarFormulario(t, app, "/tarefas/"+mudanca+"/subtarefas", url.Values{"titulo": {"  "}})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || !strings.Contains(string(body), "O título é obrigatório.") {
+		t.Errorf("Criar subtarefa sem título: obtido %d", resp.StatusCode)
+	}
+	for _, titulo := range []string{"Embalar caixas", "Contratar frete"} {
+		if resp := enviarFormulario(t, app, "/tarefas/"+mudanca+"/subtarefas", url.Values{"titulo": {titulo}}); resp.StatusCode != fiber.StatusSeeOther {
+			t.Fatalf("Criar subtarefa: obtido %d", resp.StatusCode)
+		}
+	}
+	pagina, _ = api.Listar(context.Background(), cliente.Consulta{Pais: []string{mudanca}})
+	if len(pagina.Tarefas) != 2 {
+		t.Fatalf("Subtarefas não criadas: %+v", pagina.Tarefas)
+	}
+	caixas, frete := pagina.Tarefas[0].ID, pagina.Tarefas[1].ID
+
+	// As subtarefas aparecem sob o pai, com o progresso calculado pela API,
+	// e não na lista principal
+	corpo := obterPagina(t, app, "/")
+	if !strings.Contains(corpo, `<span class="progresso">1/2 concluídas</span>`) || !strings.Contains(corpo, `class="subtarefa "`) {
+		t.Errorf("Subtarefas não exibidas sob a tarefa pai")
+	}
+	if strings.Count(corpo, `<div class="tarefa `) != 1 {
+		t.Errorf("Subtarefas exibidas também como tarefas da lista")
+	}
+
+	enviarFormulario(t, app, "/tarefas/"+mudanca+"/conclusao-automatica", comVersao(t, api, mudanca, url.Values{"concluir_com_subtarefas": {"true"}}))
+	if tarefa, _ := api.Buscar(context.Background(), mudanca); !tarefa.ConcluirComSubtarefas {
+		t.Errorf("Conclusão automática não ativada: %+v", tarefa)
+	}
+
+	// Excluir o pai mantendo as subtarefas não as leva para a lixeira
+	resp = enviarFormulario(t, app, "/tarefas/"+mudanca+"/remover", comVersao(t, api, mudanca, url.Values{"subtarefas": {"promover"}}))
+	if resp.StatusCode != fiber.StatusSeeOther {
+		t.Errorf("Remover tarefa pai: obtido %d", resp.StatusCode)
+	}
+	if _, err := api.Buscar(context.Background(), caixas); err != nil {
+		t.Errorf("Subtarefa removida com o pai: %v", err)
+	}
+
+	// Excluir em cascata remove também as subtarefas
+	enviarFormulario(t, app, "/tarefas/"+caixas+"/subtarefas", url.Values{"titulo": {"Comprar fita"}})
+	enviarFormulario(t, app, "/tarefas/"+caixas+"/remover", comVersao(t, api, caixas, url.Values{"subtarefas": {"remover"}}))
+	pagina, _ = api.Listar(context.Background(), cliente.Consulta{})
+	if len(pagina.Tarefas) != 1 || pagina.Tarefas[0].ID != frete {
+		t.Errorf("Remoção em cascata deixou tarefas: %+v", pagina.Tarefas)
+	}
+}
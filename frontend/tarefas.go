@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/seu-usuario/ci-cd-demo/dominio"
+	"github.com/seu-usuario/ci-cd-demo/dominio/cliente"
+)
+
+// aplicacao reúne as dependências usadas pelas rotas do frontend
+type aplicacao struct {
+	api   cliente.API
+	cache *cacheTarefas
+}
+
+// tarefaVisao é uma tarefa como exibida na página, com o estado do seu
+// formulário de edição
+type tarefaVisao struct {
+	dominio.Tarefa
+	// TituloEditado é o título enviado no formulário que falhou na validação
+	TituloEditado string
+	// ErroEdicao é a mensagem de validação do formulário da tarefa
+	ErroEdicao string
+	// Destinos são os projetos oferecidos no formulário de mover a tarefa
+	Destinos []opcaoProjeto
+	// Chips são as etiquetas da tarefa, exibidas com suas cores
+	Chips []etiquetaVisao
+	// Opcoes são todas as etiquetas, marcadas as da tarefa, no formulário
+	// de etiquetar
+	Opcoes []etiquetaVisao
+	// Progresso resume as subtarefas, como "3/5 concluídas"; vazio sem subtarefas
+	Progresso string
+	// Repeticao descreve a recorrência, como "toda semana (segunda)"; vazio
+	// em tarefas que não se repetem
+	Repeticao string
+	// Filhas são as subtarefas diretas, exibidas sob a tarefa
+	Filhas []subtarefaVisao
+	// Pendencias lista as dependências pendentes que bloqueiam a tarefa
+	Pendencias string
+	// Dependencias são as tarefas oferecidas no formulário de dependências,
+	// marcadas as de que a tarefa depende
+	Dependencias []opcaoDependencia
+	// NovaSubtarefa e ErroSubtarefa guardam o formulário de nova subtarefa
+	// que falhou na validação
+	NovaSubtarefa string
+	ErroSubtarefa string
+	// NomeEstado é o nome do estado da tarefa no fluxo do seu projeto
+	NomeEstado string
+	// FluxoProprio indica que o projeto da tarefa tem fluxo próprio, cujos
+	// estados seguintes substituem o botão de concluir e reabrir
+	FluxoProprio bool
+	// Proximos são os estados para os quais a tarefa pode passar
+	Proximos []opcaoEstado
+}
+
+// opcaoProjeto é um projeto no formulário de mover uma tarefa
+type opcaoProjeto struct {
+	ID          string
+	Nome        string
+	Selecionado bool
+}
+
+// formularioInvalido guarda o estado de um formulário rejeitado, exibido ao
+// renderizar a página novamente
+type formularioInvalido struct {
+	// projeto indica um formulário de projeto, e não de tarefa
+	projeto bool
+	// subtarefa indica o formulário de nova subtarefa da tarefa id
+	subtarefa bool
+	// anexo indica o formulário de envio de anexo da página da tarefa
+	anexo bool
+	// id é a tarefa ou o projeto editado; vazio para os formulários de criação
+	id string
+	// titulo é o título da tarefa ou o nome do projeto ou da etiqueta enviado
+	titulo string
+	// cor é a cor enviada no formulário de etiqueta
+	cor      string
+	mensagem string
+}
+
+// paginaInicial atende GET / listando a página de tarefas do filtro e do
+// cursor indicados na URL
+func (a *aplicacao) paginaInicial(c *fiber.Ctx) error {
+	return a.renderizarTarefas(c, fiber.StatusOK, nil, "")
+}
+
+// renderizarTarefas busca a página de tarefas e renderiza o índice com o
+// status informado, o formulário inválido (se houver) e uma mensagem geral
+func (a *aplicacao) renderizarTarefas(c *fiber.Ctx, status int, form *formularioInvalido, aviso string) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	filtro := filtroDaRequisicao(c)
+	chave := chavePagina{
+		sessao: c.Cookies(cookieSessao),
+		filtro: filtro.valores().Encode(),
+		cursor: cursorDaRequisicao(c),
+	}
+	dados := fiber.Map{
+		"Titulo":          "Gerenciador de Tarefas",
+		"Cursor":          chave.cursor,
+		"Projeto":         filtro.projeto,
+		"FiltroEtiquetas": strings.Join(filtro.etiquetas, ","),
+		"ModoEtiquetas":   filtro.modo(),
+		"Aviso":           aviso,
+	}
+	if form != nil && form.id == "" {
+		if form.projeto {
+			dados["NovoProjeto"] = fiber.Map{"Nome": form.titulo, "Erro": form.mensagem}
+		} else {
+			dados["NovaTarefa"] = fiber.Map{"Titulo": form.titulo, "Erro": form.mensagem}
+		}
+	}
+
+	// Buscar a página de tarefas, suas subtarefas e os projetos e etiquetas
+	// da barra lateral
+	pagina, err := a.api.Listar(ctx, filtro.consulta(chave.cursor))
+	var subtarefas []dominio.Tarefa
+	var projetos []dominio.Projeto
+	var etiquetas []dominio.Etiqueta
+	if err == nil {
+		subtarefas, err = a.listarSubtarefas(ctx, pagina.Tarefas)
+	}
+	if err == nil {
+		projetos, err = a.api.ListarProjetos(ctx)
+	}
+	if err == nil {
+		etiquetas, err = a.api.ListarEtiquetas(ctx)
+	}
+	if errors.Is(err, cliente.ErrNaoAutenticado) {
+		// Token expirado ou revogado: pedir novo login, sem exibir o cache
+		return a.encerrarSessao(c)
+	}
+	if err == nil {
+		a.cache.guardar(chave, paginaEmCache{pagina: pagina, subtarefas: subtarefas, projetos: projetos, etiquetas: etiquetas})
+	} else {
+		log.Printf("erro ao buscar tarefas: %v", err)
+
+		// Sem a API, exibir a última versão conhecida da página
+		emCache, ok := a.cache.obter(chave)
+		if !ok {
+			dados["Erro"] = "Não foi possível carregar as tarefas. Tente novamente em instantes."
+			return c.Status(fiber.StatusServiceUnavailable).Render("index", dados)
+		}
+		pagina, subtarefas, projetos, etiquetas = emCache.pagina, emCache.subtarefas, emCache.projetos, emCache.etiquetas
+		dados["Desatualizado"] = emCache.obtidaEm.Format("02/01/2006 15:04:05")
+	}
+
+	visoes := make([]projetoVisao, len(projetos))
+	for i, p := range projetos {
+		visoes[i] = projetoVisao{Projeto: p, Atual: p.ID == filtro.projeto, NomeEditado: p.Nome}
+		if form != nil && form.projeto && form.id == p.ID {
+			visoes[i].NomeEditado = form.titulo
+			visoes[i].ErroEdicao = form.mensagem
+		}
+		if visoes[i].Atual {
+			dados["ProjetoAtual"] = visoes[i]
+		}
+	}
+
+	exibidas := append(slices.Clone(pagina.Tarefas), subtarefas...)
+	tarefas := make([]tarefaVisao, len(pagina.Tarefas))
+	for i, t := range pagina.Tarefas {
+		tarefas[i] = tarefaVisao{
+			Tarefa:        t,
+			TituloEditado: t.Titulo,
+			Progresso:     progresso(t),
+			Filhas:        subtarefasDe(subtarefas, t.ID),
+			Pendencias:    pendenciasDe(t, exibidas),
+			Dependencias:  dependenciasDe(t, exibidas),
+		}
+		estadoDe(&tarefas[i], projetos)
+		if regra, err := dominio.LerRegraRecorrencia(t.Recorrencia); err == nil && t.Recorrencia != "" {
+			tarefas[i].Repeticao = regra.Descrever()
+		}
+		switch {
+		case form == nil || form.projeto || form.id != t.ID:
+		case form.subtarefa:
+			tarefas[i].NovaSubtarefa = form.titulo
+			tarefas[i].ErroSubtarefa = form.mensagem
+		default:
+			tarefas[i].TituloEditado = form.titulo
+			tarefas[i].ErroEdicao = form.mensagem
+		}
+		for _, p := range projetos {
+			tarefas[i].Destinos = append(tarefas[i].Destinos, opcaoProjeto{ID: p.ID, Nome: p.Nome, Selecionado: p.ID == t.ProjetoID})
+		}
+		for _, e := range etiquetas {
+			v := novaEtiquetaVisao(e, t.TemEtiqueta(e.ID))
+			if v.Marcada {
+				tarefas[i].Chips = append(tarefas[i].Chips, v)
+			}
+			tarefas[i].Opcoes = append(tarefas[i].Opcoes, v)
+		}
+	}
+
+	// O filtro de etiquetas marca as etiquetas escolhidas
+	filtrosEtiquetas := make([]etiquetaVisao, len(etiquetas))
+	for i, e := range etiquetas {
+		filtrosEtiquetas[i] = novaEtiquetaVisao(e, filtro.tem(e.ID))
+	}
+
+	// Renderizar o template com os dados
+	dados["Projetos"] = visoes
+	dados["TemProjetos"] = len(visoes) > 0
+	dados["Etiquetas"] = filtrosEtiquetas
+	dados["TemEtiquetas"] = len(etiquetas) > 0
+	dados["Alguma"] = filtro.alguma
+	dados["Filtrando"] = len(filtro.etiquetas) > 0
+	dados["LimparFiltro"] = enderecoLista(filtroLista{projeto: filtro.projeto}, "")
+	dados["Tarefas"] = tarefas
+	if pagina.NextCursor != "" {
+		dados["ProximaPagina"] = enderecoLista(filtro, pagina.NextCursor)
+	}
+	return c.Status(status).Render("index", dados)
+}
+
+// criarTarefa atende POST /tarefas, criando a tarefa no projeto exibido e
+// com as etiquetas do filtro, para que ela apareça na lista
+func (a *aplicacao) criarTarefa(c *fiber.Ctx) error {
+	titulo := strings.TrimSpace(c.FormValue("titulo"))
+	form := &formularioInvalido{titulo: titulo}
+
+	filtro := filtroDaRequisicao(c)
+	t := dominio.Tarefa{Titulo: titulo, ProjetoID: filtro.projeto, Etiquetas: filtro.etiquetas}
+	if err := t.Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	if _, err := a.api.Criar(ctx, t); err != nil {
+		return a.responderErroAlteracao(c, form, err)
+	}
+	return a.voltar(c)
+}
+
+// renomearTarefa atende POST /tarefas/:id/renomear
+func (a *aplicacao) renomearTarefa(c *fiber.Ctx) error {
+	id := c.Params("id")
+	titulo := strings.TrimSpace(c.FormValue("titulo"))
+	form := &formularioInvalido{id: id, titulo: titulo}
+
+	if err := (dominio.Tarefa{Titulo: titulo}).Validar(); err != nil {
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{Titulo: &titulo, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, id, alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, id, &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, form, err)
+	}
+	return a.voltar(c)
+}
+
+// alternarTarefa atende POST /tarefas/:id/alternar. O formulário envia o novo
+// valor de concluida, para que reenviar a mesma página não desfaça a ação.
+func (a *aplicacao) alternarTarefa(c *fiber.Ctx) error {
+	id := c.Params("id")
+	concluida, err := strconv.ParseBool(c.FormValue("concluida"))
+	if err != nil {
+		return a.renderizarTarefas(c, fiber.StatusBadRequest, nil, "Formulário inválido.")
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{Concluida: &concluida, Versao: versaoDoFormulario(c)}
+	_, err = a.api.Alterar(ctx, id, alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, id, &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
+
+// moverTarefa atende POST /tarefas/:id/mover, colocando a tarefa no projeto
+// escolhido ou, com projeto_id vazio, tirando-a de seu projeto
+func (a *aplicacao) moverTarefa(c *fiber.Ctx) error {
+	destino := c.FormValue("projeto_id")
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{ProjetoID: &destino, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, c.Params("id"), alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if errors.Is(err, cliente.ErrRequisicaoInvalida) {
+		// O projeto de destino foi removido depois que a página foi exibida
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, nil, mensagemUsuario(err))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
+
+// etiquetarTarefa atende POST /tarefas/:id/etiquetas, substituindo as
+// etiquetas da tarefa pelas marcadas no formulário
+func (a *aplicacao) etiquetarTarefa(c *fiber.Ctx) error {
+	marcadas := []string{}
+	for _, id := range c.Request().PostArgs().PeekMulti("etiqueta") {
+		marcadas = append(marcadas, string(id))
+	}
+
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+	alteracao := cliente.Alteracao{Etiquetas: &marcadas, Versao: versaoDoFormulario(c)}
+	_, err := a.api.Alterar(ctx, c.Params("id"), alteracao)
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), &alteracao, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if errors.Is(err, cliente.ErrRequisicaoInvalida) {
+		// Uma etiqueta marcada foi removida depois que a página foi exibida
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, nil, mensagemUsuario(err))
+	}
+	if err != nil {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
+
+// removerTarefa atende POST /tarefas/:id/remover. Com subtarefas=remover,
+// as subtarefas são removidas junto; senão passam para o pai da tarefa.
+func (a *aplicacao) removerTarefa(c *fiber.Ctx) error {
+	ctx, cancelar := context.WithTimeout(c.UserContext(), tempoLimiteRequisicao)
+	defer cancelar()
+
+	remover := a.api.Remover
+	if c.FormValue("subtarefas") == dominio.SubtarefasRemover {
+		remover = a.api.RemoverEmCascata
+	}
+	// Remover uma tarefa que já não existe deixa a lista no estado desejado
+	err := remover(ctx, c.Params("id"), versaoDoFormulario(c))
+	if precisaConfirmar(err) {
+		return a.renderizarConflito(c, c.Params("id"), nil, enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)))
+	}
+	if err != nil && !errors.Is(err, cliente.ErrNaoEncontrada) {
+		return a.responderErroAlteracao(c, nil, err)
+	}
+	return a.voltar(c)
+}
+
+// responderErroAlteracao renderiza a página com a mensagem adequada a um erro
+// da API ao salvar um formulário
+func (a *aplicacao) responderErroAlteracao(c *fiber.Ctx, form *formularioInvalido, err error) error {
+	switch {
+	case errors.Is(err, cliente.ErrNaoAutenticado):
+		return a.encerrarSessao(c)
+	case errors.Is(err, cliente.ErrRequisicaoInvalida) && form != nil:
+		form.mensagem = mensagemUsuario(err)
+		return a.renderizarTarefas(c, fiber.StatusUnprocessableEntity, form, "")
+	case errors.Is(err, cliente.ErrNaoEncontrada):
+		return a.renderizarTarefas(c, fiber.StatusNotFound, nil, "A tarefa não existe mais; ela pode ter sido removida.")
+	case errors.Is(err, cliente.ErrTarefaBloqueada):
+		return a.renderizarTarefas(c, fiber.StatusConflict, form, "A tarefa depende de tarefas pendentes. Conclua-as antes de concluir esta.")
+	case errors.Is(err, cliente.ErrTransicaoInvalida), errors.Is(err, cliente.ErrLimiteEstado):
+		return a.renderizarTarefas(c, fiber.StatusConflict, form, mensagemUsuario(err))
+	case errors.Is(err, cliente.ErrConflito):
+		return a.renderizarTarefas(c, fiber.StatusConflict, form, "A tarefa foi alterada por outra pessoa. Confira a versão atual e tente novamente.")
+	}
+
+	log.Printf("erro ao salvar tarefa: %v", err)
+	return a.renderizarTarefas(c, fiber.StatusServiceUnavailable, form, avisoFalhaAPI(err))
+}
+
+// voltar redireciona para a página de onde o formulário foi enviado, para que
+// recarregar a página não reenvie o formulário
+func (a *aplicacao) voltar(c *fiber.Ctx) error {
+	return c.Redirect(enderecoLista(filtroDaRequisicao(c), cursorDaRequisicao(c)), fiber.StatusSeeOther)
+}
+
+// filtroLista é o filtro da lista de tarefas: o projeto exibido e as
+// etiquetas escolhidas, exigidas todas ou, se alguma, qualquer uma delas
+type filtroLista struct {
+	projeto   string
+	etiquetas []string
+	alguma    bool
+}
+
+// filtroDaRequisicao obtém o filtro da página atual, enviado na URL em GET e
+// como campos ocultos nos formulários. As etiquetas chegam separadas por
+// vírgula ou repetidas, como as envia o formulário de filtro.
+func filtroDaRequisicao(c *fiber.Ctx) filtroLista {
+	f := filtroLista{projeto: c.Query("projeto"), alguma: c.Query("modo") == "alguma"}
+	etiquetas := c.Context().QueryArgs().PeekMulti("etiquetas")
+	if f.projeto == "" && len(etiquetas) == 0 {
+		f.projeto = c.FormValue("projeto")
+		f.alguma = c.FormValue("modo") == "alguma"
+		etiquetas = c.Request().PostArgs().PeekMulti("etiquetas")
+	}
+	for _, valor := range etiquetas {
+		for _, id := range strings.Split(string(valor), ",") {
+			if id = strings.TrimSpace(id); id != "" && !f.tem(id) {
+				f.etiquetas = append(f.etiquetas, id)
+			}
+		}
+	}
+	return f
+}
+
+// tem informa se a etiqueta faz parte do filtro
+func (f filtroLista) tem(id string) bool {
+	for _, e := range f.etiquetas {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// modo é o valor do campo modo: "alguma" ou vazio, que exige todas
+func (f filtroLista) modo() string {
+	if f.alguma {
+		return "alguma"
+	}
+	return ""
+}
+
+// consulta converte o filtro na consulta à API da página indicada pelo
+// cursor. A lista traz só as tarefas sem pai; as subtarefas aparecem sob elas.
+func (f filtroLista) consulta(cursor string) cliente.Consulta {
+	c := cliente.Consulta{Projeto: f.projeto, Etiquetas: f.etiquetas, Raiz: true, Cursor: cursor}
+	if f.alguma {
+		c.ModoEtiquetas = "alguma"
+	}
+	return c
+}
+
+// valores converte o filtro nos parâmetros de URL da lista
+func (f filtroLista) valores() url.Values {
+	q := url.Values{}
+	if f.projeto != "" {
+		q.Set("projeto", f.projeto)
+	}
+	if len(f.etiquetas) > 0 {
+		q.Set("etiquetas", strings.Join(f.etiquetas, ","))
+		if f.alguma {
+			q.Set("modo", "alguma")
+		}
+	}
+	return q
+}
+
+// enderecoLista monta o endereço da lista de tarefas com o filtro, na página
+// indicada pelo cursor; vazios, exibem todas as tarefas desde o início
+func enderecoLista(filtro filtroLista, cursor string) string {
+	q := filtro.valores()
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	if len(q) == 0 {
+		return "/"
+	}
+	return "/?" + q.Encode()
+}
+
+// cursorDaRequisicao obtém o cursor da página atual, enviado na URL em GET e
+// como campo oculto nos formulários
+func cursorDaRequisicao(c *fiber.Ctx) string {
+	if cursor := c.Query("cursor"); cursor != "" {
+		return cursor
+	}
+	return c.FormValue("cursor")
+}
+
+// mensagemUsuario extrai de um erro de validação ou da API a mensagem a ser
+// exibida no formulário, preferindo a do campo inválido
+func mensagemUsuario(err error) string {
+	msg := "dados inválidos"
+	var erroValidacao *dominio.ErroValidacao
+	var erroAPI *cliente.ErroAPI
+	switch {
+	case errors.As(err, &erroValidacao):
+		msg = erroValidacao.Mensagens.PtBR
+	case errors.As(err, &erroAPI) && len(erroAPI.Campos) > 0 && erroAPI.Campos[0].Mensagens.PtBR != "":
+		msg = erroAPI.Campos[0].Mensagens.PtBR
+	case errors.As(err, &erroAPI) && erroAPI.Mensagem != "":
+		msg = erroAPI.Mensagem
+	}
+	return frase(msg)
+}
+
+// avisoFalhaAPI descreve uma falha da API que o usuário não pode corrigir,
+// incluindo o identificador da requisição para facilitar o suporte
+func avisoFalhaAPI(err error) string {
+	var erroAPI *cliente.ErroAPI
+	if !errors.As(err, &erroAPI) {
+		return "Não foi possível salvar a alteração: a API está indisponível. Tente novamente em instantes."
+	}
+	aviso := "Não foi possível salvar a alteração"
+	if erroAPI.Mensagem != "" {
+		aviso += ": " + erroAPI.Mensagem
+	}
+	aviso += ". Tente novamente em instantes."
+	if erroAPI.RequestID != "" {
+		aviso += " (código da requisição: " + erroAPI.RequestID + ")"
+	}
+	return aviso
+}
+
+// frase inicia a mensagem com letra maiúscula e a termina com ponto
+func frase(msg string) string {
+	r, n := utf8.DecodeRuneInString(msg)
+	return string(unicode.ToUpper(r)) + msg[n:] + "."
+}
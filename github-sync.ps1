@@ -117,7 +117,8 @@ jobs:
     - name: Build API Docker image
       uses: docker/build-push-action@v4
       with:
-        context: ./api
+        context: .
+        file: ./api/Dockerfile
         push: false
         tags: ci-cd-demo-api:latest
         cache-from: type=gha
@@ -180,7 +181,8 @@ jobs:
     - name: Build and push API image
       uses: docker/build-push-action@v4
       with:
-        context: ./api
+        context: .
+        file: ./api/Dockerfile
         push: true
         tags: `${{ secrets.DOCKERHUB_USERNAME }}/ci-cd-demo-api:develop
     
@@ -216,7 +218,8 @@ jobs:
     - name: Build and push API image
       uses: docker/build-push-action@v4
       with:
-        context: ./api
+        context: .
+        file: ./api/Dockerfile
         push: true
         tags: `${{ secrets.DOCKERHUB_USERNAME }}/ci-cd-demo-api:test
     
@@ -260,7 +263,8 @@ jobs:
     - name: Build and push API image
       uses: docker/build-push-action@v4
       with:
-        context: ./api
+        context: .
+        file: ./api/Dockerfile
         push: true
         tags: `${{ secrets.DOCKERHUB_USERNAME }}/ci-cd-demo-api:`${{ steps.set-version.outputs.VERSION }}
     